@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin"
+	"github.com/alejoacosta74/cryptonaut/pkg/cosmos"
+	"github.com/alejoacosta74/cryptonaut/pkg/ethereum"
+	"github.com/spf13/cobra"
+)
+
+var addressCmd = &cobra.Command{
+	Use:   "address",
+	Short: "Decode and validate addresses of any supported chain",
+	Long:  "Decode and validate addresses of any supported chain",
+}
+
+var addressInspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Detect, decode and validate an address",
+	Long: `Detect the format of an address and decode it: Base58Check and Bech32/Bech32m
+SegWit addresses of the bitcoin networks (see --network of the bitcoin
+commands), EIP-55 checksummed Ethereum addresses and Cosmos SDK bech32
+addresses. Checksum errors are reported with the valid addresses one typo
+away.
+	Usage:
+	cryptonaut address inspect bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4
+	cryptonaut address inspect 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed
+	cryptonaut address inspect cosmos1...
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAddressInspectCmd,
+}
+
+func init() {
+	addressCmd.AddCommand(addressInspectCmd)
+
+	rootCmd.AddCommand(addressCmd)
+}
+
+func runAddressInspectCmd(cmd *cobra.Command, args []string) error {
+	address := strings.TrimSpace(args[0])
+	if info, err := ethereum.InspectAddress(address); err == nil {
+		return printJSON(info)
+	}
+	if info, err := bitcoin.InspectAddress(address); err == nil {
+		return printJSON(info)
+	}
+	if info, err := cosmos.InspectAddress(address); err == nil {
+		return printJSON(info)
+	}
+	return fmt.Errorf("unrecognized address format: %s", address)
+}
@@ -3,11 +3,13 @@ package cmd
 import (
 	"encoding/hex"
 	"fmt"
+	"strings"
 
 	"github.com/alejoacosta74/cryptonaut/internal/config"
 	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin"
 	"github.com/alejoacosta74/cryptonaut/pkg/crypto/ecdsa/secp256k1"
 	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -38,8 +40,16 @@ var bitcoinPubkeyCmd = &cobra.Command{
 var bitcoinAddressCmd = &cobra.Command{
 	Use:   "address",
 	Short: "Get the Bitcoin address from a public key",
-	Long:  "Get the Bitcoin address from a public key",
-	RunE:  runBitcoinAddressCmd,
+	Long: `Get the Bitcoin address from a public key
+	Usage:
+	cryptonaut bitcoin address --private-key <key> --address-type p2wpkh
+	cryptonaut bitcoin address --private-key <key> --address-type all --network signet
+
+	Supported address types: p2pkh, p2sh-p2wpkh, p2wpkh, p2tr (or all)
+	Supported networks: mainnet, testnet (testnet3), testnet4, signet, regtest,
+	litecoin, litecoin-testnet, dogecoin, dogecoin-testnet
+	`,
+	RunE: runBitcoinAddressCmd,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		return cmd.Parent().Parent().MarkPersistentFlagRequired(config.FlagPrivateKey)
 	},
@@ -47,8 +57,11 @@ var bitcoinAddressCmd = &cobra.Command{
 
 var convertKeyCmd = &cobra.Command{
 	Use:   "convert",
-	Short: "Convert a Bitcoin private key from/to WIF and Hex",
-	Long: `Convert a Bitcoin private key from/to WIF and Hex
+	Short: "Convert a Bitcoin private key between hex, WIF and BIP38",
+	Long: `Convert a Bitcoin private key between hex, WIF and BIP38 encrypted keys.
+By default hex keys are converted to WIF and the others to hex. WIF and BIP38
+keys are encoded for --network, BIP38 keys are encrypted and decrypted with
+--passphrase.
 	Usage:
 	cryptonaut convert 6abd31bf5fe56e1aa5a49b8430a2bcaa276b4cd352b3d7072e89bb9a8a204cc1                 
 	Private key: KzoCR5BTboQXqG9ah8HiHtigrK2DkrpgouYg94m4ZRWiCVEybGoy
@@ -56,9 +69,16 @@ var convertKeyCmd = &cobra.Command{
 	cryptonaut convert KzoCR5BTboQXqG9ah8HiHtigrK2DkrpgouYg94m4ZRWiCVEybGoy
 	Private key: 6abd31bf5fe56e1aa5a49b8430a2bcaa276b4cd352b3d7072e89bb9a8a204cc1
 
+	cryptonaut convert KzoCR5BTboQXqG9ah8HiHtigrK2DkrpgouYg94m4ZRWiCVEybGoy --to bip38 --passphrase <passphrase>
+	cryptonaut convert 6PYN... --to wif --passphrase <passphrase> --network testnet
 	`,
 	Args: cobra.ExactArgs(1),
 	RunE: runConvertKey,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// bound here instead of init() as the bip38 commands bind the same key
+		bindFlags(cmd, config.FlagPassphrase)
+		return nil
+	},
 }
 
 func init() {
@@ -72,24 +92,36 @@ func init() {
 	// flat to specify the testnet flag
 	bitcoinAddressCmd.Flags().BoolP(config.FlagTestnet, "t", false, "Use testnet")
 	viper.BindPFlag(config.FlagTestnet, bitcoinAddressCmd.Flags().Lookup(config.FlagTestnet))
+	bitcoinAddressCmd.Flags().MarkDeprecated(config.FlagTestnet, "use --network testnet")
+	// flag to specify the network (overrides --testnet)
+	bitcoinCmd.PersistentFlags().StringP(config.FlagNetwork, "n", "", networkFlagUsage)
+	viper.BindPFlag(config.FlagNetwork, bitcoinCmd.PersistentFlags().Lookup(config.FlagNetwork))
+	// flags to convert to and from BIP38 encrypted keys
+	convertKeyCmd.Flags().String(config.FlagTo, "", "Output format (hex, wif or bip38)")
+	viper.BindPFlag(config.FlagTo, convertKeyCmd.Flags().Lookup(config.FlagTo))
+	convertKeyCmd.Flags().String(config.FlagPassphrase, "", "Passphrase of BIP38 keys")
+	// flag to specify the address type
+	bitcoinAddressCmd.Flags().String(config.FlagAddressType, string(bitcoin.AddressTypeP2PKH), "Address type (p2pkh, p2sh-p2wpkh, p2wpkh, p2tr, all)")
+	viper.BindPFlag(config.FlagAddressType, bitcoinAddressCmd.Flags().Lookup(config.FlagAddressType))
 
 	rootCmd.AddCommand(bitcoinCmd)
 }
 
 func runBitcoinGenerateCmd(cmd *cobra.Command, args []string) error {
 	format := viper.GetString(config.FlagBitcoinFormat)
-	var privKey *btcec.PrivateKey
-	var err error
+	privKey, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		return err
+	}
 	switch format {
 	case "hex":
-		privKey, err = secp256k1.GeneratePrivateKey()
+		cmd.Println("Private key:", hex.EncodeToString(privKey.Serialize()))
+	case "wif":
+		net, err := bitcoinNetworkParams()
 		if err != nil {
 			return err
 		}
-		cmd.Println("Private key:", hex.EncodeToString(privKey.Serialize()))
-	case "wif":
-		isTestnet := viper.GetBool(config.FlagTestnet)
-		privKeyWIF, err := bitcoin.ConvertPrivateKeyToWIF(privKey, isTestnet, true)
+		privKeyWIF, err := bitcoin.ConvertPrivateKeyToWIF(privKey, net, true)
 		if err != nil {
 			return err
 		}
@@ -116,9 +148,33 @@ func runBitcoinPubkeyCmd(cmd *cobra.Command, args []string) error {
 }
 
 func runBitcoinAddressCmd(cmd *cobra.Command, args []string) error {
-	privateKeyHex := viper.GetString(config.FlagPrivateKey)
-	isTestnet := viper.GetBool(config.FlagTestnet)
-	address, err := bitcoin.GenerateAddressFromPrivateKey(privateKeyHex, isTestnet)
+	privateKey := viper.GetString(config.FlagPrivateKey)
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return err
+	}
+
+	addrTypeStr := viper.GetString(config.FlagAddressType)
+	if addrTypeStr == "all" {
+		for _, addrType := range bitcoin.AddressTypes {
+			if addrType != bitcoin.AddressTypeP2PKH && net.Bech32HRPSegwit == "" {
+				// networks without segwit only have legacy addresses
+				continue
+			}
+			address, err := bitcoin.GenerateAddress(privateKey, addrType, net)
+			if err != nil {
+				return fmt.Errorf("invalid address: %w", err)
+			}
+			cmd.Printf("Address (%s): %s\n", addrType, address)
+		}
+		return nil
+	}
+
+	addrType, err := bitcoin.ParseAddressType(addrTypeStr)
+	if err != nil {
+		return err
+	}
+	address, err := bitcoin.GenerateAddress(privateKey, addrType, net)
 	if err != nil {
 		return fmt.Errorf("invalid address: %w", err)
 	}
@@ -126,9 +182,43 @@ func runBitcoinAddressCmd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// networkFlagUsage is the help of the --network flags
+var networkFlagUsage = fmt.Sprintf("Network (%s)", strings.Join(bitcoin.NetworkNames(), ", "))
+
+// bitcoinNetwork resolves the network from the --network flag, falling back
+// to --testnet when no network is given
+func bitcoinNetwork() (*bitcoin.Network, error) {
+	network := viper.GetString(config.FlagNetwork)
+	if network == "" && viper.GetBool(config.FlagTestnet) {
+		network = string(config.NetworkTestnet)
+	}
+	return bitcoin.LookupNetwork(network)
+}
+
+// bitcoinNetworkParams resolves the chain parameters of the --network flag
+func bitcoinNetworkParams() (*chaincfg.Params, error) {
+	network, err := bitcoinNetwork()
+	if err != nil {
+		return nil, err
+	}
+	return network.Params, nil
+}
+
+// bindNetwork binds --network of the commands outside of the bitcoin
+// command, which binds it in init()
+func bindNetwork(cmd *cobra.Command, args []string) error {
+	bindFlags(cmd, config.FlagNetwork)
+	return nil
+}
+
 func runConvertKey(cmd *cobra.Command, args []string) error {
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return err
+	}
 	privKey := args[0]
-	result, err := bitcoin.ConvertKey(privKey)
+	format := bitcoin.KeyFormat(strings.ToLower(viper.GetString(config.FlagTo)))
+	result, err := bitcoin.ConvertKeyTo(privKey, format, viper.GetString(config.FlagPassphrase), net)
 	if err != nil {
 		cmd.PrintErrln(err)
 		return err
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/alejoacosta74/cryptonaut/internal/config"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var bitcoinBip38Cmd = &cobra.Command{
+	Use:   "bip38",
+	Short: "BIP38 passphrase-protected keys generated by a third party",
+	Long: `Generate BIP38 keys in EC multiply mode: the passphrase owner creates an
+intermediate code, anyone holding the code can generate new keys encrypted
+with the passphrase without learning their private key, and the owner checks
+the confirmation code of each key. Encrypted keys are decrypted with
+"bitcoin convert --passphrase".`,
+}
+
+var bitcoinBip38IntermediateCmd = &cobra.Command{
+	Use:   "intermediate",
+	Short: "Create an intermediate code from a passphrase",
+	Long: `Create an intermediate code from a passphrase, optionally embedding a lot and
+sequence number in the generated keys
+	Usage:
+	cryptonaut bitcoin bip38 intermediate --passphrase <passphrase> --lot 263183 --sequence 1
+	`,
+	Args: cobra.NoArgs,
+	RunE: runBitcoinBip38IntermediateCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// bound here instead of init() as the convert and script run commands bind the same keys
+		bindFlags(cmd, config.FlagPassphrase, config.FlagSequence)
+		return nil
+	},
+}
+
+var bitcoinBip38GenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate an encrypted key from an intermediate code",
+	Long: `Generate a new key encrypted with the passphrase behind an intermediate code,
+its address and the confirmation code for the passphrase owner
+	Usage:
+	cryptonaut bitcoin bip38 generate passphrase... --network testnet
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinBip38GenerateCmd,
+}
+
+var bitcoinBip38ConfirmCmd = &cobra.Command{
+	Use:   "confirm",
+	Short: "Check a confirmation code with the passphrase",
+	Long: `Check the confirmation code of a generated key with the passphrase of the
+intermediate code and print the address of the key
+	Usage:
+	cryptonaut bitcoin bip38 confirm cfrm38... --passphrase <passphrase>
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinBip38ConfirmCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		bindFlags(cmd, config.FlagPassphrase)
+		return nil
+	},
+}
+
+func init() {
+	bitcoinBip38Cmd.AddCommand(bitcoinBip38IntermediateCmd)
+	bitcoinBip38Cmd.AddCommand(bitcoinBip38GenerateCmd)
+	bitcoinBip38Cmd.AddCommand(bitcoinBip38ConfirmCmd)
+
+	bitcoinBip38IntermediateCmd.Flags().String(config.FlagPassphrase, "", "Passphrase protecting the generated keys")
+	bitcoinBip38IntermediateCmd.MarkFlagRequired(config.FlagPassphrase)
+	bitcoinBip38IntermediateCmd.Flags().Int(config.FlagLot, -1, fmt.Sprintf("Lot number, 0 to %d", bitcoin.BIP38MaxLot))
+	viper.BindPFlag(config.FlagLot, bitcoinBip38IntermediateCmd.Flags().Lookup(config.FlagLot))
+	bitcoinBip38IntermediateCmd.Flags().Int(config.FlagSequence, 0, fmt.Sprintf("Sequence number, 0 to %d", bitcoin.BIP38MaxSequence))
+
+	bitcoinBip38ConfirmCmd.Flags().String(config.FlagPassphrase, "", "Passphrase of the intermediate code")
+	bitcoinBip38ConfirmCmd.MarkFlagRequired(config.FlagPassphrase)
+
+	// Add the bitcoinBip38Cmd to the bitcoinCmd root command
+	bitcoinCmd.AddCommand(bitcoinBip38Cmd)
+}
+
+func runBitcoinBip38IntermediateCmd(cmd *cobra.Command, args []string) error {
+	var lotSequence *bitcoin.BIP38LotSequence
+	if lot := viper.GetInt(config.FlagLot); lot >= 0 {
+		sequence := viper.GetInt(config.FlagSequence)
+		if sequence < 0 {
+			return fmt.Errorf("invalid sequence number %d", sequence)
+		}
+		lotSequence = &bitcoin.BIP38LotSequence{Lot: uint32(lot), Sequence: uint32(sequence)}
+	}
+	code, err := bitcoin.NewBIP38IntermediateCode(viper.GetString(config.FlagPassphrase), lotSequence)
+	if err != nil {
+		return err
+	}
+	cmd.Println("Intermediate code:", code)
+	return nil
+}
+
+func runBitcoinBip38GenerateCmd(cmd *cobra.Command, args []string) error {
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return err
+	}
+	key, err := bitcoin.GenerateBIP38Key(args[0], viper.GetBool(config.FlagPubKeyCompressed), net)
+	if err != nil {
+		return err
+	}
+	cmd.Println("Encrypted key:", key.EncryptedKey)
+	cmd.Println("Address:", key.Address)
+	cmd.Println("Confirmation code:", key.ConfirmationCode)
+	return nil
+}
+
+func runBitcoinBip38ConfirmCmd(cmd *cobra.Command, args []string) error {
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return err
+	}
+	address, lotSequence, err := bitcoin.VerifyBIP38Confirmation(args[0], viper.GetString(config.FlagPassphrase), net)
+	if err != nil {
+		return err
+	}
+	cmd.Println("Address:", address)
+	if lotSequence != nil {
+		cmd.Println("Lot:", lotSequence.Lot)
+		cmd.Println("Sequence:", lotSequence.Sequence)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alejoacosta74/cryptonaut/internal/config"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin/descriptor"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var bitcoinDescriptorCmd = &cobra.Command{
+	Use:   "descriptor",
+	Short: "Bitcoin output script descriptors",
+	Long: `Bitcoin output script descriptors (BIP380-386).
+
+Supported script expressions: pk, pkh, wpkh, sh, wsh, multi, sortedmulti,
+tr (with script trees), addr and raw. wsh() and tr() script trees accept
+miniscript, tr() script trees also sortedmulti_a. Keys can be hex, WIF or
+xpub/xprv (tpub/tprv) with key origin info and a derivation path ending in /*
+for ranged descriptors.`,
+}
+
+var bitcoinDescriptorChecksumCmd = &cobra.Command{
+	Use:   "checksum",
+	Short: "Compute the checksum of a descriptor",
+	Long: `Compute the checksum of a descriptor, replacing any existing checksum
+	Usage:
+	cryptonaut bitcoin descriptor checksum "wpkh(02f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9)"
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinDescriptorChecksumCmd,
+}
+
+var bitcoinDescriptorDeriveCmd = &cobra.Command{
+	Use:   "derive",
+	Short: "Derive the addresses of a descriptor",
+	Long: `Derive the addresses of a descriptor over a range of indexes. The checksum
+is validated when present. Scripts without an address form, like bare
+multisig, are printed in hex.
+	Usage:
+	cryptonaut bitcoin descriptor derive "wpkh([73c5da0a/84'/0'/0']xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V/0/*)" --range 0-99
+	cryptonaut bitcoin descriptor derive "wsh(and_v(v:pk(<key>),older(144)))"
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinDescriptorDeriveCmd,
+}
+
+func init() {
+	bitcoinDescriptorCmd.AddCommand(bitcoinDescriptorChecksumCmd)
+	bitcoinDescriptorCmd.AddCommand(bitcoinDescriptorDeriveCmd)
+
+	bitcoinDescriptorDeriveCmd.Flags().String(config.FlagRange, "0", "Index or range of indexes to derive (e.g. 5 or 0-99)")
+	viper.BindPFlag(config.FlagRange, bitcoinDescriptorDeriveCmd.Flags().Lookup(config.FlagRange))
+
+	// Add the bitcoinDescriptorCmd to the bitcoinCmd root command
+	bitcoinCmd.AddCommand(bitcoinDescriptorCmd)
+}
+
+func runBitcoinDescriptorChecksumCmd(cmd *cobra.Command, args []string) error {
+	desc, err := descriptor.AddChecksum(args[0])
+	if err != nil {
+		return err
+	}
+	cmd.Println("Descriptor:", desc)
+	return nil
+}
+
+func runBitcoinDescriptorDeriveCmd(cmd *cobra.Command, args []string) error {
+	desc, err := descriptor.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse descriptor: %v", err)
+	}
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return err
+	}
+	from, to, err := parseRange(viper.GetString(config.FlagRange))
+	if err != nil {
+		return err
+	}
+	if !desc.IsRange() {
+		to = from
+	}
+
+	cmd.Println("Descriptor:", desc)
+	for index := from; index <= to; index++ {
+		out, err := desc.Derive(index, net)
+		if err != nil {
+			return fmt.Errorf("failed to derive index %d: %v", index, err)
+		}
+		if out.Address == "" {
+			cmd.Printf("Script (%d): %s\n", index, hex.EncodeToString(out.ScriptPubKey))
+			continue
+		}
+		cmd.Printf("Address (%d): %s\n", index, out.Address)
+	}
+	return nil
+}
+
+// parseRange reads a single index or an inclusive "from-to" range
+func parseRange(s string) (uint32, uint32, error) {
+	fromStr, toStr, found := strings.Cut(s, "-")
+	if !found {
+		toStr = fromStr
+	}
+	from, err := strconv.ParseUint(fromStr, 10, 31)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range start %s", fromStr)
+	}
+	to, err := strconv.ParseUint(toStr, 10, 31)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range end %s", toStr)
+	}
+	if to < from {
+		return 0, 0, fmt.Errorf("invalid range %s", s)
+	}
+	return uint32(from), uint32(to), nil
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/alejoacosta74/cryptonaut/internal/config"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var bitcoinMempoolCmd = &cobra.Command{
+	Use:   "mempool",
+	Short: "Watch Bitcoin mempool transactions",
+	Long: `Watch the transactions entering the mempool of a Bitcoin Core node and print
+them decoded as JSON, followed by {"block": <hash>} for every new block.
+
+With --zmq the node's notifications are used (bitcoind -zmqpubrawtx and
+-zmqpubhashblock, e.g. tcp://127.0.0.1:28332). Otherwise getrawmempool is
+polled over RPC every --poll-interval (see "bitcoin node" for the rpc flags).
+Transactions can be filtered by the addresses or scripts they pay to.
+	Usage:
+	cryptonaut bitcoin mempool --zmq tcp://127.0.0.1:28332 --address bc1q...
+	cryptonaut bitcoin mempool -n regtest --script 0014... --poll-interval 1s
+	`,
+	Args: cobra.NoArgs,
+	RunE: runBitcoinMempoolCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// bound here instead of init() as the message and tx commands bind the same keys
+		bindFlags(cmd, config.FlagAddress, config.FlagScript)
+		return bindBitcoinRPCFlags(cmd, args)
+	},
+}
+
+func init() {
+	bitcoinMempoolCmd.Flags().String(config.FlagZMQ, "", "ZMQ endpoint publishing rawtx and hashblock notifications")
+	viper.BindPFlag(config.FlagZMQ, bitcoinMempoolCmd.Flags().Lookup(config.FlagZMQ))
+	bitcoinMempoolCmd.Flags().Duration(config.FlagPollInterval, 5*time.Second, "Interval between mempool polls without --zmq")
+	viper.BindPFlag(config.FlagPollInterval, bitcoinMempoolCmd.Flags().Lookup(config.FlagPollInterval))
+	bitcoinMempoolCmd.Flags().StringArray(config.FlagAddress, nil, "Only show transactions paying to this address (repeatable)")
+	bitcoinMempoolCmd.Flags().StringArray(config.FlagScript, nil, "Only show transactions paying to this script in hex (repeatable)")
+	addBitcoinRPCFlags(bitcoinMempoolCmd)
+
+	// Add the bitcoinMempoolCmd to the bitcoinCmd root command
+	bitcoinCmd.AddCommand(bitcoinMempoolCmd)
+}
+
+type bitcoinBlockInfo struct {
+	Block string `json:"block"`
+}
+
+func runBitcoinMempoolCmd(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return err
+	}
+	filter, err := bitcoin.NewMempoolFilter(viper.GetStringSlice(config.FlagAddress), viper.GetStringSlice(config.FlagScript), net)
+	if err != nil {
+		return err
+	}
+
+	var watcher *bitcoin.MempoolWatcher
+	if endpoint := viper.GetString(config.FlagZMQ); endpoint != "" {
+		watcher = bitcoin.NewZMQMempoolWatcher(endpoint, filter)
+	} else {
+		client, err := newBitcoinRPCClient()
+		if err != nil {
+			return err
+		}
+		watcher, err = bitcoin.NewPollingMempoolWatcher(client, viper.GetDuration(config.FlagPollInterval), filter)
+		if err != nil {
+			return err
+		}
+	}
+	if err := watcher.Start(ctx); err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	// Print events until interrupted
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	for {
+		select {
+		case event := <-watcher.Events():
+			var info interface{} = bitcoinBlockInfo{Block: event.BlockHash}
+			if event.Type == bitcoin.MempoolEventTx {
+				info = newBitcoinTxInfo(event.Tx, net, nil)
+			}
+			if err := printJSON(info); err != nil {
+				return err
+			}
+		case err := <-watcher.Err():
+			return err
+		case <-sigChan:
+			return nil
+		}
+	}
+}
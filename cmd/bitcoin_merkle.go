@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/alejoacosta74/cryptonaut/internal/config"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin/merkle"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin/pow"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var bitcoinMerkleCmd = &cobra.Command{
+	Use:   "merkle",
+	Short: "Bitcoin transaction merkle trees and SPV proofs",
+	Long: `Compute the merkle root of a block's transactions, merkle branches proving
+that a transaction is part of a block, and verify the merkleblock proofs
+returned by gettxoutproof. Txids and hashes are given in the usual reversed
+hex.`,
+}
+
+var bitcoinMerkleRootCmd = &cobra.Command{
+	Use:   "root",
+	Short: "Compute the merkle root of a block's txids",
+	Long: `Compute the merkle root of the txids of a block, in block order. Lists ending
+with duplicated txids have the same root as the list without them
+(CVE-2012-2459) and are reported as mutated.
+	Usage:
+	cryptonaut bitcoin merkle root <txid> <txid>...
+	`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runBitcoinMerkleRootCmd,
+}
+
+var bitcoinMerkleBranchCmd = &cobra.Command{
+	Use:   "branch",
+	Short: "Generate the merkle branch of a transaction",
+	Long: `Generate the merkle branch of --txid from the txids of its block. With the
+--header of the block, the branch is also encoded as a merkleblock, the
+format of gettxoutproof.
+	Usage:
+	cryptonaut bitcoin merkle branch --txid <txid> <txid> <txid>...
+	`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runBitcoinMerkleBranchCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		bindFlags(cmd, config.FlagTxid, config.FlagHeader)
+		return nil
+	},
+}
+
+var bitcoinMerkleVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the merkle branch of a transaction",
+	Long: `Verify that the merkle branch of --txid at --index leads to --root, or to the
+merkle root of the block --header
+	Usage:
+	cryptonaut bitcoin merkle verify --txid <txid> --index 2 --branch <hash> --branch <hash> --root <root>
+	`,
+	Args: cobra.NoArgs,
+	RunE: runBitcoinMerkleVerifyCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// bound here instead of init() as the multisig and psbt commands bind --index
+		bindFlags(cmd, config.FlagTxid, config.FlagIndex, config.FlagHeader)
+		return nil
+	},
+}
+
+var bitcoinMerkleProofCmd = &cobra.Command{
+	Use:   "proof",
+	Short: "Verify a merkleblock proof",
+	Long: `Parse a merkleblock, as returned by gettxoutproof, and verify its partial
+merkle tree against the merkle root and the proof of work of its header. With
+--txid the transaction must be one of the proven ones.
+	Usage:
+	cryptonaut bitcoin merkle proof <merkleblock hex> --txid <txid>
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinMerkleProofCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		bindFlags(cmd, config.FlagTxid)
+		return nil
+	},
+}
+
+func init() {
+	bitcoinMerkleCmd.AddCommand(bitcoinMerkleRootCmd)
+	bitcoinMerkleCmd.AddCommand(bitcoinMerkleBranchCmd)
+	bitcoinMerkleCmd.AddCommand(bitcoinMerkleVerifyCmd)
+	bitcoinMerkleCmd.AddCommand(bitcoinMerkleProofCmd)
+
+	bitcoinMerkleBranchCmd.Flags().String(config.FlagTxid, "", "Txid of the transaction")
+	bitcoinMerkleBranchCmd.MarkFlagRequired(config.FlagTxid)
+	bitcoinMerkleBranchCmd.Flags().String(config.FlagHeader, "", "Block header in hex, to encode the branch as a merkleblock")
+
+	bitcoinMerkleVerifyCmd.Flags().String(config.FlagTxid, "", "Txid of the transaction")
+	bitcoinMerkleVerifyCmd.MarkFlagRequired(config.FlagTxid)
+	bitcoinMerkleVerifyCmd.Flags().Int(config.FlagIndex, 0, "Index of the transaction in the block")
+	bitcoinMerkleVerifyCmd.Flags().StringArray(config.FlagBranch, nil, "Hash of the branch, from the bottom up (repeatable)")
+	viper.BindPFlag(config.FlagBranch, bitcoinMerkleVerifyCmd.Flags().Lookup(config.FlagBranch))
+	bitcoinMerkleVerifyCmd.Flags().String(config.FlagRoot, "", "Merkle root")
+	viper.BindPFlag(config.FlagRoot, bitcoinMerkleVerifyCmd.Flags().Lookup(config.FlagRoot))
+	bitcoinMerkleVerifyCmd.Flags().String(config.FlagHeader, "", "Block header in hex, instead of --root")
+
+	bitcoinMerkleProofCmd.Flags().String(config.FlagTxid, "", "Txid that must be proven")
+
+	// Add the bitcoinMerkleCmd to the bitcoinCmd root command
+	bitcoinCmd.AddCommand(bitcoinMerkleCmd)
+}
+
+// parseHashes parses hashes in reversed hex
+func parseHashes(hashes []string) ([]chainhash.Hash, error) {
+	parsed := make([]chainhash.Hash, len(hashes))
+	for i, hash := range hashes {
+		h, err := chainhash.NewHashFromStr(hash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hash %s: %v", hash, err)
+		}
+		parsed[i] = *h
+	}
+	return parsed, nil
+}
+
+func runBitcoinMerkleRootCmd(cmd *cobra.Command, args []string) error {
+	txids, err := parseHashes(args)
+	if err != nil {
+		return err
+	}
+	root, mutated := merkle.Root(txids)
+	cmd.Println("Merkle root:", root)
+	if mutated {
+		return fmt.Errorf("mutated transaction list, duplicated txids give the same root (CVE-2012-2459)")
+	}
+	return nil
+}
+
+type merkleBranchInfo struct {
+	Txid       string   `json:"txid"`
+	Index      int      `json:"index"`
+	Branch     []string `json:"branch"`
+	MerkleRoot string   `json:"merkleRoot"`
+	Proof      string   `json:"proof,omitempty"`
+}
+
+func runBitcoinMerkleBranchCmd(cmd *cobra.Command, args []string) error {
+	txids, err := parseHashes(args)
+	if err != nil {
+		return err
+	}
+	txid, err := chainhash.NewHashFromStr(viper.GetString(config.FlagTxid))
+	if err != nil {
+		return fmt.Errorf("invalid txid: %v", err)
+	}
+	index := -1
+	for i := range txids {
+		if txids[i] == *txid {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("txid %s is not in the block", txid)
+	}
+	branch, err := merkle.Branch(txids, index)
+	if err != nil {
+		return err
+	}
+	root, mutated := merkle.Root(txids)
+	if mutated {
+		return fmt.Errorf("mutated transaction list, duplicated txids give the same root (CVE-2012-2459)")
+	}
+
+	info := merkleBranchInfo{Txid: txid.String(), Index: index, Branch: []string{}, MerkleRoot: root.String()}
+	for _, hash := range branch {
+		info.Branch = append(info.Branch, hash.String())
+	}
+	if headerHex := viper.GetString(config.FlagHeader); headerHex != "" {
+		header, err := pow.DecodeHeader(headerHex)
+		if err != nil {
+			return err
+		}
+		mb, err := merkle.NewMerkleBlock(*header, txids, []int{index})
+		if err != nil {
+			return err
+		}
+		if info.Proof, err = merkle.EncodeMerkleBlock(mb); err != nil {
+			return err
+		}
+	}
+	return printJSON(info)
+}
+
+func runBitcoinMerkleVerifyCmd(cmd *cobra.Command, args []string) error {
+	txid, err := chainhash.NewHashFromStr(viper.GetString(config.FlagTxid))
+	if err != nil {
+		return fmt.Errorf("invalid txid: %v", err)
+	}
+	branch, err := parseHashes(viper.GetStringSlice(config.FlagBranch))
+	if err != nil {
+		return err
+	}
+	var root chainhash.Hash
+	switch headerHex, rootHex := viper.GetString(config.FlagHeader), viper.GetString(config.FlagRoot); {
+	case headerHex != "":
+		header, err := pow.DecodeHeader(headerHex)
+		if err != nil {
+			return err
+		}
+		root = header.MerkleRoot
+	case rootHex != "":
+		hash, err := chainhash.NewHashFromStr(rootHex)
+		if err != nil {
+			return fmt.Errorf("invalid merkle root: %v", err)
+		}
+		root = *hash
+	default:
+		return fmt.Errorf("either --%s or --%s is required", config.FlagRoot, config.FlagHeader)
+	}
+
+	err = merkle.VerifyBranch(*txid, branch, viper.GetInt(config.FlagIndex), root)
+	cmd.Println("Branch is valid:", err == nil)
+	if err != nil {
+		cmd.Println("Reason:", err)
+	}
+	return nil
+}
+
+type merkleMatchInfo struct {
+	Txid  string `json:"txid"`
+	Index int    `json:"index"`
+}
+
+type merkleProofInfo struct {
+	BlockHash    string            `json:"blockHash"`
+	MerkleRoot   string            `json:"merkleRoot"`
+	Transactions uint32            `json:"transactions"`
+	Matches      []merkleMatchInfo `json:"matches"`
+}
+
+func newMerkleProofInfo(mb *wire.MsgMerkleBlock, matches []merkle.Match) merkleProofInfo {
+	info := merkleProofInfo{
+		BlockHash:    mb.Header.BlockHash().String(),
+		MerkleRoot:   mb.Header.MerkleRoot.String(),
+		Transactions: mb.Transactions,
+		Matches:      []merkleMatchInfo{},
+	}
+	for _, match := range matches {
+		info.Matches = append(info.Matches, merkleMatchInfo{Txid: match.Txid.String(), Index: match.Index})
+	}
+	return info
+}
+
+func runBitcoinMerkleProofCmd(cmd *cobra.Command, args []string) error {
+	net, err := powNetworkParams()
+	if err != nil {
+		return err
+	}
+	mb, err := merkle.DecodeMerkleBlock(args[0])
+	if err != nil {
+		return err
+	}
+	if err := pow.CheckProofOfWork(&mb.Header, net); err != nil {
+		return fmt.Errorf("invalid block header: %v", err)
+	}
+	matches, err := merkle.VerifyMerkleBlock(mb)
+	if err != nil {
+		return err
+	}
+	if txidHex := viper.GetString(config.FlagTxid); txidHex != "" {
+		txid, err := chainhash.NewHashFromStr(txidHex)
+		if err != nil {
+			return fmt.Errorf("invalid txid: %v", err)
+		}
+		found := false
+		for _, match := range matches {
+			found = found || match.Txid == *txid
+		}
+		if !found {
+			return fmt.Errorf("txid %s is not proven by the merkleblock", txid)
+		}
+	}
+	return printJSON(newMerkleProofInfo(mb, matches))
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/alejoacosta74/cryptonaut/internal/config"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var bitcoinMessageCmd = &cobra.Command{
+	Use:   "message",
+	Short: "Bitcoin signed messages (legacy and BIP322)",
+	Long: `Sign and verify messages the way Bitcoin wallets do.
+
+Two formats are supported:
+	legacy         the "Bitcoin Signed Message:\n" compact recoverable signature
+	               of Bitcoin Core's signmessage (P2PKH addresses, and P2WPKH /
+	               P2SH-P2WPKH addresses with their BIP137 header, not P2TR)
+	bip322-simple  BIP322 proof encoded as a witness stack (P2WPKH and P2TR)
+	bip322-full    BIP322 proof encoded as the full to_sign transaction`,
+}
+
+var bitcoinMessageSignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Sign a message with a Bitcoin private key",
+	Long: `Sign a message with a Bitcoin private key
+	Usage:
+	cryptonaut bitcoin message sign "hello world" --private-key <hex or WIF>
+	cryptonaut bitcoin message sign "hello world" --private-key <hex or WIF> --address-type p2tr
+	cryptonaut bitcoin message sign "hello world" --private-key <hex or WIF> --address-type p2wpkh --message-format bip322-full
+
+	The format defaults to legacy for P2PKH addresses and to bip322-simple otherwise.
+	`,
+	Args: cobra.ExactArgs(1), // message to sign
+	RunE: runBitcoinMessageSignCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// bound here instead of init() as the address command binds the same key
+		bindFlags(cmd, config.FlagAddressType)
+		return cmd.Parent().Parent().Parent().MarkPersistentFlagRequired(config.FlagPrivateKey)
+	},
+}
+
+var bitcoinMessageVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify a message signed by a Bitcoin address",
+	Long: `Verify a message signed by a Bitcoin address. The signature format is
+detected automatically.
+	Usage:
+	cryptonaut bitcoin message verify "hello world" --address <address> --signature <base64 signature>
+	`,
+	Args: cobra.ExactArgs(1), // message to verify
+	RunE: runBitcoinMessageVerifyCmd,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		cmd.MarkFlagRequired(config.FlagAddress)
+		cmd.MarkFlagRequired(config.FlagSignature)
+	},
+}
+
+func init() {
+	bitcoinMessageCmd.AddCommand(bitcoinMessageSignCmd)
+	bitcoinMessageCmd.AddCommand(bitcoinMessageVerifyCmd)
+
+	bitcoinMessageSignCmd.Flags().String(config.FlagAddressType, string(bitcoin.AddressTypeP2PKH), "Address type (p2pkh, p2sh-p2wpkh, p2wpkh, p2tr)")
+	bitcoinMessageSignCmd.Flags().String(config.FlagMessageFormat, "", "Signature format (legacy, bip322-simple, bip322-full)")
+	viper.BindPFlag(config.FlagMessageFormat, bitcoinMessageSignCmd.Flags().Lookup(config.FlagMessageFormat))
+
+	bitcoinMessageVerifyCmd.Flags().String(config.FlagAddress, "", "Address of the signer")
+	viper.BindPFlag(config.FlagAddress, bitcoinMessageVerifyCmd.Flags().Lookup(config.FlagAddress))
+
+	// Add the bitcoinMessageCmd to the bitcoinCmd root command
+	bitcoinCmd.AddCommand(bitcoinMessageCmd)
+}
+
+func runBitcoinMessageSignCmd(cmd *cobra.Command, args []string) error {
+	message := args[0]
+	privKey, err := bitcoin.ParsePrivateKey(viper.GetString(config.FlagPrivateKey))
+	if err != nil {
+		return err
+	}
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return err
+	}
+	addrType, err := bitcoin.ParseAddressType(viper.GetString(config.FlagAddressType))
+	if err != nil {
+		return err
+	}
+
+	format := bitcoin.MessageFormat(viper.GetString(config.FlagMessageFormat))
+	if format == "" {
+		format = bitcoin.MessageFormatBIP322Simple
+		if addrType == bitcoin.AddressTypeP2PKH {
+			format = bitcoin.MessageFormatLegacy
+		}
+	}
+
+	compressed := viper.GetBool(config.FlagPubKeyCompressed)
+	if !compressed && addrType != bitcoin.AddressTypeP2PKH {
+		return fmt.Errorf("uncompressed keys can only sign for %s addresses", bitcoin.AddressTypeP2PKH)
+	}
+	address, err := bitcoin.AddressFromPublicKey(privKey.PubKey(), addrType, net)
+	if err != nil {
+		return err
+	}
+
+	var signature string
+	switch format {
+	case bitcoin.MessageFormatLegacy:
+		if !compressed {
+			if address, err = bitcoin.UncompressedP2PKHAddress(privKey.PubKey(), net); err != nil {
+				return err
+			}
+		}
+		if signature, err = bitcoin.SignMessageLegacy(privKey, addrType, message, compressed); err != nil {
+			return err
+		}
+	case bitcoin.MessageFormatBIP322Simple, bitcoin.MessageFormatBIP322Full:
+		if signature, err = bitcoin.SignMessageBIP322(privKey, addrType, message, format, net); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid message format: %s", format)
+	}
+
+	cmd.Println("Address:", address.EncodeAddress())
+	cmd.Println("Signature:", signature)
+	return nil
+}
+
+func runBitcoinMessageVerifyCmd(cmd *cobra.Command, args []string) error {
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return err
+	}
+	valid, format, err := bitcoin.VerifyMessage(viper.GetString(config.FlagAddress), args[0],
+		viper.GetString(config.FlagSignature), net)
+	if err != nil {
+		return err
+	}
+	cmd.Println("Format:", format)
+	cmd.Println("Signature is valid:", valid)
+	return nil
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/alejoacosta74/cryptonaut/internal/config"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var bitcoinMultisigCmd = &cobra.Command{
+	Use:   "multisig",
+	Short: "M-of-N multisig wallets",
+	Long: `M-of-N multisig wallets with sorted keys (BIP67).
+
+Keys are hex public keys or xpubs, optionally with key origin and derivation
+path in descriptor notation. A bare xpub is derived at <xpub>/0/<address-index>.
+Supported types: p2wsh, p2sh-p2wsh and p2tr (a multi_a script leaf behind an
+unspendable internal key).`,
+}
+
+var bitcoinMultisigCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a multisig address",
+	Long: `Create a multisig address and print its scripts and descriptor
+	Usage:
+	cryptonaut bitcoin multisig create --required 2 --key <pubkey1> --key <pubkey2> --key <pubkey3>
+	cryptonaut bitcoin multisig create --required 2 --key <xpub1> --key <xpub2> --address-type p2tr --address-index 5
+	`,
+	Args:    cobra.NoArgs,
+	RunE:    runBitcoinMultisigCreateCmd,
+	PreRunE: bindMultisigFlags,
+}
+
+var bitcoinMultisigSignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Add a cosigner signature to a PSBT spending a multisig output",
+	Long: `Add a cosigner signature to a PSBT spending a multisig output. The multisig
+scripts are added to the inputs spending it, so the PSBT can be signed
+by each cosigner, merged with "psbt combine" and completed with "psbt finalize".
+	Usage:
+	cryptonaut bitcoin multisig sign <psbt> --required 2 --key <pubkey1> --key <pubkey2> --key <pubkey3> --private-key <hex or WIF>
+	cryptonaut bitcoin multisig sign <psbt> --required 2 --key <xpub1> --key <xpub2> --mnemonic "your mnemonic phrase" --index 0
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinMultisigSignCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		bindFlags(cmd, config.FlagMnemonic, config.FlagIndex, config.FlagNetworkCoinType)
+		return bindMultisigFlags(cmd, args)
+	},
+}
+
+func init() {
+	bitcoinMultisigCmd.AddCommand(bitcoinMultisigCreateCmd)
+	bitcoinMultisigCmd.AddCommand(bitcoinMultisigSignCmd)
+
+	for _, cmd := range []*cobra.Command{bitcoinMultisigCreateCmd, bitcoinMultisigSignCmd} {
+		cmd.Flags().StringArray(config.FlagKey, nil, "Cosigner public key or xpub (repeatable)")
+		cmd.Flags().Int(config.FlagRequired, 0, "Number of required signatures")
+		cmd.Flags().String(config.FlagAddressType, string(bitcoin.MultisigP2WSH), "Multisig type (p2wsh, p2sh-p2wsh, p2tr)")
+		cmd.Flags().Uint32(config.FlagAddressIndex, 0, "Derivation index of xpub keys")
+	}
+	bitcoinMultisigSignCmd.Flags().String(config.FlagMnemonic, "", "Mnemonic phrase used to derive the signing key")
+	bitcoinMultisigSignCmd.Flags().Int(config.FlagIndex, 0, "Derivation index of the signing key")
+	addNetworkCoinTypeFlag(bitcoinMultisigSignCmd)
+
+	// Add the bitcoinMultisigCmd to the bitcoinCmd root command
+	bitcoinCmd.AddCommand(bitcoinMultisigCmd)
+}
+
+// bindMultisigFlags binds the flags shared by the multisig commands
+func bindMultisigFlags(cmd *cobra.Command, args []string) error {
+	// bound here instead of init() as the address commands bind the same keys
+	bindFlags(cmd, config.FlagKey, config.FlagRequired, config.FlagAddressType, config.FlagAddressIndex)
+	if err := cmd.MarkFlagRequired(config.FlagKey); err != nil {
+		return err
+	}
+	return cmd.MarkFlagRequired(config.FlagRequired)
+}
+
+// multisigFromFlags builds the multisig described by the command flags
+func multisigFromFlags() (*bitcoin.Multisig, error) {
+	msType, err := bitcoin.ParseMultisigType(viper.GetString(config.FlagAddressType))
+	if err != nil {
+		return nil, err
+	}
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return nil, err
+	}
+	return bitcoin.NewMultisig(msType, viper.GetInt(config.FlagRequired), viper.GetStringSlice(config.FlagKey),
+		viper.GetUint32(config.FlagAddressIndex), net)
+}
+
+func runBitcoinMultisigCreateCmd(cmd *cobra.Command, args []string) error {
+	ms, err := multisigFromFlags()
+	if err != nil {
+		return err
+	}
+
+	cmd.Println("Address:", ms.Address)
+	cmd.Println("Script pubkey:", hex.EncodeToString(ms.ScriptPubKey))
+	cmd.Println("Descriptor:", ms.Descriptor)
+	if ms.RedeemScript != nil {
+		cmd.Println("Redeem script:", hex.EncodeToString(ms.RedeemScript))
+	}
+	if ms.WitnessScript != nil {
+		cmd.Println("Witness script:", hex.EncodeToString(ms.WitnessScript))
+	}
+	if ms.TapLeafScript != nil {
+		cmd.Println("Internal key:", hex.EncodeToString(ms.InternalKey))
+		cmd.Println("Leaf script:", hex.EncodeToString(ms.TapLeafScript))
+		cmd.Println("Control block:", hex.EncodeToString(ms.ControlBlock))
+	}
+	return nil
+}
+
+func runBitcoinMultisigSignCmd(cmd *cobra.Command, args []string) error {
+	packet, err := bitcoin.DecodePSBT(args[0])
+	if err != nil {
+		return err
+	}
+	ms, err := multisigFromFlags()
+	if err != nil {
+		return err
+	}
+	updated, err := bitcoin.UpdateMultisigPSBT(packet, ms)
+	if err != nil {
+		return err
+	}
+	if len(updated) == 0 {
+		return fmt.Errorf("no input of the psbt spends %s", ms.Address)
+	}
+
+	privKey, err := psbtSigningKey()
+	if err != nil {
+		return err
+	}
+	signed, err := bitcoin.SignPSBT(packet, privKey)
+	if err != nil {
+		return err
+	}
+	if len(signed) == 0 {
+		return fmt.Errorf("the key is not a cosigner of %s", ms.Address)
+	}
+
+	cmd.Println("Signed inputs:", signed)
+	return printPsbt(cmd, packet)
+}
@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alejoacosta74/cryptonaut/internal/config"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var bitcoinNodeCmd = &cobra.Command{
+	Use:   "node",
+	Short: "Query a Bitcoin Core node over JSON-RPC",
+	Long: `Query a Bitcoin Core node over JSON-RPC.
+
+The node is reached at --rpc-url, by default on localhost at the RPC port of
+--network (8332 mainnet, 18332 testnet, 48332 testnet4, 38332 signet, 18443
+regtest, 9332 litecoin, 22555 dogecoin). The credentials are --rpc-user and
+--rpc-password, or the cookie file written by the node: --rpc-cookie, by
+default ~/.bitcoin/<network>/.cookie when present.`,
+}
+
+var bitcoinNodeGetBlockchainInfoCmd = &cobra.Command{
+	Use:   "getblockchaininfo",
+	Short: "Show the state of the node's chain",
+	Long: `Show the state of the node's chain
+	Usage:
+	cryptonaut bitcoin node getblockchaininfo -n regtest
+	`,
+	Args:    cobra.NoArgs,
+	RunE:    runBitcoinNodeGetBlockchainInfoCmd,
+	PreRunE: bindBitcoinRPCFlags,
+}
+
+var bitcoinNodeGetRawTransactionCmd = &cobra.Command{
+	Use:   "getrawtransaction",
+	Short: "Fetch a transaction by id",
+	Long: `Fetch a transaction by id, printed in hex or decoded with --decode.
+Transactions outside the mempool require a node with -txindex, or --block-hash.
+	Usage:
+	cryptonaut bitcoin node getrawtransaction <txid> --decode
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinNodeGetRawTransactionCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		bindFlags(cmd, config.FlagDecode, config.FlagBlockHash)
+		return bindBitcoinRPCFlags(cmd, args)
+	},
+}
+
+var bitcoinNodeSendRawTransactionCmd = &cobra.Command{
+	Use:   "sendrawtransaction",
+	Short: "Broadcast a raw transaction",
+	Long: `Broadcast a raw transaction
+	Usage:
+	cryptonaut bitcoin node sendrawtransaction <raw tx hex>
+	`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runBitcoinNodeSendRawTransactionCmd,
+	PreRunE: bindBitcoinRPCFlags,
+}
+
+var bitcoinNodeTestMempoolAcceptCmd = &cobra.Command{
+	Use:   "testmempoolaccept",
+	Short: "Check whether raw transactions would be accepted by the mempool",
+	Long: `Check whether raw transactions would be accepted by the mempool, without
+broadcasting them
+	Usage:
+	cryptonaut bitcoin node testmempoolaccept <raw tx hex> [<raw tx hex>...]
+	`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    runBitcoinNodeTestMempoolAcceptCmd,
+	PreRunE: bindBitcoinRPCFlags,
+}
+
+var bitcoinNodeScanTxOutSetCmd = &cobra.Command{
+	Use:   "scantxoutset",
+	Short: "Find the unspent outputs of addresses or descriptors",
+	Long: `Find the unspent outputs of addresses or descriptors in the UTXO set. The
+unspents can be saved as the --utxos file of "tx build".
+	Usage:
+	cryptonaut bitcoin node scantxoutset <address> "wpkh(<xpub>/0/*)"
+	`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    runBitcoinNodeScanTxOutSetCmd,
+	PreRunE: bindBitcoinRPCFlags,
+}
+
+var bitcoinNodeEstimateSmartFeeCmd = &cobra.Command{
+	Use:   "estimatesmartfee",
+	Short: "Estimate the fee rate to confirm within a number of blocks",
+	Long: `Estimate the fee rate to confirm within a number of blocks
+	Usage:
+	cryptonaut bitcoin node estimatesmartfee 6 --estimate-mode economical
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinNodeEstimateSmartFeeCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		bindFlags(cmd, config.FlagEstimateMode)
+		return bindBitcoinRPCFlags(cmd, args)
+	},
+}
+
+func init() {
+	bitcoinNodeCmd.AddCommand(bitcoinNodeGetBlockchainInfoCmd)
+	bitcoinNodeCmd.AddCommand(bitcoinNodeGetRawTransactionCmd)
+	bitcoinNodeCmd.AddCommand(bitcoinNodeSendRawTransactionCmd)
+	bitcoinNodeCmd.AddCommand(bitcoinNodeTestMempoolAcceptCmd)
+	bitcoinNodeCmd.AddCommand(bitcoinNodeScanTxOutSetCmd)
+	bitcoinNodeCmd.AddCommand(bitcoinNodeEstimateSmartFeeCmd)
+
+	// the rpc flags are registered on every command talking to a node
+	for _, cmd := range bitcoinNodeCmd.Commands() {
+		addBitcoinRPCFlags(cmd)
+	}
+
+	bitcoinNodeGetRawTransactionCmd.Flags().Bool(config.FlagDecode, false, "Decode the transaction")
+	bitcoinNodeGetRawTransactionCmd.Flags().String(config.FlagBlockHash, "", "Hash of the block containing the transaction")
+	bitcoinNodeEstimateSmartFeeCmd.Flags().String(config.FlagEstimateMode, "", "Estimate mode (economical or conservative)")
+
+	// Add the bitcoinNodeCmd to the bitcoinCmd root command
+	bitcoinCmd.AddCommand(bitcoinNodeCmd)
+}
+
+// addBitcoinRPCFlags registers the flags locating and authenticating to a
+// node, shared by the node and mempool commands
+func addBitcoinRPCFlags(cmd *cobra.Command) {
+	cmd.Flags().String(config.FlagRPCURL, "", "URL of the node's RPC server (default http://127.0.0.1:<network RPC port>)")
+	cmd.Flags().String(config.FlagRPCUser, "", "RPC user")
+	cmd.Flags().String(config.FlagRPCPassword, "", "RPC password")
+	cmd.Flags().String(config.FlagRPCCookie, "", "Path of the node's .cookie file")
+}
+
+// bindBitcoinRPCFlags binds the rpc flags of the running command, as they
+// are registered on several commands
+func bindBitcoinRPCFlags(cmd *cobra.Command, args []string) error {
+	bindFlags(cmd, config.FlagRPCURL, config.FlagRPCUser, config.FlagRPCPassword, config.FlagRPCCookie)
+	return nil
+}
+
+// newBitcoinRPCClient connects to the node given by the --rpc flags,
+// defaulting to the local node of --network
+func newBitcoinRPCClient() (*bitcoin.RPCClient, error) {
+	network, err := bitcoinNetwork()
+	if err != nil {
+		return nil, err
+	}
+	cfg := bitcoin.RPCConfig{
+		URL:        viper.GetString(config.FlagRPCURL),
+		User:       viper.GetString(config.FlagRPCUser),
+		Password:   viper.GetString(config.FlagRPCPassword),
+		CookieFile: viper.GetString(config.FlagRPCCookie),
+	}
+	if cfg.URL == "" {
+		cfg.URL = fmt.Sprintf("http://127.0.0.1:%d", network.RPCPort)
+	}
+	if cfg.CookieFile == "" && cfg.User == "" {
+		if cookie, err := defaultCookieFile(network); err == nil {
+			cfg.CookieFile = cookie
+		}
+	}
+	return bitcoin.NewRPCClient(cfg)
+}
+
+// defaultCookieFile returns the cookie file of a local node running with the
+// default data directory
+func defaultCookieFile(network *bitcoin.Network) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	cookie := filepath.Join(home, filepath.FromSlash(network.DataDir), ".cookie")
+	if _, err := os.Stat(cookie); err != nil {
+		return "", err
+	}
+	return cookie, nil
+}
+
+func printJSON(v interface{}) error {
+	jsonData, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error marshaling JSON: %v", err)
+	}
+	fmt.Println(string(jsonData))
+	return nil
+}
+
+func runBitcoinNodeGetBlockchainInfoCmd(cmd *cobra.Command, args []string) error {
+	client, err := newBitcoinRPCClient()
+	if err != nil {
+		return err
+	}
+	info, err := client.GetBlockchainInfo()
+	if err != nil {
+		return err
+	}
+	return printJSON(info)
+}
+
+func runBitcoinNodeGetRawTransactionCmd(cmd *cobra.Command, args []string) error {
+	client, err := newBitcoinRPCClient()
+	if err != nil {
+		return err
+	}
+	rawTx, tx, err := client.GetRawTransaction(args[0], viper.GetString(config.FlagBlockHash))
+	if err != nil {
+		return err
+	}
+	if !viper.GetBool(config.FlagDecode) {
+		cmd.Println("Raw transaction:", rawTx)
+		return nil
+	}
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return err
+	}
+	return printJSON(newBitcoinTxInfo(tx, net, nil))
+}
+
+func runBitcoinNodeSendRawTransactionCmd(cmd *cobra.Command, args []string) error {
+	client, err := newBitcoinRPCClient()
+	if err != nil {
+		return err
+	}
+	txid, err := client.SendRawTransaction(args[0])
+	if err != nil {
+		return err
+	}
+	cmd.Println("Txid:", txid)
+	return nil
+}
+
+func runBitcoinNodeTestMempoolAcceptCmd(cmd *cobra.Command, args []string) error {
+	client, err := newBitcoinRPCClient()
+	if err != nil {
+		return err
+	}
+	results, err := client.TestMempoolAccept(args)
+	if err != nil {
+		return err
+	}
+	return printJSON(results)
+}
+
+func runBitcoinNodeScanTxOutSetCmd(cmd *cobra.Command, args []string) error {
+	client, err := newBitcoinRPCClient()
+	if err != nil {
+		return err
+	}
+	descriptors := make([]string, len(args))
+	for i, arg := range args {
+		descriptors[i] = arg
+		if !strings.Contains(arg, "(") {
+			descriptors[i] = "addr(" + arg + ")"
+		}
+	}
+	result, err := client.ScanTxOutSet(descriptors)
+	if err != nil {
+		return err
+	}
+	return printJSON(result)
+}
+
+func runBitcoinNodeEstimateSmartFeeCmd(cmd *cobra.Command, args []string) error {
+	target, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid confirmation target %s", args[0])
+	}
+	client, err := newBitcoinRPCClient()
+	if err != nil {
+		return err
+	}
+	estimate, err := client.EstimateSmartFee(target, viper.GetString(config.FlagEstimateMode))
+	if err != nil {
+		return err
+	}
+	cmd.Printf("Fee rate: %.8f BTC/kvB (%.2f sat/vB)\n", estimate.FeeRate, estimate.SatPerVByte())
+	cmd.Println("Blocks:", estimate.Blocks)
+	return nil
+}
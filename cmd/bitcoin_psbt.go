@@ -0,0 +1,385 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/alejoacosta74/cryptonaut/internal/config"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin"
+	"github.com/alejoacosta74/cryptonaut/pkg/crypto"
+	"github.com/alejoacosta74/cryptonaut/pkg/hd"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var bitcoinPsbtCmd = &cobra.Command{
+	Use:   "psbt",
+	Short: "Partially signed Bitcoin transaction (BIP174/BIP370) commands",
+	Long: `Partially signed Bitcoin transaction (BIP174/BIP370) commands.
+
+PSBTs are accepted in base64 or hex encoding. Version 2 (BIP370) PSBTs are
+converted to version 0 (BIP174), which is the format used for the output.`,
+}
+
+var bitcoinPsbtCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a PSBT from an unsigned raw transaction",
+	Long: `Create a PSBT from an unsigned raw transaction
+	Usage:
+	cryptonaut bitcoin psbt create <unsigned raw tx hex>
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinPsbtCreateCmd,
+}
+
+var bitcoinPsbtDecodeCmd = &cobra.Command{
+	Use:   "decode",
+	Short: "Decode a PSBT",
+	Long: `Decode a PSBT into JSON
+	Usage:
+	cryptonaut bitcoin psbt decode <psbt>
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinPsbtDecodeCmd,
+}
+
+var bitcoinPsbtSignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Sign the PSBT inputs spendable by a private key",
+	Long: `Sign the PSBT inputs spendable by a private key (P2PKH, P2WPKH,
+P2SH-P2WPKH, P2SH/P2WSH multisig, P2TR key-path inputs and P2TR script-path
+inputs listing their leaf scripts)
+	Usage:
+	cryptonaut bitcoin psbt sign <psbt> --private-key <hex or WIF>
+	cryptonaut bitcoin psbt sign <psbt> --mnemonic "your mnemonic phrase" --index 0
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinPsbtSignCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// bound here instead of init() as the hd command binds the same keys
+		bindFlags(cmd, config.FlagMnemonic, config.FlagIndex, config.FlagNetworkCoinType)
+		return nil
+	},
+}
+
+var bitcoinPsbtCombineCmd = &cobra.Command{
+	Use:   "combine",
+	Short: "Combine several PSBTs for the same transaction",
+	Long: `Combine several PSBTs for the same transaction
+	Usage:
+	cryptonaut bitcoin psbt combine <psbt> <psbt> [<psbt>...]
+	`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runBitcoinPsbtCombineCmd,
+}
+
+var bitcoinPsbtFinalizeCmd = &cobra.Command{
+	Use:   "finalize",
+	Short: "Finalize a PSBT and optionally extract the raw transaction",
+	Long: `Finalize a PSBT and optionally extract the raw transaction
+	Usage:
+	cryptonaut bitcoin psbt finalize <psbt>
+	cryptonaut bitcoin psbt finalize <psbt> --extract
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinPsbtFinalizeCmd,
+}
+
+var bitcoinPsbtExtractCmd = &cobra.Command{
+	Use:   "extract",
+	Short: "Extract the raw transaction from a finalized PSBT",
+	Long: `Extract the raw transaction from a finalized PSBT
+	Usage:
+	cryptonaut bitcoin psbt extract <psbt>
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinPsbtExtractCmd,
+}
+
+func init() {
+	bitcoinPsbtCmd.AddCommand(bitcoinPsbtCreateCmd)
+	bitcoinPsbtCmd.AddCommand(bitcoinPsbtDecodeCmd)
+	bitcoinPsbtCmd.AddCommand(bitcoinPsbtSignCmd)
+	bitcoinPsbtCmd.AddCommand(bitcoinPsbtCombineCmd)
+	bitcoinPsbtCmd.AddCommand(bitcoinPsbtFinalizeCmd)
+	bitcoinPsbtCmd.AddCommand(bitcoinPsbtExtractCmd)
+
+	bitcoinPsbtSignCmd.Flags().String(config.FlagMnemonic, "", "Mnemonic phrase used to derive the signing key")
+	bitcoinPsbtSignCmd.Flags().Int(config.FlagIndex, 0, "Derivation index of the signing key")
+	addNetworkCoinTypeFlag(bitcoinPsbtSignCmd)
+
+	bitcoinPsbtFinalizeCmd.Flags().Bool(config.FlagExtract, false, "Extract the raw transaction after finalizing")
+	viper.BindPFlag(config.FlagExtract, bitcoinPsbtFinalizeCmd.Flags().Lookup(config.FlagExtract))
+
+	// Add the bitcoinPsbtCmd to the bitcoinCmd root command
+	bitcoinCmd.AddCommand(bitcoinPsbtCmd)
+}
+
+func runBitcoinPsbtCreateCmd(cmd *cobra.Command, args []string) error {
+	tx, err := bitcoin.DecodeBitcoinRawTx(args[0])
+	if err != nil {
+		return err
+	}
+	packet, err := bitcoin.CreatePSBT(tx)
+	if err != nil {
+		return err
+	}
+	return printPsbt(cmd, packet)
+}
+
+func runBitcoinPsbtDecodeCmd(cmd *cobra.Command, args []string) error {
+	packet, err := bitcoin.DecodePSBT(args[0])
+	if err != nil {
+		return err
+	}
+
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return err
+	}
+
+	jsonData, err := json.MarshalIndent(newBitcoinPsbtInfo(packet, net), "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error marshaling JSON: %v", err)
+	}
+
+	fmt.Println(string(jsonData))
+	return nil
+}
+
+func runBitcoinPsbtSignCmd(cmd *cobra.Command, args []string) error {
+	packet, err := bitcoin.DecodePSBT(args[0])
+	if err != nil {
+		return err
+	}
+
+	privKey, err := psbtSigningKey()
+	if err != nil {
+		return err
+	}
+
+	signed, err := bitcoin.SignPSBT(packet, privKey)
+	if err != nil {
+		return err
+	}
+	if len(signed) == 0 {
+		return fmt.Errorf("the key cannot sign any input of the psbt")
+	}
+
+	cmd.Println("Signed inputs:", signed)
+	return printPsbt(cmd, packet)
+}
+
+func runBitcoinPsbtCombineCmd(cmd *cobra.Command, args []string) error {
+	packets := make([]*psbt.Packet, len(args))
+	for i, arg := range args {
+		packet, err := bitcoin.DecodePSBT(arg)
+		if err != nil {
+			return fmt.Errorf("psbt %d: %w", i, err)
+		}
+		packets[i] = packet
+	}
+
+	combined, err := bitcoin.CombinePSBTs(packets)
+	if err != nil {
+		return err
+	}
+	return printPsbt(cmd, combined)
+}
+
+func runBitcoinPsbtFinalizeCmd(cmd *cobra.Command, args []string) error {
+	packet, err := bitcoin.DecodePSBT(args[0])
+	if err != nil {
+		return err
+	}
+	if err := bitcoin.FinalizePSBT(packet); err != nil {
+		return err
+	}
+
+	if !viper.GetBool(config.FlagExtract) {
+		cmd.Println("Complete:", packet.IsComplete())
+		return printPsbt(cmd, packet)
+	}
+	return printPsbtTx(cmd, packet)
+}
+
+func runBitcoinPsbtExtractCmd(cmd *cobra.Command, args []string) error {
+	packet, err := bitcoin.DecodePSBT(args[0])
+	if err != nil {
+		return err
+	}
+	return printPsbtTx(cmd, packet)
+}
+
+// psbtSigningKey returns the key given with --private-key, or derived from
+// --mnemonic and --index
+func psbtSigningKey() (*btcec.PrivateKey, error) {
+	if privKey := viper.GetString(config.FlagPrivateKey); privKey != "" {
+		return bitcoin.ParsePrivateKey(privKey)
+	}
+
+	mnemonic := viper.GetString(config.FlagMnemonic)
+	if mnemonic == "" {
+		return nil, fmt.Errorf("either --%s or --%s is required", config.FlagPrivateKey, config.FlagMnemonic)
+	}
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return nil, err
+	}
+	hdNode, err := bitcoinHDNode(mnemonic, net)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create hdnode: %v", err)
+	}
+	return hd.DeriveBitcoinPrivateKey(hdNode, crypto.DerivationIndex(viper.GetInt(config.FlagIndex)))
+}
+
+func printPsbt(cmd *cobra.Command, packet *psbt.Packet) error {
+	encoded, err := bitcoin.EncodePSBT(packet)
+	if err != nil {
+		return err
+	}
+	cmd.Println("PSBT:", encoded)
+	return nil
+}
+
+func printPsbtTx(cmd *cobra.Command, packet *psbt.Packet) error {
+	tx, err := bitcoin.ExtractPSBTTx(packet)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return err
+	}
+	cmd.Println("Raw transaction:", hex.EncodeToString(buf.Bytes()))
+	return nil
+}
+
+// newBitcoinPsbtInfo converts a PSBT to our display format
+func newBitcoinPsbtInfo(packet *psbt.Packet, net *chaincfg.Params) bitcoinPsbtInfo {
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut)
+	for i, txIn := range packet.UnsignedTx.TxIn {
+		in := packet.Inputs[i]
+		if in.WitnessUtxo != nil {
+			prevOuts[txIn.PreviousOutPoint] = in.WitnessUtxo
+		} else if in.NonWitnessUtxo != nil && int(txIn.PreviousOutPoint.Index) < len(in.NonWitnessUtxo.TxOut) {
+			prevOuts[txIn.PreviousOutPoint] = in.NonWitnessUtxo.TxOut[txIn.PreviousOutPoint.Index]
+		}
+	}
+
+	info := bitcoinPsbtInfo{
+		Tx:       newBitcoinTxInfo(packet.UnsignedTx, net, prevOuts),
+		Inputs:   make([]bitcoinPsbtInputInfo, len(packet.Inputs)),
+		Outputs:  make([]bitcoinPsbtOutputInfo, len(packet.Outputs)),
+		Complete: packet.IsComplete(),
+	}
+	if fee, err := packet.GetTxFee(); err == nil {
+		info.Fee = int64(fee)
+	}
+
+	for i, in := range packet.Inputs {
+		inputInfo := bitcoinPsbtInputInfo{
+			RedeemScript:       hex.EncodeToString(in.RedeemScript),
+			WitnessScript:      hex.EncodeToString(in.WitnessScript),
+			FinalScriptSig:     hex.EncodeToString(in.FinalScriptSig),
+			FinalScriptWitness: hex.EncodeToString(in.FinalScriptWitness),
+			TaprootKeySpendSig: hex.EncodeToString(in.TaprootKeySpendSig),
+			TaprootInternalKey: hex.EncodeToString(in.TaprootInternalKey),
+			TaprootMerkleRoot:  hex.EncodeToString(in.TaprootMerkleRoot),
+		}
+		if in.SighashType != 0 {
+			inputInfo.SighashType = uint32(in.SighashType)
+		}
+		if in.WitnessUtxo != nil {
+			inputInfo.WitnessUtxo = &bitcoinOutputInfo{
+				Value:        in.WitnessUtxo.Value,
+				ScriptPubKey: bitcoin.ClassifyScript(in.WitnessUtxo.PkScript, net),
+			}
+		}
+		if in.NonWitnessUtxo != nil {
+			inputInfo.NonWitnessUtxo = in.NonWitnessUtxo.TxHash().String()
+		}
+		if len(in.PartialSigs) > 0 {
+			inputInfo.PartialSigs = make(map[string]string, len(in.PartialSigs))
+			for _, sig := range in.PartialSigs {
+				inputInfo.PartialSigs[hex.EncodeToString(sig.PubKey)] = hex.EncodeToString(sig.Signature)
+			}
+		}
+		for _, deriv := range in.Bip32Derivation {
+			inputInfo.Bip32Derivation = append(inputInfo.Bip32Derivation, newBitcoinBip32Info(deriv))
+		}
+		info.Inputs[i] = inputInfo
+	}
+
+	for i, out := range packet.Outputs {
+		outputInfo := bitcoinPsbtOutputInfo{
+			RedeemScript:       hex.EncodeToString(out.RedeemScript),
+			WitnessScript:      hex.EncodeToString(out.WitnessScript),
+			TaprootInternalKey: hex.EncodeToString(out.TaprootInternalKey),
+		}
+		for _, deriv := range out.Bip32Derivation {
+			outputInfo.Bip32Derivation = append(outputInfo.Bip32Derivation, newBitcoinBip32Info(deriv))
+		}
+		info.Outputs[i] = outputInfo
+	}
+
+	return info
+}
+
+func newBitcoinBip32Info(deriv *psbt.Bip32Derivation) bitcoinBip32Info {
+	path := "m"
+	for _, index := range deriv.Bip32Path {
+		if index >= 0x80000000 {
+			path += fmt.Sprintf("/%d'", index-0x80000000)
+		} else {
+			path += fmt.Sprintf("/%d", index)
+		}
+	}
+	return bitcoinBip32Info{
+		PubKey:      hex.EncodeToString(deriv.PubKey),
+		Fingerprint: fmt.Sprintf("%08x", deriv.MasterKeyFingerprint),
+		Path:        path,
+	}
+}
+
+type bitcoinPsbtInfo struct {
+	Tx       bitcoinTxInfo           `json:"tx"`
+	Inputs   []bitcoinPsbtInputInfo  `json:"inputs"`
+	Outputs  []bitcoinPsbtOutputInfo `json:"outputs"`
+	Fee      int64                   `json:"fee,omitempty"`
+	Complete bool                    `json:"complete"`
+}
+
+type bitcoinPsbtInputInfo struct {
+	WitnessUtxo        *bitcoinOutputInfo `json:"witnessUtxo,omitempty"`
+	NonWitnessUtxo     string             `json:"nonWitnessUtxo,omitempty"`
+	PartialSigs        map[string]string  `json:"partialSignatures,omitempty"`
+	SighashType        uint32             `json:"sighashType,omitempty"`
+	RedeemScript       string             `json:"redeemScript,omitempty"`
+	WitnessScript      string             `json:"witnessScript,omitempty"`
+	Bip32Derivation    []bitcoinBip32Info `json:"bip32Derivation,omitempty"`
+	FinalScriptSig     string             `json:"finalScriptSig,omitempty"`
+	FinalScriptWitness string             `json:"finalScriptWitness,omitempty"`
+	TaprootKeySpendSig string             `json:"taprootKeySpendSig,omitempty"`
+	TaprootInternalKey string             `json:"taprootInternalKey,omitempty"`
+	TaprootMerkleRoot  string             `json:"taprootMerkleRoot,omitempty"`
+}
+
+type bitcoinPsbtOutputInfo struct {
+	RedeemScript       string             `json:"redeemScript,omitempty"`
+	WitnessScript      string             `json:"witnessScript,omitempty"`
+	Bip32Derivation    []bitcoinBip32Info `json:"bip32Derivation,omitempty"`
+	TaprootInternalKey string             `json:"taprootInternalKey,omitempty"`
+}
+
+type bitcoinBip32Info struct {
+	PubKey      string `json:"pubkey"`
+	Fingerprint string `json:"masterFingerprint"`
+	Path        string `json:"path"`
+}
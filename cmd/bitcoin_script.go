@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/alejoacosta74/cryptonaut/internal/config"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin/script"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var bitcoinScriptCmd = &cobra.Command{
+	Use:   "script",
+	Short: "Bitcoin script assembler, disassembler and interpreter",
+	Long: `Bitcoin script assembler, disassembler and interpreter.
+
+Scripts are written in ASM as whitespace separated tokens:
+	OP_DUP, DUP       opcodes, with or without the OP_ prefix
+	144, -1           decimal numbers of up to 10 digits, minimally encoded
+	62e907b1...       hex data, pushed with the smallest push opcode
+	0x4c01ff          raw hex, inserted without push opcode
+	'hello'           text, pushed as bytes`,
+}
+
+var bitcoinScriptAssembleCmd = &cobra.Command{
+	Use:   "assemble",
+	Short: "Compile a script from ASM to hex",
+	Long: `Compile a script from ASM to hex
+	Usage:
+	cryptonaut bitcoin script assemble "OP_DUP OP_HASH160 62e907b15cbf27d5425399ebf6f0fb50ebb88f18 OP_EQUALVERIFY OP_CHECKSIG"
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinScriptAssembleCmd,
+}
+
+var bitcoinScriptDisassembleCmd = &cobra.Command{
+	Use:   "disassemble",
+	Short: "Decompile a script from hex to ASM",
+	Long: `Decompile a script from hex to ASM
+	Usage:
+	cryptonaut bitcoin script disassemble 76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinScriptDisassembleCmd,
+}
+
+var bitcoinScriptRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Execute a scriptSig and witness against a scriptPubKey step by step",
+	Long: `Execute a scriptSig and witness against a scriptPubKey, printing the stacks
+after every opcode and the opcode that failed, if any. Scripts are given in
+hex or ASM, witness items in hex.
+
+Without --tx the script is spent by a made-up transaction built from
+--sequence and --locktime: timelocks can be tested but signatures will not
+verify. Pass the real spending transaction with --tx and --input to check
+signatures.
+	Usage:
+	cryptonaut bitcoin script run --script-pubkey "800000 OP_CHECKLOCKTIMEVERIFY OP_DROP OP_TRUE" --locktime 800000
+	cryptonaut bitcoin script run --script-sig <hex> --script-pubkey <hex> --tx <raw tx hex> --input 0
+	cryptonaut bitcoin script run --witness <sig> --witness <pubkey> --script-pubkey <hex> --tx <raw tx hex> --amount 100000
+	`,
+	Args: cobra.NoArgs,
+	RunE: runBitcoinScriptRunCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// bound here instead of init() as the tx commands bind the same keys
+		bindFlags(cmd, config.FlagScriptSig, config.FlagScriptPubKey, config.FlagWitness, config.FlagTx,
+			config.FlagInputIndex, config.FlagAmount, config.FlagSequence, config.FlagLockTime)
+		return cmd.MarkFlagRequired(config.FlagScriptPubKey)
+	},
+}
+
+func init() {
+	bitcoinScriptCmd.AddCommand(bitcoinScriptAssembleCmd)
+	bitcoinScriptCmd.AddCommand(bitcoinScriptDisassembleCmd)
+	bitcoinScriptCmd.AddCommand(bitcoinScriptRunCmd)
+
+	bitcoinScriptRunCmd.Flags().String(config.FlagScriptSig, "", "Signature script (hex or ASM)")
+	bitcoinScriptRunCmd.Flags().String(config.FlagScriptPubKey, "", "Spent output script (hex or ASM)")
+	bitcoinScriptRunCmd.Flags().StringArray(config.FlagWitness, nil, "Witness item in hex (repeatable, bottom of the stack first)")
+	bitcoinScriptRunCmd.Flags().String(config.FlagTx, "", "Spending transaction in hex")
+	bitcoinScriptRunCmd.Flags().Int(config.FlagInputIndex, 0, "Index of the input spending the script in --tx")
+	bitcoinScriptRunCmd.Flags().Int64(config.FlagAmount, 0, "Value of the spent output in sats")
+	bitcoinScriptRunCmd.Flags().Uint32(config.FlagSequence, 0, "Input sequence of the made-up spending transaction")
+	bitcoinScriptRunCmd.Flags().Uint32(config.FlagLockTime, 0, "Locktime of the made-up spending transaction")
+
+	// Add the bitcoinScriptCmd to the bitcoinCmd root command
+	bitcoinCmd.AddCommand(bitcoinScriptCmd)
+}
+
+func runBitcoinScriptAssembleCmd(cmd *cobra.Command, args []string) error {
+	s, err := script.Assemble(args[0])
+	if err != nil {
+		return err
+	}
+	cmd.Println("Script:", hex.EncodeToString(s))
+	return nil
+}
+
+func runBitcoinScriptDisassembleCmd(cmd *cobra.Command, args []string) error {
+	s, err := hex.DecodeString(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid script hex: %v", err)
+	}
+	asm, err := script.Disassemble(s)
+	if err != nil {
+		return err
+	}
+	cmd.Println("ASM:", asm)
+	return nil
+}
+
+func runBitcoinScriptRunCmd(cmd *cobra.Command, args []string) error {
+	pkScript, err := script.Parse(viper.GetString(config.FlagScriptPubKey))
+	if err != nil {
+		return fmt.Errorf("invalid script pubkey: %v", err)
+	}
+	sigScript, err := script.Parse(viper.GetString(config.FlagScriptSig))
+	if err != nil {
+		return fmt.Errorf("invalid script sig: %v", err)
+	}
+	var witness wire.TxWitness
+	for _, item := range viper.GetStringSlice(config.FlagWitness) {
+		data, err := hex.DecodeString(item)
+		if err != nil {
+			return fmt.Errorf("invalid witness item %s: %v", item, err)
+		}
+		witness = append(witness, data)
+	}
+
+	opts := script.ExecOptions{
+		InputIndex: viper.GetInt(config.FlagInputIndex),
+		Amount:     viper.GetInt64(config.FlagAmount),
+		Sequence:   viper.GetUint32(config.FlagSequence),
+		LockTime:   viper.GetUint32(config.FlagLockTime),
+	}
+	if rawTx := viper.GetString(config.FlagTx); rawTx != "" {
+		if opts.Tx, err = bitcoin.DecodeBitcoinRawTx(rawTx); err != nil {
+			return err
+		}
+	}
+
+	trace, err := script.Execute(pkScript, sigScript, witness, opts)
+	if err != nil {
+		return err
+	}
+	for i, step := range trace.Steps {
+		cmd.Printf("Step %d: %s\n", i+1, step.Opcode)
+		cmd.Printf("    Stack: %s\n", formatStack(step.Stack))
+		if len(step.AltStack) > 0 {
+			cmd.Printf("    Alt stack: %s\n", formatStack(step.AltStack))
+		}
+	}
+
+	cmd.Println("Script is valid:", trace.Valid())
+	if !trace.Valid() {
+		if trace.FailedAt != "" {
+			cmd.Println("Failed at:", trace.FailedAt)
+		}
+		cmd.Println("Reason:", trace.Err)
+	}
+	return nil
+}
+
+// formatStack prints a stack bottom first, empty items as ""
+func formatStack(stack [][]byte) string {
+	items := make([]string, len(stack))
+	for i, item := range stack {
+		items[i] = hex.EncodeToString(item)
+		if len(item) == 0 {
+			items[i] = `""`
+		}
+	}
+	return "[" + strings.Join(items, " ") + "]"
+}
+
+// bindFlags binds the local flags of a command to viper. Commands sharing
+// flag names call it from PreRunE, so that only the executed command is bound.
+func bindFlags(cmd *cobra.Command, names ...string) {
+	for _, name := range names {
+		viper.BindPFlag(name, cmd.Flags().Lookup(name))
+	}
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alejoacosta74/cryptonaut/internal/config"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin/script"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin/taproot"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var bitcoinTaprootCmd = &cobra.Command{
+	Use:   "taproot",
+	Short: "Taproot script trees",
+	Long: `Taproot script trees (BIP341) and script-path spends (BIP342).
+
+Leaves are given with --leaf as a tapscript in hex or ASM, optionally followed
+by :<weight> (default 1). Heavier leaves, the ones more likely to be spent,
+are placed closer to the root. Without --internal-key the unspendable BIP341
+NUMS key is used, so the output can only be spent through its scripts.`,
+}
+
+var bitcoinTaprootBuildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build a taproot output from an internal key and script leaves",
+	Long: `Build a taproot output and print its address and the control block of each leaf
+	Usage:
+	cryptonaut bitcoin taproot build --internal-key <x-only pubkey> --leaf "<pubkey1> OP_CHECKSIG:3" --leaf "144 OP_CSV OP_DROP <pubkey2> OP_CHECKSIG"
+	`,
+	Args:    cobra.NoArgs,
+	RunE:    runBitcoinTaprootBuildCmd,
+	PreRunE: bindTaprootFlags,
+}
+
+var bitcoinTaprootSpendCmd = &cobra.Command{
+	Use:   "spend",
+	Short: "Sign a transaction input spending a taproot leaf",
+	Long: `Sign an input of a transaction through the script path of a taproot leaf and
+set its witness: the --witness items (bottom of the stack first), the
+signature, the leaf script and its control block. The outputs spent by the
+transaction are read from --prevouts.
+	Usage:
+	cryptonaut bitcoin taproot spend <raw tx hex> --leaf "<pubkey1> OP_CHECKSIG" --leaf "<pubkey2> OP_CHECKSIG" --leaf-index 1 --input 0 --prevouts prevouts.json --private-key <hex or WIF>
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinTaprootSpendCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// bound here instead of init() as the tx and script commands bind the same keys
+		bindFlags(cmd, config.FlagLeafIndex, config.FlagInputIndex, config.FlagPrevouts, config.FlagWitness, config.FlagSigHash)
+		if err := cmd.MarkFlagRequired(config.FlagPrevouts); err != nil {
+			return err
+		}
+		return bindTaprootFlags(cmd, args)
+	},
+}
+
+func init() {
+	bitcoinTaprootCmd.AddCommand(bitcoinTaprootBuildCmd)
+	bitcoinTaprootCmd.AddCommand(bitcoinTaprootSpendCmd)
+
+	for _, cmd := range []*cobra.Command{bitcoinTaprootBuildCmd, bitcoinTaprootSpendCmd} {
+		cmd.Flags().String(config.FlagInternalKey, "", "Internal key, x-only or compressed hex (default unspendable NUMS key)")
+		cmd.Flags().StringArray(config.FlagLeaf, nil, "Leaf script in hex or ASM, optionally followed by :<weight> (repeatable)")
+	}
+	bitcoinTaprootSpendCmd.Flags().Int(config.FlagLeafIndex, 0, "Index of the spent leaf, in --leaf order")
+	bitcoinTaprootSpendCmd.Flags().Int(config.FlagInputIndex, 0, "Index of the signed input")
+	bitcoinTaprootSpendCmd.Flags().String(config.FlagPrevouts, "", "JSON file with the outputs spent by the transaction")
+	bitcoinTaprootSpendCmd.Flags().StringArray(config.FlagWitness, nil, "Witness item in hex placed below the signature (repeatable, bottom of the stack first)")
+	bitcoinTaprootSpendCmd.Flags().String(config.FlagSigHash, "DEFAULT", "Sighash type, e.g. DEFAULT, ALL, SINGLE|ANYONECANPAY")
+
+	// Add the bitcoinTaprootCmd to the bitcoinCmd root command
+	bitcoinCmd.AddCommand(bitcoinTaprootCmd)
+}
+
+// bindTaprootFlags binds the flags shared by the taproot commands
+func bindTaprootFlags(cmd *cobra.Command, args []string) error {
+	bindFlags(cmd, config.FlagInternalKey, config.FlagLeaf)
+	return nil
+}
+
+// taprootTreeFromFlags builds the taproot output given by --internal-key and
+// --leaf
+func taprootTreeFromFlags() (*taproot.Tree, error) {
+	var leaves []taproot.Leaf
+	for _, value := range viper.GetStringSlice(config.FlagLeaf) {
+		leaf, err := parseTaprootLeaf(value)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+	}
+	return taproot.New(viper.GetString(config.FlagInternalKey), leaves)
+}
+
+// parseTaprootLeaf parses a leaf given as <script>[:<weight>]
+func parseTaprootLeaf(s string) (taproot.Leaf, error) {
+	leaf := taproot.Leaf{Weight: 1}
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		if weight, err := strconv.Atoi(strings.TrimSpace(s[i+1:])); err == nil {
+			s, leaf.Weight = s[:i], weight
+		}
+	}
+	var err error
+	if leaf.Script, err = script.Parse(s); err != nil {
+		return leaf, fmt.Errorf("invalid leaf script %s: %v", s, err)
+	}
+	return leaf, nil
+}
+
+func runBitcoinTaprootBuildCmd(cmd *cobra.Command, args []string) error {
+	tree, err := taprootTreeFromFlags()
+	if err != nil {
+		return err
+	}
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return err
+	}
+	address, err := tree.Address(net)
+	if err != nil {
+		return err
+	}
+	pkScript, err := tree.ScriptPubKey()
+	if err != nil {
+		return err
+	}
+
+	cmd.Println("Address:", address)
+	cmd.Println("Script pubkey:", hex.EncodeToString(pkScript))
+	cmd.Println("Internal key:", hex.EncodeToString(tree.InternalKey.SerializeCompressed()[1:]))
+	cmd.Println("Output key:", hex.EncodeToString(pkScript[2:]))
+	if tree.MerkleRoot != nil {
+		cmd.Println("Merkle root:", hex.EncodeToString(tree.MerkleRoot))
+	}
+	for i, leaf := range tree.Leaves {
+		controlBlock, err := tree.ControlBlock(i)
+		if err != nil {
+			return err
+		}
+		asm, err := script.Disassemble(leaf.Script)
+		if err != nil {
+			return err
+		}
+		cmd.Printf("Leaf %d (weight %d, depth %d):\n", i, leaf.Weight, tree.Depth(i))
+		cmd.Println("    Script:", asm)
+		cmd.Println("    Leaf hash:", hex.EncodeToString(tree.LeafHash(i)))
+		cmd.Println("    Control block:", hex.EncodeToString(controlBlock))
+	}
+	return nil
+}
+
+func runBitcoinTaprootSpendCmd(cmd *cobra.Command, args []string) error {
+	tx, err := bitcoin.DecodeBitcoinRawTx(args[0])
+	if err != nil {
+		return err
+	}
+	tree, err := taprootTreeFromFlags()
+	if err != nil {
+		return err
+	}
+	idx := viper.GetInt(config.FlagInputIndex)
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return fmt.Errorf("input index %d out of range, the transaction has %d inputs", idx, len(tx.TxIn))
+	}
+	prevOuts, err := sighashPrevOuts(tx, idx)
+	if err != nil {
+		return err
+	}
+	pkScript, err := tree.ScriptPubKey()
+	if err != nil {
+		return err
+	}
+	if prevOuts[idx] != nil && !bytes.Equal(prevOuts[idx].PkScript, pkScript) {
+		return fmt.Errorf("input %d does not spend the taproot output", idx)
+	}
+	hashType, err := bitcoin.ParseSigHashType(viper.GetString(config.FlagSigHash))
+	if err != nil {
+		return err
+	}
+	if viper.GetString(config.FlagPrivateKey) == "" {
+		return fmt.Errorf("--%s is required", config.FlagPrivateKey)
+	}
+	privKey, err := bitcoin.ParsePrivateKey(viper.GetString(config.FlagPrivateKey))
+	if err != nil {
+		return err
+	}
+
+	leafIndex := viper.GetInt(config.FlagLeafIndex)
+	sig, err := tree.SignLeaf(tx, idx, prevOuts, hashType, leafIndex, privKey)
+	if err != nil {
+		return err
+	}
+	var items [][]byte
+	for _, item := range viper.GetStringSlice(config.FlagWitness) {
+		data, err := hex.DecodeString(item)
+		if err != nil {
+			return fmt.Errorf("invalid witness item %s: %v", item, err)
+		}
+		items = append(items, data)
+	}
+	if tx.TxIn[idx].Witness, err = tree.Witness(leafIndex, append(items, sig)...); err != nil {
+		return err
+	}
+	cmd.Println("Signature:", hex.EncodeToString(sig))
+	cmd.Println("Witness:", formatStack(tx.TxIn[idx].Witness))
+
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return err
+	}
+	cmd.Println("Raw transaction:", hex.EncodeToString(buf.Bytes()))
+	return nil
+}
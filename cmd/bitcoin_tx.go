@@ -1,12 +1,21 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 
+	"github.com/alejoacosta74/cryptonaut/internal/config"
 	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var bitcoinTxCmd = &cobra.Command{
@@ -18,12 +27,167 @@ var bitcoinTxCmd = &cobra.Command{
 var bitcoinTxDecodeCmd = &cobra.Command{
 	Use:   "decode",
 	Short: "Decode a Bitcoin transaction",
-	Long:  "Decode a Bitcoin transaction",
-	RunE:  runBitcoinTxDecodeCmd,
+	Long: `Decode a Bitcoin transaction, classifying its scripts and computing its
+weight and virtual size. Output addresses are encoded for the network given
+with --network.
+
+When the outputs spent by the transaction are supplied with --prevouts (a JSON
+file in the same format as the UTXOs of "tx build"), the input values, the fee
+and the fee rate are reported too.
+	Usage:
+	cryptonaut bitcoin tx decode <raw tx hex>
+	cryptonaut bitcoin tx decode <raw tx hex> --prevouts prevouts.json --network testnet
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinTxDecodeCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// bound here instead of init() as the sighash command binds the same key
+		bindFlags(cmd, config.FlagPrevouts)
+		return nil
+	},
+}
+
+var bitcoinTxSighashCmd = &cobra.Command{
+	Use:   "sighash",
+	Short: "Compute the signature hash of a transaction input",
+	Long: `Compute the digest signed by a transaction input and print the components
+of its preimage. The algorithm (legacy, BIP143 segwit v0 or BIP341 taproot)
+is inferred from the spent script unless given with --sigversion.
+
+The spent outputs are given with --script-pubkey and --amount, either once
+for the signed input or once per input in input order (taproot signs every
+spent output unless ANYONECANPAY is used), or with a --prevouts JSON file.
+--script overrides the signed script: the redeem script of P2SH inputs or the
+witness script of P2WSH inputs (taken from the witness when omitted).
+	Usage:
+	cryptonaut bitcoin tx sighash <raw tx hex> --input 0 --script-pubkey 0014... --amount 100000
+	cryptonaut bitcoin tx sighash <raw tx hex> --input 1 --prevouts prevouts.json --sighash "SINGLE|ANYONECANPAY"
+	cryptonaut bitcoin tx sighash <raw tx hex> --input 0 --prevouts prevouts.json --leaf-script <tapscript hex>
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinTxSighashCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// bound here instead of init() as the decode command binds the same key
+		bindFlags(cmd, config.FlagPrevouts)
+		return nil
+	},
+}
+
+var bitcoinTxBuildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build a Bitcoin transaction from a set of UTXOs",
+	Long: `Build a Bitcoin transaction from a set of UTXOs, selecting the coins to
+spend (branch and bound with knapsack fallback) and computing the fee from
+the estimated virtual size. The output is an unsigned PSBT, or a signed raw
+transaction with --sign.
+
+The UTXO file is a JSON array as returned by Bitcoin Core's listunspent:
+	[{"txid": "...", "vout": 0, "amount": 0.001, "scriptPubKey": "0014..."}]
+Values may be given in satoshis with "value" instead of "amount". P2SH and
+P2WSH coins also need "redeemScript" / "witnessScript", and legacy coins need
+the previous transaction ("prevTx") to produce a PSBT.
+	Usage:
+	cryptonaut bitcoin tx build --utxos utxos.json --output <address>:<sats> --fee-rate 5 --change-address <address>
+	cryptonaut bitcoin tx build --utxos utxos.json --output <address>:<sats> --fee-rate 5 --change-address <address> --rbf --sign --private-key <hex or WIF>
+	`,
+	Args: cobra.NoArgs,
+	RunE: runBitcoinTxBuildCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// bound here instead of init() as the hd command binds the same keys
+		bindFlags(cmd, config.FlagMnemonic, config.FlagIndex, config.FlagNetworkCoinType)
+		if err := cmd.MarkFlagRequired(config.FlagUtxos); err != nil {
+			return err
+		}
+		if err := cmd.MarkFlagRequired(config.FlagOutput); err != nil {
+			return err
+		}
+		return cmd.MarkFlagRequired(config.FlagChangeAddress)
+	},
+}
+
+var bitcoinTxBumpCmd = &cobra.Command{
+	Use:   "bump",
+	Short: "Raise the fee rate of an unconfirmed transaction",
+	Long: `Raise the fee rate of an unconfirmed transaction to --fee-rate, with one of
+two methods:
+	rbf   a BIP125 replacement spending the same inputs, the extra fee being
+	      taken from the change output (dropped when left as dust)
+	cpfp  a child spending the change output, paying enough fee for the parent
+	      and the child together to reach the fee rate
+
+The outputs spent by the transaction are given with --prevouts (a JSON file in
+the same format as the UTXOs of "tx build"). The change output is given with
+--change-index or found by --change-address, which also receives the CPFP
+child output. The output is an unsigned PSBT.
+	Usage:
+	cryptonaut bitcoin tx bump <raw tx hex> --prevouts prevouts.json --fee-rate 20 --change-address <address>
+	cryptonaut bitcoin tx bump <raw tx hex> --prevouts prevouts.json --fee-rate 20 --change-index 1 --method cpfp
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBitcoinTxBumpCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// bound here instead of init() as the build, decode and sighash
+		// commands bind the same keys
+		bindFlags(cmd, config.FlagPrevouts, config.FlagFeeRate, config.FlagChangeAddress)
+		if err := cmd.MarkFlagRequired(config.FlagPrevouts); err != nil {
+			return err
+		}
+		return cmd.MarkFlagRequired(config.FlagFeeRate)
+	},
 }
 
 func init() {
 	bitcoinTxCmd.AddCommand(bitcoinTxDecodeCmd)
+	bitcoinTxCmd.AddCommand(bitcoinTxBuildCmd)
+	bitcoinTxCmd.AddCommand(bitcoinTxBumpCmd)
+
+	bitcoinTxCmd.AddCommand(bitcoinTxSighashCmd)
+
+	bitcoinTxDecodeCmd.Flags().String(config.FlagPrevouts, "", "JSON file with the outputs spent by the transaction")
+
+	bitcoinTxSighashCmd.Flags().String(config.FlagPrevouts, "", "JSON file with the outputs spent by the transaction")
+	bitcoinTxSighashCmd.Flags().Int(config.FlagInputIndex, 0, "Index of the signed input")
+	viper.BindPFlag(config.FlagInputIndex, bitcoinTxSighashCmd.Flags().Lookup(config.FlagInputIndex))
+	bitcoinTxSighashCmd.Flags().StringArray(config.FlagScriptPubKey, nil, "Spent output script in hex (repeatable)")
+	viper.BindPFlag(config.FlagScriptPubKey, bitcoinTxSighashCmd.Flags().Lookup(config.FlagScriptPubKey))
+	bitcoinTxSighashCmd.Flags().IntSlice(config.FlagAmount, nil, "Spent output amount in sats (repeatable)")
+	viper.BindPFlag(config.FlagAmount, bitcoinTxSighashCmd.Flags().Lookup(config.FlagAmount))
+	bitcoinTxSighashCmd.Flags().String(config.FlagScript, "", "Signed script in hex (redeem script or witness script)")
+	viper.BindPFlag(config.FlagScript, bitcoinTxSighashCmd.Flags().Lookup(config.FlagScript))
+	bitcoinTxSighashCmd.Flags().String(config.FlagSigHash, "", "Sighash type, e.g. ALL, NONE, SINGLE|ANYONECANPAY, DEFAULT (default ALL, DEFAULT for taproot)")
+	viper.BindPFlag(config.FlagSigHash, bitcoinTxSighashCmd.Flags().Lookup(config.FlagSigHash))
+	bitcoinTxSighashCmd.Flags().String(config.FlagSigVersion, "", "Signature hash algorithm (legacy, segwitv0, taproot)")
+	viper.BindPFlag(config.FlagSigVersion, bitcoinTxSighashCmd.Flags().Lookup(config.FlagSigVersion))
+	bitcoinTxSighashCmd.Flags().String(config.FlagLeafScript, "", "Tapscript of a taproot script-path spend in hex")
+	viper.BindPFlag(config.FlagLeafScript, bitcoinTxSighashCmd.Flags().Lookup(config.FlagLeafScript))
+	bitcoinTxSighashCmd.Flags().String(config.FlagLeafHash, "", "Leaf hash of a taproot script-path spend in hex")
+	viper.BindPFlag(config.FlagLeafHash, bitcoinTxSighashCmd.Flags().Lookup(config.FlagLeafHash))
+
+	bitcoinTxBuildCmd.Flags().String(config.FlagUtxos, "", "JSON file with the UTXOs available to fund the transaction")
+	viper.BindPFlag(config.FlagUtxos, bitcoinTxBuildCmd.Flags().Lookup(config.FlagUtxos))
+	bitcoinTxBuildCmd.Flags().StringArrayP(config.FlagOutput, "o", nil, "Output as <address>:<amount in sats> (repeatable)")
+	viper.BindPFlag(config.FlagOutput, bitcoinTxBuildCmd.Flags().Lookup(config.FlagOutput))
+	bitcoinTxBuildCmd.Flags().Float64(config.FlagFeeRate, 1, "Fee rate in sat/vB")
+	viper.BindPFlag(config.FlagFeeRate, bitcoinTxBuildCmd.Flags().Lookup(config.FlagFeeRate))
+	bitcoinTxBuildCmd.Flags().String(config.FlagChangeAddress, "", "Address receiving the change")
+	viper.BindPFlag(config.FlagChangeAddress, bitcoinTxBuildCmd.Flags().Lookup(config.FlagChangeAddress))
+	bitcoinTxBuildCmd.Flags().Bool(config.FlagRBF, false, "Signal BIP125 replace-by-fee")
+	viper.BindPFlag(config.FlagRBF, bitcoinTxBuildCmd.Flags().Lookup(config.FlagRBF))
+	bitcoinTxBuildCmd.Flags().Uint32(config.FlagLockTime, 0, "Transaction locktime")
+	viper.BindPFlag(config.FlagLockTime, bitcoinTxBuildCmd.Flags().Lookup(config.FlagLockTime))
+	bitcoinTxBuildCmd.Flags().Bool(config.FlagSign, false, "Sign the transaction and output the raw transaction")
+	viper.BindPFlag(config.FlagSign, bitcoinTxBuildCmd.Flags().Lookup(config.FlagSign))
+	bitcoinTxBuildCmd.Flags().String(config.FlagMnemonic, "", "Mnemonic phrase used to derive the signing key")
+	bitcoinTxBuildCmd.Flags().Int(config.FlagIndex, 0, "Derivation index of the signing key")
+	addNetworkCoinTypeFlag(bitcoinTxBuildCmd)
+
+	bitcoinTxBumpCmd.Flags().String(config.FlagPrevouts, "", "JSON file with the outputs spent by the transaction")
+	bitcoinTxBumpCmd.Flags().Float64(config.FlagFeeRate, 0, "Target fee rate in sat/vB")
+	bitcoinTxBumpCmd.Flags().String(config.FlagChangeAddress, "", "Address of the change output")
+	bitcoinTxBumpCmd.Flags().Int(config.FlagChangeIndex, -1, "Index of the change output")
+	viper.BindPFlag(config.FlagChangeIndex, bitcoinTxBumpCmd.Flags().Lookup(config.FlagChangeIndex))
+	bitcoinTxBumpCmd.Flags().String(config.FlagBumpMethod, "rbf", "Fee bumping method (rbf, cpfp)")
+	viper.BindPFlag(config.FlagBumpMethod, bitcoinTxBumpCmd.Flags().Lookup(config.FlagBumpMethod))
 
 	// Add the bitcoinTxCmd to the bitcoinCmd root command
 	bitcoinCmd.AddCommand(bitcoinTxCmd)
@@ -36,32 +200,352 @@ func runBitcoinTxDecodeCmd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("Error decoding Bitcoin raw transaction: %v", err)
 	}
 
-	// Convert to our display format
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return err
+	}
+	var prevOuts map[wire.OutPoint]*wire.TxOut
+	if path := viper.GetString(config.FlagPrevouts); path != "" {
+		utxos, err := bitcoin.LoadUTXOs(path)
+		if err != nil {
+			return err
+		}
+		if prevOuts, err = bitcoin.PrevOutsFromUTXOs(utxos); err != nil {
+			return err
+		}
+	}
+
+	txInfo := newBitcoinTxInfo(tx, net, prevOuts)
+
+	jsonData, err := json.MarshalIndent(txInfo, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error marshaling JSON: %v", err)
+	}
+
+	fmt.Println(string(jsonData))
+	return nil
+}
+
+func runBitcoinTxBuildCmd(cmd *cobra.Command, args []string) error {
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return err
+	}
+	utxos, err := bitcoin.LoadUTXOs(viper.GetString(config.FlagUtxos))
+	if err != nil {
+		return err
+	}
+	outputs, err := parseTxOutputs(viper.GetStringSlice(config.FlagOutput))
+	if err != nil {
+		return err
+	}
+
+	built, err := bitcoin.BuildTransaction(bitcoin.TxBuildOptions{
+		UTXOs:         utxos,
+		Outputs:       outputs,
+		FeeRate:       viper.GetFloat64(config.FlagFeeRate),
+		ChangeAddress: viper.GetString(config.FlagChangeAddress),
+		RBF:           viper.GetBool(config.FlagRBF),
+		LockTime:      viper.GetUint32(config.FlagLockTime),
+		Net:           net,
+	})
+	if err != nil {
+		return err
+	}
+
+	cmd.Println("Inputs:", len(built.Inputs))
+	cmd.Println("Fee:", built.Fee)
+	cmd.Println("Virtual size:", built.VSize)
+	if built.ChangeIndex >= 0 {
+		cmd.Println("Change:", built.Tx.TxOut[built.ChangeIndex].Value)
+	}
+
+	if !viper.GetBool(config.FlagSign) {
+		packet, err := built.PSBT()
+		if err != nil {
+			return err
+		}
+		return printPsbt(cmd, packet)
+	}
+
+	privKey, err := psbtSigningKey()
+	if err != nil {
+		return err
+	}
+	prevOuts := make([]*wire.TxOut, len(built.Inputs))
+	for i, u := range built.Inputs {
+		if prevOuts[i], err = u.TxOut(); err != nil {
+			return err
+		}
+	}
+	signed, err := bitcoin.SignTransaction(built.Tx, prevOuts, []*btcec.PrivateKey{privKey})
+	if err != nil {
+		return err
+	}
+	if len(signed) != len(built.Tx.TxIn) {
+		return fmt.Errorf("the key can only sign inputs %v, build a PSBT instead", signed)
+	}
+
+	var buf bytes.Buffer
+	if err := built.Tx.Serialize(&buf); err != nil {
+		return err
+	}
+	cmd.Println("Raw transaction:", hex.EncodeToString(buf.Bytes()))
+	return nil
+}
+
+func runBitcoinTxBumpCmd(cmd *cobra.Command, args []string) error {
+	tx, err := bitcoin.DecodeBitcoinRawTx(args[0])
+	if err != nil {
+		return err
+	}
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return err
+	}
+	prevOuts, err := bitcoin.LoadUTXOs(viper.GetString(config.FlagPrevouts))
+	if err != nil {
+		return err
+	}
+	opts := bitcoin.BumpOptions{
+		Tx:            tx,
+		PrevOuts:      prevOuts,
+		FeeRate:       viper.GetFloat64(config.FlagFeeRate),
+		ChangeIndex:   viper.GetInt(config.FlagChangeIndex),
+		ChangeAddress: viper.GetString(config.FlagChangeAddress),
+		Net:           net,
+	}
+
+	var bumped *bitcoin.BumpedTx
+	method := viper.GetString(config.FlagBumpMethod)
+	switch method {
+	case "rbf":
+		bumped, err = bitcoin.BumpFeeRBF(opts)
+	case "cpfp":
+		bumped, err = bitcoin.BumpFeeCPFP(opts)
+	default:
+		return fmt.Errorf("invalid method %q, expected rbf or cpfp", method)
+	}
+	if err != nil {
+		return err
+	}
+
+	cmd.Println("Original fee:", bumped.OriginalFee)
+	cmd.Printf("Original fee rate: %.2f sat/vB\n", float64(bumped.OriginalFee)/float64(bumped.OriginalVSize))
+	cmd.Println("Fee:", bumped.Fee)
+	cmd.Println("Virtual size:", bumped.VSize)
+	if bumped.ChangeIndex >= 0 {
+		cmd.Println("Change:", bumped.Tx.TxOut[bumped.ChangeIndex].Value)
+	}
+	if method == "cpfp" {
+		cmd.Printf("Package fee rate: %.2f sat/vB\n", bumped.FeeRate)
+	} else {
+		cmd.Printf("Fee rate: %.2f sat/vB\n", bumped.FeeRate)
+	}
+
+	packet, err := bumped.PSBT()
+	if err != nil {
+		return err
+	}
+	return printPsbt(cmd, packet)
+}
+
+func runBitcoinTxSighashCmd(cmd *cobra.Command, args []string) error {
+	tx, err := bitcoin.DecodeBitcoinRawTx(args[0])
+	if err != nil {
+		return err
+	}
+	idx := viper.GetInt(config.FlagInputIndex)
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return fmt.Errorf("input index %d out of range, the transaction has %d inputs", idx, len(tx.TxIn))
+	}
+	prevOuts, err := sighashPrevOuts(tx, idx)
+	if err != nil {
+		return err
+	}
+	script, err := hex.DecodeString(viper.GetString(config.FlagScript))
+	if err != nil {
+		return fmt.Errorf("invalid script: %v", err)
+	}
+
+	version := bitcoin.SigHashVersion(viper.GetString(config.FlagSigVersion))
+	if version == "" {
+		if prevOuts[idx] == nil {
+			return fmt.Errorf("the spent output of input %d is required", idx)
+		}
+		version = bitcoin.SigHashVersionForScript(prevOuts[idx].PkScript)
+	}
+
+	hashType := txscript.SigHashAll
+	if version == bitcoin.SigHashTaproot {
+		hashType = txscript.SigHashDefault
+	}
+	if s := viper.GetString(config.FlagSigHash); s != "" {
+		if hashType, err = bitcoin.ParseSigHashType(s); err != nil {
+			return err
+		}
+	}
+
+	var sigHash *bitcoin.SigHash
+	switch version {
+	case bitcoin.SigHashLegacy:
+		if len(script) == 0 {
+			if prevOuts[idx] == nil {
+				return fmt.Errorf("either the spent output or --%s is required", config.FlagScript)
+			}
+			script = prevOuts[idx].PkScript
+		}
+		sigHash, err = bitcoin.CalcLegacySigHash(tx, idx, script, hashType)
+	case bitcoin.SigHashSegwitV0:
+		if prevOuts[idx] == nil {
+			return fmt.Errorf("the spent output of input %d is required", idx)
+		}
+		if len(script) == 0 {
+			script = prevOuts[idx].PkScript
+			if witness := tx.TxIn[idx].Witness; txscript.IsPayToWitnessScriptHash(script) && len(witness) > 0 {
+				script = witness[len(witness)-1]
+			}
+		}
+		sigHash, err = bitcoin.CalcSegwitV0SigHash(tx, idx, script, prevOuts[idx].Value, hashType)
+	case bitcoin.SigHashTaproot:
+		var leafHash []byte
+		if leafHash, err = hex.DecodeString(viper.GetString(config.FlagLeafHash)); err != nil {
+			return fmt.Errorf("invalid leaf hash: %v", err)
+		}
+		if leafScript := viper.GetString(config.FlagLeafScript); leafScript != "" {
+			script, err := hex.DecodeString(leafScript)
+			if err != nil {
+				return fmt.Errorf("invalid leaf script: %v", err)
+			}
+			leafHash = bitcoin.TapLeafHash(script)
+		}
+		if len(leafHash) == 0 {
+			leafHash = nil
+		}
+		sigHash, err = bitcoin.CalcTaprootSigHash(tx, idx, prevOuts, hashType, leafHash)
+	default:
+		return fmt.Errorf("invalid sighash version: %s", version)
+	}
+	if err != nil {
+		return err
+	}
+
+	cmd.Println("Sighash version:", sigHash.Version)
+	cmd.Printf("Sighash type: %s (0x%02x)\n", bitcoin.SigHashTypeString(hashType), uint32(hashType))
+	for _, component := range sigHash.Components {
+		cmd.Printf("  %s: %s\n", component.Name, component.Value)
+	}
+	if len(sigHash.Preimage) > 0 {
+		cmd.Println("Preimage:", hex.EncodeToString(sigHash.Preimage))
+	}
+	cmd.Println("Sighash:", hex.EncodeToString(sigHash.Digest))
+	return nil
+}
+
+// sighashPrevOuts collects the outputs spent by tx, in input order, from
+// --prevouts or from --script-pubkey and --amount. Unknown outputs are nil.
+func sighashPrevOuts(tx *wire.MsgTx, idx int) ([]*wire.TxOut, error) {
+	prevOuts := make([]*wire.TxOut, len(tx.TxIn))
+	if path := viper.GetString(config.FlagPrevouts); path != "" {
+		utxos, err := bitcoin.LoadUTXOs(path)
+		if err != nil {
+			return nil, err
+		}
+		known, err := bitcoin.PrevOutsFromUTXOs(utxos)
+		if err != nil {
+			return nil, err
+		}
+		for i, txIn := range tx.TxIn {
+			prevOuts[i] = known[txIn.PreviousOutPoint]
+		}
+		return prevOuts, nil
+	}
+
+	scripts := viper.GetStringSlice(config.FlagScriptPubKey)
+	amounts := viper.GetIntSlice(config.FlagAmount)
+	if len(scripts) != len(amounts) {
+		return nil, fmt.Errorf("--%s and --%s must be given the same number of times", config.FlagScriptPubKey, config.FlagAmount)
+	}
+	var indexes []int
+	switch len(scripts) {
+	case 0:
+		return prevOuts, nil
+	case 1:
+		indexes = []int{idx}
+	case len(tx.TxIn):
+		for i := range tx.TxIn {
+			indexes = append(indexes, i)
+		}
+	default:
+		return nil, fmt.Errorf("expected the spent output of input %d or of all %d inputs, got %d", idx, len(tx.TxIn), len(scripts))
+	}
+	for i, inputIndex := range indexes {
+		pkScript, err := hex.DecodeString(scripts[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid script pubkey %s: %v", scripts[i], err)
+		}
+		prevOuts[inputIndex] = wire.NewTxOut(int64(amounts[i]), pkScript)
+	}
+	return prevOuts, nil
+}
+
+// parseTxOutputs parses outputs given as <address>:<amount in sats>
+func parseTxOutputs(values []string) ([]bitcoin.TxOutput, error) {
+	outputs := make([]bitcoin.TxOutput, len(values))
+	for i, value := range values {
+		address, amount, found := strings.Cut(value, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid output %q, expected <address>:<sats>", value)
+		}
+		sats, err := strconv.ParseInt(amount, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount in output %q: %v", value, err)
+		}
+		outputs[i] = bitcoin.TxOutput{Address: address, Value: sats}
+	}
+	return outputs, nil
+}
+
+// newBitcoinTxInfo converts a transaction to our display format. prevOuts
+// may be nil; the input values and fee are only reported when every spent
+// output is known.
+func newBitcoinTxInfo(tx *wire.MsgTx, net *chaincfg.Params, prevOuts map[wire.OutPoint]*wire.TxOut) bitcoinTxInfo {
+	weight := bitcoin.TxWeight(tx)
 	txInfo := bitcoinTxInfo{
-		Hash:     tx.TxHash().String(),
-		Version:  tx.Version,
-		Locktime: tx.LockTime,
-		Size:     tx.SerializeSize(),
-		Inputs:   make([]bitcoinInputInfo, len(tx.TxIn)),
-		Outputs:  make([]bitcoinOutputInfo, len(tx.TxOut)),
+		Hash:         tx.TxHash().String(),
+		WitnessHash:  tx.WitnessHash().String(),
+		Version:      tx.Version,
+		Locktime:     tx.LockTime,
+		LocktimeInfo: bitcoin.DescribeLockTime(tx),
+		Size:         tx.SerializeSize(),
+		Weight:       weight,
+		VSize:        bitcoin.TxVSize(tx),
+		SegWit:       tx.HasWitness(),
+		RBF:          bitcoin.SignalsRBF(tx),
+		Inputs:       make([]bitcoinInputInfo, len(tx.TxIn)),
+		Outputs:      make([]bitcoinOutputInfo, len(tx.TxOut)),
 	}
 
 	// Convert inputs
 	for i, input := range tx.TxIn {
 		txInfo.Inputs[i] = bitcoinInputInfo{
-			TxID:      input.PreviousOutPoint.Hash.String(),
-			Vout:      input.PreviousOutPoint.Index,
-			ScriptSig: hex.EncodeToString(input.SignatureScript),
-			Sequence:  input.Sequence,
+			TxID:             input.PreviousOutPoint.Hash.String(),
+			Vout:             input.PreviousOutPoint.Index,
+			ScriptSig:        hex.EncodeToString(input.SignatureScript),
+			ScriptSigAsm:     bitcoin.DisassembleScript(input.SignatureScript),
+			Sequence:         input.Sequence,
+			RelativeLocktime: bitcoin.DescribeRelativeLockTime(tx, i),
 		}
 		// Add witness data if present
-		if len(input.Witness) > 0 {
-			witnessData := make([]string, len(input.Witness))
-			for j, w := range input.Witness {
-				witnessData[j] = hex.EncodeToString(w)
+		for _, w := range input.Witness {
+			txInfo.Inputs[i].Witness = append(txInfo.Inputs[i].Witness, hex.EncodeToString(w))
+		}
+		if prevOut, ok := prevOuts[input.PreviousOutPoint]; ok {
+			txInfo.Inputs[i].Prevout = &bitcoinOutputInfo{
+				Value:        prevOut.Value,
+				ScriptPubKey: bitcoin.ClassifyScript(prevOut.PkScript, net),
 			}
-			witnessJSON, _ := json.Marshal(witnessData)
-			txInfo.Inputs[i].WitnessTypes = string(witnessJSON)
+			addSpendScriptsAsm(&txInfo.Inputs[i], input, prevOut.PkScript)
 		}
 	}
 
@@ -69,37 +553,66 @@ func runBitcoinTxDecodeCmd(cmd *cobra.Command, args []string) error {
 	for i, output := range tx.TxOut {
 		txInfo.Outputs[i] = bitcoinOutputInfo{
 			Value:        output.Value,
-			ScriptPubKey: hex.EncodeToString(output.PkScript),
+			ScriptPubKey: bitcoin.ClassifyScript(output.PkScript, net),
 		}
 	}
 
-	jsonData, err := json.MarshalIndent(txInfo, "", "    ")
-	if err != nil {
-		return fmt.Errorf("Error marshaling JSON: %v", err)
+	if fee, err := bitcoin.TxFee(tx, prevOuts); err == nil {
+		feeRate := float64(fee) / float64(txInfo.VSize)
+		txInfo.Fee = &fee
+		txInfo.FeeRate = &feeRate
 	}
 
-	fmt.Println(string(jsonData))
-	return nil
+	return txInfo
+}
+
+// addSpendScriptsAsm disassembles the redeem and witness scripts revealed by
+// an input spending a P2SH or P2WSH output
+func addSpendScriptsAsm(info *bitcoinInputInfo, input *wire.TxIn, pkScript []byte) {
+	if txscript.IsPayToScriptHash(pkScript) {
+		pushes, err := txscript.PushedData(input.SignatureScript)
+		if err != nil || len(pushes) == 0 {
+			return
+		}
+		pkScript = pushes[len(pushes)-1]
+		info.RedeemScriptAsm = bitcoin.DisassembleScript(pkScript)
+	}
+	if txscript.IsPayToWitnessScriptHash(pkScript) && len(input.Witness) > 0 {
+		info.WitnessScriptAsm = bitcoin.DisassembleScript(input.Witness[len(input.Witness)-1])
+	}
 }
 
 type bitcoinTxInfo struct {
-	Hash     string              `json:"hash"`
-	Version  int32               `json:"version"`
-	Locktime uint32              `json:"locktime"`
-	Size     int                 `json:"size"`
-	Inputs   []bitcoinInputInfo  `json:"inputs"`
-	Outputs  []bitcoinOutputInfo `json:"outputs"`
+	Hash         string              `json:"hash"`
+	WitnessHash  string              `json:"wtxid"`
+	Version      int32               `json:"version"`
+	Locktime     uint32              `json:"locktime"`
+	LocktimeInfo string              `json:"locktimeInfo"`
+	Size         int                 `json:"size"`
+	Weight       int64               `json:"weight"`
+	VSize        int64               `json:"vsize"`
+	SegWit       bool                `json:"segwit"`
+	RBF          bool                `json:"rbf"`
+	Inputs       []bitcoinInputInfo  `json:"inputs"`
+	Outputs      []bitcoinOutputInfo `json:"outputs"`
+	Fee          *int64              `json:"fee,omitempty"`
+	FeeRate      *float64            `json:"feeRate,omitempty"` // sat/vB
 }
 
 type bitcoinInputInfo struct {
-	TxID         string `json:"txid"`
-	Vout         uint32 `json:"vout"`
-	ScriptSig    string `json:"scriptSig"`
-	Sequence     uint32 `json:"sequence"`
-	WitnessTypes string `json:"witness,omitempty"`
+	TxID             string             `json:"txid"`
+	Vout             uint32             `json:"vout"`
+	ScriptSig        string             `json:"scriptSig"`
+	ScriptSigAsm     string             `json:"scriptSigAsm"`
+	Sequence         uint32             `json:"sequence"`
+	RelativeLocktime string             `json:"relativeLocktime,omitempty"`
+	Witness          []string           `json:"witness,omitempty"`
+	RedeemScriptAsm  string             `json:"redeemScriptAsm,omitempty"`
+	WitnessScriptAsm string             `json:"witnessScriptAsm,omitempty"`
+	Prevout          *bitcoinOutputInfo `json:"prevout,omitempty"`
 }
 
 type bitcoinOutputInfo struct {
-	Value        int64  `json:"value"`
-	ScriptPubKey string `json:"scriptPubKey"`
+	Value        int64              `json:"value"`
+	ScriptPubKey bitcoin.ScriptInfo `json:"scriptPubKey"`
 }
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/alejoacosta74/cryptonaut/pkg/ethereum/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+)
+
+var ethereumAbiCmd = &cobra.Command{
+	Use:   "abi",
+	Short: "Ethereum ABI encoding and decoding",
+	Long: `Encode and decode calldata and ABI values offline.
+A signature is a function such as "transfer(address,uint256)", whose calldata
+starts with its selector, or a parameter list such as "(address,uint256)",
+encoded without selector. Parameters may be named, and tuples nested.
+
+Arguments are given one per parameter: integers in decimal or 0x prefixed hex,
+addresses, bytes and fixed bytes in hex, booleans as true or false, arrays as
+[v1, v2, ...] and tuples as (v1, v2, ...). Strings inside arrays and tuples
+are double quoted when they hold commas, brackets or parentheses.`,
+}
+
+var ethereumAbiEncodeCmd = &cobra.Command{
+	Use:   "encode <signature> <arg>...",
+	Short: "ABI-encode calldata or parameters",
+	Long: `ABI-encode the arguments of a function signature into calldata, or of a
+parameter list without selector.
+	Usage:
+	cryptonaut ethereum abi encode "transfer(address,uint256)" 0x1234567890123456789012345678901234567890 1000
+	cryptonaut ethereum abi encode "submit((address,uint256[]),bytes)" "(0x1234567890123456789012345678901234567890, [1, 2])" 0xdeadbeef
+	cryptonaut ethereum abi encode "(string,bool)" "hello" true
+	`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runEthereumAbiEncodeCmd,
+}
+
+var ethereumAbiEncodePackedCmd = &cobra.Command{
+	Use:   "encode-packed <signature> <arg>...",
+	Short: "Encode values as Solidity's abi.encodePacked",
+	Long: `Encode the arguments of a signature as Solidity's abi.encodePacked, without
+selector: static types in their own size, strings and bytes unpadded and array
+elements padded to 32 bytes. Tuples and arrays of dynamic types are not
+supported.
+	Usage:
+	cryptonaut ethereum abi encode-packed "(int16,bytes1,uint16,string)" -- -1 0x42 0x03 "Hello, world!"
+	cryptonaut ethereum abi encode-packed "(address,uint256)" 0x1234567890123456789012345678901234567890 1
+	`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runEthereumAbiEncodePackedCmd,
+}
+
+var ethereumAbiDecodeCmd = &cobra.Command{
+	Use:   "decode <signature> <hex data>",
+	Short: "Decode calldata or ABI-encoded parameters",
+	Long: `Decode the calldata of a function signature, whose selector must match the
+data, or the ABI-encoded values of a parameter list such as return data.
+	Usage:
+	cryptonaut ethereum abi decode "transfer(address to, uint256 amount)" 0xa9059cbb...
+	cryptonaut ethereum abi decode "(uint256,string)" 0x0000...
+	`,
+	Args: cobra.ExactArgs(2),
+	RunE: runEthereumAbiDecodeCmd,
+}
+
+func init() {
+	ethereumAbiCmd.AddCommand(ethereumAbiEncodeCmd)
+	ethereumAbiCmd.AddCommand(ethereumAbiEncodePackedCmd)
+	ethereumAbiCmd.AddCommand(ethereumAbiDecodeCmd)
+
+	ethereumCmd.AddCommand(ethereumAbiCmd)
+}
+
+func runEthereumAbiEncodeCmd(cmd *cobra.Command, args []string) error {
+	data, err := abi.Encode(args[0], args[1:])
+	if err != nil {
+		return err
+	}
+	fmt.Println(hexutil.Encode(data))
+	return nil
+}
+
+func runEthereumAbiEncodePackedCmd(cmd *cobra.Command, args []string) error {
+	data, err := abi.EncodePacked(args[0], args[1:])
+	if err != nil {
+		return err
+	}
+	fmt.Println(hexutil.Encode(data))
+	return nil
+}
+
+func runEthereumAbiDecodeCmd(cmd *cobra.Command, args []string) error {
+	data, err := hexutil.Decode(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid data: %v", err)
+	}
+	call, err := abi.Decode(args[0], data)
+	if err != nil {
+		return err
+	}
+	return printJSON(call)
+}
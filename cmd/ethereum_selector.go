@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/alejoacosta74/cryptonaut/internal/config"
+	"github.com/alejoacosta74/cryptonaut/pkg/ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var ethereumSelectorCmd = &cobra.Command{
+	Use:   "selector",
+	Short: "Function selector and event topic signature database",
+	Long: `Look up, compute and import function, error and event signatures offline.
+The database embeds the signatures of common contracts (ERC-20, ERC-721,
+ERC-1155, ERC-4626, Uniswap, Safe, ENS, OpenZeppelin errors...) and is
+extended with imports kept in ~/.cryptonaut/signatures.txt, or --signature-db.`,
+}
+
+var ethereumSelectorLookupCmd = &cobra.Command{
+	Use:   "lookup <selector or topic>...",
+	Short: "Find the signatures of 4-byte selectors and 32-byte event topics",
+	Long: `Find the signatures of 4-byte function and error selectors and 32-byte event
+topics. Several signatures may share a selector.
+	Usage:
+	cryptonaut ethereum selector lookup 0xa9059cbb
+	cryptonaut ethereum selector lookup 0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef
+	`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    runEthereumSelectorLookupCmd,
+	PreRunE: bindSignatureDB,
+}
+
+var ethereumSelectorComputeCmd = &cobra.Command{
+	Use:   "compute <signature>...",
+	Short: "Compute the selector and topic of signatures",
+	Long: `Compute the 4-byte selector of function and error signatures and the 32-byte
+topic of event signatures, canonical or human-readable.
+	Usage:
+	cryptonaut ethereum selector compute "transfer(address,uint256)"
+	cryptonaut ethereum selector compute "event Transfer(address indexed from, address indexed to, uint256 value)"
+	`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    runEthereumSelectorComputeCmd,
+	PreRunE: bindSignatureDB,
+}
+
+var ethereumSelectorImportCmd = &cobra.Command{
+	Use:   "import <file>...",
+	Short: "Import the signatures of ABI files into the database",
+	Long: `Import the function, event and error signatures of JSON ABIs, compiler
+artifacts or files of signatures, one per line, into the database. Events are
+stored prefixed by "event " so that they are not offered as function selectors.
+	Usage:
+	cryptonaut ethereum selector import MyToken.json
+	cryptonaut ethereum selector import signatures.txt --signature-db ./signatures.txt
+	`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    runEthereumSelectorImportCmd,
+	PreRunE: bindSignatureDB,
+}
+
+func init() {
+	ethereumSelectorCmd.AddCommand(ethereumSelectorLookupCmd)
+	ethereumSelectorCmd.AddCommand(ethereumSelectorComputeCmd)
+	ethereumSelectorCmd.AddCommand(ethereumSelectorImportCmd)
+
+	ethereumSelectorCmd.PersistentFlags().String(config.FlagSignatureDB, "", "Signature database file (default ~/.cryptonaut/signatures.txt)")
+
+	ethereumCmd.AddCommand(ethereumSelectorCmd)
+}
+
+// bindSignatureDB binds --signature-db, shared with the tx decode command
+func bindSignatureDB(cmd *cobra.Command, args []string) error {
+	bindFlags(cmd, config.FlagSignatureDB)
+	return nil
+}
+
+// signatureDBPath returns the file of the imported signatures
+func signatureDBPath() (string, error) {
+	if path := viper.GetString(config.FlagSignatureDB); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %v", err)
+	}
+	return filepath.Join(home, config.DefaultConfigDir, config.SignaturesFileName), nil
+}
+
+func loadSignatureDB() (*ethereum.SignatureDB, error) {
+	path, err := signatureDBPath()
+	if err != nil {
+		return nil, err
+	}
+	return ethereum.LoadSignatureDB(path)
+}
+
+func runEthereumSelectorLookupCmd(cmd *cobra.Command, args []string) error {
+	db, err := loadSignatureDB()
+	if err != nil {
+		return err
+	}
+	for _, arg := range args {
+		key, err := hexutil.Decode(arg)
+		if err != nil {
+			return fmt.Errorf("invalid selector or topic %s: %v", arg, err)
+		}
+		var signatures []string
+		switch len(key) {
+		case 4:
+			signatures = db.LookupSelector(key)
+		case common.HashLength:
+			signatures = db.LookupTopic(common.BytesToHash(key))
+		default:
+			return fmt.Errorf("invalid selector or topic %s, expected 4 or 32 bytes", arg)
+		}
+		if len(signatures) == 0 {
+			cmd.Println(arg, "unknown")
+		}
+		for _, signature := range signatures {
+			cmd.Println(arg, signature)
+		}
+	}
+	return nil
+}
+
+func runEthereumSelectorComputeCmd(cmd *cobra.Command, args []string) error {
+	for _, arg := range args {
+		signatures, err := ethereum.CanonicalSignatures([]byte(arg))
+		if err != nil {
+			return err
+		}
+		for _, signature := range signatures {
+			if event, ok := ethereum.CutEventPrefix(signature); ok {
+				cmd.Println("Signature:", event)
+				cmd.Println("Topic:", ethereum.SignatureTopic(event).Hex())
+				continue
+			}
+			cmd.Println("Signature:", signature)
+			cmd.Println("Selector:", hexutil.Encode(ethereum.SignatureSelector(signature)))
+		}
+	}
+	return nil
+}
+
+func runEthereumSelectorImportCmd(cmd *cobra.Command, args []string) error {
+	path, err := signatureDBPath()
+	if err != nil {
+		return err
+	}
+	var signatures []string
+	for _, file := range args {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", file, err)
+		}
+		fileSignatures, err := ethereum.CanonicalSignatures(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %v", file, err)
+		}
+		signatures = append(signatures, fileSignatures...)
+	}
+	added, err := ethereum.ImportSignatures(path, signatures)
+	if err != nil {
+		return err
+	}
+	cmd.Printf("Imported %d new signatures of %d into %s\n", added, len(signatures), path)
+	return nil
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alejoacosta74/cryptonaut/internal/config"
+	"github.com/alejoacosta74/cryptonaut/pkg/ethereum"
+	"github.com/alejoacosta74/cryptonaut/pkg/hd"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var ethereumTxSignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Build and sign an Ethereum transaction",
+	Long: `Build and sign an Ethereum transaction offline and print its raw RLP
+encoding and hash. The transaction is read from a JSON --spec file and/or the
+flags, which override the spec fields. The type (legacy, access-list,
+dynamic-fee, blob or set-code) is inferred from the fee fields, blobs and
+authorizations when --type is not given. Amounts are in wei, decimal or 0x
+prefixed hex.
+
+Blob transactions pack each --blob data into a blob and print the network
+encoding, carrying the blobs, KZG commitments and proofs. Set-code (EIP-7702)
+transactions sign each --authorize delegation with the sender key and the
+nonce following the transaction one.
+
+The signing key is given with --private-key, --mnemonic and --index (the
+m/44'/60'/0'/0/index path of "hd ethereum") or --keystore and --passphrase.
+	Usage:
+	cryptonaut ethereum tx sign --private-key <hex> --chain-id 1 --nonce 0 --to 0x... --value 1000000000000000000 --gas 21000 --max-fee 30000000000 --max-priority-fee 1000000000
+	cryptonaut ethereum tx sign --mnemonic "<words>" --index 1 --chain-id 1 --to 0x... --gas 21000 --gas-price 20000000000
+	cryptonaut ethereum tx sign --keystore key.json --passphrase <pass> --spec tx.json
+	cryptonaut ethereum tx sign --private-key <hex> --spec tx.json --blob 0x68656c6c6f --max-blob-fee 1000000000
+	cryptonaut ethereum tx sign --private-key <hex> --chain-id 1 --to <own address> --gas 100000 --max-fee 30000000000 --max-priority-fee 1000000000 --authorize 0x...
+	`,
+	Args: cobra.NoArgs,
+	RunE: runEthereumTxSignCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// bound here instead of init() as the bitcoin, pow and swap commands bind the same keys
+		bindFlags(cmd, config.FlagSpec, config.FlagTxType, config.FlagChainID, config.FlagNonce, config.FlagTo,
+			config.FlagValue, config.FlagGas, config.FlagGasPrice, config.FlagMaxFee, config.FlagMaxPriorityFee,
+			config.FlagMaxBlobFee, config.FlagData, config.FlagBlob, config.FlagAuthorize, config.FlagMnemonic,
+			config.FlagIndex, config.FlagKeystore, config.FlagPassphrase)
+		return nil
+	},
+}
+
+func init() {
+	ethereumTxCmd.AddCommand(ethereumTxSignCmd)
+
+	ethereumTxSignCmd.Flags().String(config.FlagSpec, "", "JSON file describing the transaction")
+	ethereumTxSignCmd.Flags().String(config.FlagTxType, "", "Transaction type (legacy, access-list, dynamic-fee, blob or set-code)")
+	ethereumTxSignCmd.Flags().String(config.FlagChainID, "", "Chain ID")
+	ethereumTxSignCmd.Flags().Uint64(config.FlagNonce, 0, "Sender account nonce")
+	ethereumTxSignCmd.Flags().String(config.FlagTo, "", "Recipient address, omit to create a contract")
+	ethereumTxSignCmd.Flags().String(config.FlagValue, "", "Value in wei")
+	ethereumTxSignCmd.Flags().Uint64(config.FlagGas, 0, "Gas limit")
+	ethereumTxSignCmd.Flags().String(config.FlagGasPrice, "", "Gas price in wei (legacy and access-list)")
+	ethereumTxSignCmd.Flags().String(config.FlagMaxFee, "", "Max fee per gas in wei")
+	ethereumTxSignCmd.Flags().String(config.FlagMaxPriorityFee, "", "Max priority fee per gas in wei")
+	ethereumTxSignCmd.Flags().String(config.FlagMaxBlobFee, "", "Max fee per blob gas in wei")
+	ethereumTxSignCmd.Flags().String(config.FlagData, "", "Call data in hex")
+	ethereumTxSignCmd.Flags().StringArray(config.FlagBlob, nil, "Blob data in hex (repeatable)")
+	ethereumTxSignCmd.Flags().StringArray(config.FlagAuthorize, nil, "Address to delegate the sender account code to (repeatable)")
+	ethereumTxSignCmd.Flags().String(config.FlagMnemonic, "", "Mnemonic phrase used to derive the signing key")
+	ethereumTxSignCmd.Flags().Int(config.FlagIndex, 0, "Derivation index of the signing key")
+	ethereumTxSignCmd.Flags().String(config.FlagKeystore, "", "Keystore file of the signing key")
+	ethereumTxSignCmd.Flags().String(config.FlagPassphrase, "", "Passphrase of the keystore file")
+}
+
+func runEthereumTxSignCmd(cmd *cobra.Command, args []string) error {
+	spec := &ethereum.TxSpec{}
+	if path := viper.GetString(config.FlagSpec); path != "" {
+		var err error
+		if spec, err = ethereum.LoadTxSpec(path); err != nil {
+			return err
+		}
+	}
+	if err := applyTxSpecFlags(cmd, spec); err != nil {
+		return err
+	}
+
+	key, err := ethereumSigningKey()
+	if err != nil {
+		return err
+	}
+	signed, err := spec.Sign(key)
+	if err != nil {
+		return err
+	}
+	cmd.Println("Type:", signed.Type)
+	cmd.Println("From:", signed.From.Hex())
+	cmd.Println("Hash:", signed.Hash.Hex())
+	cmd.Println("Raw transaction:", hexutil.Encode(signed.Raw))
+	return nil
+}
+
+// applyTxSpecFlags overrides the spec fields with the flags set
+func applyTxSpecFlags(cmd *cobra.Command, spec *ethereum.TxSpec) error {
+	changed := cmd.Flags().Changed
+	if changed(config.FlagTxType) {
+		spec.Type = strings.ToLower(viper.GetString(config.FlagTxType))
+	}
+	if changed(config.FlagNonce) {
+		spec.Nonce = math.HexOrDecimal64(viper.GetUint64(config.FlagNonce))
+	}
+	if changed(config.FlagGas) {
+		spec.Gas = math.HexOrDecimal64(viper.GetUint64(config.FlagGas))
+	}
+	if changed(config.FlagTo) {
+		to := viper.GetString(config.FlagTo)
+		if !common.IsHexAddress(to) {
+			return fmt.Errorf("invalid recipient address: %s", to)
+		}
+		address := common.HexToAddress(to)
+		spec.To = &address
+	}
+	for name, field := range map[string]**math.HexOrDecimal256{
+		config.FlagChainID:        &spec.ChainID,
+		config.FlagValue:          &spec.Value,
+		config.FlagGasPrice:       &spec.GasPrice,
+		config.FlagMaxFee:         &spec.MaxFeePerGas,
+		config.FlagMaxPriorityFee: &spec.MaxPriorityFeePerGas,
+		config.FlagMaxBlobFee:     &spec.MaxFeePerBlobGas,
+	} {
+		if !changed(name) {
+			continue
+		}
+		value, ok := math.ParseBig256(viper.GetString(name))
+		if !ok {
+			return fmt.Errorf("invalid --%s: %s", name, viper.GetString(name))
+		}
+		*field = (*math.HexOrDecimal256)(value)
+	}
+	if changed(config.FlagData) {
+		data, err := hex.DecodeString(strings.TrimPrefix(viper.GetString(config.FlagData), "0x"))
+		if err != nil {
+			return fmt.Errorf("invalid call data: %v", err)
+		}
+		spec.Data = data
+	}
+	for _, blob := range viper.GetStringSlice(config.FlagBlob) {
+		data, err := hex.DecodeString(strings.TrimPrefix(blob, "0x"))
+		if err != nil {
+			return fmt.Errorf("invalid blob data: %v", err)
+		}
+		spec.Blobs = append(spec.Blobs, data)
+	}
+	for _, address := range viper.GetStringSlice(config.FlagAuthorize) {
+		if !common.IsHexAddress(address) {
+			return fmt.Errorf("invalid authorization address: %s", address)
+		}
+		spec.AuthorizationList = append(spec.AuthorizationList, ethereum.AuthorizationSpec{Address: common.HexToAddress(address)})
+	}
+	return nil
+}
+
+// ethereumSigningKey returns the key given with --private-key, --mnemonic or
+// --keystore
+func ethereumSigningKey() (*ecdsa.PrivateKey, error) {
+	if privKey := viper.GetString(config.FlagPrivateKey); privKey != "" {
+		key, err := ethereum.ParsePrivateKeyFromString(strings.TrimPrefix(privKey, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %v", err)
+		}
+		return key, nil
+	}
+
+	if mnemonic := viper.GetString(config.FlagMnemonic); mnemonic != "" {
+		hdNode, err := hd.CreateEthereumHDNode(mnemonic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create hdnode: %v", err)
+		}
+		index := viper.GetInt(config.FlagIndex)
+		if index < 0 || index >= 1<<31 {
+			return nil, fmt.Errorf("invalid derivation index %d", index)
+		}
+		return hd.DeriveEthereumPrivateKey(hdNode, uint32(index))
+	}
+
+	if path := viper.GetString(config.FlagKeystore); path != "" {
+		keyJSON, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keystore: %v", err)
+		}
+		key, err := keystore.DecryptKey(keyJSON, viper.GetString(config.FlagPassphrase))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt keystore: %v", err)
+		}
+		return key.PrivateKey, nil
+	}
+	return nil, fmt.Errorf("either --%s, --%s or --%s is required", config.FlagPrivateKey, config.FlagMnemonic, config.FlagKeystore)
+}
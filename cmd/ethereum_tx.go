@@ -2,13 +2,16 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"math/big"
 	"os"
 	"os/signal"
 
 	"github.com/alejoacosta74/cryptonaut/internal/config"
 	"github.com/alejoacosta74/cryptonaut/pkg/ethereum"
+	"github.com/alejoacosta74/cryptonaut/pkg/ethereum/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -22,22 +25,49 @@ var ethereumTxCmd = &cobra.Command{
 var ethereumDecodeRawTxCmd = &cobra.Command{
 	Use:   "decode",
 	Short: "Decode a raw Ethereum transaction",
-	Long:  "Decode a raw Ethereum transaction",
-	Args:  cobra.ExactArgs(1),
-	Run:   runDecodeEthereumRawTx,
+	Long: `Decode a raw Ethereum transaction of any type (legacy, EIP-2930, EIP-1559,
+EIP-4844 blob and EIP-7702 set-code), given in its EIP-2718 binary encoding or
+RLP encoded as in blocks, and recover its sender. Blob transactions may carry
+their blobs, as broadcast on the network.
+
+With --abi, a JSON ABI, compiler artifact or file of human-readable
+signatures, the calldata is decoded into the called function and its named
+arguments. Without it, or when the ABI lacks the function, the candidate
+signatures of the selector are looked up in the offline signature database
+(see "ethereum selector") and the call is decoded with the first whose
+parameter types match, as guessedCall.
+	Usage:
+	cryptonaut ethereum tx decode 0x02f873...
+	cryptonaut ethereum tx decode 0x02f8b0... --abi erc20.json
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDecodeEthereumRawTx,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// bound here instead of init() as the mempool and selector commands bind the same keys
+		bindFlags(cmd, config.FlagABI, config.FlagSignatureDB)
+		return nil
+	},
 }
 
 var ethereumMempoolSubscribeCmd = &cobra.Command{
 	Use:   "mempool",
 	Short: "Subscribe to Ethereum mempool transactions",
-	Long:  "Subscribe to Ethereum mempool transactions",
+	Long:  "Subscribe to Ethereum mempool transactions, decoding their calldata with --abi",
 	RunE:  runSubscribeEthereumMempool,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		bindFlags(cmd, config.FlagABI)
+		return nil
+	},
 }
 
 func init() {
 	ethereumTxCmd.AddCommand(ethereumDecodeRawTxCmd)
 	ethereumTxCmd.AddCommand(ethereumMempoolSubscribeCmd)
 
+	ethereumDecodeRawTxCmd.Flags().String(config.FlagABI, "", "Contract ABI file decoding the calldata")
+	ethereumDecodeRawTxCmd.Flags().String(config.FlagSignatureDB, "", "Signature database file (default ~/.cryptonaut/signatures.txt)")
+
+	ethereumMempoolSubscribeCmd.Flags().String(config.FlagABI, "", "Contract ABI file decoding the calldata")
 	ethereumMempoolSubscribeCmd.Flags().StringP("to-address", "t", "", "Filter transactions by to address")
 	viper.BindPFlag("to-address", ethereumMempoolSubscribeCmd.Flags().Lookup("to-address"))
 	ethereumMempoolSubscribeCmd.Flags().StringP(config.FlagWsUrl, "w", "", "Websocket URL")
@@ -47,49 +77,130 @@ func init() {
 	ethereumCmd.AddCommand(ethereumTxCmd)
 }
 
-func runDecodeEthereumRawTx(cmd *cobra.Command, args []string) {
-	rawTx := args[0]
-	tx, err := ethereum.DecodeEthereumRawTx(rawTx)
+func runDecodeEthereumRawTx(cmd *cobra.Command, args []string) error {
+	tx, err := ethereum.DecodeTx(args[0])
 	if err != nil {
-		fmt.Println("Error decoding Ethereum raw transaction:", err)
-		return
+		return fmt.Errorf("failed to decode Ethereum raw transaction: %v", err)
 	}
 
 	txInfo := ethereumTxInfo{
-		Hash:     tx.Hash().String(),
-		Nonce:    tx.Nonce(),
-		GasPrice: tx.GasPrice().String(),
-		Gas:      tx.Gas(),
-		To:       tx.To().String(),
-		Value:    tx.Value().String(),
-		Data:     string(tx.Data()),
-		ChainID:  tx.ChainId().String(),
-		Type:     tx.Type(),
+		Hash:       tx.Hash.Hex(),
+		Type:       tx.Type,
+		Nonce:      tx.Nonce,
+		From:       tx.From.Hex(),
+		Value:      tx.Value.String(),
+		Gas:        tx.Gas,
+		Data:       hexutil.Encode(tx.Data),
+		AccessList: tx.AccessList,
+		Blobs:      tx.Blobs,
+		V:          tx.V.String(),
+		R:          hexutil.EncodeBig(tx.R),
+		S:          hexutil.EncodeBig(tx.S),
+		YParity:    tx.YParity,
 	}
-
-	jsonData, err := json.MarshalIndent(txInfo, "", "    ")
-	if err != nil {
-		fmt.Println("Error marshaling JSON:", err)
-		return
+	if tx.ChainID != nil {
+		txInfo.ChainID = tx.ChainID.String()
+	}
+	if tx.To != nil {
+		txInfo.To = tx.To.Hex()
+	} else {
+		txInfo.ContractAddress = tx.ContractAddress().Hex()
+	}
+	if selector := tx.Selector(); selector != nil {
+		txInfo.Selector = hexutil.Encode(selector)
+		if path := viper.GetString(config.FlagABI); path != "" {
+			contract, err := abi.Load(path)
+			if err != nil {
+				return err
+			}
+			// undecodable calldata is reported without failing the decoding
+			if txInfo.Call, err = contract.DecodeCalldata(tx.Data); err != nil {
+				txInfo.CallError = err.Error()
+			}
+		}
+		if txInfo.Call == nil {
+			db, err := loadSignatureDB()
+			if err != nil {
+				return err
+			}
+			txInfo.Signatures = db.LookupSelector(selector)
+			if guessed, err := db.GuessCalldata(tx.Data); err == nil {
+				txInfo.GuessedCall = guessed
+			}
+		}
+	}
+	txInfo.GasPrice = bigString(tx.GasPrice)
+	txInfo.MaxFeePerGas = bigString(tx.GasFeeCap)
+	txInfo.MaxPriorityFeePerGas = bigString(tx.GasTipCap)
+	txInfo.MaxFeePerBlobGas = bigString(tx.BlobGasFeeCap)
+	for _, hash := range tx.BlobHashes {
+		txInfo.BlobVersionedHashes = append(txInfo.BlobVersionedHashes, hash.Hex())
+	}
+	for _, auth := range tx.AuthList {
+		authInfo := ethereumAuthorizationInfo{
+			ChainID: auth.ChainID.String(),
+			Address: auth.Address.Hex(),
+			Nonce:   auth.Nonce,
+			YParity: auth.YParity,
+			R:       hexutil.EncodeBig(auth.R),
+			S:       hexutil.EncodeBig(auth.S),
+		}
+		// an invalid authorization is skipped, the transaction remains valid
+		if authority, err := auth.Authority(); err == nil {
+			authInfo.Authority = authority.Hex()
+		}
+		txInfo.AuthorizationList = append(txInfo.AuthorizationList, authInfo)
 	}
 
-	fmt.Println(string(jsonData))
+	return printJSON(txInfo)
+}
+
+// bigString returns x in decimal, empty when unset
+func bigString(x *big.Int) string {
+	if x == nil {
+		return ""
+	}
+	return x.String()
 }
 
 type ethereumTxInfo struct {
-	Hash                 string `json:"hash"`
-	Nonce                uint64 `json:"nonce"`
-	GasPrice             string `json:"gasPrice"`
-	Gas                  uint64 `json:"gas"`
-	To                   string `json:"to"`
-	Value                string `json:"value"`
-	Data                 string `json:"data"`
-	ChainID              string `json:"chainId"`
-	Type                 uint8  `json:"type"`
-	AccessList           string `json:"accessList,omitempty"`
-	MaxFeePerGas         string `json:"maxFeePerGas,omitempty"`
-	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas,omitempty"`
-	Signature            string `json:"signature,omitempty"`
+	Hash                 string                      `json:"hash"`
+	Type                 uint8                       `json:"type"`
+	ChainID              string                      `json:"chainId,omitempty"`
+	Nonce                uint64                      `json:"nonce"`
+	From                 string                      `json:"from"`
+	To                   string                      `json:"to,omitempty"`
+	ContractAddress      string                      `json:"contractAddress,omitempty"`
+	Value                string                      `json:"value"`
+	Gas                  uint64                      `json:"gas"`
+	GasPrice             string                      `json:"gasPrice,omitempty"`
+	MaxFeePerGas         string                      `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string                      `json:"maxPriorityFeePerGas,omitempty"`
+	MaxFeePerBlobGas     string                      `json:"maxFeePerBlobGas,omitempty"`
+	Data                 string                      `json:"data"`
+	Selector             string                      `json:"selector,omitempty"`
+	Call                 *abi.Call                   `json:"call,omitempty"`
+	CallError            string                      `json:"callError,omitempty"`
+	Signatures           []string                    `json:"signatures,omitempty"`
+	GuessedCall          *abi.Call                   `json:"guessedCall,omitempty"`
+	AccessList           types.AccessList            `json:"accessList,omitempty"`
+	BlobVersionedHashes  []string                    `json:"blobVersionedHashes,omitempty"`
+	Blobs                int                         `json:"blobs,omitempty"`
+	AuthorizationList    []ethereumAuthorizationInfo `json:"authorizationList,omitempty"`
+	V                    string                      `json:"v"`
+	R                    string                      `json:"r"`
+	S                    string                      `json:"s"`
+	YParity              uint8                       `json:"yParity"`
+}
+
+type ethereumAuthorizationInfo struct {
+	ChainID   string `json:"chainId"`
+	Address   string `json:"address"`
+	Nonce     uint64 `json:"nonce"`
+	YParity   uint8  `json:"yParity"`
+	R         string `json:"r"`
+	S         string `json:"s"`
+	Authority string `json:"authority,omitempty"`
 }
 
 func runSubscribeEthereumMempool(cmd *cobra.Command, args []string) error {
@@ -112,6 +223,13 @@ func runSubscribeEthereumMempool(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	defer sub.Stop()
+	if path := viper.GetString(config.FlagABI); path != "" {
+		contract, err := abi.Load(path)
+		if err != nil {
+			return err
+		}
+		sub.SetABI(contract)
+	}
 
 	if err := sub.Start(ctx); err != nil {
 		return err
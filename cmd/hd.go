@@ -10,6 +10,7 @@ import (
 	"github.com/alejoacosta74/cryptonaut/internal/config"
 	"github.com/alejoacosta74/cryptonaut/pkg/crypto"
 	"github.com/alejoacosta74/cryptonaut/pkg/hd"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -23,7 +24,7 @@ var hdDerivationCmd = &cobra.Command{
 Supports BIP39 mnemonic generation and BIP32 key derivation.
 
 Usage:
-    cryptonaut hd derive bitcoin --mnemonic "your mnemonic phrase" --index 0 --testnet
+    cryptonaut hd bitcoin --mnemonic "your mnemonic phrase" --index 0 --network testnet
     cryptonaut hd derive ethereum --mnemonic "your mnemonic phrase"  --index 1
 
 The index parameter determines which child key to derive.
@@ -49,13 +50,20 @@ var deriveBitcoinKeysCmd = &cobra.Command{
 	Long: `Derive cryptographic keys from a BIP39 mnemonic phrase for bitcoin.
 
     Usage:
-        cryptonaut hd bitcoin --mnemonic "your mnemonic phrase" --index 0 --testnet
-        cryptonaut hd bitcoin --mnemonic "your mnemonic phrase"  --index 1
+        cryptonaut hd bitcoin --mnemonic "your mnemonic phrase" --index 0 --network testnet
+        cryptonaut hd bitcoin --mnemonic "your mnemonic phrase"  --index 1 --network litecoin
 
     The index parameter determines which child key to derive.
 	If not specified, the first child key is derived.
+	Keys are derived on the BIP44 path m/44'/0'/0'/0 on every network. With
+	--network-coin-type the coin type of --network is used instead
+	(m/44'/1'/0'/0 on the test networks, m/44'/2'/0'/0 for litecoin).
 	`,
 	RunE: runDeriveBitcoinKeysCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		bindFlags(cmd, config.FlagNetworkCoinType)
+		return bindNetwork(cmd, args)
+	},
 }
 
 var deriveEthereumKeysCmd = &cobra.Command{
@@ -86,6 +94,9 @@ func init() {
 	deriveBitcoinKeysCmd.MarkPersistentFlagRequired(config.FlagMnemonic)
 	deriveEthereumKeysCmd.MarkPersistentFlagRequired(config.FlagMnemonic)
 
+	deriveBitcoinKeysCmd.Flags().StringP(config.FlagNetwork, "n", "", networkFlagUsage)
+	addNetworkCoinTypeFlag(deriveBitcoinKeysCmd)
+
 	hdDerivationCmd.PersistentFlags().Int(config.FlagIndex, 0, "Derivation index")
 	viper.BindPFlag(config.FlagIndex, hdDerivationCmd.PersistentFlags().Lookup(config.FlagIndex))
 
@@ -94,18 +105,29 @@ func init() {
 
 }
 
+// addNetworkCoinTypeFlag registers the opt-in for the BIP44 coin type of
+// --network on the commands deriving bitcoin keys from a mnemonic
+func addNetworkCoinTypeFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool(config.FlagNetworkCoinType, false, "Derive with the BIP44 coin type of --network instead of 0'")
+}
+
+// bitcoinHDNode derives the external chain of the mnemonic, on the coin type
+// of the network with --network-coin-type and on Bitcoin's otherwise
+func bitcoinHDNode(mnemonic string, network *chaincfg.Params) (*hdkeychain.ExtendedKey, error) {
+	if viper.GetBool(config.FlagNetworkCoinType) {
+		return hd.CreateBitcoinHDNodeWithCoinType(mnemonic, network, network.HDCoinType)
+	}
+	return hd.CreateBitcoinHDNode(mnemonic, network)
+}
+
 func runDeriveBitcoinKeysCmd(cmd *cobra.Command, args []string) error {
 	mnemonic := viper.GetString(config.FlagMnemonic)
 	index := viper.GetInt(config.FlagIndex)
-	testnet := viper.GetBool(config.FlagTestnet)
-
-	var network *chaincfg.Params
-	if testnet {
-		network = &chaincfg.TestNet3Params
-	} else {
-		network = &chaincfg.MainNetParams
+	network, err := bitcoinNetworkParams()
+	if err != nil {
+		return err
 	}
-	hdNode, err := hd.CreateBitcoinHDNode(mnemonic, network)
+	hdNode, err := bitcoinHDNode(mnemonic, network)
 	if err != nil {
 		return fmt.Errorf("failed to create hdnode: %v", err)
 	}
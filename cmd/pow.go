@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alejoacosta74/cryptonaut/internal/config"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin/pow"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var powCmd = &cobra.Command{
+	Use:   "pow",
+	Short: "Bitcoin proof of work",
+}
+
+var powHeaderCmd = &cobra.Command{
+	Use:   "header",
+	Short: "Decode a block header and check its proof of work",
+	Long: `Decode an 80-byte block header and check its target and proof of work
+	Usage:
+	cryptonaut pow header <header hex>
+	cryptonaut pow header -n regtest <header hex>
+	`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runPowHeaderCmd,
+	PreRunE: bindNetwork,
+}
+
+var powChainCmd = &cobra.Command{
+	Use:   "chain",
+	Short: "Validate a series of block headers",
+	Long: `Validate a series of consecutive block headers read from a file in hex, one
+per line or concatenated. The headers must link to each other, have valid
+proof of work and follow the difficulty retargets of --network. The
+chainwork of the series and its retargets are printed as JSON.
+	Usage:
+	cryptonaut pow chain headers.txt --start-height 32256
+	`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runPowChainCmd,
+	PreRunE: bindNetwork,
+}
+
+var powMineCmd = &cobra.Command{
+	Use:   "mine",
+	Short: "Simulate mining a block header",
+	Long: `Simulate mining by grinding the nonce of a block header until its hash meets
+the target, across --workers goroutines. The difficulty is set with
+--zero-bits, the number of leading zero bits of the hash, or with the
+compact --bits. The merkle root commits to --data.
+	Usage:
+	cryptonaut pow mine --zero-bits 24 --workers 8
+	cryptonaut pow mine --bits 1f00ffff --data "hello"
+	`,
+	Args: cobra.NoArgs,
+	RunE: runPowMineCmd,
+}
+
+func init() {
+	powCmd.AddCommand(powHeaderCmd)
+	powCmd.AddCommand(powChainCmd)
+	powCmd.AddCommand(powMineCmd)
+
+	powCmd.PersistentFlags().StringP(config.FlagNetwork, "n", "", networkFlagUsage)
+
+	powChainCmd.Flags().Int32(config.FlagStartHeight, 0, "Height of the first header")
+	viper.BindPFlag(config.FlagStartHeight, powChainCmd.Flags().Lookup(config.FlagStartHeight))
+
+	powMineCmd.Flags().Int(config.FlagZeroBits, 20, "Number of leading zero bits of the block hash")
+	viper.BindPFlag(config.FlagZeroBits, powMineCmd.Flags().Lookup(config.FlagZeroBits))
+	powMineCmd.Flags().String(config.FlagBits, "", "Target in compact hex format, overrides --zero-bits")
+	viper.BindPFlag(config.FlagBits, powMineCmd.Flags().Lookup(config.FlagBits))
+	powMineCmd.Flags().Int(config.FlagWorkers, runtime.NumCPU(), "Number of mining goroutines")
+	viper.BindPFlag(config.FlagWorkers, powMineCmd.Flags().Lookup(config.FlagWorkers))
+	powMineCmd.Flags().String(config.FlagPrevHash, "", "Hash of the previous block (default zero)")
+	viper.BindPFlag(config.FlagPrevHash, powMineCmd.Flags().Lookup(config.FlagPrevHash))
+	powMineCmd.Flags().String(config.FlagData, "cryptonaut", "Data committed by the merkle root")
+	viper.BindPFlag(config.FlagData, powMineCmd.Flags().Lookup(config.FlagData))
+
+	rootCmd.AddCommand(powCmd)
+}
+
+// powNetworkParams resolves the chain parameters of --network, which must be
+// a bitcoin network as litecoin and dogecoin blocks are hashed with scrypt
+func powNetworkParams() (*chaincfg.Params, error) {
+	network, err := bitcoinNetwork()
+	if err != nil {
+		return nil, err
+	}
+	if network.Coin != bitcoin.CoinBitcoin {
+		return nil, fmt.Errorf("proof of work of %s blocks is not supported", network.Coin)
+	}
+	return network.Params, nil
+}
+
+func runPowHeaderCmd(cmd *cobra.Command, args []string) error {
+	net, err := powNetworkParams()
+	if err != nil {
+		return err
+	}
+	header, err := pow.DecodeHeader(args[0])
+	if err != nil {
+		return err
+	}
+	target, err := pow.CompactToTarget(header.Bits)
+	if err != nil {
+		return err
+	}
+	difficulty, err := pow.Difficulty(header.Bits)
+	if err != nil {
+		return err
+	}
+	work, err := pow.Work(header.Bits)
+	if err != nil {
+		return err
+	}
+
+	cmd.Println("Hash:", header.BlockHash())
+	cmd.Printf("Version: 0x%08x\n", uint32(header.Version))
+	cmd.Println("Previous block:", header.PrevBlock)
+	cmd.Println("Merkle root:", header.MerkleRoot)
+	cmd.Println("Time:", header.Timestamp.UTC().Format(time.RFC3339))
+	cmd.Printf("Bits: %08x\n", header.Bits)
+	cmd.Println("Nonce:", header.Nonce)
+	cmd.Printf("Target: %064x\n", target)
+	cmd.Println("Difficulty:", difficulty)
+	cmd.Printf("Work: %x\n", work)
+	if err := pow.CheckProofOfWork(header, net); err != nil {
+		cmd.Println("Proof of work: invalid,", err)
+	} else {
+		cmd.Println("Proof of work: valid")
+	}
+	return nil
+}
+
+// powChainInfo adds the chainwork in hex to the chain summary
+type powChainInfo struct {
+	*pow.ChainSummary
+	ChainWork string `json:"chainwork"`
+}
+
+func runPowChainCmd(cmd *cobra.Command, args []string) error {
+	net, err := powNetworkParams()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read headers file: %v", err)
+	}
+	raw, err := hex.DecodeString(strings.Join(strings.Fields(string(data)), ""))
+	if err != nil {
+		return fmt.Errorf("invalid headers hex: %v", err)
+	}
+	headers, err := pow.ParseHeaders(raw)
+	if err != nil {
+		return err
+	}
+	summary, err := pow.ValidateChain(headers, viper.GetInt32(config.FlagStartHeight), net)
+	if err != nil {
+		return err
+	}
+	return printJSON(powChainInfo{ChainSummary: summary, ChainWork: summary.ChainWorkHex()})
+}
+
+func runPowMineCmd(cmd *cobra.Command, args []string) error {
+	bits, err := pow.TargetBits(viper.GetInt(config.FlagZeroBits))
+	if err != nil {
+		return err
+	}
+	if bitsHex := viper.GetString(config.FlagBits); bitsHex != "" {
+		parsed, err := strconv.ParseUint(strings.TrimPrefix(bitsHex, "0x"), 16, 32)
+		if err != nil {
+			return fmt.Errorf("invalid bits %s: %v", bitsHex, err)
+		}
+		bits = uint32(parsed)
+	}
+	if _, err := pow.CompactToTarget(bits); err != nil {
+		return err
+	}
+	header := wire.BlockHeader{
+		Version:    0x20000000,
+		MerkleRoot: chainhash.DoubleHashH([]byte(viper.GetString(config.FlagData))),
+		Timestamp:  time.Unix(time.Now().Unix(), 0),
+		Bits:       bits,
+	}
+	if prevHash := viper.GetString(config.FlagPrevHash); prevHash != "" {
+		hash, err := chainhash.NewHashFromStr(prevHash)
+		if err != nil {
+			return fmt.Errorf("invalid previous block hash %s: %v", prevHash, err)
+		}
+		header.PrevBlock = *hash
+	}
+	difficulty, err := pow.Difficulty(bits)
+	if err != nil {
+		return err
+	}
+
+	workers := viper.GetInt(config.FlagWorkers)
+	cmd.Printf("Mining with bits %08x (difficulty %g) on %d workers\n", bits, difficulty, workers)
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+	miner := pow.NewMiner(workers)
+
+	// report the hashrate while mining
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				cmd.Printf("Hashes: %d (%s)\n", miner.Hashes(), formatHashRate(miner.HashRate()))
+			}
+		}
+	}()
+
+	result, err := miner.Mine(ctx, header)
+	if err == context.Canceled {
+		cmd.Printf("Interrupted after %d hashes (%s)\n", miner.Hashes(), formatHashRate(miner.HashRate()))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	cmd.Println("Header:", hex.EncodeToString(pow.SerializeHeader(&result.Header)))
+	cmd.Println("Hash:", result.Hash)
+	cmd.Println("Nonce:", result.Header.Nonce)
+	cmd.Println("Time:", result.Header.Timestamp.UTC().Format(time.RFC3339))
+	cmd.Println("Hashes:", result.Hashes)
+	cmd.Println("Elapsed:", result.Elapsed.Round(time.Millisecond))
+	cmd.Println("Hashrate:", formatHashRate(result.HashRate()))
+	return nil
+}
+
+// formatHashRate formats hashes per second with a metric prefix
+func formatHashRate(rate float64) string {
+	return formatRate(rate, "H/s")
+}
+
+// formatRate formats a rate with a metric prefix
+func formatRate(rate float64, unit string) string {
+	prefixes := []string{"", "k", "M", "G"}
+	prefix := 0
+	for rate >= 1000 && prefix < len(prefixes)-1 {
+		rate /= 1000
+		prefix++
+	}
+	return fmt.Sprintf("%.2f %s%s", rate, prefixes[prefix], unit)
+}
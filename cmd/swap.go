@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/alejoacosta74/cryptonaut/internal/config"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin"
+	"github.com/alejoacosta74/cryptonaut/pkg/swap"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var swapCmd = &cobra.Command{
+	Use:   "swap",
+	Short: "Cross-chain atomic swap tools",
+	Long:  "Cross-chain atomic swap tools",
+}
+
+var swapHtlcCmd = &cobra.Command{
+	Use:   "htlc",
+	Short: "Hashed timelock contracts on Bitcoin and Ethereum",
+	Long: `Hashed timelock contracts (HTLC) locking funds to the SHA256 preimage of a
+hash lock, refundable after a timelock. The same secret unlocks the HTLCs of
+both chains of a swap.
+
+With --chain bitcoin (the default) the HTLC is a P2WSH script paying the
+--recipient public key with the preimage, or the --refund public key after
+a CLTV (absolute) or CSV (relative) timelock. Claim and refund transactions
+are built and signed from the witness script.
+
+With --chain ethereum the commands print the calldata of the HashedTimelock
+contract: newContract(receiver, hashlock, timelock) sent with the locked
+value, withdraw(contractId, preimage) and refund(contractId).`,
+}
+
+var swapHtlcCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an HTLC",
+	Long: `Create an HTLC locked to --hash-lock, or to a new random secret printed along
+with its hash lock
+	Usage:
+	cryptonaut swap htlc create --recipient <pubkey> --refund <pubkey> --locktime 850000 --network testnet
+	cryptonaut swap htlc create --recipient <pubkey> --refund <pubkey> --locktime 144 --lock-type csv --hash-lock <sha256 hex>
+	cryptonaut swap htlc create --chain ethereum --recipient <address> --refund <sender address> --amount <wei> --locktime <unix time> --hash-lock <sha256 hex>
+	`,
+	Args: cobra.NoArgs,
+	RunE: runSwapHtlcCreateCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// bound here instead of init() as the tx and script commands bind the same keys
+		bindFlags(cmd, config.FlagHashLock, config.FlagRecipient, config.FlagRefund, config.FlagLockType,
+			config.FlagLockTime, config.FlagAmount, config.FlagNetwork)
+		if err := cmd.MarkFlagRequired(config.FlagRecipient); err != nil {
+			return err
+		}
+		if err := cmd.MarkFlagRequired(config.FlagRefund); err != nil {
+			return err
+		}
+		return cmd.MarkFlagRequired(config.FlagLockTime)
+	},
+}
+
+var swapHtlcClaimCmd = &cobra.Command{
+	Use:   "claim",
+	Short: "Claim an HTLC with the preimage of its hash lock",
+	Long: `Claim an HTLC with the preimage of its hash lock. On Bitcoin the HTLC output
+is spent to --to with a transaction signed by the recipient key; on Ethereum
+the withdraw calldata is printed.
+	Usage:
+	cryptonaut swap htlc claim --script <witness script hex> --utxo <txid>:<vout> --amount <sats> --preimage <hex> --to <address> --fee-rate 5 --private-key <hex or WIF>
+	cryptonaut swap htlc claim --chain ethereum --contract-id <hex> --preimage <hex>
+	`,
+	Args: cobra.NoArgs,
+	RunE: runSwapHtlcClaimCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		bindSwapSpendFlags(cmd)
+		bindFlags(cmd, config.FlagPreimage)
+		return cmd.MarkFlagRequired(config.FlagPreimage)
+	},
+}
+
+var swapHtlcRefundCmd = &cobra.Command{
+	Use:   "refund",
+	Short: "Refund an HTLC after its timelock",
+	Long: `Refund an HTLC after its timelock. On Bitcoin the HTLC output is spent back
+to --to with a transaction signed by the refund key, with the locktime or
+sequence required by the script; on Ethereum the refund calldata is printed.
+	Usage:
+	cryptonaut swap htlc refund --script <witness script hex> --utxo <txid>:<vout> --amount <sats> --to <address> --fee-rate 5 --private-key <hex or WIF>
+	cryptonaut swap htlc refund --chain ethereum --contract-id <hex>
+	`,
+	Args: cobra.NoArgs,
+	RunE: runSwapHtlcRefundCmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		bindSwapSpendFlags(cmd)
+		return nil
+	},
+}
+
+func init() {
+	swapHtlcCmd.AddCommand(swapHtlcCreateCmd)
+	swapHtlcCmd.AddCommand(swapHtlcClaimCmd)
+	swapHtlcCmd.AddCommand(swapHtlcRefundCmd)
+
+	swapHtlcCmd.PersistentFlags().StringP(config.FlagNetwork, "n", "", networkFlagUsage)
+
+	swapHtlcCreateCmd.Flags().String(config.FlagHashLock, "", "SHA256 hash lock in hex (default: a new random secret)")
+	swapHtlcCreateCmd.Flags().String(config.FlagRecipient, "", "Recipient public key (bitcoin) or address (ethereum)")
+	swapHtlcCreateCmd.Flags().String(config.FlagRefund, "", "Refund public key (bitcoin) or sender address (ethereum)")
+	swapHtlcCreateCmd.Flags().String(config.FlagLockType, string(swap.LockCLTV), "Bitcoin timelock type (cltv, csv)")
+	swapHtlcCreateCmd.Flags().Uint32(config.FlagLockTime, 0, "Block height or unix time (cltv, ethereum) or number of blocks (csv) of the timelock")
+	swapHtlcCreateCmd.Flags().String(config.FlagAmount, "", "Locked value in wei (ethereum)")
+
+	swapHtlcClaimCmd.Flags().String(config.FlagPreimage, "", "Preimage of the hash lock in hex")
+	for _, cmd := range []*cobra.Command{swapHtlcClaimCmd, swapHtlcRefundCmd} {
+		cmd.Flags().String(config.FlagScript, "", "HTLC witness script in hex (bitcoin)")
+		cmd.Flags().String(config.FlagUtxo, "", "HTLC output as <txid>:<vout> (bitcoin)")
+		cmd.Flags().String(config.FlagAmount, "", "Value of the HTLC output in sats (bitcoin)")
+		cmd.Flags().String(config.FlagTo, "", "Destination address (bitcoin)")
+		cmd.Flags().Float64(config.FlagFeeRate, 1, "Fee rate in sat/vB (bitcoin)")
+		cmd.Flags().String(config.FlagMnemonic, "", "Mnemonic phrase used to derive the signing key (bitcoin)")
+		cmd.Flags().Int(config.FlagIndex, 0, "Derivation index of the signing key (bitcoin)")
+		addNetworkCoinTypeFlag(cmd)
+		cmd.Flags().String(config.FlagContractID, "", "HashedTimelock contract id in hex (ethereum)")
+	}
+
+	swapCmd.AddCommand(swapHtlcCmd)
+	rootCmd.AddCommand(swapCmd)
+}
+
+// bindSwapSpendFlags binds the flags shared by the claim and refund commands
+func bindSwapSpendFlags(cmd *cobra.Command) {
+	// bound here instead of init() as the tx, script and hd commands bind the same keys
+	bindFlags(cmd, config.FlagScript, config.FlagUtxo, config.FlagAmount, config.FlagTo, config.FlagFeeRate,
+		config.FlagMnemonic, config.FlagIndex, config.FlagNetworkCoinType, config.FlagContractID, config.FlagNetwork)
+}
+
+// swapChain returns the chain of the swap commands, bitcoin by default
+func swapChain() (string, error) {
+	switch chain := strings.ToLower(viper.GetString(config.FlagChain)); chain {
+	case "", "bitcoin":
+		return "bitcoin", nil
+	case "ethereum":
+		return chain, nil
+	default:
+		return "", fmt.Errorf("unsupported swap chain %s, expected bitcoin or ethereum", chain)
+	}
+}
+
+// swapHexFlag decodes a hex flag of the given size
+func swapHexFlag(name string, size int) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(viper.GetString(name), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s: %v", name, err)
+	}
+	if len(b) != size {
+		return nil, fmt.Errorf("--%s must be %d bytes, got %d", name, size, len(b))
+	}
+	return b, nil
+}
+
+// swapEthereumAddress parses an address flag
+func swapEthereumAddress(name string) (common.Address, error) {
+	s := viper.GetString(name)
+	if !common.IsHexAddress(s) {
+		return common.Address{}, fmt.Errorf("invalid --%s address: %s", name, s)
+	}
+	return common.HexToAddress(s), nil
+}
+
+func runSwapHtlcCreateCmd(cmd *cobra.Command, args []string) error {
+	chain, err := swapChain()
+	if err != nil {
+		return err
+	}
+
+	var secret, hashLock []byte
+	if viper.GetString(config.FlagHashLock) != "" {
+		if hashLock, err = swapHexFlag(config.FlagHashLock, 32); err != nil {
+			return err
+		}
+	} else if secret, hashLock, err = swap.NewSecret(); err != nil {
+		return err
+	}
+
+	if chain == "ethereum" {
+		if err := printEthereumHTLC(cmd, hashLock); err != nil {
+			return err
+		}
+	} else if err := printBitcoinHTLC(cmd, hashLock); err != nil {
+		return err
+	}
+
+	cmd.Println("Hash lock:", hex.EncodeToString(hashLock))
+	if secret != nil {
+		cmd.Println("Secret:", hex.EncodeToString(secret))
+	}
+	return nil
+}
+
+func printBitcoinHTLC(cmd *cobra.Command, hashLock []byte) error {
+	var keys []*btcec.PublicKey
+	for _, name := range []string{config.FlagRecipient, config.FlagRefund} {
+		keyBytes, err := hex.DecodeString(viper.GetString(name))
+		if err != nil {
+			return fmt.Errorf("invalid --%s public key: %v", name, err)
+		}
+		key, err := btcec.ParsePubKey(keyBytes)
+		if err != nil {
+			return fmt.Errorf("invalid --%s public key: %v", name, err)
+		}
+		keys = append(keys, key)
+	}
+	lockType, err := swap.ParseLockType(viper.GetString(config.FlagLockType))
+	if err != nil {
+		return err
+	}
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return err
+	}
+	htlc, err := swap.NewBitcoinHTLC(hashLock, keys[0], keys[1], lockType, viper.GetUint32(config.FlagLockTime), net)
+	if err != nil {
+		return err
+	}
+
+	cmd.Println("Address:", htlc.Address)
+	cmd.Println("Script pubkey:", hex.EncodeToString(htlc.ScriptPubKey))
+	cmd.Println("Witness script:", hex.EncodeToString(htlc.WitnessScript))
+	return nil
+}
+
+func printEthereumHTLC(cmd *cobra.Command, hashLock []byte) error {
+	receiver, err := swapEthereumAddress(config.FlagRecipient)
+	if err != nil {
+		return err
+	}
+	sender, err := swapEthereumAddress(config.FlagRefund)
+	if err != nil {
+		return err
+	}
+	amount, ok := new(big.Int).SetString(viper.GetString(config.FlagAmount), 10)
+	if !ok || amount.Sign() <= 0 {
+		return fmt.Errorf("--%s must be a positive amount of wei", config.FlagAmount)
+	}
+	htlc := &swap.EthereumHTLC{
+		Sender:   sender,
+		Receiver: receiver,
+		Amount:   amount,
+		HashLock: [32]byte(hashLock),
+		TimeLock: new(big.Int).SetUint64(uint64(viper.GetUint32(config.FlagLockTime))),
+	}
+	data, err := htlc.NewContractCalldata()
+	if err != nil {
+		return err
+	}
+	contractID := htlc.ContractID()
+
+	cmd.Println("Calldata:", "0x"+hex.EncodeToString(data))
+	cmd.Println("Value:", amount)
+	cmd.Println("Contract id:", "0x"+hex.EncodeToString(contractID[:]))
+	return nil
+}
+
+func runSwapHtlcClaimCmd(cmd *cobra.Command, args []string) error {
+	chain, err := swapChain()
+	if err != nil {
+		return err
+	}
+	preimage, err := swapHexFlag(config.FlagPreimage, swap.SecretSize)
+	if err != nil {
+		return err
+	}
+
+	if chain == "ethereum" {
+		contractID, err := swapHexFlag(config.FlagContractID, 32)
+		if err != nil {
+			return err
+		}
+		data, err := swap.WithdrawCalldata([32]byte(contractID), preimage)
+		if err != nil {
+			return err
+		}
+		cmd.Println("Calldata:", "0x"+hex.EncodeToString(data))
+		return nil
+	}
+
+	return spendBitcoinHTLC(cmd, func(htlc *swap.BitcoinHTLC, outPoint wire.OutPoint, value int64, dest []byte, feeRate float64, key *btcec.PrivateKey) (*wire.MsgTx, error) {
+		return htlc.ClaimTx(outPoint, value, preimage, dest, feeRate, key)
+	})
+}
+
+func runSwapHtlcRefundCmd(cmd *cobra.Command, args []string) error {
+	chain, err := swapChain()
+	if err != nil {
+		return err
+	}
+
+	if chain == "ethereum" {
+		contractID, err := swapHexFlag(config.FlagContractID, 32)
+		if err != nil {
+			return err
+		}
+		data, err := swap.RefundCalldata([32]byte(contractID))
+		if err != nil {
+			return err
+		}
+		cmd.Println("Calldata:", "0x"+hex.EncodeToString(data))
+		return nil
+	}
+
+	return spendBitcoinHTLC(cmd, (*swap.BitcoinHTLC).RefundTx)
+}
+
+// spendBitcoinHTLC builds the HTLC spend described by the command flags
+// with build and prints the signed raw transaction
+func spendBitcoinHTLC(cmd *cobra.Command, build func(*swap.BitcoinHTLC, wire.OutPoint, int64, []byte, float64, *btcec.PrivateKey) (*wire.MsgTx, error)) error {
+	net, err := bitcoinNetworkParams()
+	if err != nil {
+		return err
+	}
+	script, err := hex.DecodeString(viper.GetString(config.FlagScript))
+	if err != nil {
+		return fmt.Errorf("invalid --%s: %v", config.FlagScript, err)
+	}
+	htlc, err := swap.ParseBitcoinHTLC(script, net)
+	if err != nil {
+		return err
+	}
+	outPoint, err := wire.NewOutPointFromString(viper.GetString(config.FlagUtxo))
+	if err != nil {
+		return fmt.Errorf("invalid --%s, expected <txid>:<vout>: %v", config.FlagUtxo, err)
+	}
+	value, err := strconv.ParseInt(viper.GetString(config.FlagAmount), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid --%s: %v", config.FlagAmount, err)
+	}
+	dest, err := bitcoin.AddressToScript(viper.GetString(config.FlagTo), net)
+	if err != nil {
+		return err
+	}
+	privKey, err := psbtSigningKey()
+	if err != nil {
+		return err
+	}
+
+	tx, err := build(htlc, *outPoint, value, dest, viper.GetFloat64(config.FlagFeeRate), privKey)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return err
+	}
+	cmd.Println("HTLC address:", htlc.Address)
+	cmd.Println("Fee:", value-tx.TxOut[0].Value)
+	cmd.Println("Raw transaction:", hex.EncodeToString(buf.Bytes()))
+	return nil
+}
@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/alejoacosta74/cryptonaut/internal/config"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin"
+	"github.com/alejoacosta74/cryptonaut/pkg/vanity"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var vanityCmd = &cobra.Command{
+	Use:   "vanity",
+	Short: "Generate vanity addresses",
+	Long: `Search for a key whose address matches a prefix, a suffix and/or a regular
+expression, across --workers goroutines. The chain is given with --chain
+(bitcoin by default, ethereum or cosmos). Prefixes include the fixed start of
+the address: "1" or "bc1q" on bitcoin, "cosmos1" on cosmos; the "0x" of
+ethereum prefixes is optional.
+
+Ethereum addresses are matched in their EIP-55 checksummed form unless
+--ignore-case is set, so each letter of the pattern must also match the case
+of the checksum. The expected number of attempts, the probability of a match
+so far and the time to the next probability milestone are reported while
+searching. Interrupt with Ctrl-C.
+	Usage:
+	cryptonaut vanity --prefix bc1qcafe
+	cryptonaut vanity --prefix 1Kid --ignore-case --address-type p2pkh
+	cryptonaut vanity --chain ethereum --prefix 0xC0FFEE --suffix 42
+	cryptonaut vanity --chain cosmos --cosmos-address-prefix osmo --regex "^osmo1(abc|xyz)"
+	`,
+	Args:    cobra.NoArgs,
+	RunE:    runVanityCmd,
+	PreRunE: bindVanityFlags,
+}
+
+var vanityCreate2Cmd = &cobra.Command{
+	Use:   "create2",
+	Short: "Search a CREATE2 salt for a vanity contract address",
+	Long: `Search the salt making the address of a contract deployed with CREATE2 by
+--deployer match the pattern. The address is
+keccak256(0xff ++ deployer ++ salt ++ keccak256(init code))[12:].
+	Usage:
+	cryptonaut vanity create2 --deployer 0x4e59b44847b379578588920ca78fbf26c0b4956c --init-code <hex> --prefix 0x0000
+	cryptonaut vanity create2 --deployer <address> --init-code-hash <hex> --prefix dead --ignore-case
+	`,
+	Args: cobra.NoArgs,
+	RunE: runVanityCreate2Cmd,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		bindFlags(cmd, config.FlagDeployer, config.FlagInitCode, config.FlagInitCodeHash)
+		if err := cmd.MarkFlagRequired(config.FlagDeployer); err != nil {
+			return err
+		}
+		return bindVanityFlags(cmd, args)
+	},
+}
+
+func init() {
+	vanityCmd.AddCommand(vanityCreate2Cmd)
+
+	vanityCmd.PersistentFlags().String(config.FlagPrefix, "", "Address prefix")
+	vanityCmd.PersistentFlags().String(config.FlagSuffix, "", "Address suffix")
+	vanityCmd.PersistentFlags().String(config.FlagRegex, "", "Regular expression the address must match")
+	vanityCmd.PersistentFlags().Bool(config.FlagIgnoreCase, false, "Match regardless of case")
+	vanityCmd.PersistentFlags().Int(config.FlagWorkers, runtime.NumCPU(), "Number of search goroutines")
+
+	vanityCmd.Flags().String(config.FlagAddressType, string(bitcoin.AddressTypeP2WPKH), "Bitcoin address type (p2pkh, p2sh-p2wpkh, p2wpkh, p2tr)")
+	vanityCmd.Flags().StringP(config.FlagNetwork, "n", "", networkFlagUsage)
+	vanityCmd.Flags().String(config.FlagCosmosAddressPrefix, "cosmos", "Cosmos address prefix")
+
+	vanityCreate2Cmd.Flags().String(config.FlagDeployer, "", "Address of the deploying contract or factory")
+	vanityCreate2Cmd.Flags().String(config.FlagInitCode, "", "Contract init code in hex")
+	vanityCreate2Cmd.Flags().String(config.FlagInitCodeHash, "", "Keccak256 hash of the init code in hex")
+
+	rootCmd.AddCommand(vanityCmd)
+}
+
+// bindVanityFlags binds the flags shared by the vanity commands
+func bindVanityFlags(cmd *cobra.Command, args []string) error {
+	// bound here instead of init() as the pow, address and cosmos commands bind the same keys
+	bindFlags(cmd, config.FlagPrefix, config.FlagSuffix, config.FlagRegex, config.FlagIgnoreCase, config.FlagWorkers,
+		config.FlagAddressType, config.FlagNetwork, config.FlagCosmosAddressPrefix)
+	return nil
+}
+
+func vanityPattern() vanity.Pattern {
+	return vanity.Pattern{
+		Prefix:     viper.GetString(config.FlagPrefix),
+		Suffix:     viper.GetString(config.FlagSuffix),
+		Regex:      viper.GetString(config.FlagRegex),
+		IgnoreCase: viper.GetBool(config.FlagIgnoreCase),
+	}
+}
+
+func runVanityCmd(cmd *cobra.Command, args []string) error {
+	var gen vanity.Generator
+	chain := strings.ToLower(viper.GetString(config.FlagChain))
+	switch chain {
+	case "", "bitcoin":
+		addrType, err := bitcoin.ParseAddressType(viper.GetString(config.FlagAddressType))
+		if err != nil {
+			return err
+		}
+		net, err := bitcoinNetworkParams()
+		if err != nil {
+			return err
+		}
+		chain = "bitcoin"
+		gen = vanity.BitcoinGenerator{Type: addrType, Net: net}
+	case "ethereum":
+		gen = vanity.EthereumGenerator{}
+	case "cosmos":
+		gen = vanity.CosmosGenerator{Prefix: viper.GetString(config.FlagCosmosAddressPrefix)}
+	default:
+		return fmt.Errorf("unsupported chain %s, expected bitcoin, ethereum or cosmos", chain)
+	}
+
+	result, err := searchVanity(cmd, chain+" addresses", gen)
+	if err != nil || result == nil {
+		return err
+	}
+
+	cmd.Println("Address:", result.Address)
+	switch chain {
+	case "bitcoin":
+		privKey, _ := btcec.PrivKeyFromBytes(result.Key)
+		net, err := bitcoinNetworkParams()
+		if err != nil {
+			return err
+		}
+		wif, err := bitcoin.ConvertPrivateKeyToWIF(privKey, net, true)
+		if err != nil {
+			return err
+		}
+		cmd.Println("Private key:", hex.EncodeToString(result.Key))
+		cmd.Println("WIF:", wif.String())
+		cmd.Println("Public key:", hex.EncodeToString(privKey.PubKey().SerializeCompressed()))
+	case "ethereum":
+		cmd.Println("Private key:", "0x"+hex.EncodeToString(result.Key))
+	case "cosmos":
+		cmd.Println("Private key:", hex.EncodeToString(result.Key))
+	}
+	printVanityStats(cmd, result)
+	return nil
+}
+
+func runVanityCreate2Cmd(cmd *cobra.Command, args []string) error {
+	deployer := viper.GetString(config.FlagDeployer)
+	if !common.IsHexAddress(deployer) {
+		return fmt.Errorf("invalid deployer address: %s", deployer)
+	}
+	var initCodeHash common.Hash
+	switch initCode, codeHash := viper.GetString(config.FlagInitCode), viper.GetString(config.FlagInitCodeHash); {
+	case initCode != "":
+		code, err := hex.DecodeString(strings.TrimPrefix(initCode, "0x"))
+		if err != nil {
+			return fmt.Errorf("invalid init code: %v", err)
+		}
+		initCodeHash = crypto.Keccak256Hash(code)
+	case codeHash != "":
+		hash, err := hex.DecodeString(strings.TrimPrefix(codeHash, "0x"))
+		if err != nil || len(hash) != common.HashLength {
+			return fmt.Errorf("invalid init code hash: %s", codeHash)
+		}
+		initCodeHash = common.BytesToHash(hash)
+	default:
+		return fmt.Errorf("either --%s or --%s is required", config.FlagInitCode, config.FlagInitCodeHash)
+	}
+
+	gen := vanity.Create2Generator{Deployer: common.HexToAddress(deployer), InitCodeHash: initCodeHash}
+	result, err := searchVanity(cmd, "CREATE2 salts", gen)
+	if err != nil || result == nil {
+		return err
+	}
+
+	cmd.Println("Address:", result.Address)
+	cmd.Println("Salt:", "0x"+hex.EncodeToString(result.Key))
+	cmd.Println("Init code hash:", initCodeHash.Hex())
+	printVanityStats(cmd, result)
+	return nil
+}
+
+// searchVanity runs the search, reporting its progress until a match or an
+// interrupt, which returns a nil result
+func searchVanity(cmd *cobra.Command, what string, gen vanity.Generator) (*vanity.Result, error) {
+	matcher, err := vanity.Compile(gen, vanityPattern())
+	if err != nil {
+		return nil, err
+	}
+	workers := viper.GetInt(config.FlagWorkers)
+	// a regular expression alone gives no estimate
+	difficulty := matcher.Difficulty
+	estimated := difficulty > 1 || viper.GetString(config.FlagRegex) == ""
+	if !estimated {
+		cmd.Printf("Searching %s on %d workers\n", what, workers)
+	} else {
+		bound := ""
+		if viper.GetString(config.FlagRegex) != "" {
+			bound = "at least "
+		}
+		cmd.Printf("Searching %s on %d workers, difficulty %s1 in %.0f (50%% chance after %.0f attempts)\n",
+			what, workers, bound, difficulty, vanity.AttemptsFor(difficulty, 0.5))
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+	searcher := vanity.NewSearcher(workers)
+
+	// report the progress while searching
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				attempts, rate := searcher.Attempts(), searcher.Rate()
+				if !estimated {
+					cmd.Printf("Attempts: %d (%s)\n", attempts, formatRate(rate, "keys/s"))
+					continue
+				}
+				probability := vanity.Probability(difficulty, attempts)
+				cmd.Printf("Attempts: %d (%s), probability %.1f%%%s\n", attempts, formatRate(rate, "keys/s"),
+					100*probability, vanityETA(difficulty, attempts, probability, rate))
+			}
+		}
+	}()
+
+	result, err := searcher.Search(ctx, gen, matcher)
+	if err == context.Canceled {
+		cmd.Printf("Interrupted after %d attempts (%s)\n", searcher.Attempts(), formatRate(searcher.Rate(), "keys/s"))
+		return nil, nil
+	}
+	return result, err
+}
+
+// vanityETA returns the time until the next milestone of the probability
+// of a match
+func vanityETA(difficulty float64, attempts uint64, probability, rate float64) string {
+	if rate <= 0 {
+		return ""
+	}
+	for _, milestone := range []float64{0.5, 0.9, 0.99} {
+		if probability < milestone {
+			seconds := (vanity.AttemptsFor(difficulty, milestone) - float64(attempts)) / rate
+			return fmt.Sprintf(", %.0f%% chance in %s", 100*milestone, formatETA(seconds))
+		}
+	}
+	return ""
+}
+
+// formatETA formats a number of seconds, in years beyond a year
+func formatETA(seconds float64) string {
+	const year = 365.25 * 24 * 3600
+	if seconds > year {
+		return fmt.Sprintf("%.3g years", seconds/year)
+	}
+	return time.Duration(math.Ceil(seconds) * float64(time.Second)).String()
+}
+
+func printVanityStats(cmd *cobra.Command, result *vanity.Result) {
+	cmd.Println("Attempts:", result.Attempts)
+	cmd.Println("Elapsed:", result.Elapsed.Round(time.Millisecond))
+	cmd.Println("Rate:", formatRate(result.Rate(), "keys/s"))
+}
@@ -26,11 +26,110 @@ const (
 	FlagWsUrl    = "ws-url"
 
 	// BIP44 derivation flags
-	FlagMnemonic = "mnemonic"
-	FlagIndex    = "index"
+	FlagMnemonic        = "mnemonic"
+	FlagIndex           = "index"
+	FlagNetworkCoinType = "network-coin-type"
 
 	// Bitcoin flags
 	FlagBitcoinFormat = "bitcoin-format"
+	FlagAddressType   = "address-type"
+	FlagExtract       = "extract"
+	FlagUtxos         = "utxos"
+	FlagOutput        = "output"
+	FlagFeeRate       = "fee-rate"
+	FlagChangeAddress = "change-address"
+	FlagRBF           = "rbf"
+	FlagLockTime      = "locktime"
+	FlagSign          = "sign"
+	FlagPrevouts      = "prevouts"
+	FlagInputIndex    = "input"
+	FlagScriptPubKey  = "script-pubkey"
+	FlagAmount        = "amount"
+	FlagScript        = "script"
+	FlagSigHash       = "sighash"
+	FlagSigVersion    = "sigversion"
+	FlagLeafScript    = "leaf-script"
+	FlagLeafHash      = "leaf-hash"
+	FlagAddress       = "address"
+	FlagMessageFormat = "message-format"
+	FlagTx            = "tx"
+	FlagScriptSig     = "script-sig"
+	FlagWitness       = "witness"
+	FlagSequence      = "sequence"
+	FlagRange         = "range"
+	FlagKey           = "key"
+	FlagRequired      = "required"
+	FlagAddressIndex  = "address-index"
+	FlagInternalKey   = "internal-key"
+	FlagLeaf          = "leaf"
+	FlagLeafIndex     = "leaf-index"
+	FlagBumpMethod    = "method"
+	FlagChangeIndex   = "change-index"
+
+	// Bitcoin node flags
+	FlagRPCURL       = "rpc-url"
+	FlagRPCUser      = "rpc-user"
+	FlagRPCPassword  = "rpc-password"
+	FlagRPCCookie    = "rpc-cookie"
+	FlagDecode       = "decode"
+	FlagBlockHash    = "block-hash"
+	FlagEstimateMode = "estimate-mode"
+	FlagZMQ          = "zmq"
+	FlagPollInterval = "poll-interval"
+
+	// BIP38 flags
+	FlagTo         = "to"
+	FlagPassphrase = "passphrase"
+	FlagLot        = "lot"
+
+	// Merkle flags
+	FlagTxid   = "txid"
+	FlagBranch = "branch"
+	FlagRoot   = "root"
+	FlagHeader = "header"
+
+	// PoW flags
+	FlagStartHeight = "start-height"
+	FlagBits        = "bits"
+	FlagZeroBits    = "zero-bits"
+	FlagWorkers     = "workers"
+	FlagPrevHash    = "prev-hash"
+	FlagData        = "data"
+
+	// Swap flags
+	FlagHashLock   = "hash-lock"
+	FlagRecipient  = "recipient"
+	FlagRefund     = "refund"
+	FlagLockType   = "lock-type"
+	FlagUtxo       = "utxo"
+	FlagPreimage   = "preimage"
+	FlagContractID = "contract-id"
+
+	// Vanity flags
+	FlagPrefix       = "prefix"
+	FlagSuffix       = "suffix"
+	FlagRegex        = "regex"
+	FlagIgnoreCase   = "ignore-case"
+	FlagDeployer     = "deployer"
+	FlagInitCode     = "init-code"
+	FlagInitCodeHash = "init-code-hash"
+
+	// Ethereum transaction flags
+	FlagSpec           = "spec"
+	FlagTxType         = "type"
+	FlagChainID        = "chain-id"
+	FlagNonce          = "nonce"
+	FlagValue          = "value"
+	FlagGas            = "gas"
+	FlagGasPrice       = "gas-price"
+	FlagMaxFee         = "max-fee"
+	FlagMaxPriorityFee = "max-priority-fee"
+	FlagMaxBlobFee     = "max-blob-fee"
+	FlagBlob           = "blob"
+	FlagAuthorize      = "authorize"
+	FlagKeystore       = "keystore"
+	FlagABI            = "abi"
+	FlagSignatureDB    = "signature-db"
 
 	// ECDSA flags
 	FlagSignatureR = "r"
@@ -4,4 +4,5 @@ package config
 const (
 	DefaultConfigFileName = "cryptonaut.yaml"
 	DefaultConfigDir      = ".cryptonaut"
+	SignaturesFileName    = "signatures.txt"
 )
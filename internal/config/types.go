@@ -15,4 +15,6 @@ type Network string
 const (
 	NetworkMainnet Network = "mainnet"
 	NetworkTestnet Network = "testnet"
+	NetworkSignet  Network = "signet"
+	NetworkRegtest Network = "regtest"
 )
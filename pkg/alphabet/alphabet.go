@@ -0,0 +1,48 @@
+// Package alphabet holds the character sets of address encodings and finds
+// the likely corrections of mistyped addresses
+package alphabet
+
+import "strings"
+
+// Base58 is the alphabet of Base58Check strings, without 0, O, I and l
+const Base58 = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// Bech32 is the data character set of bech32 strings (BIP173)
+const Bech32 = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// Bech32Corrections returns the strings accepted by isValid that differ from
+// the bech32 string s by one substituted character or two swapped adjacent
+// characters at or after position start. A bech32 checksum detects any such
+// typo, so an address with a single typo has a single correction.
+func Bech32Corrections(s string, start int, isValid func(string) bool) []string {
+	return Corrections(strings.ToLower(s), start, Bech32, isValid)
+}
+
+// Corrections returns the strings accepted by isValid that differ from s by
+// one character of alphabet substituted or two swapped adjacent characters
+// at or after position start
+func Corrections(s string, start int, alphabet string, isValid func(string) bool) []string {
+	var found []string
+	seen := map[string]bool{s: true}
+	try := func(candidate string) {
+		if !seen[candidate] && isValid(candidate) {
+			found = append(found, candidate)
+		}
+		seen[candidate] = true
+	}
+	b := []byte(s)
+	for i := start; i < len(b); i++ {
+		original := b[i]
+		for j := 0; j < len(alphabet); j++ {
+			b[i] = alphabet[j]
+			try(string(b))
+		}
+		b[i] = original
+		if i+1 < len(b) && b[i] != b[i+1] {
+			b[i], b[i+1] = b[i+1], b[i]
+			try(string(b))
+			b[i], b[i+1] = b[i+1], b[i]
+		}
+	}
+	return found
+}
@@ -0,0 +1,25 @@
+package alphabet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBech32Corrections(t *testing.T) {
+	const address = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+	isValid := func(s string) bool {
+		_, _, err := bech32.Decode(s)
+		return err == nil
+	}
+
+	// one substituted character and two swapped characters
+	for _, typo := range []string{
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5",
+		"bc1qw508d6qejxtdg4y5r3zarvayr0c5xw7kv8f3t4",
+	} {
+		require.Equal(t, []string{address}, Bech32Corrections(typo, 3, isValid), typo)
+	}
+	require.Empty(t, Bech32Corrections(address, 3, isValid))
+}
@@ -0,0 +1,249 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alejoacosta74/cryptonaut/pkg/alphabet"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+)
+
+// Address encodings
+const (
+	EncodingBase58Check = "base58check"
+	EncodingBech32      = "bech32"
+	EncodingBech32m     = "bech32m"
+)
+
+// AddressInfo describes a Base58Check or SegWit address of the supported
+// networks. For invalid addresses Error gives the reason and Corrections the
+// valid addresses one typo away, the info then describes the first correction.
+type AddressInfo struct {
+	Address        string   `json:"address"`
+	Encoding       string   `json:"encoding"`
+	Chains         []string `json:"chains,omitempty"`
+	Networks       []string `json:"networks,omitempty"`
+	Type           string   `json:"type,omitempty"`
+	Version        *int     `json:"version,omitempty"`
+	HRP            string   `json:"hrp,omitempty"`
+	WitnessVersion *int     `json:"witnessVersion,omitempty"`
+	Payload        string   `json:"payload,omitempty"`
+	Valid          bool     `json:"valid"`
+	Error          string   `json:"error,omitempty"`
+	Corrections    []string `json:"corrections,omitempty"`
+}
+
+// InspectAddress decodes and validates a Base58Check address, or a SegWit
+// address with the HRP of a supported network. It fails only when the
+// address has neither form, invalid addresses are reported in the info.
+func InspectAddress(address string) (*AddressInfo, error) {
+	hrp, isBech32 := bech32HRP(address)
+	switch {
+	case isBech32 && len(segwitNetworks(strings.ToLower(hrp))) > 0:
+		return inspectSegwitAddress(address), nil
+	case isBase58(address):
+		return inspectBase58Address(address), nil
+	case isBech32:
+		return nil, fmt.Errorf("unknown segwit prefix %q", hrp)
+	}
+	return nil, fmt.Errorf("not a base58check or segwit address")
+}
+
+// bech32HRP returns the human readable part of a string shaped as bech32
+func bech32HRP(s string) (string, bool) {
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+7 > len(s) {
+		return "", false
+	}
+	for _, c := range strings.ToLower(s[sep+1:]) {
+		if !strings.ContainsRune(alphabet.Bech32, c) {
+			return "", false
+		}
+	}
+	return s[:sep], true
+}
+
+func isBase58(s string) bool {
+	if len(s) < 26 || len(s) > 35 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune(alphabet.Base58, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// segwitNetworks returns the networks with a segwit HRP
+func segwitNetworks(hrp string) []*Network {
+	var matches []*Network
+	for _, n := range networks {
+		if n.Params.Bech32HRPSegwit != "" && n.Params.Bech32HRPSegwit == hrp {
+			matches = append(matches, n)
+		}
+	}
+	return matches
+}
+
+func (info *AddressInfo) setNetworks(matches []*Network) {
+	for _, n := range matches {
+		info.Networks = append(info.Networks, n.Name())
+		if len(info.Chains) == 0 || info.Chains[len(info.Chains)-1] != n.Coin {
+			info.Chains = append(info.Chains, n.Coin)
+		}
+	}
+}
+
+func inspectSegwitAddress(address string) *AddressInfo {
+	info := &AddressInfo{Address: address, Encoding: EncodingBech32}
+	version, program, encoding, err := decodeSegwitAddress(address)
+	if err != nil {
+		info.Error = err.Error()
+		info.Corrections = segwitCorrections(address)
+		if len(info.Corrections) == 0 {
+			return info
+		}
+		// describe the address as corrected
+		version, program, encoding, _ = decodeSegwitAddress(info.Corrections[0])
+	}
+	hrp, _ := bech32HRP(address)
+	info.HRP = strings.ToLower(hrp)
+	info.setNetworks(segwitNetworks(info.HRP))
+	info.Encoding = encoding
+	info.WitnessVersion = &version
+	info.Payload = hex.EncodeToString(program)
+	info.Type = witnessType(version, len(program))
+	info.Valid = err == nil
+	return info
+}
+
+// decodeSegwitAddress decodes a BIP173/BIP350 address, checking the checksum
+// variant of its witness version and the length of its program
+func decodeSegwitAddress(address string) (int, []byte, string, error) {
+	_, data, variant, err := bech32.DecodeGeneric(address)
+	if err != nil {
+		var checksumErr bech32.ErrInvalidChecksum
+		if errors.As(err, &checksumErr) {
+			return 0, nil, "", fmt.Errorf("invalid bech32 checksum")
+		}
+		return 0, nil, "", fmt.Errorf("invalid bech32 string: %v", err)
+	}
+	if len(data) == 0 {
+		return 0, nil, "", fmt.Errorf("missing witness version")
+	}
+	version := int(data[0])
+	program, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return 0, nil, "", fmt.Errorf("invalid witness program: %v", err)
+	}
+	encoding := EncodingBech32
+	if variant == bech32.VersionM {
+		encoding = EncodingBech32m
+	}
+	switch {
+	case version > 16:
+		return 0, nil, "", fmt.Errorf("invalid witness version %d", version)
+	case len(program) < 2 || len(program) > 40:
+		return 0, nil, "", fmt.Errorf("invalid witness program length %d", len(program))
+	case version == 0 && len(program) != 20 && len(program) != 32:
+		return 0, nil, "", fmt.Errorf("invalid witness v0 program length %d", len(program))
+	case version == 0 && variant != bech32.Version0:
+		return 0, nil, "", fmt.Errorf("witness v0 addresses must use bech32, not bech32m")
+	case version > 0 && variant != bech32.VersionM:
+		return 0, nil, "", fmt.Errorf("witness v%d addresses must use bech32m (BIP350), not bech32", version)
+	}
+	return version, program, encoding, nil
+}
+
+func witnessType(version, programLen int) string {
+	switch {
+	case version == 0 && programLen == 20:
+		return "p2wpkh"
+	case version == 0 && programLen == 32:
+		return "p2wsh"
+	case version == 1 && programLen == 32:
+		return "p2tr"
+	}
+	return "witness_unknown"
+}
+
+// segwitCorrections returns the valid segwit addresses one substituted or
+// swapped character away from address, or address with the checksum variant
+// required by its witness version
+func segwitCorrections(address string) []string {
+	lower := strings.ToLower(address)
+	isValid := func(s string) bool {
+		_, _, _, err := decodeSegwitAddress(s)
+		return err == nil
+	}
+	if isValid(lower) {
+		// bech32 strings must not mix cases
+		return []string{lower}
+	}
+	if hrp, data, _, err := bech32.DecodeGeneric(lower); err == nil && len(data) > 0 {
+		// right data, wrong checksum variant
+		encode := bech32.EncodeM
+		if data[0] == 0 {
+			encode = bech32.Encode
+		}
+		if fixed, err := encode(hrp, data); err == nil && isValid(fixed) {
+			return []string{fixed}
+		}
+	}
+	sep := strings.LastIndexByte(lower, '1')
+	return alphabet.Bech32Corrections(lower, sep+1, isValid)
+}
+
+func inspectBase58Address(address string) *AddressInfo {
+	info := &AddressInfo{Address: address, Encoding: EncodingBase58Check}
+	decoded, version, err := base58.CheckDecode(address)
+	if err != nil {
+		if errors.Is(err, base58.ErrChecksum) {
+			info.Error = "invalid base58check checksum"
+		} else {
+			info.Error = fmt.Sprintf("invalid base58check string: %v", err)
+		}
+		info.Corrections = alphabet.Corrections(address, 0, alphabet.Base58, func(s string) bool {
+			decoded, version, err := base58.CheckDecode(s)
+			return err == nil && len(decoded) == 20 && len(base58Networks(version)) > 0
+		})
+		if len(info.Corrections) == 0 {
+			return info
+		}
+		decoded, version, _ = base58.CheckDecode(info.Corrections[0])
+	}
+	v := int(version)
+	info.Version = &v
+	info.Payload = hex.EncodeToString(decoded)
+	matches := base58Networks(version)
+	info.setNetworks(matches)
+	switch {
+	case len(decoded) != 20:
+		info.Error = fmt.Sprintf("invalid payload length %d, addresses have a 20-byte hash", len(decoded))
+		return info
+	case len(matches) == 0:
+		info.Error = fmt.Sprintf("unknown address version 0x%02x", version)
+		return info
+	case matches[0].Params.PubKeyHashAddrID == version:
+		info.Type = "p2pkh"
+	default:
+		info.Type = "p2sh"
+	}
+	info.Valid = err == nil
+	return info
+}
+
+// base58Networks returns the networks with a P2PKH or P2SH version byte
+func base58Networks(version byte) []*Network {
+	var matches []*Network
+	for _, n := range networks {
+		if n.Params.PubKeyHashAddrID == version || n.Params.ScriptHashAddrID == version {
+			matches = append(matches, n)
+		}
+	}
+	return matches
+}
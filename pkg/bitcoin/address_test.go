@@ -0,0 +1,140 @@
+package bitcoin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectAddress(t *testing.T) {
+	testCases := []struct {
+		name           string
+		address        string
+		encoding       string
+		networks       []string
+		addrType       string
+		witnessVersion int
+		payload        string
+		err            string
+		corrections    []string
+	}{
+		{
+			name:     "p2pkh",
+			address:  "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH",
+			encoding: EncodingBase58Check,
+			networks: []string{"mainnet"},
+			addrType: "p2pkh",
+			payload:  "751e76e8199196d454941c45d1b3a323f1433bd6",
+		},
+		{
+			name:     "p2sh",
+			address:  "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy",
+			encoding: EncodingBase58Check,
+			networks: []string{"mainnet"},
+			addrType: "p2sh",
+			payload:  "b472a266d0bd89c13706a4132ccfb16f7c3b9fcb",
+		},
+		{
+			name:     "dogecoin p2pkh",
+			address:  "DFpN6QqFfUm3gKNaxN6tNcab1FArL9cZLE",
+			encoding: EncodingBase58Check,
+			networks: []string{"dogecoin"},
+			addrType: "p2pkh",
+			payload:  "751e76e8199196d454941c45d1b3a323f1433bd6",
+		},
+		{
+			name:        "p2pkh with swapped characters",
+			address:     "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAHM",
+			encoding:    EncodingBase58Check,
+			networks:    []string{"mainnet"},
+			addrType:    "p2pkh",
+			payload:     "751e76e8199196d454941c45d1b3a323f1433bd6",
+			err:         "invalid base58check checksum",
+			corrections: []string{"1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"},
+		},
+		{
+			// BIP173 test vector
+			name:     "p2wpkh",
+			address:  "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4",
+			encoding: EncodingBech32,
+			networks: []string{"mainnet"},
+			addrType: "p2wpkh",
+			payload:  "751e76e8199196d454941c45d1b3a323f1433bd6",
+		},
+		{
+			// BIP350 test vector
+			name:           "p2tr on the test networks",
+			address:        "tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c",
+			encoding:       EncodingBech32m,
+			networks:       []string{"testnet3", "testnet4", "signet"},
+			addrType:       "p2tr",
+			witnessVersion: 1,
+			payload:        "000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433",
+		},
+		{
+			name:        "p2wpkh with a substituted character",
+			address:     "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5",
+			encoding:    EncodingBech32,
+			networks:    []string{"mainnet"},
+			addrType:    "p2wpkh",
+			payload:     "751e76e8199196d454941c45d1b3a323f1433bd6",
+			err:         "invalid bech32 checksum",
+			corrections: []string{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		},
+		{
+			name:        "mixed case",
+			address:     "bc1qw508d6qejxtdg4y5r3zarvarY0c5xw7kv8f3t4",
+			encoding:    EncodingBech32,
+			networks:    []string{"mainnet"},
+			addrType:    "p2wpkh",
+			payload:     "751e76e8199196d454941c45d1b3a323f1433bd6",
+			err:         "invalid bech32 string: string not all lowercase or all uppercase",
+			corrections: []string{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		},
+		{
+			// BIP173 witness v1 address, invalid since BIP350
+			name:           "witness v1 with a bech32 checksum",
+			address:        "bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7k7grplx",
+			encoding:       EncodingBech32m,
+			networks:       []string{"mainnet"},
+			addrType:       "witness_unknown",
+			witnessVersion: 1,
+			payload:        "751e76e8199196d454941c45d1b3a323f1433bd6751e76e8199196d454941c45d1b3a323f1433bd6",
+			err:            "witness v1 addresses must use bech32m (BIP350), not bech32",
+			corrections:    []string{"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := InspectAddress(tc.address)
+			require.NoError(t, err)
+			require.Equal(t, tc.encoding, info.Encoding)
+			require.Equal(t, tc.networks, info.Networks)
+			require.Equal(t, tc.addrType, info.Type)
+			require.Equal(t, tc.payload, info.Payload)
+			if info.WitnessVersion != nil {
+				require.Equal(t, tc.witnessVersion, *info.WitnessVersion)
+			}
+			require.Equal(t, tc.err, info.Error)
+			require.Equal(t, tc.err == "", info.Valid)
+			require.Equal(t, tc.corrections, info.Corrections)
+		})
+	}
+
+	// BIP350 invalid addresses
+	for _, address := range []string{
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd",
+		"bc1qr508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+		"BC1QR508D6QEJXTDG4Y5R3ZARVARYV98GJ9P",
+	} {
+		info, err := InspectAddress(address)
+		require.NoError(t, err)
+		require.False(t, info.Valid, address)
+	}
+
+	_, err := InspectAddress("cosmos1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc5lzv7xu")
+	require.ErrorContains(t, err, "unknown segwit prefix")
+	_, err = InspectAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	require.Error(t, err)
+}
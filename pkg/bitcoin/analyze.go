@@ -0,0 +1,180 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// lockTimeThreshold separates block heights from unix timestamps
+	lockTimeThreshold = 500000000
+
+	sequenceLockTimeDisabled = 1 << 31
+	sequenceLockTimeIsSecs   = 1 << 22
+	sequenceLockTimeMask     = 0x0000ffff
+	sequenceLockTimeGranule  = 512 // seconds
+)
+
+// ScriptInfo describes an output script
+type ScriptInfo struct {
+	Type         string   `json:"type"`
+	Hex          string   `json:"hex"`
+	ASM          string   `json:"asm"`
+	Addresses    []string `json:"addresses,omitempty"`
+	RequiredSigs int      `json:"requiredSigs,omitempty"`
+	Data         string   `json:"data,omitempty"` // OP_RETURN payload
+	Text         string   `json:"text,omitempty"` // OP_RETURN payload, when printable
+}
+
+var scriptClassNames = map[txscript.ScriptClass]string{
+	txscript.NonStandardTy:         "nonstandard",
+	txscript.PubKeyTy:              "p2pk",
+	txscript.PubKeyHashTy:          "p2pkh",
+	txscript.ScriptHashTy:          "p2sh",
+	txscript.WitnessV0PubKeyHashTy: "p2wpkh",
+	txscript.WitnessV0ScriptHashTy: "p2wsh",
+	txscript.WitnessV1TaprootTy:    "p2tr",
+	txscript.MultiSigTy:            "multisig",
+	txscript.NullDataTy:            "op_return",
+	txscript.WitnessUnknownTy:      "witness_unknown",
+}
+
+// ClassifyScript identifies the template of an output script and the
+// addresses it pays to on the given network
+func ClassifyScript(pkScript []byte, net *chaincfg.Params) ScriptInfo {
+	class, addrs, required, _ := txscript.ExtractPkScriptAddrs(pkScript, net)
+	info := ScriptInfo{
+		Type: scriptClassNames[class],
+		Hex:  hex.EncodeToString(pkScript),
+		ASM:  DisassembleScript(pkScript),
+	}
+	for _, addr := range addrs {
+		info.Addresses = append(info.Addresses, addr.EncodeAddress())
+	}
+	switch class {
+	case txscript.MultiSigTy:
+		info.RequiredSigs = required
+	case txscript.NullDataTy:
+		pushes, err := txscript.PushedData(pkScript)
+		if err != nil {
+			break
+		}
+		var data []byte
+		for _, push := range pushes {
+			data = append(data, push...)
+		}
+		info.Data = hex.EncodeToString(data)
+		if isPrintable(data) {
+			info.Text = string(data)
+		}
+	}
+	return info
+}
+
+// DisassembleScript returns the ASM representation of a script. Malformed
+// scripts are disassembled up to the failing opcode.
+func DisassembleScript(script []byte) string {
+	asm, err := txscript.DisasmString(script)
+	if err != nil {
+		return asm + " [error]"
+	}
+	return asm
+}
+
+// SignalsRBF reports whether a transaction opts in to BIP125 replacement
+func SignalsRBF(tx *wire.MsgTx) bool {
+	for _, txIn := range tx.TxIn {
+		if txIn.Sequence < SequenceLocktime {
+			return true
+		}
+	}
+	return false
+}
+
+// DescribeLockTime explains the nLockTime of a transaction
+func DescribeLockTime(tx *wire.MsgTx) string {
+	if tx.LockTime == 0 {
+		return "none"
+	}
+	var desc string
+	if tx.LockTime < lockTimeThreshold {
+		desc = fmt.Sprintf("block height %d", tx.LockTime)
+	} else {
+		desc = "time " + time.Unix(int64(tx.LockTime), 0).UTC().Format(time.RFC3339)
+	}
+	for _, txIn := range tx.TxIn {
+		if txIn.Sequence != wire.MaxTxInSequenceNum {
+			return desc
+		}
+	}
+	return desc + " (disabled, all inputs are final)"
+}
+
+// DescribeRelativeLockTime explains the BIP68 relative locktime of an input,
+// returning an empty string when the input has none
+func DescribeRelativeLockTime(tx *wire.MsgTx, index int) string {
+	sequence := tx.TxIn[index].Sequence
+	if tx.Version < 2 || sequence&sequenceLockTimeDisabled != 0 {
+		return ""
+	}
+	value := sequence & sequenceLockTimeMask
+	if sequence&sequenceLockTimeIsSecs != 0 {
+		return fmt.Sprintf("%d seconds", value*sequenceLockTimeGranule)
+	}
+	return fmt.Sprintf("%d blocks", value)
+}
+
+// PrevOutsFromUTXOs indexes the outputs of a UTXO set by outpoint
+func PrevOutsFromUTXOs(utxos []UTXO) (map[wire.OutPoint]*wire.TxOut, error) {
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut, len(utxos))
+	for _, u := range utxos {
+		outPoint, err := u.OutPoint()
+		if err != nil {
+			return nil, err
+		}
+		txOut, err := u.TxOut()
+		if err != nil {
+			return nil, err
+		}
+		prevOuts[*outPoint] = txOut
+	}
+	return prevOuts, nil
+}
+
+// TxFee returns the fee paid by a transaction given the outputs it spends
+func TxFee(tx *wire.MsgTx, prevOuts map[wire.OutPoint]*wire.TxOut) (int64, error) {
+	var fee int64
+	for i, txIn := range tx.TxIn {
+		prevOut, ok := prevOuts[txIn.PreviousOutPoint]
+		if !ok {
+			return 0, fmt.Errorf("missing previous output for input %d (%s)", i, txIn.PreviousOutPoint)
+		}
+		fee += prevOut.Value
+	}
+	for _, txOut := range tx.TxOut {
+		fee -= txOut.Value
+	}
+	if fee < 0 {
+		return 0, fmt.Errorf("outputs exceed inputs by %d sats", -fee)
+	}
+	return fee, nil
+}
+
+func isPrintable(data []byte) bool {
+	if len(data) == 0 || !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
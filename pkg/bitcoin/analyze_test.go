@@ -0,0 +1,100 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyScript(t *testing.T) {
+	tests := []struct {
+		name      string
+		script    string
+		wantType  string
+		wantAddrs []string
+	}{
+		{
+			name:      "p2pkh",
+			script:    "76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac",
+			wantType:  "p2pkh",
+			wantAddrs: []string{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"},
+		},
+		{
+			name:      "p2wpkh",
+			script:    "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+			wantType:  "p2wpkh",
+			wantAddrs: []string{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		},
+		{
+			name:      "p2wsh",
+			script:    "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262",
+			wantType:  "p2wsh",
+			wantAddrs: []string{"bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3"},
+		},
+		{
+			name:     "nonstandard",
+			script:   "51",
+			wantType: "nonstandard",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := hex.DecodeString(tt.script)
+			require.NoError(t, err)
+			info := ClassifyScript(script, &chaincfg.MainNetParams)
+			require.Equal(t, tt.wantType, info.Type)
+			require.Equal(t, tt.wantAddrs, info.Addresses)
+		})
+	}
+}
+
+func TestClassifyScriptNullDataAndMultisig(t *testing.T) {
+	nullData, err := txscript.NullDataScript([]byte("hello world"))
+	require.NoError(t, err)
+	info := ClassifyScript(nullData, &chaincfg.MainNetParams)
+	require.Equal(t, "op_return", info.Type)
+	require.Equal(t, "hello world", info.Text)
+	require.Equal(t, "OP_RETURN 68656c6c6f20776f726c64", info.ASM)
+
+	pubKey, _ := hex.DecodeString("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	multisig, err := txscript.NewScriptBuilder().AddOp(txscript.OP_1).AddData(pubKey).
+		AddOp(txscript.OP_1).AddOp(txscript.OP_CHECKMULTISIG).Script()
+	require.NoError(t, err)
+	info = ClassifyScript(multisig, &chaincfg.MainNetParams)
+	require.Equal(t, "multisig", info.Type)
+	require.Equal(t, 1, info.RequiredSigs)
+	require.Len(t, info.Addresses, 1)
+}
+
+func TestLockTimeAndFee(t *testing.T) {
+	prevHash, _ := chainhash.NewHashFromStr("1234567890123456789012345678901234567890123456789012345678901234")
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(prevHash, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(9000, []byte{txscript.OP_TRUE}))
+	tx.LockTime = 800000
+
+	require.False(t, SignalsRBF(tx))
+	require.Equal(t, "block height 800000 (disabled, all inputs are final)", DescribeLockTime(tx))
+	require.Empty(t, DescribeRelativeLockTime(tx, 0))
+
+	tx.TxIn[0].Sequence = 144
+	require.True(t, SignalsRBF(tx))
+	require.Equal(t, "block height 800000", DescribeLockTime(tx))
+	require.Equal(t, "144 blocks", DescribeRelativeLockTime(tx, 0))
+
+	tx.LockTime = 1700000000
+	require.Equal(t, "time 2023-11-14T22:13:20Z", DescribeLockTime(tx))
+
+	_, err := TxFee(tx, nil)
+	require.Error(t, err)
+	fee, err := TxFee(tx, map[wire.OutPoint]*wire.TxOut{
+		tx.TxIn[0].PreviousOutPoint: wire.NewTxOut(10000, nil),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), fee)
+}
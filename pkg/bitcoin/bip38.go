@@ -0,0 +1,512 @@
+package bitcoin
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+)
+
+// BIP38 passphrase-protected private keys
+// (https://github.com/bitcoin/bips/blob/master/bip-0038.mediawiki).
+// The address hash protecting against wrong passphrases is computed from the
+// P2PKH address of the key on the given network.
+
+const (
+	bip38KeySize          = 39
+	bip38IntermediateSize = 49
+	bip38ConfirmationSize = 51
+
+	bip38FlagNoECMultiply = 0xc0
+	bip38FlagCompressed   = 0x20
+	bip38FlagLotSequence  = 0x04
+
+	// scrypt parameters of the passphrase, and of the EC-multiply passpoint
+	bip38ScryptN     = 16384
+	bip38ScryptR     = 8
+	bip38ScryptP     = 8
+	bip38PointScrypt = 1024
+
+	// BIP38MaxLot and BIP38MaxSequence bound the lot and sequence numbers
+	// of intermediate codes
+	BIP38MaxLot      = 1048575
+	BIP38MaxSequence = 4095
+)
+
+var (
+	bip38PrefixNoECMultiply = []byte{0x01, 0x42}
+	bip38PrefixECMultiply   = []byte{0x01, 0x43}
+	bip38MagicIntermediate  = []byte{0x2c, 0xe9, 0xb3, 0xe1, 0xff, 0x39, 0xe2}
+	bip38MagicConfirmation  = []byte{0x64, 0x3b, 0xf6, 0xa8, 0x9a}
+)
+
+// BIP38LotSequence is the lot and sequence number an intermediate code can
+// embed in the keys generated from it
+type BIP38LotSequence struct {
+	Lot      uint32
+	Sequence uint32
+}
+
+// BIP38GeneratedKey is a key generated from an intermediate code, known
+// only encrypted to the generator
+type BIP38GeneratedKey struct {
+	EncryptedKey     string
+	ConfirmationCode string
+	Address          string
+}
+
+// IsBIP38 reports whether key looks like a BIP38 encrypted private key
+func IsBIP38(key string) bool {
+	b, err := base58CheckDecode(key)
+	return err == nil && len(b) == bip38KeySize &&
+		(bytes.HasPrefix(b, bip38PrefixNoECMultiply) || bytes.HasPrefix(b, bip38PrefixECMultiply))
+}
+
+// EncryptBIP38 encrypts a private key with a passphrase without EC
+// multiplication
+func EncryptBIP38(key *btcec.PrivateKey, compressed bool, passphrase string, net *chaincfg.Params) (string, error) {
+	addressHash, err := bip38AddressHash(key.PubKey(), compressed, net)
+	if err != nil {
+		return "", err
+	}
+	derived, err := scrypt.Key(normalizePassphrase(passphrase), addressHash, bip38ScryptN, bip38ScryptR, bip38ScryptP, 64)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive key: %v", err)
+	}
+
+	flag := byte(bip38FlagNoECMultiply)
+	if compressed {
+		flag |= bip38FlagCompressed
+	}
+	privKey := key.Serialize()
+	encrypted := append(append([]byte{}, bip38PrefixNoECMultiply...), flag)
+	encrypted = append(encrypted, addressHash...)
+	for i := 0; i < 32; i += 16 {
+		block, err := aesXorEncrypt(privKey[i:i+16], derived[i:i+16], derived[32:])
+		if err != nil {
+			return "", err
+		}
+		encrypted = append(encrypted, block...)
+	}
+	return base58CheckEncode(encrypted), nil
+}
+
+// DecryptBIP38 decrypts a BIP38 encrypted private key, with or without EC
+// multiplication, into a WIF for net
+func DecryptBIP38(encryptedKey, passphrase string, net *chaincfg.Params) (*btcutil.WIF, error) {
+	b, err := base58CheckDecode(encryptedKey)
+	if err != nil || len(b) != bip38KeySize {
+		return nil, fmt.Errorf("invalid BIP38 encrypted key")
+	}
+	flag, addressHash := b[2], b[3:7]
+	compressed := flag&bip38FlagCompressed != 0
+
+	var key *btcec.PrivateKey
+	switch {
+	case bytes.HasPrefix(b, bip38PrefixNoECMultiply):
+		key, err = decryptBIP38NoECMultiply(b, passphrase)
+	case bytes.HasPrefix(b, bip38PrefixECMultiply):
+		key, err = decryptBIP38ECMultiply(b, passphrase)
+	default:
+		return nil, fmt.Errorf("invalid BIP38 encrypted key")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := bip38AddressHash(key.PubKey(), compressed, net)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(hash, addressHash) {
+		return nil, fmt.Errorf("wrong passphrase or network")
+	}
+	wif, err := btcutil.NewWIF(key, net, compressed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create private key: %v", err)
+	}
+	return wif, nil
+}
+
+func decryptBIP38NoECMultiply(b []byte, passphrase string) (*btcec.PrivateKey, error) {
+	derived, err := scrypt.Key(normalizePassphrase(passphrase), b[3:7], bip38ScryptN, bip38ScryptR, bip38ScryptP, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %v", err)
+	}
+	privKey := make([]byte, 0, 32)
+	for i := 0; i < 32; i += 16 {
+		half, err := aesDecryptXor(b[7+i:7+i+16], derived[i:i+16], derived[32:])
+		if err != nil {
+			return nil, err
+		}
+		privKey = append(privKey, half...)
+	}
+	return parseScalarKey(privKey)
+}
+
+func decryptBIP38ECMultiply(b []byte, passphrase string) (*btcec.PrivateKey, error) {
+	flag, addressHash, ownerEntropy := b[2], b[3:7], b[7:15]
+	passFactor, err := bip38PassFactor(passphrase, ownerEntropy, flag&bip38FlagLotSequence != 0)
+	if err != nil {
+		return nil, err
+	}
+	passKey, err := parseScalarKey(passFactor)
+	if err != nil {
+		return nil, err
+	}
+	derived, err := bip38DerivePointKey(passKey.PubKey(), addressHash, ownerEntropy)
+	if err != nil {
+		return nil, err
+	}
+
+	// encryptedpart2 holds the end of encryptedpart1 and of seedb
+	part2, err := aesDecryptXor(b[23:39], derived[16:32], derived[32:])
+	if err != nil {
+		return nil, err
+	}
+	part1 := append(append([]byte{}, b[15:23]...), part2[:8]...)
+	seedB, err := aesDecryptXor(part1, derived[:16], derived[32:])
+	if err != nil {
+		return nil, err
+	}
+	seedB = append(seedB, part2[8:]...)
+
+	var k, factorB btcec.ModNScalar
+	k.SetByteSlice(passFactor)
+	factorBHash := chainhash.DoubleHashB(seedB)
+	if factorB.SetByteSlice(factorBHash) || factorB.IsZero() {
+		return nil, fmt.Errorf("invalid BIP38 factor")
+	}
+	k.Mul(&factorB)
+	if k.IsZero() {
+		return nil, fmt.Errorf("invalid BIP38 private key")
+	}
+	return btcec.PrivKeyFromScalar(&k), nil
+}
+
+// NewBIP38IntermediateCode creates the intermediate code a passphrase owner
+// hands out to have keys generated for it (EC multiply mode). lotSequence
+// is optional.
+func NewBIP38IntermediateCode(passphrase string, lotSequence *BIP38LotSequence) (string, error) {
+	ownerSalt := make([]byte, 8)
+	saltSize := 8
+	if lotSequence != nil {
+		if lotSequence.Lot > BIP38MaxLot || lotSequence.Sequence > BIP38MaxSequence {
+			return "", fmt.Errorf("lot must be at most %d and sequence at most %d", BIP38MaxLot, BIP38MaxSequence)
+		}
+		saltSize = 4
+		binary.BigEndian.PutUint32(ownerSalt[4:], lotSequence.Lot*(BIP38MaxSequence+1)+lotSequence.Sequence)
+	}
+	if _, err := rand.Read(ownerSalt[:saltSize]); err != nil {
+		return "", fmt.Errorf("failed to generate owner salt: %v", err)
+	}
+	return bip38IntermediateCode(passphrase, ownerSalt, lotSequence != nil)
+}
+
+func bip38IntermediateCode(passphrase string, ownerEntropy []byte, hasLotSequence bool) (string, error) {
+	passFactor, err := bip38PassFactor(passphrase, ownerEntropy, hasLotSequence)
+	if err != nil {
+		return "", err
+	}
+	key, err := parseScalarKey(passFactor)
+	if err != nil {
+		return "", err
+	}
+	lastMagic := byte(0x51)
+	if !hasLotSequence {
+		lastMagic = 0x53
+	}
+	code := append(append([]byte{}, bip38MagicIntermediate...), lastMagic)
+	code = append(code, ownerEntropy...)
+	code = append(code, key.PubKey().SerializeCompressed()...)
+	return base58CheckEncode(code), nil
+}
+
+// ParseBIP38IntermediateCode returns the lot and sequence embedded in an
+// intermediate code, nil when it has none
+func ParseBIP38IntermediateCode(code string) (*BIP38LotSequence, error) {
+	_, ownerEntropy, hasLotSequence, err := decodeBIP38IntermediateCode(code)
+	if err != nil || !hasLotSequence {
+		return nil, err
+	}
+	return bip38ParseLotSequence(ownerEntropy), nil
+}
+
+func decodeBIP38IntermediateCode(code string) (passPoint *btcec.PublicKey, ownerEntropy []byte, hasLotSequence bool, err error) {
+	b, err := base58CheckDecode(code)
+	if err != nil || len(b) != bip38IntermediateSize || !bytes.HasPrefix(b, bip38MagicIntermediate) ||
+		(b[7] != 0x51 && b[7] != 0x53) {
+		return nil, nil, false, fmt.Errorf("invalid BIP38 intermediate code")
+	}
+	passPoint, err = btcec.ParsePubKey(b[16:])
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("invalid BIP38 intermediate code passpoint: %v", err)
+	}
+	return passPoint, b[8:16], b[7] == 0x51, nil
+}
+
+// GenerateBIP38Key generates a new key from an intermediate code, returning
+// it encrypted with the passphrase of the code's owner together with the
+// code confirming the key to the owner
+func GenerateBIP38Key(intermediateCode string, compressed bool, net *chaincfg.Params) (*BIP38GeneratedKey, error) {
+	seedB := make([]byte, 24)
+	if _, err := rand.Read(seedB); err != nil {
+		return nil, fmt.Errorf("failed to generate seed: %v", err)
+	}
+	return generateBIP38Key(intermediateCode, seedB, compressed, net)
+}
+
+func generateBIP38Key(intermediateCode string, seedB []byte, compressed bool, net *chaincfg.Params) (*BIP38GeneratedKey, error) {
+	passPoint, ownerEntropy, hasLotSequence, err := decodeBIP38IntermediateCode(intermediateCode)
+	if err != nil {
+		return nil, err
+	}
+	factorB := chainhash.DoubleHashB(seedB)
+	factorKey, err := parseScalarKey(factorB)
+	if err != nil {
+		return nil, err
+	}
+	pubKey := multiplyPoint(passPoint, factorKey)
+
+	flag := byte(0)
+	if compressed {
+		flag |= bip38FlagCompressed
+	}
+	if hasLotSequence {
+		flag |= bip38FlagLotSequence
+	}
+	address, err := bip38Address(pubKey, compressed, net)
+	if err != nil {
+		return nil, err
+	}
+	addressHash := chainhash.DoubleHashB([]byte(address))[:4]
+	derived, err := bip38DerivePointKey(passPoint, addressHash, ownerEntropy)
+	if err != nil {
+		return nil, err
+	}
+
+	part1, err := aesXorEncrypt(seedB[:16], derived[:16], derived[32:])
+	if err != nil {
+		return nil, err
+	}
+	part2, err := aesXorEncrypt(append(append([]byte{}, part1[8:]...), seedB[16:]...), derived[16:32], derived[32:])
+	if err != nil {
+		return nil, err
+	}
+	encrypted := append(append([]byte{}, bip38PrefixECMultiply...), flag)
+	encrypted = append(encrypted, addressHash...)
+	encrypted = append(encrypted, ownerEntropy...)
+	encrypted = append(encrypted, part1[:8]...)
+	encrypted = append(encrypted, part2...)
+
+	// the confirmation code encrypts pointb, letting the owner recompute
+	// the public key without the private key
+	pointB := factorKey.PubKey().SerializeCompressed()
+	prefix := pointB[0] ^ (derived[63] & 0x01)
+	x1, err := aesXorEncrypt(pointB[1:17], derived[:16], derived[32:])
+	if err != nil {
+		return nil, err
+	}
+	x2, err := aesXorEncrypt(pointB[17:], derived[16:32], derived[32:])
+	if err != nil {
+		return nil, err
+	}
+	confirmation := append(append([]byte{}, bip38MagicConfirmation...), flag)
+	confirmation = append(confirmation, addressHash...)
+	confirmation = append(confirmation, ownerEntropy...)
+	confirmation = append(confirmation, prefix)
+	confirmation = append(confirmation, x1...)
+	confirmation = append(confirmation, x2...)
+
+	return &BIP38GeneratedKey{
+		EncryptedKey:     base58CheckEncode(encrypted),
+		ConfirmationCode: base58CheckEncode(confirmation),
+		Address:          address,
+	}, nil
+}
+
+// VerifyBIP38Confirmation checks a confirmation code with the passphrase of
+// the intermediate code owner and returns the address of the generated key
+func VerifyBIP38Confirmation(confirmationCode, passphrase string, net *chaincfg.Params) (string, *BIP38LotSequence, error) {
+	b, err := base58CheckDecode(confirmationCode)
+	if err != nil || len(b) != bip38ConfirmationSize || !bytes.HasPrefix(b, bip38MagicConfirmation) {
+		return "", nil, fmt.Errorf("invalid BIP38 confirmation code")
+	}
+	flag, addressHash, ownerEntropy, encryptedPointB := b[5], b[6:10], b[10:18], b[18:]
+	hasLotSequence := flag&bip38FlagLotSequence != 0
+
+	passFactor, err := bip38PassFactor(passphrase, ownerEntropy, hasLotSequence)
+	if err != nil {
+		return "", nil, err
+	}
+	passKey, err := parseScalarKey(passFactor)
+	if err != nil {
+		return "", nil, err
+	}
+	derived, err := bip38DerivePointKey(passKey.PubKey(), addressHash, ownerEntropy)
+	if err != nil {
+		return "", nil, err
+	}
+	x1, err := aesDecryptXor(encryptedPointB[1:17], derived[:16], derived[32:])
+	if err != nil {
+		return "", nil, err
+	}
+	x2, err := aesDecryptXor(encryptedPointB[17:], derived[16:32], derived[32:])
+	if err != nil {
+		return "", nil, err
+	}
+	pointBBytes := append([]byte{encryptedPointB[0] ^ (derived[63] & 0x01)}, x1...)
+	pointB, err := btcec.ParsePubKey(append(pointBBytes, x2...))
+	if err != nil {
+		return "", nil, fmt.Errorf("wrong passphrase")
+	}
+
+	address, err := bip38Address(multiplyPoint(pointB, passKey), flag&bip38FlagCompressed != 0, net)
+	if err != nil {
+		return "", nil, err
+	}
+	if !bytes.Equal(chainhash.DoubleHashB([]byte(address))[:4], addressHash) {
+		return "", nil, fmt.Errorf("wrong passphrase or network")
+	}
+	var lotSequence *BIP38LotSequence
+	if hasLotSequence {
+		lotSequence = bip38ParseLotSequence(ownerEntropy)
+	}
+	return address, lotSequence, nil
+}
+
+// bip38PassFactor derives the passfactor of an EC multiply passphrase,
+// whose owner salt is the start of the owner entropy with lot and sequence
+func bip38PassFactor(passphrase string, ownerEntropy []byte, hasLotSequence bool) ([]byte, error) {
+	ownerSalt := ownerEntropy
+	if hasLotSequence {
+		ownerSalt = ownerEntropy[:4]
+	}
+	preFactor, err := scrypt.Key(normalizePassphrase(passphrase), ownerSalt, bip38ScryptN, bip38ScryptR, bip38ScryptP, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %v", err)
+	}
+	if !hasLotSequence {
+		return preFactor, nil
+	}
+	return chainhash.DoubleHashB(append(preFactor, ownerEntropy...)), nil
+}
+
+// bip38DerivePointKey derives the encryption key of EC multiply keys from
+// the passpoint
+func bip38DerivePointKey(passPoint *btcec.PublicKey, addressHash, ownerEntropy []byte) ([]byte, error) {
+	salt := append(append([]byte{}, addressHash...), ownerEntropy...)
+	derived, err := scrypt.Key(passPoint.SerializeCompressed(), salt, bip38PointScrypt, 1, 1, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %v", err)
+	}
+	return derived, nil
+}
+
+func bip38ParseLotSequence(ownerEntropy []byte) *BIP38LotSequence {
+	lotSequence := binary.BigEndian.Uint32(ownerEntropy[4:])
+	return &BIP38LotSequence{
+		Lot:      lotSequence / (BIP38MaxSequence + 1),
+		Sequence: lotSequence % (BIP38MaxSequence + 1),
+	}
+}
+
+func bip38Address(pubKey *btcec.PublicKey, compressed bool, net *chaincfg.Params) (string, error) {
+	if compressed {
+		addr, err := AddressFromPublicKey(pubKey, AddressTypeP2PKH, net)
+		if err != nil {
+			return "", err
+		}
+		return addr.EncodeAddress(), nil
+	}
+	addr, err := UncompressedP2PKHAddress(pubKey, net)
+	if err != nil {
+		return "", err
+	}
+	return addr.EncodeAddress(), nil
+}
+
+func bip38AddressHash(pubKey *btcec.PublicKey, compressed bool, net *chaincfg.Params) ([]byte, error) {
+	address, err := bip38Address(pubKey, compressed, net)
+	if err != nil {
+		return nil, err
+	}
+	return chainhash.DoubleHashB([]byte(address))[:4], nil
+}
+
+// normalizePassphrase applies the NFC normalization required by BIP38
+func normalizePassphrase(passphrase string) []byte {
+	return []byte(norm.NFC.String(passphrase))
+}
+
+// parseScalarKey parses a private key, rejecting zero and values above the
+// curve order
+func parseScalarKey(b []byte) (*btcec.PrivateKey, error) {
+	var k btcec.ModNScalar
+	if k.SetByteSlice(b) || k.IsZero() {
+		return nil, fmt.Errorf("invalid private key")
+	}
+	return btcec.PrivKeyFromScalar(&k), nil
+}
+
+// multiplyPoint returns point * k
+func multiplyPoint(point *btcec.PublicKey, k *btcec.PrivateKey) *btcec.PublicKey {
+	var p, result btcec.JacobianPoint
+	point.AsJacobian(&p)
+	btcec.ScalarMultNonConst(&k.Key, &p, &result)
+	result.ToAffine()
+	return btcec.NewPublicKey(&result.X, &result.Y)
+}
+
+// aesXorEncrypt encrypts the 16-byte block data xor mask with AES-256
+func aesXorEncrypt(data, mask, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+	buf := make([]byte, aes.BlockSize)
+	for i := range buf {
+		buf[i] = data[i] ^ mask[i]
+	}
+	block.Encrypt(buf, buf)
+	return buf, nil
+}
+
+// aesDecryptXor decrypts the 16-byte block data with AES-256 and xors it
+// with mask
+func aesDecryptXor(data, mask, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+	buf := make([]byte, aes.BlockSize)
+	block.Decrypt(buf, data)
+	for i := range buf {
+		buf[i] ^= mask[i]
+	}
+	return buf, nil
+}
+
+// base58CheckEncode encodes b with a checksum, its first byte being the
+// version of base58.CheckEncode
+func base58CheckEncode(b []byte) string {
+	return base58.CheckEncode(b[1:], b[0])
+}
+
+func base58CheckDecode(s string) ([]byte, error) {
+	payload, version, err := base58.CheckDecode(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{version}, payload...), nil
+}
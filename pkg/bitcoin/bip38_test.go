@@ -0,0 +1,168 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+// BIP38 test vectors
+func TestBIP38NoECMultiply(t *testing.T) {
+	testCases := []struct {
+		name       string
+		passphrase string
+		encrypted  string
+		wif        string
+		keyHex     string
+	}{
+		{
+			name:       "uncompressed",
+			passphrase: "TestingOneTwoThree",
+			encrypted:  "6PRVWUbkzzsbcVac2qwfssoUJAN1Xhrg6bNk8J7Nzm5H7kxEbn2Nh2ZoGg",
+			wif:        "5KN7MzqK5wt2TP1fQCYyHBtDrXdJuXbUzm4A9rKAteGu3Qi5CVR",
+			keyHex:     "cbf4b9f70470856bb4f40f80b87edb90865997ffee6df315ab166d713af433a5",
+		},
+		{
+			name:       "compressed",
+			passphrase: "Satoshi",
+			encrypted:  "6PYLtMnXvfG3oJde97zRyLYFZCYizPU5T3LwgdYJz1fRhh16bU7u6PPmY7",
+			wif:        "KwYgW8gcxj1JWJXhPSu4Fqwzfhp5Yfi42mdYmMa4XqK7NJxXUSK7",
+			keyHex:     "09c2686880095b1a4c249ee3ac4eea8a014f11e6f986d0b5025ac1f39afbd9ae",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			wif, err := DecryptBIP38(tc.encrypted, tc.passphrase, &chaincfg.MainNetParams)
+			require.NoError(t, err)
+			require.Equal(t, tc.wif, wif.String())
+
+			encrypted, err := EncryptBIP38(wif.PrivKey, wif.CompressPubKey, tc.passphrase, &chaincfg.MainNetParams)
+			require.NoError(t, err)
+			require.Equal(t, tc.encrypted, encrypted)
+
+			converted, err := ConvertKeyTo(tc.encrypted, KeyFormatHex, tc.passphrase, &chaincfg.MainNetParams)
+			require.NoError(t, err)
+			require.Equal(t, tc.keyHex, converted)
+			converted, err = ConvertKeyTo(tc.wif, KeyFormatBIP38, tc.passphrase, &chaincfg.MainNetParams)
+			require.NoError(t, err)
+			require.Equal(t, tc.encrypted, converted)
+
+			_, err = DecryptBIP38(tc.encrypted, "wrong", &chaincfg.MainNetParams)
+			require.EqualError(t, err, "wrong passphrase or network")
+			// the address hash depends on the network
+			_, err = DecryptBIP38(tc.encrypted, tc.passphrase, &chaincfg.TestNet3Params)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestBIP38ECMultiply(t *testing.T) {
+	testCases := []struct {
+		name         string
+		passphrase   string
+		intermediate string
+		encrypted    string
+		address      string
+		wif          string
+		confirmation string
+		lotSequence  *BIP38LotSequence
+	}{
+		{
+			name:         "no lot and sequence",
+			passphrase:   "TestingOneTwoThree",
+			intermediate: "passphrasepxFy57B9v8HtUsszJYKReoNDV6VHjUSGt8EVJmux9n1J3Ltf1gRxyDGXqnf9qm",
+			encrypted:    "6PfQu77ygVyJLZjfvMLyhLMQbYnu5uguoJJ4kMCLqWwPEdfpwANVS76gTX",
+			address:      "1PE6TQi6HTVNz5DLwB1LcpMBALubfuN2z2",
+			wif:          "5K4caxezwjGCGfnoPTZ8tMcJBLB7Jvyjv4xxeacadhq8nLisLR2",
+		},
+		{
+			name:         "lot and sequence",
+			passphrase:   "MOLON LABE",
+			intermediate: "passphraseaB8feaLQDENqCgr4gKZpmf4VoaT6qdjJNJiv7fsKvjqavcJxvuR1hy25aTu5sX",
+			encrypted:    "6PgNBNNzDkKdhkT6uJntUXwwzQV8Rr2tZcbkDcuC9DZRsS6AtHts4Ypo1j",
+			address:      "1Jscj8ALrYu2y9TD8NrpvDBugPedmbj4Yh",
+			wif:          "5JLdxTtcTHcfYcmJsNVy1v2PMDx432JPoYcBTVVRHpPaxUrdtf8",
+			confirmation: "cfrm38V8aXBn7JWA1ESmFMUn6erxeBGZGAxJPY4e36S9QWkzZKtaVqLNMgnifETYw7BPwWC9aPD",
+			lotSequence:  &BIP38LotSequence{Lot: 263183, Sequence: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			wif, err := DecryptBIP38(tc.encrypted, tc.passphrase, &chaincfg.MainNetParams)
+			require.NoError(t, err)
+			require.Equal(t, tc.wif, wif.String())
+			_, err = DecryptBIP38(tc.encrypted, "wrong", &chaincfg.MainNetParams)
+			require.Error(t, err)
+
+			lotSequence, err := ParseBIP38IntermediateCode(tc.intermediate)
+			require.NoError(t, err)
+			require.Equal(t, tc.lotSequence, lotSequence)
+			_, ownerEntropy, hasLotSequence, err := decodeBIP38IntermediateCode(tc.intermediate)
+			require.NoError(t, err)
+			code, err := bip38IntermediateCode(tc.passphrase, ownerEntropy, hasLotSequence)
+			require.NoError(t, err)
+			require.Equal(t, tc.intermediate, code)
+
+			if tc.confirmation != "" {
+				address, lotSequence, err := VerifyBIP38Confirmation(tc.confirmation, tc.passphrase, &chaincfg.MainNetParams)
+				require.NoError(t, err)
+				require.Equal(t, tc.address, address)
+				require.Equal(t, tc.lotSequence, lotSequence)
+				_, _, err = VerifyBIP38Confirmation(tc.confirmation, "wrong", &chaincfg.MainNetParams)
+				require.Error(t, err)
+			}
+
+			// keys generated from the intermediate code decrypt with the passphrase
+			for _, compressed := range []bool{false, true} {
+				generated, err := GenerateBIP38Key(tc.intermediate, compressed, &chaincfg.TestNet3Params)
+				require.NoError(t, err)
+				require.True(t, IsBIP38(generated.EncryptedKey))
+				wif, err := DecryptBIP38(generated.EncryptedKey, tc.passphrase, &chaincfg.TestNet3Params)
+				require.NoError(t, err)
+				require.Equal(t, compressed, wif.CompressPubKey)
+				require.True(t, wif.IsForNet(&chaincfg.TestNet3Params))
+				pubKey := wif.SerializePubKey()
+				address, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pubKey), &chaincfg.TestNet3Params)
+				require.NoError(t, err)
+				require.Equal(t, generated.Address, address.EncodeAddress())
+
+				confirmed, _, err := VerifyBIP38Confirmation(generated.ConfirmationCode, tc.passphrase, &chaincfg.TestNet3Params)
+				require.NoError(t, err)
+				require.Equal(t, generated.Address, confirmed)
+			}
+		})
+	}
+
+	_, err := NewBIP38IntermediateCode("secret", &BIP38LotSequence{Lot: BIP38MaxLot + 1})
+	require.Error(t, err)
+	code, err := NewBIP38IntermediateCode("secret", &BIP38LotSequence{Lot: 7, Sequence: 9})
+	require.NoError(t, err)
+	lotSequence, err := ParseBIP38IntermediateCode(code)
+	require.NoError(t, err)
+	require.Equal(t, &BIP38LotSequence{Lot: 7, Sequence: 9}, lotSequence)
+}
+
+func TestConvertKeyNetwork(t *testing.T) {
+	keyHex := "9df5a907ff17ed6a4e02c00c2c119049a045f52a4e817b06b2ec54eb68f70079"
+	testnetWIF, err := ConvertKeyTo(keyHex, "", "", &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	require.Equal(t, "cSskiL8MnumXcGL1GXHaXS1TeGocu1VBVkvKgLU9H6meK44dbFLU", testnetWIF)
+	wif, err := btcutil.DecodeWIF(testnetWIF)
+	require.NoError(t, err)
+	require.Equal(t, keyHex, hex.EncodeToString(wif.PrivKey.Serialize()))
+
+	// a WIF can be re-encoded for another network
+	mainnetWIF, err := ConvertKeyTo(testnetWIF, KeyFormatWIF, "", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, "L2WmFR8WMr5GSprjt7UTA7WQ23WDEZPVRimrZv1dmz7e4JzxqSNq", mainnetWIF)
+
+	_, err = ConvertKeyTo(keyHex, KeyFormatBIP38, "", &chaincfg.MainNetParams)
+	require.Error(t, err)
+	_, err = ConvertKeyTo(keyHex, "pem", "", &chaincfg.MainNetParams)
+	require.Error(t, err)
+}
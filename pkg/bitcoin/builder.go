@@ -0,0 +1,482 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// SequenceRBF is the highest sequence number signaling BIP125 replaceability
+	SequenceRBF uint32 = wire.MaxTxInSequenceNum - 2
+	// SequenceLocktime is the sequence number enabling nLockTime without RBF
+	SequenceLocktime uint32 = wire.MaxTxInSequenceNum - 1
+
+	// Serialized sizes used for weight estimation
+	txOverheadWeight    = 4 * (4 + 1 + 1 + 4) // version, input count, output count, locktime
+	segwitMarkerWeight  = 2                   // marker and flag bytes
+	inputBaseWeight     = 4 * (32 + 4 + 4)    // outpoint and sequence
+	ecdsaSigPushSize    = 1 + 72 + 1          // DER signature with sighash byte, plus push opcode
+	compressedPushSize  = 1 + 33              // compressed public key plus push opcode
+	schnorrWitnessSize  = 1 + 1 + 64          // item count, length and signature
+	p2wpkhWitnessSize   = 1 + ecdsaSigPushSize + compressedPushSize
+	p2shP2wpkhSigScript = 1 + 22 // push of the P2WPKH program
+)
+
+// UTXO is an unspent transaction output available to fund a transaction.
+// The JSON layout accepts both satoshi values and the BTC denominated
+// "amount" used by Bitcoin Core's listunspent / scantxoutset.
+type UTXO struct {
+	TxID          string  `json:"txid"`
+	Vout          uint32  `json:"vout"`
+	Value         int64   `json:"value,omitempty"`
+	Amount        float64 `json:"amount,omitempty"`
+	ScriptPubKey  string  `json:"scriptPubKey"`
+	RedeemScript  string  `json:"redeemScript,omitempty"`
+	WitnessScript string  `json:"witnessScript,omitempty"`
+	PrevTx        string  `json:"prevTx,omitempty"`
+}
+
+// TxOutput is a payment to an address
+type TxOutput struct {
+	Address string `json:"address"`
+	Value   int64  `json:"value"`
+}
+
+// TxBuildOptions holds the parameters of BuildTransaction
+type TxBuildOptions struct {
+	UTXOs         []UTXO
+	Outputs       []TxOutput
+	FeeRate       float64 // sat/vB
+	ChangeAddress string
+	RBF           bool
+	LockTime      uint32
+	Net           *chaincfg.Params
+}
+
+// BuiltTx is an unsigned transaction produced by BuildTransaction
+type BuiltTx struct {
+	Tx          *wire.MsgTx
+	Inputs      []UTXO // spent coins, in input order
+	Fee         int64
+	VSize       int64
+	ChangeIndex int // -1 when there is no change output
+}
+
+// LoadUTXOs reads a JSON array of UTXOs from a file
+func LoadUTXOs(path string) ([]UTXO, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read utxo file: %v", err)
+	}
+	var utxos []UTXO
+	if err := json.Unmarshal(data, &utxos); err != nil {
+		return nil, fmt.Errorf("failed to parse utxo file: %v", err)
+	}
+	if err := setUTXOValues(utxos); err != nil {
+		return nil, err
+	}
+	return utxos, nil
+}
+
+// setUTXOValues fills the satoshi value of UTXOs given in BTC
+func setUTXOValues(utxos []UTXO) error {
+	for i := range utxos {
+		if utxos[i].Value == 0 && utxos[i].Amount > 0 {
+			amount, err := btcutil.NewAmount(utxos[i].Amount)
+			if err != nil {
+				return fmt.Errorf("utxo %d: invalid amount: %v", i, err)
+			}
+			utxos[i].Value = int64(amount)
+		}
+	}
+	return nil
+}
+
+// OutPoint returns the outpoint of the UTXO
+func (u UTXO) OutPoint() (*wire.OutPoint, error) {
+	hash, err := chainhash.NewHashFromStr(u.TxID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid txid %s: %v", u.TxID, err)
+	}
+	return wire.NewOutPoint(hash, u.Vout), nil
+}
+
+// TxOut returns the output being spent
+func (u UTXO) TxOut() (*wire.TxOut, error) {
+	pkScript, err := hex.DecodeString(u.ScriptPubKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scriptPubKey for %s:%d: %v", u.TxID, u.Vout, err)
+	}
+	return wire.NewTxOut(u.Value, pkScript), nil
+}
+
+// BuildTransaction selects coins to pay the outputs at the given fee rate,
+// adding a change output when the leftover is worth keeping. Coins are
+// chosen with branch and bound, falling back to the knapsack solver.
+func BuildTransaction(opts TxBuildOptions) (*BuiltTx, error) {
+	if len(opts.Outputs) == 0 {
+		return nil, fmt.Errorf("at least one output is required")
+	}
+	if opts.FeeRate <= 0 {
+		return nil, fmt.Errorf("fee rate must be positive")
+	}
+	if opts.Net == nil {
+		opts.Net = &chaincfg.MainNetParams
+	}
+
+	tx := wire.NewMsgTx(2)
+	tx.LockTime = opts.LockTime
+
+	var outputsValue int64
+	for _, out := range opts.Outputs {
+		pkScript, err := AddressToScript(out.Address, opts.Net)
+		if err != nil {
+			return nil, err
+		}
+		if out.Value <= 0 {
+			return nil, fmt.Errorf("invalid amount %d for %s", out.Value, out.Address)
+		}
+		tx.AddTxOut(wire.NewTxOut(out.Value, pkScript))
+		outputsValue += out.Value
+	}
+
+	changeScript, err := AddressToScript(opts.ChangeAddress, opts.Net)
+	if err != nil {
+		return nil, fmt.Errorf("invalid change address: %v", err)
+	}
+
+	// Weight of everything except the inputs, assuming a segwit transaction
+	baseWeight := int64(txOverheadWeight + segwitMarkerWeight)
+	for _, out := range tx.TxOut {
+		baseWeight += outputWeight(out.PkScript)
+	}
+	changeWeight := outputWeight(changeScript)
+	changeFee := feeForWeight(changeWeight, opts.FeeRate)
+	costOfChange := changeFee + feeForWeight(p2wpkhInputWeight(), opts.FeeRate)
+
+	candidates := make([]selectionCandidate, len(opts.UTXOs))
+	inputWeights := make([]int64, len(opts.UTXOs))
+	for i, u := range opts.UTXOs {
+		weight, err := estimateInputWeight(u)
+		if err != nil {
+			return nil, err
+		}
+		inputWeights[i] = weight
+		candidates[i] = selectionCandidate{
+			index:          i,
+			effectiveValue: u.Value - feeForWeight(weight, opts.FeeRate),
+		}
+	}
+
+	target := outputsValue + feeForWeight(baseWeight, opts.FeeRate)
+	selected, ok := selectCoinsBnB(candidates, target, costOfChange)
+	if !ok {
+		selected, ok = selectCoinsKnapsack(candidates, target, costOfChange)
+		if !ok {
+			return nil, ErrInsufficientFunds
+		}
+	}
+
+	sequence := wire.MaxTxInSequenceNum
+	if opts.RBF {
+		sequence = SequenceRBF
+	} else if opts.LockTime != 0 {
+		sequence = SequenceLocktime
+	}
+
+	built := &BuiltTx{Tx: tx, ChangeIndex: -1}
+	weight := baseWeight
+	var inputsValue int64
+	for _, i := range selected {
+		u := opts.UTXOs[i]
+		outPoint, err := u.OutPoint()
+		if err != nil {
+			return nil, err
+		}
+		txIn := wire.NewTxIn(outPoint, nil, nil)
+		txIn.Sequence = sequence
+		tx.AddTxIn(txIn)
+		built.Inputs = append(built.Inputs, u)
+		weight += inputWeights[i]
+		inputsValue += u.Value
+	}
+
+	fee := feeForWeight(weight, opts.FeeRate)
+	excess := inputsValue - outputsValue - fee
+	if excess < 0 {
+		return nil, ErrInsufficientFunds
+	}
+	if change := excess - changeFee; change >= DustThreshold(changeScript) && excess > costOfChange {
+		tx.AddTxOut(wire.NewTxOut(change, changeScript))
+		built.ChangeIndex = len(tx.TxOut) - 1
+		weight += changeWeight
+		fee += changeFee
+	} else {
+		fee += excess
+	}
+
+	built.Fee = fee
+	built.VSize = weightToVSize(weight)
+	return built, nil
+}
+
+// PSBT returns the unsigned transaction as a PSBT carrying the UTXO
+// information required by signers
+func (b *BuiltTx) PSBT() (*psbt.Packet, error) {
+	packet, err := CreatePSBT(b.Tx)
+	if err != nil {
+		return nil, err
+	}
+	for i, u := range b.Inputs {
+		txOut, err := u.TxOut()
+		if err != nil {
+			return nil, err
+		}
+		pInput := &packet.Inputs[i]
+		if u.PrevTx != "" {
+			prevTx, err := DecodeBitcoinRawTx(u.PrevTx)
+			if err != nil {
+				return nil, fmt.Errorf("input %d: %v", i, err)
+			}
+			pInput.NonWitnessUtxo = prevTx
+		}
+		if isWitnessUTXO(u) {
+			pInput.WitnessUtxo = txOut
+		}
+		if pInput.RedeemScript, err = hex.DecodeString(u.RedeemScript); err != nil {
+			return nil, fmt.Errorf("input %d: invalid redeem script: %v", i, err)
+		}
+		if pInput.WitnessScript, err = hex.DecodeString(u.WitnessScript); err != nil {
+			return nil, fmt.Errorf("input %d: invalid witness script: %v", i, err)
+		}
+		if len(pInput.RedeemScript) == 0 {
+			pInput.RedeemScript = nil
+		}
+		if len(pInput.WitnessScript) == 0 {
+			pInput.WitnessScript = nil
+		}
+		if pInput.WitnessUtxo == nil && pInput.NonWitnessUtxo == nil {
+			return nil, fmt.Errorf("input %d: legacy inputs require the previous transaction (prevTx)", i)
+		}
+	}
+	return packet, nil
+}
+
+// SignTransaction signs every input of tx spendable by one of the keys.
+// prevOuts holds the spent outputs in input order. Single-key P2PKH,
+// P2WPKH, P2SH-P2WPKH and P2TR key-path inputs are supported; inputs that
+// no key can spend are left untouched. It returns the signed input indexes.
+func SignTransaction(tx *wire.MsgTx, prevOuts []*wire.TxOut, keys []*btcec.PrivateKey) ([]int, error) {
+	if len(prevOuts) != len(tx.TxIn) {
+		return nil, fmt.Errorf("expected %d previous outputs, got %d", len(tx.TxIn), len(prevOuts))
+	}
+	fetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for i, txIn := range tx.TxIn {
+		fetcher.AddPrevOut(txIn.PreviousOutPoint, prevOuts[i])
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, fetcher)
+
+	var signed []int
+	for i, prevOut := range prevOuts {
+		pkScript := prevOut.PkScript
+		for _, key := range keys {
+			pubKey := key.PubKey().SerializeCompressed()
+			pubKeyHash := btcutil.Hash160(pubKey)
+
+			var err error
+			switch {
+			case txscript.IsPayToTaproot(pkScript):
+				outputKey := txscript.ComputeTaprootKeyNoScript(key.PubKey())
+				if !bytes.Equal(schnorr.SerializePubKey(outputKey), pkScript[2:]) {
+					continue
+				}
+				tx.TxIn[i].Witness, err = txscript.TaprootWitnessSignature(tx, sigHashes, i,
+					prevOut.Value, pkScript, txscript.SigHashDefault, key)
+			case txscript.IsPayToWitnessPubKeyHash(pkScript):
+				if !bytes.Equal(pkScript[2:], pubKeyHash) {
+					continue
+				}
+				tx.TxIn[i].Witness, err = txscript.WitnessSignature(tx, sigHashes, i,
+					prevOut.Value, pkScript, txscript.SigHashAll, key, true)
+			case txscript.IsPayToScriptHash(pkScript):
+				program, _ := AddressFromPublicKey(key.PubKey(), AddressTypeP2SHP2WPKH, &chaincfg.MainNetParams)
+				if !bytes.Equal(pkScript[2:22], program.ScriptAddress()) {
+					continue
+				}
+				redeemScript, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(pubKeyHash).Script()
+				tx.TxIn[i].Witness, err = txscript.WitnessSignature(tx, sigHashes, i,
+					prevOut.Value, redeemScript, txscript.SigHashAll, key, true)
+				if err == nil {
+					tx.TxIn[i].SignatureScript, err = txscript.NewScriptBuilder().AddData(redeemScript).Script()
+				}
+			case txscript.IsPayToPubKeyHash(pkScript):
+				if !bytes.Equal(pkScript[3:23], pubKeyHash) {
+					continue
+				}
+				tx.TxIn[i].SignatureScript, err = txscript.SignatureScript(tx, i, pkScript,
+					txscript.SigHashAll, key, true)
+			default:
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to sign input %d: %v", i, err)
+			}
+			signed = append(signed, i)
+			break
+		}
+	}
+	return signed, nil
+}
+
+// AddressToScript returns the output script paying to an address
+func AddressToScript(address string, net *chaincfg.Params) ([]byte, error) {
+	addr, err := btcutil.DecodeAddress(address, net)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %s: %v", address, err)
+	}
+	if !addr.IsForNet(net) {
+		return nil, fmt.Errorf("address %s is not valid for %s", address, net.Name)
+	}
+	return txscript.PayToAddrScript(addr)
+}
+
+// TxWeight returns the BIP141 weight of a transaction
+func TxWeight(tx *wire.MsgTx) int64 {
+	return int64(tx.SerializeSizeStripped()*(4-1) + tx.SerializeSize())
+}
+
+// TxVSize returns the virtual size of a transaction in vbytes
+func TxVSize(tx *wire.MsgTx) int64 {
+	return weightToVSize(TxWeight(tx))
+}
+
+// estimateInputWeight returns the expected weight of an input spending the
+// UTXO once signed, including its share of the segwit serialization
+func estimateInputWeight(u UTXO) (int64, error) {
+	pkScript, err := hex.DecodeString(u.ScriptPubKey)
+	if err != nil {
+		return 0, fmt.Errorf("invalid scriptPubKey for %s:%d: %v", u.TxID, u.Vout, err)
+	}
+	redeemScript, err := hex.DecodeString(u.RedeemScript)
+	if err != nil {
+		return 0, fmt.Errorf("invalid redeemScript for %s:%d: %v", u.TxID, u.Vout, err)
+	}
+	witnessScript, err := hex.DecodeString(u.WitnessScript)
+	if err != nil {
+		return 0, fmt.Errorf("invalid witnessScript for %s:%d: %v", u.TxID, u.Vout, err)
+	}
+
+	switch {
+	case txscript.IsPayToTaproot(pkScript):
+		return inputBaseWeight + 4*1 + schnorrWitnessSize, nil
+	case txscript.IsPayToWitnessPubKeyHash(pkScript):
+		return p2wpkhInputWeight(), nil
+	case txscript.IsPayToWitnessScriptHash(pkScript):
+		if len(witnessScript) == 0 {
+			return 0, fmt.Errorf("utxo %s:%d: P2WSH inputs require a witnessScript", u.TxID, u.Vout)
+		}
+		return inputBaseWeight + 4*1 + scriptWitnessSize(witnessScript), nil
+	case txscript.IsPayToScriptHash(pkScript):
+		switch {
+		case len(redeemScript) == 0 || txscript.IsPayToWitnessPubKeyHash(redeemScript):
+			// Without a redeem script we assume a nested P2WPKH, the common case
+			return inputBaseWeight + 4*(1+p2shP2wpkhSigScript) + p2wpkhWitnessSize, nil
+		case txscript.IsPayToWitnessScriptHash(redeemScript):
+			if len(witnessScript) == 0 {
+				return 0, fmt.Errorf("utxo %s:%d: P2SH-P2WSH inputs require a witnessScript", u.TxID, u.Vout)
+			}
+			return inputBaseWeight + 4*(1+1+34) + scriptWitnessSize(witnessScript), nil
+		default:
+			sigScriptSize := multisigSigCount(redeemScript)*ecdsaSigPushSize + 1 + pushSize(len(redeemScript))
+			return inputBaseWeight + 4*int64(wire.VarIntSerializeSize(uint64(sigScriptSize))+sigScriptSize) + 1, nil
+		}
+	case txscript.IsPayToPubKeyHash(pkScript):
+		sigScriptSize := ecdsaSigPushSize + compressedPushSize
+		return inputBaseWeight + 4*(1+int64(sigScriptSize)) + 1, nil
+	}
+	return 0, fmt.Errorf("utxo %s:%d: unsupported script type", u.TxID, u.Vout)
+}
+
+func p2wpkhInputWeight() int64 {
+	return inputBaseWeight + 4*1 + p2wpkhWitnessSize
+}
+
+// scriptWitnessSize estimates the witness of a script spend: the signatures
+// required by the script (a dummy element for CHECKMULTISIG) and the script
+func scriptWitnessSize(script []byte) int64 {
+	sigs := multisigSigCount(script)
+	items := 1 + sigs + 1
+	size := wire.VarIntSerializeSize(uint64(items)) + 1 + sigs*ecdsaSigPushSize +
+		wire.VarIntSerializeSize(uint64(len(script))) + len(script)
+	return int64(size)
+}
+
+// multisigSigCount returns the number of signatures required by a multisig
+// script, or 1 for any other script
+func multisigSigCount(script []byte) int {
+	if _, required, err := txscript.CalcMultiSigStats(script); err == nil {
+		return required
+	}
+	return 1
+}
+
+func pushSize(dataLen int) int {
+	switch {
+	case dataLen <= 75:
+		return 1 + dataLen
+	case dataLen <= 255:
+		return 2 + dataLen
+	default:
+		return 3 + dataLen
+	}
+}
+
+func isWitnessUTXO(u UTXO) bool {
+	pkScript, _ := hex.DecodeString(u.ScriptPubKey)
+	if txscript.IsWitnessProgram(pkScript) {
+		return true
+	}
+	if !txscript.IsPayToScriptHash(pkScript) {
+		return false
+	}
+	redeemScript, _ := hex.DecodeString(u.RedeemScript)
+	return len(redeemScript) == 0 || txscript.IsWitnessProgram(redeemScript)
+}
+
+func outputWeight(pkScript []byte) int64 {
+	return int64(4 * (8 + wire.VarIntSerializeSize(uint64(len(pkScript))) + len(pkScript)))
+}
+
+// DustThreshold returns the smallest relayable value of an output paying to
+// pkScript. It mirrors Bitcoin Core's dust relay rule at 3 sat/vB: an output
+// is dust when spending it costs more than a third of its value
+func DustThreshold(pkScript []byte) int64 {
+	size := outputWeight(pkScript) / 4
+	if txscript.IsWitnessProgram(pkScript) {
+		size += 32 + 4 + 1 + 107/4 + 4
+	} else {
+		size += 32 + 4 + 1 + 107 + 4
+	}
+	return 3 * size
+}
+
+func weightToVSize(weight int64) int64 {
+	return (weight + 3) / 4
+}
+
+func feeForWeight(weight int64, feeRate float64) int64 {
+	return int64(math.Ceil(float64(weightToVSize(weight)) * feeRate))
+}
@@ -0,0 +1,153 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func candidatesFromValues(values ...int64) []selectionCandidate {
+	candidates := make([]selectionCandidate, len(values))
+	for i, v := range values {
+		candidates[i] = selectionCandidate{index: i, effectiveValue: v}
+	}
+	return candidates
+}
+
+func sumCandidates(candidates []selectionCandidate, selected []int) int64 {
+	var sum int64
+	for _, i := range selected {
+		sum += candidates[i].effectiveValue
+	}
+	return sum
+}
+
+func TestSelectCoinsBnB(t *testing.T) {
+	candidates := candidatesFromValues(100000, 200000, 300000, 400000, 500000)
+
+	selected, ok := selectCoinsBnB(candidates, 700000, 0)
+	require.True(t, ok)
+	require.Equal(t, int64(700000), sumCandidates(candidates, selected))
+
+	selected, ok = selectCoinsBnB(candidates, 650000, 60000)
+	require.True(t, ok)
+	sum := sumCandidates(candidates, selected)
+	require.GreaterOrEqual(t, sum, int64(650000))
+	require.LessOrEqual(t, sum, int64(710000))
+
+	_, ok = selectCoinsBnB(candidates, 650000, 1000)
+	require.False(t, ok)
+	_, ok = selectCoinsBnB(candidates, 2000000, 1000)
+	require.False(t, ok)
+}
+
+func TestSelectCoinsKnapsack(t *testing.T) {
+	candidates := candidatesFromValues(100000, 200000, 300000, 5000000)
+
+	selected, ok := selectCoinsKnapsack(candidates, 250000, 50000)
+	require.True(t, ok)
+	require.GreaterOrEqual(t, sumCandidates(candidates, selected), int64(250000))
+	require.NotContains(t, selected, 3)
+
+	// only the large coin can pay
+	selected, ok = selectCoinsKnapsack(candidates, 1000000, 50000)
+	require.True(t, ok)
+	require.Equal(t, []int{3}, selected)
+
+	_, ok = selectCoinsKnapsack(candidates, 6000000, 50000)
+	require.False(t, ok)
+}
+
+func TestBuildAndSignTransaction(t *testing.T) {
+	net := &chaincfg.RegressionNetParams
+	key, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	var utxos []UTXO
+	for i, addrType := range []AddressType{AddressTypeP2PKH, AddressTypeP2SHP2WPKH, AddressTypeP2WPKH, AddressTypeP2TR} {
+		addr, err := AddressFromPublicKey(key.PubKey(), addrType, net)
+		require.NoError(t, err)
+		pkScript, err := txscript.PayToAddrScript(addr)
+		require.NoError(t, err)
+		utxos = append(utxos, UTXO{
+			TxID:         "1234567890123456789012345678901234567890123456789012345678901234",
+			Vout:         uint32(i),
+			Value:        40000,
+			ScriptPubKey: hex.EncodeToString(pkScript),
+		})
+	}
+	dest, err := AddressFromPublicKey(key.PubKey(), AddressTypeP2WPKH, net)
+	require.NoError(t, err)
+
+	built, err := BuildTransaction(TxBuildOptions{
+		UTXOs:         utxos,
+		Outputs:       []TxOutput{{Address: dest.EncodeAddress(), Value: 150000}},
+		FeeRate:       5,
+		ChangeAddress: dest.EncodeAddress(),
+		RBF:           true,
+		Net:           net,
+	})
+	require.NoError(t, err)
+	require.Len(t, built.Tx.TxIn, 4)
+	require.Equal(t, SequenceRBF, built.Tx.TxIn[0].Sequence)
+
+	var outputsValue int64
+	for _, out := range built.Tx.TxOut {
+		outputsValue += out.Value
+	}
+	require.Equal(t, int64(160000), outputsValue+built.Fee)
+
+	prevOuts := make([]*wire.TxOut, len(built.Inputs))
+	for i, u := range built.Inputs {
+		prevOuts[i], err = u.TxOut()
+		require.NoError(t, err)
+	}
+	signed, err := SignTransaction(built.Tx, prevOuts, []*btcec.PrivateKey{key})
+	require.NoError(t, err)
+	require.Len(t, signed, 4)
+
+	// the estimate must not undershoot the signed size by more than a few
+	// vbytes (ECDSA signatures are 71 or 72 bytes)
+	require.InDelta(t, built.VSize, TxVSize(built.Tx), 4)
+	require.GreaterOrEqual(t, float64(built.Fee), float64(TxVSize(built.Tx))*5-20)
+
+	fetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for i, txIn := range built.Tx.TxIn {
+		fetcher.AddPrevOut(txIn.PreviousOutPoint, prevOuts[i])
+	}
+	sigHashes := txscript.NewTxSigHashes(built.Tx, fetcher)
+	for i := range built.Tx.TxIn {
+		vm, err := txscript.NewEngine(prevOuts[i].PkScript, built.Tx, i,
+			txscript.StandardVerifyFlags, nil, sigHashes, prevOuts[i].Value, fetcher)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute(), "input %d", i)
+	}
+}
+
+func TestBuildTransactionInsufficientFunds(t *testing.T) {
+	net := &chaincfg.RegressionNetParams
+	key, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	addr, err := AddressFromPublicKey(key.PubKey(), AddressTypeP2WPKH, net)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	_, err = BuildTransaction(TxBuildOptions{
+		UTXOs: []UTXO{{
+			TxID:         "1234567890123456789012345678901234567890123456789012345678901234",
+			Value:        10000,
+			ScriptPubKey: hex.EncodeToString(pkScript),
+		}},
+		Outputs:       []TxOutput{{Address: addr.EncodeAddress(), Value: 10000}},
+		FeeRate:       1,
+		ChangeAddress: addr.EncodeAddress(),
+		Net:           net,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
@@ -0,0 +1,265 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// IncrementalRelayFeeRate is the fee rate, in sat/vB, that a replacement must
+// add on top of the fees it replaces (BIP125 rule 4). It is the long-standing
+// default of Bitcoin Core and also the minimum fee rate of a CPFP child.
+const IncrementalRelayFeeRate = 1.0
+
+// BumpOptions holds the parameters of BumpFeeRBF and BumpFeeCPFP
+type BumpOptions struct {
+	// Tx is the stuck transaction, signed or not
+	Tx *wire.MsgTx
+	// PrevOuts are the outputs spent by Tx, in any order
+	PrevOuts []UTXO
+	// FeeRate is the target fee rate in sat/vB
+	FeeRate float64
+	// ChangeIndex is the output of Tx paying our change, or -1 to look it up
+	// by ChangeAddress
+	ChangeIndex int
+	// ChangeAddress is the address of the change output. The CPFP child pays
+	// to it, by default to the script of the change output.
+	ChangeAddress string
+	Net           *chaincfg.Params
+}
+
+// BumpedTx is an unsigned transaction raising the fee rate of a stuck one
+type BumpedTx struct {
+	*BuiltTx
+	OriginalFee   int64
+	OriginalVSize int64
+	// FeeRate is the fee rate of the replacement, or of the parent and the
+	// child together for CPFP
+	FeeRate float64
+}
+
+// BumpFeeRBF builds a BIP125 replacement of opts.Tx spending the same inputs
+// at opts.FeeRate, the fee increase being taken from the change output. The
+// change is dropped when what is left of it would be dust. The replacement
+// pays at least the fee of the original plus the incremental relay fee for
+// its own size, and signals replaceability again.
+func BumpFeeRBF(opts BumpOptions) (*BumpedTx, error) {
+	inputs, originalFee, originalVSize, err := bumpOriginal(opts)
+	if err != nil {
+		return nil, err
+	}
+	changeIndex, err := bumpChangeIndex(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := opts.Tx.Copy()
+	for _, txIn := range tx.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+		if txIn.Sequence > SequenceRBF {
+			txIn.Sequence = SequenceRBF
+		}
+	}
+
+	weight, err := estimateTxWeight(tx, inputs)
+	if err != nil {
+		return nil, err
+	}
+	fee := replacementFee(weight, opts.FeeRate, originalFee)
+	change := tx.TxOut[changeIndex]
+	available := change.Value + originalFee
+	if available-fee >= DustThreshold(change.PkScript) {
+		change.Value = available - fee
+	} else {
+		if len(tx.TxOut) == 1 {
+			return nil, fmt.Errorf("the change output of %d sats is the only output and cannot pay the fee increase without becoming dust",
+				change.Value)
+		}
+		// dropping the change output pays the rest of it as fee
+		weight -= outputWeight(change.PkScript)
+		fee = available
+		if fee < replacementFee(weight, opts.FeeRate, originalFee) {
+			return nil, fmt.Errorf("the change output of %d sats cannot pay the fee increase: %w", change.Value, ErrInsufficientFunds)
+		}
+		tx.TxOut = append(tx.TxOut[:changeIndex], tx.TxOut[changeIndex+1:]...)
+		changeIndex = -1
+	}
+
+	vsize := weightToVSize(weight)
+	return &BumpedTx{
+		BuiltTx:       &BuiltTx{Tx: tx, Inputs: inputs, Fee: fee, VSize: vsize, ChangeIndex: changeIndex},
+		OriginalFee:   originalFee,
+		OriginalVSize: originalVSize,
+		FeeRate:       float64(fee) / float64(vsize),
+	}, nil
+}
+
+// replacementFee is the fee of a replacement of the given weight: the target
+// fee rate, but at least the replaced fee plus the incremental relay fee
+func replacementFee(weight int64, feeRate float64, originalFee int64) int64 {
+	fee := feeForWeight(weight, feeRate)
+	if minFee := originalFee + feeForWeight(weight, IncrementalRelayFeeRate); fee < minFee {
+		fee = minFee
+	}
+	return fee
+}
+
+// BumpFeeCPFP builds a child spending the change output of opts.Tx that pays
+// enough fee for the parent and the child together to reach opts.FeeRate.
+// The child sends the rest of the change to opts.ChangeAddress.
+func BumpFeeCPFP(opts BumpOptions) (*BumpedTx, error) {
+	_, parentFee, parentVSize, err := bumpOriginal(opts)
+	if err != nil {
+		return nil, err
+	}
+	if rate := float64(parentFee) / float64(parentVSize); rate >= opts.FeeRate {
+		return nil, fmt.Errorf("the transaction already pays %.2f sat/vB", rate)
+	}
+	changeIndex, err := bumpChangeIndex(opts)
+	if err != nil {
+		return nil, err
+	}
+	change := opts.Tx.TxOut[changeIndex]
+	childScript := change.PkScript
+	if opts.ChangeAddress != "" {
+		if childScript, err = AddressToScript(opts.ChangeAddress, opts.Net); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := opts.Tx.Serialize(&buf); err != nil {
+		return nil, fmt.Errorf("failed to serialize the parent transaction: %v", err)
+	}
+	input := UTXO{
+		TxID:         opts.Tx.TxHash().String(),
+		Vout:         uint32(changeIndex),
+		Value:        change.Value,
+		ScriptPubKey: hex.EncodeToString(change.PkScript),
+		PrevTx:       hex.EncodeToString(buf.Bytes()),
+	}
+	outPoint, err := input.OutPoint()
+	if err != nil {
+		return nil, err
+	}
+
+	child := wire.NewMsgTx(2)
+	txIn := wire.NewTxIn(outPoint, nil, nil)
+	txIn.Sequence = SequenceRBF
+	child.AddTxIn(txIn)
+	child.AddTxOut(wire.NewTxOut(0, childScript))
+	weight, err := estimateTxWeight(child, []UTXO{input})
+	if err != nil {
+		return nil, err
+	}
+	vsize := weightToVSize(weight)
+
+	fee := int64(math.Ceil(float64(parentVSize+vsize)*opts.FeeRate)) - parentFee
+	if minFee := feeForWeight(weight, IncrementalRelayFeeRate); fee < minFee {
+		fee = minFee
+	}
+	child.TxOut[0].Value = change.Value - fee
+	if child.TxOut[0].Value < DustThreshold(childScript) {
+		return nil, fmt.Errorf("the change output of %d sats cannot pay the %d sats child fee: %w", change.Value, fee, ErrInsufficientFunds)
+	}
+
+	return &BumpedTx{
+		BuiltTx:       &BuiltTx{Tx: child, Inputs: []UTXO{input}, Fee: fee, VSize: vsize, ChangeIndex: 0},
+		OriginalFee:   parentFee,
+		OriginalVSize: parentVSize,
+		FeeRate:       float64(parentFee+fee) / float64(parentVSize+vsize),
+	}, nil
+}
+
+// bumpOriginal checks the options and returns the spent coins of opts.Tx in
+// input order, its fee and its virtual size, estimated when it is unsigned
+func bumpOriginal(opts BumpOptions) ([]UTXO, int64, int64, error) {
+	if opts.Tx == nil || len(opts.Tx.TxIn) == 0 || len(opts.Tx.TxOut) == 0 {
+		return nil, 0, 0, fmt.Errorf("the transaction must have inputs and outputs")
+	}
+	if opts.FeeRate <= 0 {
+		return nil, 0, 0, fmt.Errorf("fee rate must be positive")
+	}
+
+	byOutPoint := make(map[wire.OutPoint]UTXO, len(opts.PrevOuts))
+	for _, u := range opts.PrevOuts {
+		outPoint, err := u.OutPoint()
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		byOutPoint[*outPoint] = u
+	}
+	inputs := make([]UTXO, len(opts.Tx.TxIn))
+	signed := true
+	for i, txIn := range opts.Tx.TxIn {
+		u, ok := byOutPoint[txIn.PreviousOutPoint]
+		if !ok {
+			return nil, 0, 0, fmt.Errorf("missing previous output for input %d (%s)", i, txIn.PreviousOutPoint)
+		}
+		inputs[i] = u
+		signed = signed && (len(txIn.SignatureScript) > 0 || len(txIn.Witness) > 0)
+	}
+	prevOuts, err := PrevOutsFromUTXOs(inputs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	fee, err := TxFee(opts.Tx, prevOuts)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	vsize := TxVSize(opts.Tx)
+	if !signed {
+		weight, err := estimateTxWeight(opts.Tx, inputs)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		vsize = weightToVSize(weight)
+	}
+	return inputs, fee, vsize, nil
+}
+
+// bumpChangeIndex returns the index of the change output of opts.Tx
+func bumpChangeIndex(opts BumpOptions) (int, error) {
+	if opts.ChangeIndex >= 0 {
+		if opts.ChangeIndex >= len(opts.Tx.TxOut) {
+			return 0, fmt.Errorf("change index %d out of range, the transaction has %d outputs", opts.ChangeIndex, len(opts.Tx.TxOut))
+		}
+		return opts.ChangeIndex, nil
+	}
+	if opts.ChangeAddress == "" {
+		return 0, fmt.Errorf("either the change index or the change address is required")
+	}
+	script, err := AddressToScript(opts.ChangeAddress, opts.Net)
+	if err != nil {
+		return 0, fmt.Errorf("invalid change address: %v", err)
+	}
+	for i, out := range opts.Tx.TxOut {
+		if bytes.Equal(out.PkScript, script) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("the transaction has no output to %s", opts.ChangeAddress)
+}
+
+// estimateTxWeight returns the expected weight of tx once its inputs,
+// spending the given coins, are signed
+func estimateTxWeight(tx *wire.MsgTx, inputs []UTXO) (int64, error) {
+	weight := int64(txOverheadWeight + segwitMarkerWeight)
+	for _, u := range inputs {
+		inputWeight, err := estimateInputWeight(u)
+		if err != nil {
+			return 0, err
+		}
+		weight += inputWeight
+	}
+	for _, out := range tx.TxOut {
+		weight += outputWeight(out.PkScript)
+	}
+	return weight, nil
+}
@@ -0,0 +1,169 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// stuckTransaction builds and signs a transaction paying 2 sat/vB and returns
+// it with its spent coins and change address
+func stuckTransaction(t *testing.T, changeValue int64) (*wire.MsgTx, []UTXO, string) {
+	net := &chaincfg.RegressionNetParams
+	key, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	addr, err := AddressFromPublicKey(key.PubKey(), AddressTypeP2WPKH, net)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+	payee, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	dest, err := AddressFromPublicKey(payee.PubKey(), AddressTypeP2WPKH, net)
+	require.NoError(t, err)
+
+	utxos := []UTXO{{
+		TxID:         "1234567890123456789012345678901234567890123456789012345678901234",
+		Vout:         1,
+		Value:        100000,
+		ScriptPubKey: hex.EncodeToString(pkScript),
+	}}
+	built, err := BuildTransaction(TxBuildOptions{
+		UTXOs:         utxos,
+		Outputs:       []TxOutput{{Address: dest.EncodeAddress(), Value: 100000 - changeValue}},
+		FeeRate:       2,
+		ChangeAddress: addr.EncodeAddress(),
+		RBF:           true,
+		Net:           net,
+	})
+	require.NoError(t, err)
+	txOut, err := utxos[0].TxOut()
+	require.NoError(t, err)
+	_, err = SignTransaction(built.Tx, []*wire.TxOut{txOut}, []*btcec.PrivateKey{key})
+	require.NoError(t, err)
+	return built.Tx, utxos, addr.EncodeAddress()
+}
+
+func TestBumpFeeRBF(t *testing.T) {
+	tx, utxos, changeAddress := stuckTransaction(t, 30000)
+	bumped, err := BumpFeeRBF(BumpOptions{
+		Tx:            tx,
+		PrevOuts:      utxos,
+		FeeRate:       10,
+		ChangeIndex:   -1,
+		ChangeAddress: changeAddress,
+		Net:           &chaincfg.RegressionNetParams,
+	})
+	require.NoError(t, err)
+	require.Equal(t, TxVSize(tx), bumped.OriginalVSize)
+	require.InDelta(t, 2, float64(bumped.OriginalFee)/float64(bumped.OriginalVSize), 0.1)
+	require.GreaterOrEqual(t, bumped.FeeRate, 10.0)
+	require.GreaterOrEqual(t, bumped.Fee, bumped.OriginalFee+bumped.VSize)
+
+	// same inputs and payment, the change pays the fee increase
+	require.Equal(t, tx.TxIn[0].PreviousOutPoint, bumped.Tx.TxIn[0].PreviousOutPoint)
+	require.Empty(t, bumped.Tx.TxIn[0].Witness)
+	require.Len(t, bumped.Tx.TxOut, 2)
+	change := bumped.Tx.TxOut[bumped.ChangeIndex]
+	require.Equal(t, tx.TxOut[bumped.ChangeIndex].Value-(bumped.Fee-bumped.OriginalFee), change.Value)
+	require.Equal(t, tx.TxOut[1-bumped.ChangeIndex].Value, bumped.Tx.TxOut[1-bumped.ChangeIndex].Value)
+	require.True(t, SignalsRBF(bumped.Tx))
+
+	_, err = bumped.PSBT()
+	require.NoError(t, err)
+}
+
+func TestBumpFeeRBFDropsDustChange(t *testing.T) {
+	tx, utxos, changeAddress := stuckTransaction(t, 2000)
+	opts := BumpOptions{
+		Tx:            tx,
+		PrevOuts:      utxos,
+		FeeRate:       15,
+		ChangeIndex:   -1,
+		ChangeAddress: changeAddress,
+		Net:           &chaincfg.RegressionNetParams,
+	}
+	bumped, err := BumpFeeRBF(opts)
+	require.NoError(t, err)
+	require.Equal(t, -1, bumped.ChangeIndex)
+	require.Len(t, bumped.Tx.TxOut, 1)
+	require.GreaterOrEqual(t, bumped.FeeRate, 15.0)
+
+	opts.FeeRate = 100
+	_, err = BumpFeeRBF(opts)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestBumpFeeRBFChangeOnly(t *testing.T) {
+	tx, utxos, changeAddress := stuckTransaction(t, 30000)
+	opts := BumpOptions{
+		Tx:            tx.Copy(),
+		PrevOuts:      utxos,
+		FeeRate:       10,
+		ChangeIndex:   -1,
+		ChangeAddress: changeAddress,
+		Net:           &chaincfg.RegressionNetParams,
+	}
+	changeIndex, err := bumpChangeIndex(opts)
+	require.NoError(t, err)
+	opts.Tx.TxOut = []*wire.TxOut{opts.Tx.TxOut[changeIndex]}
+
+	// a change-only transaction keeps its output when it can pay the increase
+	bumped, err := BumpFeeRBF(opts)
+	require.NoError(t, err)
+	require.Len(t, bumped.Tx.TxOut, 1)
+	require.Equal(t, 0, bumped.ChangeIndex)
+
+	// but it cannot be dropped when what is left would be dust
+	opts.Tx.TxOut[0].Value = 350
+	_, err = BumpFeeRBF(opts)
+	require.ErrorContains(t, err, "only output")
+}
+
+func TestBumpFeeCPFP(t *testing.T) {
+	tx, utxos, changeAddress := stuckTransaction(t, 30000)
+	opts := BumpOptions{
+		Tx:            tx,
+		PrevOuts:      utxos,
+		FeeRate:       10,
+		ChangeIndex:   -1,
+		ChangeAddress: changeAddress,
+		Net:           &chaincfg.RegressionNetParams,
+	}
+	bumped, err := BumpFeeCPFP(opts)
+	require.NoError(t, err)
+
+	require.Len(t, bumped.Tx.TxIn, 1)
+	require.Equal(t, tx.TxHash(), bumped.Tx.TxIn[0].PreviousOutPoint.Hash)
+	spent := tx.TxOut[bumped.Tx.TxIn[0].PreviousOutPoint.Index]
+	require.Equal(t, int64(30000), spent.Value+bumped.OriginalFee)
+	require.Equal(t, spent.Value-bumped.Fee, bumped.Tx.TxOut[0].Value)
+
+	packageFee := bumped.OriginalFee + bumped.Fee
+	packageVSize := bumped.OriginalVSize + bumped.VSize
+	require.GreaterOrEqual(t, float64(packageFee)/float64(packageVSize), 10.0)
+	require.Less(t, float64(packageFee-1)/float64(packageVSize), 10.0)
+
+	_, err = bumped.PSBT()
+	require.NoError(t, err)
+
+	opts.FeeRate = 1
+	_, err = BumpFeeCPFP(opts)
+	require.Error(t, err)
+}
+
+func TestBumpMissingPrevOut(t *testing.T) {
+	tx, _, changeAddress := stuckTransaction(t, 30000)
+	_, err := BumpFeeRBF(BumpOptions{
+		Tx:            tx,
+		FeeRate:       10,
+		ChangeIndex:   -1,
+		ChangeAddress: changeAddress,
+		Net:           &chaincfg.RegressionNetParams,
+	})
+	require.ErrorContains(t, err, "missing previous output")
+}
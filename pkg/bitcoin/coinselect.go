@@ -0,0 +1,213 @@
+package bitcoin
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	// bnbTotalTries bounds the depth-first search of the branch and bound
+	// coin selection, same as Bitcoin Core
+	bnbTotalTries = 100000
+
+	// knapsackIterations is the number of random passes of the knapsack solver
+	knapsackIterations = 1000
+)
+
+// ErrInsufficientFunds is returned when the available coins cannot pay for the
+// requested outputs plus fees
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// selectionCandidate is a coin considered for selection, valued net of the
+// fee needed to spend it
+type selectionCandidate struct {
+	index          int
+	effectiveValue int64
+}
+
+// selectCoinsBnB runs the branch and bound algorithm of Bitcoin Core (Murch,
+// "An Evaluation of Coin Selection Strategies") looking for an input set whose
+// effective value lies in [target, target+costOfChange], so that no change
+// output is needed. It returns the indexes of the selected candidates.
+func selectCoinsBnB(candidates []selectionCandidate, target, costOfChange int64) ([]int, bool) {
+	pool := make([]selectionCandidate, 0, len(candidates))
+	var currAvailable int64
+	for _, c := range candidates {
+		if c.effectiveValue > 0 {
+			pool = append(pool, c)
+			currAvailable += c.effectiveValue
+		}
+	}
+	if currAvailable < target {
+		return nil, false
+	}
+	sort.SliceStable(pool, func(i, j int) bool {
+		return pool[i].effectiveValue > pool[j].effectiveValue
+	})
+
+	var (
+		currValue     int64
+		currSelection []int // indexes into pool
+		bestSelection []int
+		bestWaste     int64 = math.MaxInt64
+	)
+	for try, poolIndex := 0, 0; try < bnbTotalTries; try, poolIndex = try+1, poolIndex+1 {
+		backtrack := false
+		if currValue+currAvailable < target || currValue > target+costOfChange {
+			backtrack = true
+		} else if currValue >= target {
+			// The fee rate equals the long term fee rate, so the waste is
+			// only the excess over the target
+			if waste := currValue - target; waste <= bestWaste {
+				bestSelection = append(bestSelection[:0], currSelection...)
+				bestWaste = waste
+			}
+			backtrack = true
+		}
+
+		if backtrack {
+			if len(currSelection) == 0 {
+				break
+			}
+			// Add the omitted coins back before exploring the omission
+			// branch of the last included coin
+			last := currSelection[len(currSelection)-1]
+			for poolIndex--; poolIndex > last; poolIndex-- {
+				currAvailable += pool[poolIndex].effectiveValue
+			}
+			currValue -= pool[poolIndex].effectiveValue
+			currSelection = currSelection[:len(currSelection)-1]
+		} else {
+			c := pool[poolIndex]
+			currAvailable -= c.effectiveValue
+			// Skip coins equivalent to a previously omitted one, as that
+			// branch was already explored
+			if len(currSelection) == 0 ||
+				poolIndex-1 == currSelection[len(currSelection)-1] ||
+				c.effectiveValue != pool[poolIndex-1].effectiveValue {
+				currSelection = append(currSelection, poolIndex)
+				currValue += c.effectiveValue
+			}
+		}
+	}
+
+	if bestSelection == nil {
+		return nil, false
+	}
+	selected := make([]int, len(bestSelection))
+	for i, poolIndex := range bestSelection {
+		selected[i] = pool[poolIndex].index
+	}
+	return selected, true
+}
+
+// selectCoinsKnapsack is the fallback solver of Bitcoin Core: it looks for an
+// exact match, then approximates the smallest subset reaching
+// target+minChange with random passes, and compares it against the smallest
+// single coin larger than the target.
+func selectCoinsKnapsack(candidates []selectionCandidate, target, minChange int64) ([]int, bool) {
+	var (
+		applicable   []selectionCandidate
+		total        int64
+		lowestLarger *selectionCandidate
+	)
+	for i := range candidates {
+		c := candidates[i]
+		if c.effectiveValue <= 0 {
+			continue
+		}
+		if c.effectiveValue == target {
+			return []int{c.index}, true
+		}
+		if c.effectiveValue < target+minChange {
+			applicable = append(applicable, c)
+			total += c.effectiveValue
+		} else if lowestLarger == nil || c.effectiveValue < lowestLarger.effectiveValue {
+			lowestLarger = &candidates[i]
+		}
+	}
+
+	if total == target {
+		return candidateIndexes(applicable, nil), true
+	}
+	if total < target {
+		if lowestLarger == nil {
+			return nil, false
+		}
+		return []int{lowestLarger.index}, true
+	}
+
+	sort.SliceStable(applicable, func(i, j int) bool {
+		return applicable[i].effectiveValue > applicable[j].effectiveValue
+	})
+	best, bestValue := approximateBestSubset(applicable, total, target)
+	if bestValue != target && total >= target+minChange {
+		best, bestValue = approximateBestSubset(applicable, total, target+minChange)
+	}
+
+	// Prefer the single larger coin when the subset misses an exact match
+	// and is not smaller than that coin
+	if lowestLarger != nil &&
+		((bestValue != target && bestValue < target+minChange) || lowestLarger.effectiveValue <= bestValue) {
+		return []int{lowestLarger.index}, true
+	}
+	return candidateIndexes(applicable, best), true
+}
+
+// approximateBestSubset randomly explores subsets of the (sorted) candidates
+// and returns the smallest one whose value reaches target
+func approximateBestSubset(candidates []selectionCandidate, total, target int64) ([]bool, int64) {
+	best := make([]bool, len(candidates))
+	for i := range best {
+		best[i] = true
+	}
+	bestValue := total
+
+	included := make([]bool, len(candidates))
+	for rep := 0; rep < knapsackIterations && bestValue != target; rep++ {
+		for i := range included {
+			included[i] = false
+		}
+		var value int64
+		reachedTarget := false
+		for pass := 0; pass < 2 && !reachedTarget; pass++ {
+			for i, c := range candidates {
+				// The first pass picks coins randomly, the second pass
+				// adds the coins left out by the first one
+				var pick bool
+				if pass == 0 {
+					pick = rand.Intn(2) == 1
+				} else {
+					pick = !included[i]
+				}
+				if !pick {
+					continue
+				}
+				value += c.effectiveValue
+				included[i] = true
+				if value >= target {
+					reachedTarget = true
+					if value < bestValue {
+						bestValue = value
+						copy(best, included)
+					}
+					value -= c.effectiveValue
+					included[i] = false
+				}
+			}
+		}
+	}
+	return best, bestValue
+}
+
+func candidateIndexes(candidates []selectionCandidate, mask []bool) []int {
+	var indexes []int
+	for i, c := range candidates {
+		if mask == nil || mask[i] {
+			indexes = append(indexes, c.index)
+		}
+	}
+	return indexes
+}
@@ -6,11 +6,47 @@ import (
 	"strings"
 
 	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
 )
 
-func ConvertPrivateKeyToWIF(privKey *btcec.PrivateKey, testnet, compressed bool) (*btcutil.WIF, error) {
-	net := getChainParams(testnet)
+// AddressType identifies one of the standard single-key output script templates.
+type AddressType string
+
+const (
+	// AddressTypeP2PKH is the legacy pay-to-pubkey-hash address (1...).
+	AddressTypeP2PKH AddressType = "p2pkh"
+	// AddressTypeP2SHP2WPKH is a P2WPKH program nested in a P2SH script (3...).
+	AddressTypeP2SHP2WPKH AddressType = "p2sh-p2wpkh"
+	// AddressTypeP2WPKH is the native SegWit v0 address (bc1q...).
+	AddressTypeP2WPKH AddressType = "p2wpkh"
+	// AddressTypeP2TR is the SegWit v1 Taproot key-path address (bc1p...).
+	AddressTypeP2TR AddressType = "p2tr"
+)
+
+// AddressTypes lists every supported address type in display order.
+var AddressTypes = []AddressType{
+	AddressTypeP2PKH,
+	AddressTypeP2SHP2WPKH,
+	AddressTypeP2WPKH,
+	AddressTypeP2TR,
+}
+
+// ParseAddressType converts a user supplied string into an AddressType.
+func ParseAddressType(s string) (AddressType, error) {
+	t := AddressType(strings.ToLower(s))
+	for _, known := range AddressTypes {
+		if t == known {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported address type: %s", s)
+}
+
+// ConvertPrivateKeyToWIF encodes a private key as a WIF for net
+func ConvertPrivateKeyToWIF(privKey *btcec.PrivateKey, net *chaincfg.Params, compressed bool) (*btcutil.WIF, error) {
 	privKeyWIF, err := btcutil.NewWIF(privKey, net, compressed)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create private key: %v", err)
@@ -22,42 +58,136 @@ func GeneratePublicKeyFromWIF(wif *btcutil.WIF) ([]byte, error) {
 	return wif.PrivKey.PubKey().SerializeCompressed(), nil
 }
 
-func GenerateAddressFromPrivateKey(privKey string, testnet bool) (string, error) {
-	net := getChainParams(testnet)
-	var key *btcec.PrivateKey
+// GenerateAddressFromPrivateKey returns the legacy P2PKH address of a WIF or hex private key.
+func GenerateAddressFromPrivateKey(privKey string, net *chaincfg.Params) (string, error) {
+	return GenerateAddress(privKey, AddressTypeP2PKH, net)
+}
 
-	if isWIF(privKey) {
-		wif, _ := btcutil.DecodeWIF(privKey)
-		key = wif.PrivKey
-	} else if isHex(privKey) {
-		b, _ := hex.DecodeString(strings.TrimPrefix(privKey, "0x"))
-		key, _ = btcec.PrivKeyFromBytes(b)
-	} else {
-		return "", fmt.Errorf("invalid private key format (must be WIF or hex)")
+// GenerateAddress returns the address of the given type for a WIF or hex private key.
+func GenerateAddress(privKey string, addrType AddressType, net *chaincfg.Params) (string, error) {
+	key, err := ParsePrivateKey(privKey)
+	if err != nil {
+		return "", err
 	}
 
-	addr, err := btcutil.NewAddressPubKey(key.PubKey().SerializeCompressed(), net)
+	addr, err := AddressFromPublicKey(key.PubKey(), addrType, net)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate address: %v", err)
+		return "", err
 	}
 	return addr.EncodeAddress(), nil
 }
 
+// AddressFromPublicKey builds the address of the given type for a public key.
+// Taproot addresses commit to the BIP86 key-path-only tweak of the key.
+func AddressFromPublicKey(pubKey *btcec.PublicKey, addrType AddressType, net *chaincfg.Params) (btcutil.Address, error) {
+	var (
+		addr btcutil.Address
+		err  error
+	)
+
+	segwit := addrType == AddressTypeP2SHP2WPKH || addrType == AddressTypeP2WPKH || addrType == AddressTypeP2TR
+	if segwit && net.Bech32HRPSegwit == "" {
+		return nil, fmt.Errorf("%s addresses are not supported on %s, which has no segwit", addrType, net.Name)
+	}
+
+	pubKeyHash := btcutil.Hash160(pubKey.SerializeCompressed())
+	switch addrType {
+	case AddressTypeP2PKH:
+		addr, err = btcutil.NewAddressPubKeyHash(pubKeyHash, net)
+	case AddressTypeP2WPKH:
+		addr, err = btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, net)
+	case AddressTypeP2SHP2WPKH:
+		var witnessProgram []byte
+		witnessProgram, err = txscript.NewScriptBuilder().
+			AddOp(txscript.OP_0).
+			AddData(pubKeyHash).
+			Script()
+		if err != nil {
+			break
+		}
+		addr, err = btcutil.NewAddressScriptHash(witnessProgram, net)
+	case AddressTypeP2TR:
+		outputKey := txscript.ComputeTaprootKeyNoScript(pubKey)
+		addr, err = btcutil.NewAddressTaproot(schnorr.SerializePubKey(outputKey), net)
+	default:
+		return nil, fmt.Errorf("unsupported address type: %s", addrType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate address: %v", err)
+	}
+	return addr, nil
+}
+
+// UncompressedP2PKHAddress returns the legacy address of the uncompressed
+// serialization of a public key
+func UncompressedP2PKHAddress(pubKey *btcec.PublicKey, net *chaincfg.Params) (btcutil.Address, error) {
+	addr, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pubKey.SerializeUncompressed()), net)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate address: %v", err)
+	}
+	return addr, nil
+}
+
+// KeyFormat is an encoding of a private key
+type KeyFormat string
+
+const (
+	KeyFormatHex   KeyFormat = "hex"
+	KeyFormatWIF   KeyFormat = "wif"
+	KeyFormatBIP38 KeyFormat = "bip38"
+)
+
+// ConvertKey converts a hex private key to a mainnet WIF, and a WIF to hex
 func ConvertKey(privKey string) (string, error) {
-	if isWIF(privKey) {
+	return ConvertKeyTo(privKey, "", "", &chaincfg.MainNetParams)
+}
+
+// ConvertKeyTo converts a hex, WIF or BIP38 encrypted private key to format,
+// by default hex keys to WIF and the others to hex. WIF and BIP38 keys are
+// encoded for net, passphrase encrypts and decrypts BIP38 keys. Hex keys are
+// taken as compressed.
+func ConvertKeyTo(privKey string, format KeyFormat, passphrase string, net *chaincfg.Params) (string, error) {
+	var (
+		key        *btcec.PrivateKey
+		compressed = true
+	)
+	switch {
+	case isWIF(privKey):
 		wif, _ := btcutil.DecodeWIF(privKey)
-		return hex.EncodeToString(wif.PrivKey.Serialize()), nil
-	} else if isHex(privKey) {
-		privKey, err := hex.DecodeString(privKey)
+		key, compressed = wif.PrivKey, wif.CompressPubKey
+	case isHex(privKey):
+		b, _ := hex.DecodeString(strings.TrimPrefix(privKey, "0x"))
+		key, _ = btcec.PrivKeyFromBytes(b)
+		if format == "" {
+			format = KeyFormatWIF
+		}
+	case IsBIP38(privKey):
+		if passphrase == "" {
+			return "", fmt.Errorf("a passphrase is required to decrypt a BIP38 key")
+		}
+		wif, err := DecryptBIP38(privKey, passphrase, net)
 		if err != nil {
 			return "", err
 		}
-		priv, _ := btcec.PrivKeyFromBytes(privKey)
-		wif, err := btcutil.NewWIF(priv, getChainParams(false), true)
+		key, compressed = wif.PrivKey, wif.CompressPubKey
+	default:
+		return "", fmt.Errorf("invalid private key format (must be WIF, hex or BIP38)")
+	}
+
+	switch format {
+	case "", KeyFormatHex:
+		return hex.EncodeToString(key.Serialize()), nil
+	case KeyFormatWIF:
+		wif, err := btcutil.NewWIF(key, net, compressed)
 		if err != nil {
 			return "", err
 		}
 		return wif.String(), nil
+	case KeyFormatBIP38:
+		if passphrase == "" {
+			return "", fmt.Errorf("a passphrase is required to encrypt a BIP38 key")
+		}
+		return EncryptBIP38(key, compressed, passphrase, net)
 	}
-	return "", fmt.Errorf("invalid private key format (must be WIF or hex)")
+	return "", fmt.Errorf("unsupported key format: %s", format)
 }
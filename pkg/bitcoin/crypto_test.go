@@ -7,6 +7,7 @@ import (
 	"github.com/alejoacosta74/cryptonaut/pkg/crypto/ecdsa/secp256k1"
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/stretchr/testify/require"
 )
 
@@ -16,7 +17,7 @@ func TestBitcoinKeys(t *testing.T) {
 		privateKeyHex string
 		privateKeyWIF string
 		address       string
-		isTestnet     bool
+		net           *chaincfg.Params
 		isCompressed  bool
 	}{
 		{
@@ -24,7 +25,7 @@ func TestBitcoinKeys(t *testing.T) {
 			privateKeyHex: "9df5a907ff17ed6a4e02c00c2c119049a045f52a4e817b06b2ec54eb68f70079",
 			privateKeyWIF: "L2WmFR8WMr5GSprjt7UTA7WQ23WDEZPVRimrZv1dmz7e4JzxqSNq",
 			address:       "1EoxGLjv4ZADtRBjTVeXY35czVyDdp7rU4",
-			isTestnet:     false,
+			net:           &chaincfg.MainNetParams,
 			isCompressed:  true,
 		},
 	}
@@ -50,7 +51,7 @@ func TestBitcoinKeys(t *testing.T) {
 			}
 
 			// Test GenerateAddress with hex input
-			addr, err := GenerateAddressFromPrivateKey(tc.privateKeyHex, tc.isTestnet)
+			addr, err := GenerateAddressFromPrivateKey(tc.privateKeyHex, tc.net)
 			if err != nil {
 				t.Fatalf("GenerateAddress(hex) failed: %v", err)
 			}
@@ -59,7 +60,7 @@ func TestBitcoinKeys(t *testing.T) {
 			}
 
 			// Test GenerateAddress with WIF input
-			addr, err = GenerateAddressFromPrivateKey(tc.privateKeyWIF, tc.isTestnet)
+			addr, err = GenerateAddressFromPrivateKey(tc.privateKeyWIF, tc.net)
 			if err != nil {
 				t.Fatalf("GenerateAddress(WIF) failed: %v", err)
 			}
@@ -101,29 +102,33 @@ func TestGeneratePrivateKey(t *testing.T) {
 	t.Run("generate WIF private key", func(t *testing.T) {
 		testCases := []struct {
 			name       string
-			testnet    bool
+			net        *chaincfg.Params
+			other      *chaincfg.Params
 			compressed bool
 		}{
-			{"mainnet compressed", false, true},
-			{"mainnet uncompressed", false, false},
-			{"testnet compressed", true, true},
-			{"testnet uncompressed", true, false},
+			{"mainnet compressed", &chaincfg.MainNetParams, &chaincfg.TestNet3Params, true},
+			{"mainnet uncompressed", &chaincfg.MainNetParams, &chaincfg.TestNet3Params, false},
+			{"testnet compressed", &chaincfg.TestNet3Params, &chaincfg.MainNetParams, true},
+			{"testnet uncompressed", &chaincfg.TestNet3Params, &chaincfg.MainNetParams, false},
+			{"litecoin compressed", &LitecoinParams, &chaincfg.MainNetParams, true},
+			{"dogecoin uncompressed", &DogecoinParams, &LitecoinParams, false},
 		}
 
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
 				privKey, err := secp256k1.GeneratePrivateKey()
 				require.NoError(t, err)
-				wif, err := ConvertPrivateKeyToWIF(privKey, tc.testnet, tc.compressed)
+				wif, err := ConvertPrivateKeyToWIF(privKey, tc.net, tc.compressed)
 				if err != nil {
 					t.Fatalf("GeneratePrivateKeyWIF failed: %v", err)
 				}
 				if wif == nil {
 					t.Error("GeneratePrivateKeyWIF returned nil")
 				}
-				if wif.IsForNet(getChainParams(!tc.testnet)) {
+				if !wif.IsForNet(tc.net) || wif.IsForNet(tc.other) {
 					t.Error("Generated WIF for wrong network")
 				}
+				require.True(t, isWIF(wif.String()))
 				if wif.CompressPubKey != tc.compressed {
 					t.Error("Generated WIF with wrong compression setting")
 				}
@@ -131,3 +136,67 @@ func TestGeneratePrivateKey(t *testing.T) {
 		}
 	})
 }
+
+func TestGenerateAddressTypes(t *testing.T) {
+	testCases := []struct {
+		name       string
+		privateKey string
+		addrType   AddressType
+		network    string
+		address    string
+	}{
+		{
+			// BIP84 test vector m/84'/0'/0'/0/0
+			name:       "mainnet p2wpkh",
+			privateKey: "KyZpNDKnfs94vbrwhJneDi77V6jF64PWPF8x5cdJb8ifgg2DUc9d",
+			addrType:   AddressTypeP2WPKH,
+			network:    "mainnet",
+			address:    "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu",
+		},
+		{
+			// BIP49 test vector m/49'/1'/0'/0/0
+			name:       "testnet p2sh-p2wpkh",
+			privateKey: "cULrpoZGXiuC19Uhvykx7NugygA3k86b3hmdCeyvHYQZSxojGyXJ",
+			addrType:   AddressTypeP2SHP2WPKH,
+			network:    "testnet",
+			address:    "2Mww8dCYPUpKHofjgcXcBCEGmniw9CoaiD2",
+		},
+		{
+			name:       "mainnet p2pkh",
+			privateKey: "9df5a907ff17ed6a4e02c00c2c119049a045f52a4e817b06b2ec54eb68f70079",
+			addrType:   AddressTypeP2PKH,
+			network:    "mainnet",
+			address:    "1EoxGLjv4ZADtRBjTVeXY35czVyDdp7rU4",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			net, err := GetNetworkParams(tc.network)
+			require.NoError(t, err)
+			addr, err := GenerateAddress(tc.privateKey, tc.addrType, net)
+			require.NoError(t, err)
+			require.Equal(t, tc.address, addr)
+		})
+	}
+}
+
+func TestAddressFromPublicKeyTaproot(t *testing.T) {
+	// BIP86 test vector m/86'/0'/0'/0/0
+	internalKey, err := hex.DecodeString("02cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115")
+	require.NoError(t, err)
+	pubKey, err := btcec.ParsePubKey(internalKey)
+	require.NoError(t, err)
+
+	net, err := GetNetworkParams("mainnet")
+	require.NoError(t, err)
+	addr, err := AddressFromPublicKey(pubKey, AddressTypeP2TR, net)
+	require.NoError(t, err)
+	require.Equal(t, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr", addr.EncodeAddress())
+
+	net, err = GetNetworkParams("regtest")
+	require.NoError(t, err)
+	addr, err = AddressFromPublicKey(pubKey, AddressTypeP2TR, net)
+	require.NoError(t, err)
+	require.Contains(t, addr.EncodeAddress(), "bcrt1p")
+}
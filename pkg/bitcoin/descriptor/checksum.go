@@ -0,0 +1,99 @@
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	checksumInputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+		"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+		"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	checksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	checksumLength  = 8
+)
+
+var checksumGenerator = [5]uint64{0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd}
+
+// Checksum computes the BIP380 checksum of a descriptor without checksum
+func Checksum(desc string) (string, error) {
+	symbols, err := checksumExpand(desc)
+	if err != nil {
+		return "", err
+	}
+	symbols = append(symbols, make([]uint64, checksumLength)...)
+	polymod := checksumPolymod(symbols) ^ 1
+
+	var sb strings.Builder
+	for i := 0; i < checksumLength; i++ {
+		sb.WriteByte(checksumCharset[(polymod>>(5*(checksumLength-1-i)))&31])
+	}
+	return sb.String(), nil
+}
+
+// AddChecksum appends the checksum to a descriptor, replacing any existing one
+func AddChecksum(desc string) (string, error) {
+	desc, _, _ = strings.Cut(desc, "#")
+	checksum, err := Checksum(desc)
+	if err != nil {
+		return "", err
+	}
+	return desc + "#" + checksum, nil
+}
+
+// splitChecksum separates the descriptor from its checksum, validating
+// the checksum when present
+func splitChecksum(s string) (string, error) {
+	desc, checksum, found := strings.Cut(s, "#")
+	if !found {
+		return desc, nil
+	}
+	if len(checksum) != checksumLength {
+		return "", fmt.Errorf("checksum must be %d characters long", checksumLength)
+	}
+	expected, err := Checksum(desc)
+	if err != nil {
+		return "", err
+	}
+	if checksum != expected {
+		return "", fmt.Errorf("invalid checksum %s, expected %s", checksum, expected)
+	}
+	return desc, nil
+}
+
+func checksumExpand(desc string) ([]uint64, error) {
+	var symbols, groups []uint64
+	for _, c := range desc {
+		pos := strings.IndexRune(checksumInputCharset, c)
+		if pos < 0 {
+			return nil, fmt.Errorf("invalid character %q in descriptor", c)
+		}
+		symbols = append(symbols, uint64(pos&31))
+		groups = append(groups, uint64(pos>>5))
+		if len(groups) == 3 {
+			symbols = append(symbols, groups[0]*9+groups[1]*3+groups[2])
+			groups = groups[:0]
+		}
+	}
+	switch len(groups) {
+	case 1:
+		symbols = append(symbols, groups[0])
+	case 2:
+		symbols = append(symbols, groups[0]*3+groups[1])
+	}
+	return symbols, nil
+}
+
+func checksumPolymod(symbols []uint64) uint64 {
+	chk := uint64(1)
+	for _, value := range symbols {
+		top := chk >> 35
+		chk = (chk&0x7ffffffff)<<5 ^ value
+		for i, gen := range checksumGenerator {
+			if (top>>i)&1 == 1 {
+				chk ^= gen
+			}
+		}
+	}
+	return chk
+}
@@ -0,0 +1,370 @@
+// Package descriptor parses Bitcoin output script descriptors (BIP380-386),
+// including miniscript inside wsh() and tr(), and derives their scripts
+// and addresses
+package descriptor
+
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// Descriptor is a parsed output script descriptor
+type Descriptor struct {
+	desc string
+	root *scriptExpr
+}
+
+// Output is the output script of a descriptor at a given index
+type Output struct {
+	Index         uint32
+	ScriptPubKey  []byte
+	RedeemScript  []byte
+	WitnessScript []byte
+	// Address is empty for scripts without an address form, like bare multisig
+	Address string
+	// TaprootInternalKey and TapLeafScripts are set for tr() descriptors,
+	// with the leaves in the order they appear in the descriptor
+	TaprootInternalKey []byte
+	TapLeafScripts     [][]byte
+}
+
+// scriptExpr is a SCRIPT expression
+type scriptExpr struct {
+	name string
+	key  *keyExpr
+	sub  *scriptExpr
+	ms   *msNode
+	tree *tapTree
+	addr string
+	raw  []byte
+}
+
+// tapTree is a node of a tr() script tree: either a leaf script or a branch
+type tapTree struct {
+	leaf        *msNode
+	left, right *tapTree
+}
+
+// Parse reads a descriptor, validating its checksum when present
+func Parse(s string) (*Descriptor, error) {
+	desc, err := splitChecksum(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseScript(desc, "")
+	if err != nil {
+		return nil, err
+	}
+	return &Descriptor{desc: desc, root: root}, nil
+}
+
+// String returns the descriptor with its checksum
+func (d *Descriptor) String() string {
+	desc, _ := AddChecksum(d.desc)
+	return desc
+}
+
+// IsRange reports whether the descriptor has keys ending in a wildcard
+func (d *Descriptor) IsRange() bool {
+	for _, key := range d.root.allKeys() {
+		if key.isRange() {
+			return true
+		}
+	}
+	return false
+}
+
+// Derive expands the descriptor at index. The index is ignored when the
+// descriptor is not ranged.
+func (d *Descriptor) Derive(index uint32, net *chaincfg.Params) (*Output, error) {
+	for _, key := range d.root.allKeys() {
+		if err := key.checkNet(net); err != nil {
+			return nil, err
+		}
+	}
+	out := &Output{Index: index}
+	script, err := d.root.expand(index, net, out)
+	if err != nil {
+		return nil, err
+	}
+	out.ScriptPubKey = script
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(script, net)
+	if err == nil && len(addrs) == 1 && d.root.name != "pk" && d.root.name != "multi" &&
+		d.root.name != "sortedmulti" {
+		out.Address = addrs[0].EncodeAddress()
+	}
+	return out, nil
+}
+
+// parseScript reads a SCRIPT expression nested in parent ("" at top level)
+func parseScript(s, parent string) (*scriptExpr, error) {
+	name, args, err := splitCall(s)
+	if err != nil {
+		return nil, err
+	}
+	if args == nil {
+		return nil, fmt.Errorf("invalid script expression %s", s)
+	}
+	allowed := map[string][]string{
+		"pk":          {"", "sh"},
+		"pkh":         {"", "sh"},
+		"wpkh":        {"", "sh"},
+		"sh":          {""},
+		"wsh":         {"", "sh"},
+		"multi":       {"", "sh"},
+		"sortedmulti": {"", "sh"},
+		"tr":          {""},
+		"addr":        {""},
+		"raw":         {""},
+	}
+	parents, ok := allowed[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported script expression %s", name)
+	}
+	if !slices.Contains(parents, parent) {
+		return nil, fmt.Errorf("%s() is not allowed inside %s()", name, parent)
+	}
+
+	expr := &scriptExpr{name: name}
+	switch name {
+	case "pk", "pkh", "wpkh":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s() takes a single key", name)
+		}
+		if expr.key, err = parseKey(args[0]); err != nil {
+			return nil, err
+		}
+	case "sh":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("sh() takes a single script")
+		}
+		if expr.sub, err = parseScript(args[0], name); err != nil {
+			return nil, err
+		}
+	case "wsh":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("wsh() takes a single script")
+		}
+		if subName, subArgs, _ := splitCall(args[0]); subName == "sortedmulti" {
+			expr.ms, err = parseMulti("multi", subArgs, true, contextSegwitV0)
+		} else {
+			expr.ms, err = parseMiniscript(args[0], contextSegwitV0)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if expr.ms.typ.base != 'B' {
+			return nil, fmt.Errorf("wsh() script must be of type B")
+		}
+	case "multi", "sortedmulti":
+		if expr.ms, err = parseMulti("multi", args, name == "sortedmulti", contextLegacy); err != nil {
+			return nil, err
+		}
+		if len(expr.ms.keys) > 3 && parent == "" {
+			return nil, fmt.Errorf("bare multisig is limited to 3 keys")
+		}
+		if len(expr.ms.keys) > 15 {
+			return nil, fmt.Errorf("P2SH multisig is limited to 15 keys")
+		}
+	case "tr":
+		if len(args) < 1 || len(args) > 2 {
+			return nil, fmt.Errorf("tr() takes a key and an optional script tree")
+		}
+		if expr.key, err = parseKey(args[0]); err != nil {
+			return nil, err
+		}
+		if len(args) == 2 {
+			if expr.tree, err = parseTapTree(args[1]); err != nil {
+				return nil, err
+			}
+		}
+	case "addr":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("addr() takes a single address")
+		}
+		expr.addr = args[0]
+	case "raw":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("raw() takes a single hex script")
+		}
+		if expr.raw, err = hex.DecodeString(args[0]); err != nil {
+			return nil, fmt.Errorf("invalid hex script: %v", err)
+		}
+	}
+	return expr, nil
+}
+
+// parseTapTree reads a TREE expression: a script or {TREE,TREE}
+func parseTapTree(s string) (*tapTree, error) {
+	if !strings.HasPrefix(s, "{") {
+		var leaf *msNode
+		var err error
+		if name, args, _ := splitCall(s); name == "sortedmulti_a" {
+			leaf, err = parseMulti("multi_a", args, true, contextTapscript)
+		} else {
+			leaf, err = parseMiniscript(s, contextTapscript)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if leaf.typ.base != 'B' {
+			return nil, fmt.Errorf("tapscript leaf must be of type B")
+		}
+		return &tapTree{leaf: leaf}, nil
+	}
+	if !strings.HasSuffix(s, "}") {
+		return nil, fmt.Errorf("missing '}' in %s", s)
+	}
+	branches, err := splitArgs(s[1 : len(s)-1])
+	if err != nil {
+		return nil, err
+	}
+	if len(branches) != 2 {
+		return nil, fmt.Errorf("script tree branches must have two children: %s", s)
+	}
+	left, err := parseTapTree(branches[0])
+	if err != nil {
+		return nil, err
+	}
+	right, err := parseTapTree(branches[1])
+	if err != nil {
+		return nil, err
+	}
+	return &tapTree{left: left, right: right}, nil
+}
+
+// allKeys returns every key of the expression
+func (e *scriptExpr) allKeys() []*keyExpr {
+	var keys []*keyExpr
+	if e.key != nil {
+		keys = append(keys, e.key)
+	}
+	if e.sub != nil {
+		keys = append(keys, e.sub.allKeys()...)
+	}
+	if e.ms != nil {
+		keys = append(keys, e.ms.allKeys()...)
+	}
+	if e.tree != nil {
+		keys = append(keys, e.tree.allKeys()...)
+	}
+	return keys
+}
+
+// expand returns the script of the expression at index, recording redeem
+// and witness scripts in out
+func (e *scriptExpr) expand(index uint32, net *chaincfg.Params, out *Output) ([]byte, error) {
+	builder := txscript.NewScriptBuilder()
+	switch e.name {
+	case "pk":
+		key, err := e.key.serialize(index, contextLegacy)
+		if err != nil {
+			return nil, err
+		}
+		return builder.AddData(key).AddOp(txscript.OP_CHECKSIG).Script()
+	case "pkh":
+		key, err := e.key.serialize(index, contextLegacy)
+		if err != nil {
+			return nil, err
+		}
+		return builder.AddOp(txscript.OP_DUP).AddOp(txscript.OP_HASH160).AddData(btcutil.Hash160(key)).
+			AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG).Script()
+	case "wpkh":
+		key, err := e.key.serialize(index, contextSegwitV0)
+		if err != nil {
+			return nil, err
+		}
+		return builder.AddOp(txscript.OP_0).AddData(btcutil.Hash160(key)).Script()
+	case "sh":
+		redeemScript, err := e.sub.expand(index, net, out)
+		if err != nil {
+			return nil, err
+		}
+		if len(redeemScript) > txscript.MaxScriptElementSize {
+			return nil, fmt.Errorf("redeem script is %d bytes, over the %d byte limit",
+				len(redeemScript), txscript.MaxScriptElementSize)
+		}
+		out.RedeemScript = redeemScript
+		return builder.AddOp(txscript.OP_HASH160).AddData(btcutil.Hash160(redeemScript)).
+			AddOp(txscript.OP_EQUAL).Script()
+	case "wsh":
+		witnessScript, err := e.ms.script(index, contextSegwitV0)
+		if err != nil {
+			return nil, err
+		}
+		out.WitnessScript = witnessScript
+		hash := chainhash.HashB(witnessScript)
+		return builder.AddOp(txscript.OP_0).AddData(hash).Script()
+	case "multi", "sortedmulti":
+		return e.ms.script(index, contextLegacy)
+	case "tr":
+		internalKey, err := e.key.derive(index)
+		if err != nil {
+			return nil, err
+		}
+		if e.key.uncompressed {
+			return nil, fmt.Errorf("uncompressed keys are not allowed in taproot")
+		}
+		out.TaprootInternalKey = schnorr.SerializePubKey(internalKey)
+		outputKey := txscript.ComputeTaprootKeyNoScript(internalKey)
+		if e.tree != nil {
+			root, err := e.tree.node(index, out)
+			if err != nil {
+				return nil, err
+			}
+			hash := root.TapHash()
+			outputKey = txscript.ComputeTaprootOutputKey(internalKey, hash[:])
+		}
+		return builder.AddOp(txscript.OP_1).AddData(schnorr.SerializePubKey(outputKey)).Script()
+	case "addr":
+		addr, err := btcutil.DecodeAddress(e.addr, net)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode address %s: %v", e.addr, err)
+		}
+		if !addr.IsForNet(net) {
+			return nil, fmt.Errorf("address %s is not for network %s", e.addr, net.Name)
+		}
+		return txscript.PayToAddrScript(addr)
+	case "raw":
+		return e.raw, nil
+	}
+	return nil, fmt.Errorf("unsupported script expression %s", e.name)
+}
+
+func (t *tapTree) allKeys() []*keyExpr {
+	if t.leaf != nil {
+		return t.leaf.allKeys()
+	}
+	return append(t.left.allKeys(), t.right.allKeys()...)
+}
+
+// node builds the tapscript tree at index, keeping the shape given in the
+// descriptor, and records the leaf scripts in out
+func (t *tapTree) node(index uint32, out *Output) (txscript.TapNode, error) {
+	if t.leaf != nil {
+		script, err := t.leaf.script(index, contextTapscript)
+		if err != nil {
+			return nil, err
+		}
+		out.TapLeafScripts = append(out.TapLeafScripts, script)
+		return txscript.NewBaseTapLeaf(script), nil
+	}
+	left, err := t.left.node(index, out)
+	if err != nil {
+		return nil, err
+	}
+	right, err := t.right.node(index, out)
+	if err != nil {
+		return nil, err
+	}
+	return txscript.NewTapBranch(left, right), nil
+}
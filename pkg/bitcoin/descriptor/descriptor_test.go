@@ -0,0 +1,246 @@
+package descriptor
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+)
+
+const (
+	testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	keyA         = "02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"
+	keyB         = "03fff97bd5755eeea420453a14355235d382f6472f8568a18b2f057a1460297556"
+)
+
+func testMaster(t *testing.T) *hdkeychain.ExtendedKey {
+	seed := bip39.NewSeed(testMnemonic, "")
+	master, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	return master
+}
+
+// accountXpub derives the account level xpub at m/purpose'/0'/0'
+func accountXpub(t *testing.T, purpose uint32) string {
+	key := testMaster(t)
+	for _, i := range []uint32{purpose, 0, 0} {
+		var err error
+		key, err = key.Derive(hdkeychain.HardenedKeyStart + i)
+		require.NoError(t, err)
+	}
+	xpub, err := key.Neuter()
+	require.NoError(t, err)
+	return xpub.String()
+}
+
+func TestChecksum(t *testing.T) {
+	checksum, err := Checksum("raw(deadbeef)")
+	require.NoError(t, err)
+	require.Equal(t, "89f8spxm", checksum)
+	checksum, err = Checksum("wpkh(02f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9)")
+	require.NoError(t, err)
+	require.Equal(t, "8zl0zxma", checksum)
+
+	desc, err := AddChecksum("raw(deadbeef)#00000000")
+	require.NoError(t, err)
+	require.Equal(t, "raw(deadbeef)#89f8spxm", desc)
+
+	_, err = Parse("raw(deadbeef)#89f8spxm")
+	require.NoError(t, err)
+	_, err = Parse("raw(deadbeef)#89f8spxn")
+	require.Error(t, err)
+	_, err = Parse("raw(deadbeef)#89f8")
+	require.Error(t, err)
+}
+
+func TestDeriveWalletStandards(t *testing.T) {
+	tests := []struct {
+		desc    string
+		address string
+	}{
+		{fmt.Sprintf("pkh([73c5da0a/44'/0'/0']%s/0/*)", accountXpub(t, 44)), "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA"},
+		{fmt.Sprintf("sh(wpkh([73c5da0a/49h/0h/0h]%s/0/*))", accountXpub(t, 49)), "37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf"},
+		{fmt.Sprintf("wpkh([73c5da0a/84'/0'/0']%s/0/*)", accountXpub(t, 84)), "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"},
+		{fmt.Sprintf("tr([73c5da0a/86'/0'/0']%s/0/*)", accountXpub(t, 86)), "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"},
+		// hardened steps are derived from the private key
+		{fmt.Sprintf("wpkh(%s/84'/0'/0'/0/*)", testMaster(t).String()), "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"},
+	}
+	for _, tt := range tests {
+		desc, err := Parse(tt.desc)
+		require.NoError(t, err, tt.desc)
+		require.True(t, desc.IsRange())
+		out, err := desc.Derive(0, &chaincfg.MainNetParams)
+		require.NoError(t, err, tt.desc)
+		require.Equal(t, tt.address, out.Address, tt.desc)
+
+		// a different index gives a different address
+		next, err := desc.Derive(1, &chaincfg.MainNetParams)
+		require.NoError(t, err)
+		require.NotEqual(t, out.Address, next.Address)
+	}
+
+	desc, err := Parse(fmt.Sprintf("wpkh(%s/0/*)", accountXpub(t, 84)))
+	require.NoError(t, err)
+	_, err = desc.Derive(0, &chaincfg.TestNet3Params)
+	require.Error(t, err)
+
+	_, err = Parse(fmt.Sprintf("wpkh(%s/0'/*)", accountXpub(t, 84)))
+	require.Error(t, err)
+}
+
+func TestDeriveStaticKeys(t *testing.T) {
+	pubA, err := hex.DecodeString(keyA)
+	require.NoError(t, err)
+	pubB, err := hex.DecodeString(keyB)
+	require.NoError(t, err)
+
+	desc, err := Parse("pkh(" + keyA + ")")
+	require.NoError(t, err)
+	require.False(t, desc.IsRange())
+	out, err := desc.Derive(0, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, "76a914"+hex.EncodeToString(btcutil.Hash160(pubA))+"88ac", hex.EncodeToString(out.ScriptPubKey))
+
+	desc, err = Parse("sh(wpkh(" + keyB + "))")
+	require.NoError(t, err)
+	out, err = desc.Derive(0, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, "0014"+hex.EncodeToString(btcutil.Hash160(pubB)), hex.EncodeToString(out.RedeemScript))
+
+	// sortedmulti orders the keys, so it matches multi with sorted keys
+	sorted, err := Parse(fmt.Sprintf("wsh(sortedmulti(1,%s,%s))", keyB, keyA))
+	require.NoError(t, err)
+	multi, err := Parse(fmt.Sprintf("wsh(multi(1,%s,%s))", keyA, keyB))
+	require.NoError(t, err)
+	sortedOut, err := sorted.Derive(0, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	multiOut, err := multi.Derive(0, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, multiOut.Address, sortedOut.Address)
+	require.Equal(t, multiOut.WitnessScript, sortedOut.WitnessScript)
+
+	// bare multisig has no address
+	desc, err = Parse(fmt.Sprintf("multi(1,%s,%s)", keyA, keyB))
+	require.NoError(t, err)
+	out, err = desc.Derive(0, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Empty(t, out.Address)
+
+	// uncompressed keys are only allowed outside segwit
+	uncompressed := "04a34b99f22c790c4e36b2b3c2c35a36db06226e41c692fc82b8b56ac1c540c5bd5b8dec5235a0fa8722476c7709c02559e3aa73aa03918ba2d492eea75abea235"
+	desc, err = Parse("pkh(" + uncompressed + ")")
+	require.NoError(t, err)
+	_, err = desc.Derive(0, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	desc, err = Parse("wpkh(" + uncompressed + ")")
+	require.NoError(t, err)
+	_, err = desc.Derive(0, &chaincfg.MainNetParams)
+	require.Error(t, err)
+}
+
+func TestMiniscript(t *testing.T) {
+	hashA := hex.EncodeToString(btcutil.Hash160(mustHex(t, keyA)))
+	hashB := hex.EncodeToString(btcutil.Hash160(mustHex(t, keyB)))
+	tests := []struct {
+		ms  string
+		asm string
+	}{
+		{
+			fmt.Sprintf("and_v(v:pk(%s),older(144))", keyA),
+			keyA + " OP_CHECKSIGVERIFY 9000 OP_CHECKSEQUENCEVERIFY",
+		},
+		{
+			fmt.Sprintf("or_d(pk(%s),and_v(v:pkh(%s),older(1000)))", keyA, keyB),
+			keyA + " OP_CHECKSIG OP_IFDUP OP_NOTIF OP_DUP OP_HASH160 " + hashB +
+				" OP_EQUALVERIFY OP_CHECKSIGVERIFY e803 OP_CHECKSEQUENCEVERIFY OP_ENDIF",
+		},
+		{
+			fmt.Sprintf("thresh(2,pk(%s),s:pk(%s),sln:older(12960))", keyA, keyB),
+			keyA + " OP_CHECKSIG OP_SWAP " + keyB + " OP_CHECKSIG OP_ADD OP_SWAP OP_IF 0 OP_ELSE a032 " +
+				"OP_CHECKSEQUENCEVERIFY OP_0NOTEQUAL OP_ENDIF OP_ADD 2 OP_EQUAL",
+		},
+		{
+			fmt.Sprintf("andor(pkh(%s),after(800000),and_v(v:multi(1,%s,%s),sha256(%s)))", keyA, keyA, keyB,
+				"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"),
+			"OP_DUP OP_HASH160 " + hashA + " OP_EQUALVERIFY OP_CHECKSIG OP_NOTIF 1 " + keyA + " " + keyB +
+				" 2 OP_CHECKMULTISIGVERIFY OP_SIZE 20 OP_EQUALVERIFY OP_SHA256 " +
+				"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855 OP_EQUAL OP_ELSE 00350c " +
+				"OP_CHECKLOCKTIMEVERIFY OP_ENDIF",
+		},
+	}
+	for _, tt := range tests {
+		desc, err := Parse("wsh(" + tt.ms + ")")
+		require.NoError(t, err, tt.ms)
+		out, err := desc.Derive(0, &chaincfg.MainNetParams)
+		require.NoError(t, err, tt.ms)
+		asm, err := txscript.DisasmString(out.WitnessScript)
+		require.NoError(t, err)
+		require.Equal(t, tt.asm, asm, tt.ms)
+	}
+
+	invalid := []string{
+		// and_b needs a W expression as second argument
+		fmt.Sprintf("wsh(and_b(pk(%s),pk(%s)))", keyA, keyB),
+		// top level expressions must be B
+		fmt.Sprintf("wsh(pk_k(%s))", keyA),
+		fmt.Sprintf("wsh(v:pk(%s))", keyA),
+		// multi_a is tapscript only
+		fmt.Sprintf("wsh(multi_a(1,%s))", keyA),
+		"wsh(older(0))",
+		"wsh(unknown(1))",
+	}
+	for _, desc := range invalid {
+		_, err := Parse(desc)
+		require.Error(t, err, desc)
+	}
+}
+
+func TestTaprootScriptTree(t *testing.T) {
+	xA, xB := keyA[2:], keyB[2:]
+	desc, err := Parse(fmt.Sprintf("tr(%s,{pk(%s),multi_a(1,%s,%s)})", xA, xB, xA, xB))
+	require.NoError(t, err)
+	out, err := desc.Derive(0, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+
+	leftScript, err := txscript.NewScriptBuilder().AddData(mustHex(t, xB)).AddOp(txscript.OP_CHECKSIG).Script()
+	require.NoError(t, err)
+	rightScript, err := txscript.NewScriptBuilder().AddData(mustHex(t, xA)).AddOp(txscript.OP_CHECKSIG).
+		AddData(mustHex(t, xB)).AddOp(txscript.OP_CHECKSIGADD).AddInt64(1).AddOp(txscript.OP_NUMEQUAL).Script()
+	require.NoError(t, err)
+	root := txscript.NewTapBranch(txscript.NewBaseTapLeaf(leftScript), txscript.NewBaseTapLeaf(rightScript)).TapHash()
+
+	internalKey, err := parseKey(xA)
+	require.NoError(t, err)
+	outputKey := txscript.ComputeTaprootOutputKey(internalKey.pubKey, root[:])
+	expected, err := txscript.PayToTaprootScript(outputKey)
+	require.NoError(t, err)
+	require.Equal(t, expected, out.ScriptPubKey)
+	require.Equal(t, [][]byte{leftScript, rightScript}, out.TapLeafScripts)
+	require.Equal(t, mustHex(t, xA), out.TaprootInternalKey)
+
+	// sortedmulti_a sorts the x-only keys
+	sorted, err := Parse(fmt.Sprintf("tr(%s,sortedmulti_a(1,%s,%s))", xA, xB, xA))
+	require.NoError(t, err)
+	out, err = sorted.Derive(0, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{rightScript}, out.TapLeafScripts)
+
+	_, err = Parse(fmt.Sprintf("tr(%s,multi(1,%s))", xA, keyB))
+	require.Error(t, err)
+	_, err = Parse(fmt.Sprintf("tr(%s,{pk(%s)})", xA, xB))
+	require.Error(t, err)
+	_, err = Parse(fmt.Sprintf("sh(tr(%s))", xA))
+	require.Error(t, err)
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
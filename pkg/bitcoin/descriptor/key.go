@@ -0,0 +1,210 @@
+package descriptor
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// keyExpr is a KEY expression: a hex or WIF key, or an extended key with an
+// optional derivation path ending in a wildcard, preceded by key origin info
+type keyExpr struct {
+	fingerprint []byte
+	originPath  []uint32
+
+	pubKey       *btcec.PublicKey
+	xOnly        bool
+	uncompressed bool
+
+	extKey           *hdkeychain.ExtendedKey
+	path             []uint32
+	wildcard         bool
+	hardenedWildcard bool
+}
+
+// parseKey reads a KEY expression
+func parseKey(s string) (*keyExpr, error) {
+	key := &keyExpr{}
+	if strings.HasPrefix(s, "[") {
+		end := strings.Index(s, "]")
+		if end < 0 {
+			return nil, fmt.Errorf("missing ']' in key origin of %s", s)
+		}
+		if err := key.parseOrigin(s[1:end]); err != nil {
+			return nil, err
+		}
+		s = s[end+1:]
+	}
+
+	parts := strings.Split(s, "/")
+	switch {
+	case len(parts[0]) == 64 || len(parts[0]) == 66 || len(parts[0]) == 130:
+		if len(parts) > 1 {
+			return nil, fmt.Errorf("derivation path not allowed on hex key %s", parts[0])
+		}
+		raw, err := hex.DecodeString(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex key %s: %v", parts[0], err)
+		}
+		if len(raw) == 32 {
+			key.pubKey, err = schnorr.ParsePubKey(raw)
+			key.xOnly = true
+		} else {
+			key.pubKey, err = btcec.ParsePubKey(raw)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key %s: %v", parts[0], err)
+		}
+		key.uncompressed = len(raw) == 65
+	case strings.HasPrefix(parts[0], "xpub") || strings.HasPrefix(parts[0], "tpub") ||
+		strings.HasPrefix(parts[0], "xprv") || strings.HasPrefix(parts[0], "tprv"):
+		extKey, err := hdkeychain.NewKeyFromString(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse extended key %s: %v", parts[0], err)
+		}
+		key.extKey = extKey
+		for i, elem := range parts[1:] {
+			last := i == len(parts)-2
+			switch {
+			case last && elem == "*":
+				key.wildcard = true
+			case last && (elem == "*'" || elem == "*h" || elem == "*H"):
+				key.wildcard = true
+				key.hardenedWildcard = true
+			default:
+				index, err := parsePathElement(elem)
+				if err != nil {
+					return nil, err
+				}
+				key.path = append(key.path, index)
+			}
+		}
+		if !extKey.IsPrivate() && key.hasHardenedStep() {
+			return nil, fmt.Errorf("hardened derivation requires a private extended key")
+		}
+	default:
+		if len(parts) > 1 {
+			return nil, fmt.Errorf("derivation path not allowed on key %s", parts[0])
+		}
+		wif, err := btcutil.DecodeWIF(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %s", parts[0])
+		}
+		key.pubKey = wif.PrivKey.PubKey()
+		key.uncompressed = !wif.CompressPubKey
+	}
+	return key, nil
+}
+
+func (k *keyExpr) parseOrigin(origin string) error {
+	parts := strings.Split(origin, "/")
+	fingerprint, err := hex.DecodeString(parts[0])
+	if err != nil || len(fingerprint) != 4 {
+		return fmt.Errorf("key origin fingerprint must be 8 hex characters: %s", parts[0])
+	}
+	k.fingerprint = fingerprint
+	for _, elem := range parts[1:] {
+		index, err := parsePathElement(elem)
+		if err != nil {
+			return err
+		}
+		k.originPath = append(k.originPath, index)
+	}
+	return nil
+}
+
+func parsePathElement(elem string) (uint32, error) {
+	hardened := strings.HasSuffix(elem, "'") || strings.HasSuffix(elem, "h") || strings.HasSuffix(elem, "H")
+	if hardened {
+		elem = elem[:len(elem)-1]
+	}
+	index, err := strconv.ParseUint(elem, 10, 32)
+	if err != nil || index >= hdkeychain.HardenedKeyStart {
+		return 0, fmt.Errorf("invalid derivation path element %s", elem)
+	}
+	if hardened {
+		index += hdkeychain.HardenedKeyStart
+	}
+	return uint32(index), nil
+}
+
+func (k *keyExpr) hasHardenedStep() bool {
+	if k.hardenedWildcard {
+		return true
+	}
+	for _, index := range k.path {
+		if index >= hdkeychain.HardenedKeyStart {
+			return true
+		}
+	}
+	return false
+}
+
+// isRange reports whether the key ends in a wildcard
+func (k *keyExpr) isRange() bool {
+	return k.wildcard
+}
+
+// checkNet fails when an extended key belongs to another network
+func (k *keyExpr) checkNet(net *chaincfg.Params) error {
+	if k.extKey != nil && !k.extKey.IsForNet(net) {
+		return fmt.Errorf("extended key is not for network %s", net.Name)
+	}
+	return nil
+}
+
+// derive returns the public key at the given wildcard index
+func (k *keyExpr) derive(index uint32) (*btcec.PublicKey, error) {
+	if k.extKey == nil {
+		return k.pubKey, nil
+	}
+	path := k.path
+	if k.wildcard {
+		if index >= hdkeychain.HardenedKeyStart {
+			return nil, fmt.Errorf("index %d out of range", index)
+		}
+		if k.hardenedWildcard {
+			index += hdkeychain.HardenedKeyStart
+		}
+		path = append(append([]uint32{}, path...), index)
+	}
+	extKey := k.extKey
+	for _, i := range path {
+		child, err := extKey.Derive(i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive child key: %v", err)
+		}
+		extKey = child
+	}
+	return extKey.ECPubKey()
+}
+
+// serialize returns the derived key as pushed in scripts of the given
+// context: x-only in tapscript, compressed or uncompressed elsewhere
+func (k *keyExpr) serialize(index uint32, ctx scriptContext) ([]byte, error) {
+	pubKey, err := k.derive(index)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case ctx == contextTapscript && k.uncompressed:
+		return nil, fmt.Errorf("uncompressed keys are not allowed in taproot")
+	case ctx == contextTapscript:
+		return schnorr.SerializePubKey(pubKey), nil
+	case k.xOnly:
+		return nil, fmt.Errorf("x-only keys are only allowed in taproot")
+	case ctx == contextSegwitV0 && k.uncompressed:
+		return nil, fmt.Errorf("uncompressed keys are not allowed in segwit")
+	case k.uncompressed:
+		return pubKey.SerializeUncompressed(), nil
+	default:
+		return pubKey.SerializeCompressed(), nil
+	}
+}
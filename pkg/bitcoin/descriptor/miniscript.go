@@ -0,0 +1,596 @@
+package descriptor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// scriptContext is where a script is placed, which changes how keys are
+// serialized and which fragments are allowed
+type scriptContext int
+
+const (
+	contextLegacy scriptContext = iota
+	contextSegwitV0
+	contextTapscript
+)
+
+const (
+	maxMultisigKeys        = 20
+	maxMultisigKeysTaproot = 999
+)
+
+// hashLengths holds the preimage hash fragments and their digest length
+var hashLengths = map[string]int{
+	"sha256":    32,
+	"hash256":   32,
+	"ripemd160": 20,
+	"hash160":   20,
+}
+
+// msType is the type of a miniscript fragment: its basic type (B, V, K or
+// W) and the correctness properties z, o, n, d and u
+type msType struct {
+	base          byte
+	z, o, n, d, u bool
+}
+
+// msNode is a miniscript fragment. Wrappers are stored as single letter
+// fragments and the syntactic sugar (pk, pkh, and_n, t:, l:, u:) is
+// expanded while parsing.
+type msNode struct {
+	frag   string
+	k      int64
+	keys   []*keyExpr
+	sorted bool
+	hash   []byte
+	subs   []*msNode
+	typ    msType
+}
+
+// parseMiniscript reads a miniscript expression in the given context
+func parseMiniscript(s string, ctx scriptContext) (*msNode, error) {
+	open := strings.Index(s, "(")
+	if colon := strings.Index(s, ":"); colon >= 0 && (open < 0 || colon < open) {
+		wrappers := s[:colon]
+		if wrappers == "" {
+			return nil, fmt.Errorf("empty wrapper in %s", s)
+		}
+		node, err := parseMiniscript(s[colon+1:], ctx)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(wrappers) - 1; i >= 0; i-- {
+			if node, err = wrapMiniscript(wrappers[i], node, ctx); err != nil {
+				return nil, err
+			}
+		}
+		return node, nil
+	}
+
+	name, args, err := splitCall(s)
+	if err != nil {
+		return nil, err
+	}
+	switch name {
+	case "0", "1":
+		if args != nil {
+			return nil, fmt.Errorf("%s takes no arguments", name)
+		}
+		return newMiniscript(&msNode{frag: name}, ctx)
+	case "pk_k", "pk_h", "pk", "pkh":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s takes a single key", name)
+		}
+		key, err := parseKey(args[0])
+		if err != nil {
+			return nil, err
+		}
+		frag := name
+		switch name {
+		case "pk":
+			frag = "pk_k"
+		case "pkh":
+			frag = "pk_h"
+		}
+		node, err := newMiniscript(&msNode{frag: frag, keys: []*keyExpr{key}}, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if frag != name {
+			return wrapMiniscript('c', node, ctx)
+		}
+		return node, nil
+	case "older", "after":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s takes a single number", name)
+		}
+		k, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || k < 1 || k >= 1<<31 {
+			return nil, fmt.Errorf("invalid %s value %s", name, args[0])
+		}
+		return newMiniscript(&msNode{frag: name, k: k}, ctx)
+	case "sha256", "hash256", "ripemd160", "hash160":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s takes a single hash", name)
+		}
+		hash, err := hex.DecodeString(args[0])
+		if err != nil || len(hash) != hashLengths[name] {
+			return nil, fmt.Errorf("%s expects a %d byte hex hash", name, hashLengths[name])
+		}
+		return newMiniscript(&msNode{frag: name, hash: hash}, ctx)
+	case "multi", "multi_a":
+		if (name == "multi") == (ctx == contextTapscript) {
+			return nil, fmt.Errorf("%s is not allowed in this context", name)
+		}
+		return parseMulti(name, args, false, ctx)
+	case "thresh":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("thresh needs a threshold and at least one sub expression")
+		}
+		k, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold %s", args[0])
+		}
+		subs, err := parseMiniscripts(args[1:], ctx)
+		if err != nil {
+			return nil, err
+		}
+		return newMiniscript(&msNode{frag: name, k: k, subs: subs}, ctx)
+	}
+
+	arity := map[string]int{
+		"andor": 3, "and_v": 2, "and_b": 2, "and_n": 2,
+		"or_b": 2, "or_c": 2, "or_d": 2, "or_i": 2,
+	}
+	n, ok := arity[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown miniscript fragment %s", name)
+	}
+	if len(args) != n {
+		return nil, fmt.Errorf("%s takes %d arguments", name, n)
+	}
+	subs, err := parseMiniscripts(args, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if name == "and_n" {
+		zero, err := newMiniscript(&msNode{frag: "0"}, ctx)
+		if err != nil {
+			return nil, err
+		}
+		name, subs = "andor", append(subs, zero)
+	}
+	return newMiniscript(&msNode{frag: name, subs: subs}, ctx)
+}
+
+func parseMiniscripts(args []string, ctx scriptContext) ([]*msNode, error) {
+	subs := make([]*msNode, len(args))
+	for i, arg := range args {
+		sub, err := parseMiniscript(arg, ctx)
+		if err != nil {
+			return nil, err
+		}
+		subs[i] = sub
+	}
+	return subs, nil
+}
+
+// parseMulti reads the threshold and keys of multi, sortedmulti and multi_a
+func parseMulti(frag string, args []string, sorted bool, ctx scriptContext) (*msNode, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("%s needs a threshold and at least one key", frag)
+	}
+	k, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid threshold %s", args[0])
+	}
+	node := &msNode{frag: frag, k: k, sorted: sorted}
+	for _, arg := range args[1:] {
+		key, err := parseKey(arg)
+		if err != nil {
+			return nil, err
+		}
+		node.keys = append(node.keys, key)
+	}
+	return newMiniscript(node, ctx)
+}
+
+// wrapMiniscript applies a wrapper letter to a fragment
+func wrapMiniscript(wrapper byte, node *msNode, ctx scriptContext) (*msNode, error) {
+	one := func() (*msNode, error) { return newMiniscript(&msNode{frag: "1"}, ctx) }
+	zero := func() (*msNode, error) { return newMiniscript(&msNode{frag: "0"}, ctx) }
+	switch wrapper {
+	case 'a', 's', 'c', 'd', 'v', 'j', 'n':
+		return newMiniscript(&msNode{frag: string(wrapper), subs: []*msNode{node}}, ctx)
+	case 't':
+		y, err := one()
+		if err != nil {
+			return nil, err
+		}
+		return newMiniscript(&msNode{frag: "and_v", subs: []*msNode{node, y}}, ctx)
+	case 'l':
+		x, err := zero()
+		if err != nil {
+			return nil, err
+		}
+		return newMiniscript(&msNode{frag: "or_i", subs: []*msNode{x, node}}, ctx)
+	case 'u':
+		z, err := zero()
+		if err != nil {
+			return nil, err
+		}
+		return newMiniscript(&msNode{frag: "or_i", subs: []*msNode{node, z}}, ctx)
+	}
+	return nil, fmt.Errorf("unknown wrapper %c", wrapper)
+}
+
+// newMiniscript type checks a fragment
+func newMiniscript(node *msNode, ctx scriptContext) (*msNode, error) {
+	if err := node.typeCheck(ctx); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", node.frag, err)
+	}
+	return node, nil
+}
+
+// typeCheck enforces the requirements of a fragment on its sub expressions
+// and computes its type, following the miniscript specification
+func (n *msNode) typeCheck(ctx scriptContext) error {
+	var x, y, z msType
+	if len(n.subs) > 0 {
+		x = n.subs[0].typ
+	}
+	if len(n.subs) > 1 {
+		y = n.subs[1].typ
+	}
+	if len(n.subs) > 2 {
+		z = n.subs[2].typ
+	}
+	isBKV := func(t msType) bool { return t.base == 'B' || t.base == 'K' || t.base == 'V' }
+
+	switch n.frag {
+	case "0":
+		n.typ = msType{base: 'B', z: true, u: true, d: true}
+	case "1":
+		n.typ = msType{base: 'B', z: true, u: true}
+	case "pk_k":
+		n.typ = msType{base: 'K', o: true, n: true, d: true, u: true}
+	case "pk_h":
+		n.typ = msType{base: 'K', n: true, d: true, u: true}
+	case "older", "after":
+		n.typ = msType{base: 'B', z: true}
+	case "sha256", "hash256", "ripemd160", "hash160":
+		n.typ = msType{base: 'B', o: true, n: true, d: true, u: true}
+	case "multi", "multi_a":
+		limit := maxMultisigKeys
+		if n.frag == "multi_a" {
+			limit = maxMultisigKeysTaproot
+		}
+		if len(n.keys) > limit {
+			return fmt.Errorf("at most %d keys are allowed", limit)
+		}
+		if n.k < 1 || n.k > int64(len(n.keys)) {
+			return fmt.Errorf("threshold %d out of range for %d keys", n.k, len(n.keys))
+		}
+		n.typ = msType{base: 'B', n: n.frag == "multi", d: true, u: true}
+	case "a":
+		if x.base != 'B' {
+			return fmt.Errorf("a: requires a B expression")
+		}
+		n.typ = msType{base: 'W', d: x.d, u: x.u}
+	case "s":
+		if x.base != 'B' || !x.o {
+			return fmt.Errorf("s: requires a Bo expression")
+		}
+		n.typ = msType{base: 'W', d: x.d, u: x.u}
+	case "c":
+		if x.base != 'K' {
+			return fmt.Errorf("c: requires a K expression")
+		}
+		n.typ = msType{base: 'B', o: x.o, n: x.n, d: x.d, u: true}
+	case "d":
+		if x.base != 'V' || !x.z {
+			return fmt.Errorf("d: requires a Vz expression")
+		}
+		n.typ = msType{base: 'B', o: true, n: true, d: true, u: ctx == contextTapscript}
+	case "v":
+		if x.base != 'B' {
+			return fmt.Errorf("v: requires a B expression")
+		}
+		n.typ = msType{base: 'V', z: x.z, o: x.o, n: x.n}
+	case "j":
+		if x.base != 'B' || !x.n {
+			return fmt.Errorf("j: requires a Bn expression")
+		}
+		n.typ = msType{base: 'B', o: x.o, n: true, d: true, u: x.u}
+	case "n":
+		if x.base != 'B' {
+			return fmt.Errorf("n: requires a B expression")
+		}
+		n.typ = msType{base: 'B', z: x.z, o: x.o, n: x.n, d: x.d, u: true}
+	case "and_v":
+		if x.base != 'V' || !isBKV(y) {
+			return fmt.Errorf("requires a V and a B, K or V expression")
+		}
+		n.typ = msType{base: y.base, z: x.z && y.z, o: (x.z && y.o) || (x.o && y.z),
+			n: x.n || (x.z && y.n), u: y.u}
+	case "and_b":
+		if x.base != 'B' || y.base != 'W' {
+			return fmt.Errorf("requires a B and a W expression")
+		}
+		n.typ = msType{base: 'B', z: x.z && y.z, o: (x.z && y.o) || (x.o && y.z),
+			n: x.n || (x.z && y.n), d: x.d && y.d, u: true}
+	case "andor":
+		if x.base != 'B' || !x.d || !x.u || !isBKV(y) || y.base != z.base {
+			return fmt.Errorf("requires a Bdu expression and two B, K or V expressions of the same type")
+		}
+		n.typ = msType{base: y.base, z: x.z && y.z && z.z,
+			o: (x.z && y.o && z.o) || (x.o && y.z && z.z), d: z.d, u: y.u && z.u}
+	case "or_b":
+		if x.base != 'B' || !x.d || y.base != 'W' || !y.d {
+			return fmt.Errorf("requires a Bd and a Wd expression")
+		}
+		n.typ = msType{base: 'B', z: x.z && y.z, o: (x.z && y.o) || (x.o && y.z), d: true, u: true}
+	case "or_c":
+		if x.base != 'B' || !x.d || !x.u || y.base != 'V' {
+			return fmt.Errorf("requires a Bdu and a V expression")
+		}
+		n.typ = msType{base: 'V', z: x.z && y.z, o: x.o && y.z}
+	case "or_d":
+		if x.base != 'B' || !x.d || !x.u || y.base != 'B' {
+			return fmt.Errorf("requires a Bdu and a B expression")
+		}
+		n.typ = msType{base: 'B', z: x.z && y.z, o: x.o && y.z, d: y.d, u: y.u}
+	case "or_i":
+		if !isBKV(x) || x.base != y.base {
+			return fmt.Errorf("requires two B, K or V expressions of the same type")
+		}
+		n.typ = msType{base: x.base, o: x.z && y.z, d: x.d || y.d, u: x.u && y.u}
+	case "thresh":
+		if n.k < 1 || n.k > int64(len(n.subs)) {
+			return fmt.Errorf("threshold %d out of range for %d sub expressions", n.k, len(n.subs))
+		}
+		allZ, oCount := true, 0
+		for i, sub := range n.subs {
+			want := byte('W')
+			if i == 0 {
+				want = 'B'
+			}
+			if sub.typ.base != want || !sub.typ.d || !sub.typ.u {
+				return fmt.Errorf("sub expression %d must be %cdu", i+1, want)
+			}
+			if !sub.typ.z {
+				allZ = false
+				if sub.typ.o {
+					oCount++
+				} else {
+					oCount = len(n.subs)
+				}
+			}
+		}
+		n.typ = msType{base: 'B', z: allZ, o: oCount == 1, d: true, u: true}
+	default:
+		return fmt.Errorf("unknown fragment")
+	}
+	return nil
+}
+
+// allKeys returns the keys of the expression and its sub expressions
+func (n *msNode) allKeys() []*keyExpr {
+	keys := n.keys
+	for _, sub := range n.subs {
+		keys = append(keys, sub.allKeys()...)
+	}
+	return keys
+}
+
+// msOp is a single opcode, data push or number push of a compiled script
+type msOp struct {
+	opcode byte
+	data   []byte
+	number *int64
+}
+
+func op(opcode byte) msOp   { return msOp{opcode: opcode} }
+func push(data []byte) msOp { return msOp{data: data} }
+func pushInt(n int64) msOp  { return msOp{number: &n} }
+
+// script compiles the expression with keys derived at index
+func (n *msNode) script(index uint32, ctx scriptContext) ([]byte, error) {
+	ops, err := n.compile(index, ctx)
+	if err != nil {
+		return nil, err
+	}
+	builder := txscript.NewScriptBuilder()
+	for _, o := range ops {
+		switch {
+		case o.number != nil:
+			builder.AddInt64(*o.number)
+		case o.data != nil:
+			builder.AddData(o.data)
+		default:
+			builder.AddOp(o.opcode)
+		}
+	}
+	return builder.Script()
+}
+
+func (n *msNode) compile(index uint32, ctx scriptContext) ([]msOp, error) {
+	subs := make([][]msOp, len(n.subs))
+	for i, sub := range n.subs {
+		ops, err := sub.compile(index, ctx)
+		if err != nil {
+			return nil, err
+		}
+		subs[i] = ops
+	}
+	keys := make([][]byte, len(n.keys))
+	for i, key := range n.keys {
+		serialized, err := key.serialize(index, ctx)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = serialized
+	}
+	if n.sorted {
+		sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	}
+	concat := func(parts ...interface{}) []msOp {
+		var ops []msOp
+		for _, part := range parts {
+			switch p := part.(type) {
+			case msOp:
+				ops = append(ops, p)
+			case []msOp:
+				ops = append(ops, p...)
+			}
+		}
+		return ops
+	}
+
+	switch n.frag {
+	case "0":
+		return []msOp{op(txscript.OP_0)}, nil
+	case "1":
+		return []msOp{op(txscript.OP_1)}, nil
+	case "pk_k":
+		return []msOp{push(keys[0])}, nil
+	case "pk_h":
+		return []msOp{op(txscript.OP_DUP), op(txscript.OP_HASH160), push(btcutil.Hash160(keys[0])),
+			op(txscript.OP_EQUALVERIFY)}, nil
+	case "older":
+		return []msOp{pushInt(n.k), op(txscript.OP_CHECKSEQUENCEVERIFY)}, nil
+	case "after":
+		return []msOp{pushInt(n.k), op(txscript.OP_CHECKLOCKTIMEVERIFY)}, nil
+	case "sha256", "hash256", "ripemd160", "hash160":
+		hashOp := map[string]byte{
+			"sha256":    txscript.OP_SHA256,
+			"hash256":   txscript.OP_HASH256,
+			"ripemd160": txscript.OP_RIPEMD160,
+			"hash160":   txscript.OP_HASH160,
+		}[n.frag]
+		return []msOp{op(txscript.OP_SIZE), pushInt(32), op(txscript.OP_EQUALVERIFY), op(hashOp),
+			push(n.hash), op(txscript.OP_EQUAL)}, nil
+	case "multi":
+		ops := []msOp{pushInt(n.k)}
+		for _, key := range keys {
+			ops = append(ops, push(key))
+		}
+		return append(ops, pushInt(int64(len(keys))), op(txscript.OP_CHECKMULTISIG)), nil
+	case "multi_a":
+		ops := []msOp{push(keys[0]), op(txscript.OP_CHECKSIG)}
+		for _, key := range keys[1:] {
+			ops = append(ops, push(key), op(txscript.OP_CHECKSIGADD))
+		}
+		return append(ops, pushInt(n.k), op(txscript.OP_NUMEQUAL)), nil
+	case "a":
+		return concat(op(txscript.OP_TOALTSTACK), subs[0], op(txscript.OP_FROMALTSTACK)), nil
+	case "s":
+		return concat(op(txscript.OP_SWAP), subs[0]), nil
+	case "c":
+		return concat(subs[0], op(txscript.OP_CHECKSIG)), nil
+	case "d":
+		return concat(op(txscript.OP_DUP), op(txscript.OP_IF), subs[0], op(txscript.OP_ENDIF)), nil
+	case "v":
+		return verify(subs[0]), nil
+	case "j":
+		return concat(op(txscript.OP_SIZE), op(txscript.OP_0NOTEQUAL), op(txscript.OP_IF), subs[0],
+			op(txscript.OP_ENDIF)), nil
+	case "n":
+		return concat(subs[0], op(txscript.OP_0NOTEQUAL)), nil
+	case "and_v":
+		return concat(subs[0], subs[1]), nil
+	case "and_b":
+		return concat(subs[0], subs[1], op(txscript.OP_BOOLAND)), nil
+	case "andor":
+		return concat(subs[0], op(txscript.OP_NOTIF), subs[2], op(txscript.OP_ELSE), subs[1],
+			op(txscript.OP_ENDIF)), nil
+	case "or_b":
+		return concat(subs[0], subs[1], op(txscript.OP_BOOLOR)), nil
+	case "or_c":
+		return concat(subs[0], op(txscript.OP_NOTIF), subs[1], op(txscript.OP_ENDIF)), nil
+	case "or_d":
+		return concat(subs[0], op(txscript.OP_IFDUP), op(txscript.OP_NOTIF), subs[1],
+			op(txscript.OP_ENDIF)), nil
+	case "or_i":
+		return concat(op(txscript.OP_IF), subs[0], op(txscript.OP_ELSE), subs[1], op(txscript.OP_ENDIF)), nil
+	case "thresh":
+		ops := subs[0]
+		for _, sub := range subs[1:] {
+			ops = concat(ops, sub, op(txscript.OP_ADD))
+		}
+		return concat(ops, pushInt(n.k), op(txscript.OP_EQUAL)), nil
+	}
+	return nil, fmt.Errorf("unknown miniscript fragment %s", n.frag)
+}
+
+// verify appends OP_VERIFY, merging it into the last opcode when it has a
+// VERIFY variant
+func verify(ops []msOp) []msOp {
+	verifyOps := map[byte]byte{
+		txscript.OP_EQUAL:         txscript.OP_EQUALVERIFY,
+		txscript.OP_CHECKSIG:      txscript.OP_CHECKSIGVERIFY,
+		txscript.OP_CHECKMULTISIG: txscript.OP_CHECKMULTISIGVERIFY,
+		txscript.OP_NUMEQUAL:      txscript.OP_NUMEQUALVERIFY,
+	}
+	if len(ops) > 0 {
+		last := ops[len(ops)-1]
+		if verifyOp, ok := verifyOps[last.opcode]; ok && last.data == nil && last.number == nil {
+			return append(append([]msOp{}, ops[:len(ops)-1]...), op(verifyOp))
+		}
+	}
+	return append(append([]msOp{}, ops...), op(txscript.OP_VERIFY))
+}
+
+// splitCall splits "name(arg1,arg2)" into its name and top level arguments.
+// Expressions without parentheses return nil arguments.
+func splitCall(s string) (string, []string, error) {
+	open := strings.Index(s, "(")
+	if open < 0 {
+		return s, nil, nil
+	}
+	if !strings.HasSuffix(s, ")") {
+		return "", nil, fmt.Errorf("missing ')' in %s", s)
+	}
+	args, err := splitArgs(s[open+1 : len(s)-1])
+	if err != nil {
+		return "", nil, err
+	}
+	return s[:open], args, nil
+}
+
+// splitArgs splits on the commas that are not nested in (), [] or {}
+func splitArgs(s string) ([]string, error) {
+	var (
+		args  []string
+		depth int
+		start int
+	)
+	for i, r := range s {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced brackets in %s", s)
+			}
+		case ',':
+			if depth == 0 {
+				args = append(args, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced brackets in %s", s)
+	}
+	return append(args, s[start:]), nil
+}
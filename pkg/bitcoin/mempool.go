@@ -0,0 +1,257 @@
+package bitcoin
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Bitcoin Core ZMQ notification topics
+const (
+	zmqTopicRawTx     = "rawtx"
+	zmqTopicHashBlock = "hashblock"
+)
+
+// MempoolEventType tells whether an event is a transaction or a new block
+type MempoolEventType string
+
+const (
+	MempoolEventTx    MempoolEventType = "tx"
+	MempoolEventBlock MempoolEventType = "block"
+)
+
+// MempoolEvent is a matching transaction entering the mempool, or a new
+// chain tip
+type MempoolEvent struct {
+	Type      MempoolEventType
+	Tx        *wire.MsgTx
+	BlockHash string
+}
+
+// MempoolFilter matches transactions paying to any of its output scripts.
+// An empty filter matches every transaction.
+type MempoolFilter struct {
+	scripts [][]byte
+}
+
+// NewMempoolFilter creates a filter for outputs paying to addresses or to
+// hex encoded scripts
+func NewMempoolFilter(addresses, scripts []string, net *chaincfg.Params) (*MempoolFilter, error) {
+	filter := &MempoolFilter{}
+	for _, address := range addresses {
+		script, err := AddressToScript(address, net)
+		if err != nil {
+			return nil, err
+		}
+		filter.scripts = append(filter.scripts, script)
+	}
+	for _, s := range scripts {
+		script, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid script %s: %v", s, err)
+		}
+		filter.scripts = append(filter.scripts, script)
+	}
+	return filter, nil
+}
+
+// Match reports whether tx has an output paying to one of the filter scripts
+func (f *MempoolFilter) Match(tx *wire.MsgTx) bool {
+	if len(f.scripts) == 0 {
+		return true
+	}
+	for _, txOut := range tx.TxOut {
+		for _, script := range f.scripts {
+			if bytes.Equal(txOut.PkScript, script) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MempoolWatcher streams the transactions entering a node's mempool, either
+// from its ZMQ rawtx/hashblock notifications or by polling getrawmempool
+type MempoolWatcher struct {
+	filter      *MempoolFilter
+	zmqEndpoint string
+	client      *RPCClient
+	interval    time.Duration
+	events      chan MempoolEvent
+	errs        chan error
+	cancel      context.CancelFunc
+}
+
+// NewZMQMempoolWatcher creates a watcher for the notifications published at
+// endpoint by a node running with -zmqpubrawtx and -zmqpubhashblock
+func NewZMQMempoolWatcher(endpoint string, filter *MempoolFilter) *MempoolWatcher {
+	return &MempoolWatcher{
+		filter:      filter,
+		zmqEndpoint: endpoint,
+		events:      make(chan MempoolEvent),
+		errs:        make(chan error, 1),
+	}
+}
+
+// NewPollingMempoolWatcher creates a watcher polling the mempool of the node
+// behind client every interval, which must be positive
+func NewPollingMempoolWatcher(client *RPCClient, interval time.Duration, filter *MempoolFilter) (*MempoolWatcher, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("poll interval must be positive, got %v", interval)
+	}
+	return &MempoolWatcher{
+		filter:   filter,
+		client:   client,
+		interval: interval,
+		events:   make(chan MempoolEvent),
+		errs:     make(chan error, 1),
+	}, nil
+}
+
+// Events returns the channel receiving matched transactions and new blocks
+func (w *MempoolWatcher) Events() <-chan MempoolEvent {
+	return w.events
+}
+
+// Err returns the channel receiving the error that stopped the watcher
+func (w *MempoolWatcher) Err() <-chan error {
+	return w.errs
+}
+
+// Start connects to the node and begins watching. Transactions already in
+// the mempool are not reported.
+func (w *MempoolWatcher) Start(ctx context.Context) error {
+	ctx, w.cancel = context.WithCancel(ctx)
+	if w.zmqEndpoint != "" {
+		sub, err := dialZMQ(ctx, w.zmqEndpoint, zmqTopicRawTx, zmqTopicHashBlock)
+		if err != nil {
+			w.cancel()
+			return err
+		}
+		go w.watchZMQ(ctx, sub)
+		return nil
+	}
+
+	tip, err := w.client.GetBestBlockHash()
+	if err != nil {
+		w.cancel()
+		return err
+	}
+	txids, err := w.client.GetRawMempool()
+	if err != nil {
+		w.cancel()
+		return err
+	}
+	seen := make(map[string]bool, len(txids))
+	for _, txid := range txids {
+		seen[txid] = true
+	}
+	go w.poll(ctx, tip, seen)
+	return nil
+}
+
+// Stop stops watching
+func (w *MempoolWatcher) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+}
+
+func (w *MempoolWatcher) watchZMQ(ctx context.Context, sub *zmqSubscriber) {
+	// closing the connection unblocks readMessage
+	go func() {
+		<-ctx.Done()
+		sub.close()
+	}()
+	for {
+		frames, err := sub.readMessage()
+		if err != nil {
+			if ctx.Err() == nil {
+				w.errs <- fmt.Errorf("failed to read zmq notification: %v", err)
+			}
+			return
+		}
+		// notifications are [topic, body, sequence number]
+		if len(frames) < 2 {
+			continue
+		}
+		var event MempoolEvent
+		switch string(frames[0]) {
+		case zmqTopicRawTx:
+			tx, err := DecodeBitcoinRawTx(hex.EncodeToString(frames[1]))
+			if err != nil || !w.filter.Match(tx) {
+				continue
+			}
+			event = MempoolEvent{Type: MempoolEventTx, Tx: tx}
+		case zmqTopicHashBlock:
+			event = MempoolEvent{Type: MempoolEventBlock, BlockHash: hex.EncodeToString(frames[1])}
+		default:
+			continue
+		}
+		if !w.emit(ctx, event) {
+			return
+		}
+	}
+}
+
+// poll reports the transactions added to the mempool since the previous
+// poll, and the chain tip when it changes
+func (w *MempoolWatcher) poll(ctx context.Context, tip string, seen map[string]bool) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		hash, err := w.client.GetBestBlockHash()
+		if err != nil {
+			w.errs <- err
+			return
+		}
+		if hash != tip {
+			tip = hash
+			if !w.emit(ctx, MempoolEvent{Type: MempoolEventBlock, BlockHash: hash}) {
+				return
+			}
+		}
+
+		txids, err := w.client.GetRawMempool()
+		if err != nil {
+			w.errs <- err
+			return
+		}
+		current := make(map[string]bool, len(txids))
+		for _, txid := range txids {
+			current[txid] = true
+			if seen[txid] {
+				continue
+			}
+			// the transaction may have left the mempool since getrawmempool
+			_, tx, err := w.client.GetRawTransaction(txid, "")
+			if err != nil || !w.filter.Match(tx) {
+				continue
+			}
+			if !w.emit(ctx, MempoolEvent{Type: MempoolEventTx, Tx: tx}) {
+				return
+			}
+		}
+		seen = current
+	}
+}
+
+func (w *MempoolWatcher) emit(ctx context.Context, event MempoolEvent) bool {
+	select {
+	case w.events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,157 @@
+package bitcoin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	watchedAddress = "bcrt1pch9fuehpznec00zddnle9cwazlms4axzjp5erumk62unsvmecv6s67jtrc"
+	tipHash        = "3f3887e9b0dbf9f3a7a5a1a1b1b4a2c0cfb2e6ba2dde4a5c1c49e12ab3ad2d6c"
+)
+
+// recordedRawTx returns the recorded transaction paying to watchedAddress
+func recordedRawTx(t *testing.T) string {
+	var resp struct{ Result string }
+	require.NoError(t, json.Unmarshal([]byte(recordedRPC["getrawtransaction"]), &resp))
+	return resp.Result
+}
+
+func TestMempoolFilter(t *testing.T) {
+	tx, err := DecodeBitcoinRawTx(recordedRawTx(t))
+	require.NoError(t, err)
+
+	filter, err := NewMempoolFilter([]string{watchedAddress}, nil, &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	require.True(t, filter.Match(tx))
+	filter, err = NewMempoolFilter(nil, []string{"0014751e76e8199196d454941c45d1b3a323f1433bd6"}, &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	require.False(t, filter.Match(tx))
+	empty, err := NewMempoolFilter(nil, nil, &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	require.True(t, empty.Match(tx))
+
+	_, err = NewMempoolFilter([]string{watchedAddress}, nil, &chaincfg.MainNetParams)
+	require.Error(t, err)
+}
+
+func TestZMQMempoolWatcher(t *testing.T) {
+	rawTx, err := hex.DecodeString(recordedRawTx(t))
+	require.NoError(t, err)
+	other := wire.NewMsgTx(2)
+	other.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+	var otherTx bytes.Buffer
+	require.NoError(t, other.Serialize(&otherTx))
+	blockHash, err := hex.DecodeString(tipHash)
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	// the publisher answers the handshake, checks the subscriptions and
+	// sends a non matching tx, a matching tx and a block
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		pub := &zmqSubscriber{conn: conn, reader: bufio.NewReader(conn)}
+		if pub.handshake("PUB") != nil {
+			return
+		}
+		for _, topic := range []string{zmqTopicRawTx, zmqTopicHashBlock} {
+			frames, err := pub.readMessage()
+			if err != nil || string(frames[0]) != "\x01"+topic {
+				return
+			}
+		}
+		for i, message := range [][]byte{otherTx.Bytes(), rawTx, blockHash} {
+			topic := zmqTopicRawTx
+			if i == 2 {
+				topic = zmqTopicHashBlock
+			}
+			pub.writeFrame(zmqFlagMore, []byte(topic))
+			pub.writeFrame(zmqFlagMore, message)
+			pub.writeFrame(0, []byte{byte(i), 0, 0, 0})
+		}
+		time.Sleep(time.Second)
+	}()
+
+	filter, err := NewMempoolFilter([]string{watchedAddress}, nil, &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	watcher := NewZMQMempoolWatcher("tcp://"+listener.Addr().String(), filter)
+	require.NoError(t, watcher.Start(context.Background()))
+	defer watcher.Stop()
+
+	event := <-watcher.Events()
+	require.Equal(t, MempoolEventTx, event.Type)
+	require.Len(t, event.Tx.TxOut, 2)
+	event = <-watcher.Events()
+	require.Equal(t, MempoolEvent{Type: MempoolEventBlock, BlockHash: tipHash}, event)
+
+	require.Error(t, NewZMQMempoolWatcher("ipc:///tmp/bitcoind", filter).Start(context.Background()))
+}
+
+func TestPollingMempoolWatcher(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		responses = map[string]string{
+			"getbestblockhash":  `{"result":"` + tipHash + `","error":null,"id":1}`,
+			"getrawmempool":     `{"result":["aa"],"error":null,"id":1}`,
+			"getrawtransaction": recordedRPC["getrawtransaction"],
+		}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write([]byte(responses[req.Method]))
+	}))
+	defer server.Close()
+
+	client, err := NewRPCClient(RPCConfig{URL: server.URL})
+	require.NoError(t, err)
+	filter, err := NewMempoolFilter([]string{watchedAddress}, nil, &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	for _, interval := range []time.Duration{0, -time.Second} {
+		_, err := NewPollingMempoolWatcher(client, interval, filter)
+		require.Error(t, err, interval)
+	}
+	watcher, err := NewPollingMempoolWatcher(client, 10*time.Millisecond, filter)
+	require.NoError(t, err)
+	require.NoError(t, watcher.Start(context.Background()))
+	defer watcher.Stop()
+
+	// "aa" was in the mempool before starting and is not reported
+	mu.Lock()
+	responses["getrawmempool"] = `{"result":["aa","bb"],"error":null,"id":1}`
+	mu.Unlock()
+	event := <-watcher.Events()
+	require.Equal(t, MempoolEventTx, event.Type)
+
+	mu.Lock()
+	responses["getbestblockhash"] = `{"result":"00","error":null,"id":1}`
+	mu.Unlock()
+	event = <-watcher.Events()
+	require.Equal(t, MempoolEvent{Type: MempoolEventBlock, BlockHash: "00"}, event)
+
+	mu.Lock()
+	responses["getrawmempool"] = `{"result":null,"error":{"code":-28,"message":"Loading block index..."},"id":1}`
+	mu.Unlock()
+	require.EqualError(t, <-watcher.Err(), "rpc error -28: Loading block index...")
+}
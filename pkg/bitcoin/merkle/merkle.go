@@ -0,0 +1,94 @@
+// Package merkle computes the merkle tree of a block's transactions, merkle
+// branches proving the inclusion of a transaction, and the partial merkle
+// trees of BIP37 merkleblock messages returned by gettxoutproof
+package merkle
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// hashPair returns the parent node of left and right
+func hashPair(left, right *chainhash.Hash) chainhash.Hash {
+	var b [2 * chainhash.HashSize]byte
+	copy(b[:chainhash.HashSize], left[:])
+	copy(b[chainhash.HashSize:], right[:])
+	return chainhash.DoubleHashH(b[:])
+}
+
+// Root computes the merkle root of txids. Levels with an odd number of nodes
+// duplicate their last node, so a list ending with a repeated pair of txids
+// has the same root as the list without them (CVE-2012-2459). mutated
+// reports such duplicates, which make a block invalid.
+func Root(txids []chainhash.Hash) (root chainhash.Hash, mutated bool) {
+	if len(txids) == 0 {
+		return root, false
+	}
+	level := append([]chainhash.Hash(nil), txids...)
+	for len(level) > 1 {
+		for i := 0; i+1 < len(level); i += 2 {
+			if level[i] == level[i+1] {
+				mutated = true
+			}
+		}
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		next := make([]chainhash.Hash, len(level)/2)
+		for i := range next {
+			next[i] = hashPair(&level[2*i], &level[2*i+1])
+		}
+		level = next
+	}
+	return level[0], mutated
+}
+
+// Branch returns the sibling hashes on the path from the txid at index to
+// the merkle root, from the bottom up
+func Branch(txids []chainhash.Hash, index int) ([]chainhash.Hash, error) {
+	if index < 0 || index >= len(txids) {
+		return nil, fmt.Errorf("transaction index %d out of range, the block has %d transactions", index, len(txids))
+	}
+	var branch []chainhash.Hash
+	level := append([]chainhash.Hash(nil), txids...)
+	for len(level) > 1 {
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		branch = append(branch, level[index^1])
+		next := make([]chainhash.Hash, len(level)/2)
+		for i := range next {
+			next[i] = hashPair(&level[2*i], &level[2*i+1])
+		}
+		level = next
+		index >>= 1
+	}
+	return branch, nil
+}
+
+// BranchRoot returns the merkle root committed to by the branch of the txid
+// at index
+func BranchRoot(txid chainhash.Hash, branch []chainhash.Hash, index int) chainhash.Hash {
+	node := txid
+	for i := range branch {
+		if index&1 == 1 {
+			node = hashPair(&branch[i], &node)
+		} else {
+			node = hashPair(&node, &branch[i])
+		}
+		index >>= 1
+	}
+	return node
+}
+
+// VerifyBranch checks that the branch of the txid at index leads to root
+func VerifyBranch(txid chainhash.Hash, branch []chainhash.Hash, index int, root chainhash.Hash) error {
+	if index < 0 || index >= 1<<len(branch) {
+		return fmt.Errorf("transaction index %d out of range for a branch of %d hashes", index, len(branch))
+	}
+	if computed := BranchRoot(txid, branch, index); computed != root {
+		return fmt.Errorf("merkle branch leads to %s, expected root %s", computed, root)
+	}
+	return nil
+}
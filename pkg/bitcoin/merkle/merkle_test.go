@@ -0,0 +1,143 @@
+package merkle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin/pow"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// block 100000 of mainnet
+var (
+	block100000Txids = []string{
+		"8c14f0db3df150123e6f3dbbf30f8b955a8249b62ac1d1ff16284aefa3d06d87",
+		"fff2525b8931402dd09222c50775608f75787bd2b87e56995a7bdd30f79702c4",
+		"6359f0868171b1d194cbee1af2f16ea598ae8fad666d9b012c8ed2b79a236ec4",
+		"e9a66845e05d5abc0ad04ec80f774a7e585c6e8db975962d069a522137b80c1d",
+	}
+	block100000Root = "f3e94742aca4b5ef85488dc37c06c3282295ffec960994b2c0d5ac2a25a95766"
+	block100000Hash = "000000000003ba27aa200b1cecaad478d2b00432346c3f1f3986da1afd33e506"
+)
+
+func parseHashes(t *testing.T, hashes ...string) []chainhash.Hash {
+	parsed := make([]chainhash.Hash, len(hashes))
+	for i, hash := range hashes {
+		h, err := chainhash.NewHashFromStr(hash)
+		require.NoError(t, err)
+		parsed[i] = *h
+	}
+	return parsed
+}
+
+func block100000Header(t *testing.T) wire.BlockHeader {
+	return wire.BlockHeader{
+		Version:    1,
+		PrevBlock:  parseHashes(t, "000000000002d01c1fccc21636b607dfd930d31d01c3a62104612a1719011250")[0],
+		MerkleRoot: parseHashes(t, block100000Root)[0],
+		Timestamp:  time.Unix(1293623863, 0),
+		Bits:       0x1b04864c,
+		Nonce:      274148111,
+	}
+}
+
+func TestRootAndBranch(t *testing.T) {
+	txids := parseHashes(t, block100000Txids...)
+	root, mutated := Root(txids)
+	require.Equal(t, block100000Root, root.String())
+	require.False(t, mutated)
+	header := block100000Header(t)
+	require.Equal(t, block100000Hash, header.BlockHash().String())
+
+	for i, txid := range txids {
+		branch, err := Branch(txids, i)
+		require.NoError(t, err)
+		require.Len(t, branch, 2)
+		require.NoError(t, VerifyBranch(txid, branch, i, root))
+		require.Error(t, VerifyBranch(txid, branch, i^1, root))
+	}
+	_, err := Branch(txids, 4)
+	require.Error(t, err)
+
+	// a single transaction is its own root
+	root, mutated = Root(txids[:1])
+	require.Equal(t, txids[0], root)
+	require.False(t, mutated)
+
+	// duplicating the last transactions of an odd list keeps the root
+	odd, _ := Root(txids[:3])
+	duplicated, mutated := Root(append(txids[:3:3], txids[2]))
+	require.Equal(t, odd, duplicated)
+	require.True(t, mutated)
+	branch, err := Branch(txids[:3], 2)
+	require.NoError(t, err)
+	require.Equal(t, txids[2], branch[0])
+	require.NoError(t, VerifyBranch(txids[2], branch, 2, odd))
+}
+
+func TestMerkleBlock(t *testing.T) {
+	// gettxoutproof of the coinbase of the genesis block
+	genesis := chaincfg.MainNetParams.GenesisBlock
+	proof := "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c01000000013ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a0101"
+	mb, err := DecodeMerkleBlock(proof)
+	require.NoError(t, err)
+	matches, err := VerifyMerkleBlock(mb)
+	require.NoError(t, err)
+	require.Equal(t, []Match{{Txid: genesis.Transactions[0].TxHash(), Index: 0}}, matches)
+	built, err := NewMerkleBlock(genesis.Header, []chainhash.Hash{genesis.Transactions[0].TxHash()}, []int{0})
+	require.NoError(t, err)
+	encoded, err := EncodeMerkleBlock(built)
+	require.NoError(t, err)
+	require.Equal(t, proof, encoded)
+
+	_, err = DecodeMerkleBlock(proof + "00")
+	require.Error(t, err)
+
+	txids := parseHashes(t, block100000Txids...)
+	header := block100000Header(t)
+	require.NoError(t, pow.CheckProofOfWork(&header, &chaincfg.MainNetParams))
+	for _, indexes := range [][]int{{}, {0}, {2}, {1, 3}, {0, 1, 2, 3}} {
+		mb, err := NewMerkleBlock(header, txids, indexes)
+		require.NoError(t, err)
+		matches, err := VerifyMerkleBlock(mb)
+		require.NoError(t, err)
+		require.Len(t, matches, len(indexes))
+		for i, index := range indexes {
+			require.Equal(t, Match{Txid: txids[index], Index: index}, matches[i])
+		}
+	}
+
+	mb, err = NewMerkleBlock(header, txids, []int{1})
+	require.NoError(t, err)
+	mb.Hashes[0], mb.Hashes[1] = mb.Hashes[1], mb.Hashes[0]
+	_, err = VerifyMerkleBlock(mb)
+	require.ErrorContains(t, err, "expected the header merkle root")
+	mb.Hashes = mb.Hashes[:2]
+	_, err = VerifyMerkleBlock(mb)
+	require.ErrorContains(t, err, "runs out of hashes")
+
+	_, err = NewMerkleBlock(header, txids[:3], nil)
+	require.ErrorContains(t, err, "does not match")
+}
+
+func TestMerkleBlockDuplicates(t *testing.T) {
+	// a proof for a block of three transactions replayed with the third one
+	// duplicated must be rejected
+	txids := parseHashes(t, block100000Txids...)[:3]
+	root, _ := Root(txids)
+	header := wire.BlockHeader{MerkleRoot: root}
+	duplicated := append(txids[:3:3], txids[2])
+	mb, err := NewMerkleBlock(header, duplicated, []int{3})
+	require.NoError(t, err)
+	_, err = VerifyMerkleBlock(mb)
+	require.ErrorContains(t, err, "identical left and right nodes")
+
+	mb, err = NewMerkleBlock(header, txids, []int{2})
+	require.NoError(t, err)
+	matches, err := VerifyMerkleBlock(mb)
+	require.NoError(t, err)
+	require.Equal(t, []Match{{Txid: txids[2], Index: 2}}, matches)
+}
@@ -0,0 +1,225 @@
+package merkle
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// maxBlockTransactions bounds the transaction count of a merkleblock, the
+// block weight limit over the weight of the smallest transaction
+const maxBlockTransactions = 4000000 / (4 * 60)
+
+// Match is a transaction proven by a merkleblock
+type Match struct {
+	Txid  chainhash.Hash
+	Index int
+}
+
+// DecodeMerkleBlock decodes a hex encoded merkleblock, as returned by
+// gettxoutproof
+func DecodeMerkleBlock(merkleBlockHex string) (*wire.MsgMerkleBlock, error) {
+	b, err := hex.DecodeString(merkleBlockHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid merkleblock hex: %v", err)
+	}
+	var mb wire.MsgMerkleBlock
+	r := bytes.NewReader(b)
+	if err := mb.BtcDecode(r, wire.ProtocolVersion, wire.BaseEncoding); err != nil {
+		return nil, fmt.Errorf("failed to decode merkleblock: %v", err)
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes after merkleblock", r.Len())
+	}
+	return &mb, nil
+}
+
+// EncodeMerkleBlock encodes a merkleblock in hex
+func EncodeMerkleBlock(mb *wire.MsgMerkleBlock) (string, error) {
+	var buf bytes.Buffer
+	if err := mb.BtcEncode(&buf, wire.ProtocolVersion, wire.BaseEncoding); err != nil {
+		return "", fmt.Errorf("failed to encode merkleblock: %v", err)
+	}
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+// NewMerkleBlock builds the merkleblock of a block with header and txids
+// proving the transactions at indexes
+func NewMerkleBlock(header wire.BlockHeader, txids []chainhash.Hash, indexes []int) (*wire.MsgMerkleBlock, error) {
+	if len(txids) == 0 {
+		return nil, fmt.Errorf("no transactions")
+	}
+	if root, _ := Root(txids); root != header.MerkleRoot {
+		return nil, fmt.Errorf("merkle root %s of the transactions does not match the header merkle root %s", root, header.MerkleRoot)
+	}
+	matched := make([]bool, len(txids))
+	for _, index := range indexes {
+		if index < 0 || index >= len(txids) {
+			return nil, fmt.Errorf("transaction index %d out of range, the block has %d transactions", index, len(txids))
+		}
+		matched[index] = true
+	}
+
+	tree := &partialTree{txs: len(txids)}
+	tree.build(tree.height(), 0, txids, matched)
+	mb := &wire.MsgMerkleBlock{
+		Header:       header,
+		Transactions: uint32(len(txids)),
+		Flags:        make([]byte, (len(tree.bits)+7)/8),
+	}
+	for i := range tree.hashes {
+		mb.Hashes = append(mb.Hashes, &tree.hashes[i])
+	}
+	for i, bit := range tree.bits {
+		if bit {
+			mb.Flags[i/8] |= 1 << (i % 8)
+		}
+	}
+	return mb, nil
+}
+
+// ExtractMatches walks the partial merkle tree of mb, returning its root and
+// the transactions it proves
+func ExtractMatches(mb *wire.MsgMerkleBlock) (chainhash.Hash, []Match, error) {
+	var root chainhash.Hash
+	if mb.Transactions == 0 {
+		return root, nil, fmt.Errorf("merkleblock without transactions")
+	}
+	if mb.Transactions > maxBlockTransactions {
+		return root, nil, fmt.Errorf("merkleblock with too many transactions %d", mb.Transactions)
+	}
+	if len(mb.Hashes) > int(mb.Transactions) {
+		return root, nil, fmt.Errorf("merkleblock has more hashes than transactions")
+	}
+	if len(mb.Flags)*8 < len(mb.Hashes) {
+		return root, nil, fmt.Errorf("merkleblock has fewer flag bits than hashes")
+	}
+
+	tree := &partialTree{txs: int(mb.Transactions)}
+	for _, hash := range mb.Hashes {
+		tree.hashes = append(tree.hashes, *hash)
+	}
+	for i := 0; i < len(mb.Flags)*8; i++ {
+		tree.bits = append(tree.bits, mb.Flags[i/8]&(1<<(i%8)) != 0)
+	}
+	root, err := tree.extract(tree.height(), 0)
+	if err != nil {
+		return root, nil, err
+	}
+	// every flag byte and hash must be consumed
+	if (tree.bitsUsed+7)/8 != len(mb.Flags) {
+		return root, nil, fmt.Errorf("merkleblock has unused flag bytes")
+	}
+	if tree.hashesUsed != len(tree.hashes) {
+		return root, nil, fmt.Errorf("merkleblock has unused hashes")
+	}
+	return root, tree.matches, nil
+}
+
+// VerifyMerkleBlock checks that the partial merkle tree of mb leads to the
+// merkle root of its header and returns the proven transactions. The proof
+// of work of the header is not checked.
+func VerifyMerkleBlock(mb *wire.MsgMerkleBlock) ([]Match, error) {
+	root, matches, err := ExtractMatches(mb)
+	if err != nil {
+		return nil, err
+	}
+	if root != mb.Header.MerkleRoot {
+		return nil, fmt.Errorf("merkleblock leads to %s, expected the header merkle root %s", root, mb.Header.MerkleRoot)
+	}
+	return matches, nil
+}
+
+// partialTree is the BIP37 partial merkle tree: a depth-first traversal
+// where a flag bit tells whether a node is an ancestor of a matched
+// transaction, and hashes are given for the subtrees not descended into
+type partialTree struct {
+	txs        int
+	bits       []bool
+	hashes     []chainhash.Hash
+	bitsUsed   int
+	hashesUsed int
+	matches    []Match
+}
+
+// width returns the number of nodes at height, 0 being the transactions
+func (t *partialTree) width(height uint) int {
+	return (t.txs + (1 << height) - 1) >> height
+}
+
+// height returns the height of the root
+func (t *partialTree) height() uint {
+	var height uint
+	for t.width(height) > 1 {
+		height++
+	}
+	return height
+}
+
+// hash computes the hash of the node at height and pos from txids
+func (t *partialTree) hash(height uint, pos int, txids []chainhash.Hash) chainhash.Hash {
+	if height == 0 {
+		return txids[pos]
+	}
+	left := t.hash(height-1, pos*2, txids)
+	right := left
+	if pos*2+1 < t.width(height-1) {
+		right = t.hash(height-1, pos*2+1, txids)
+	}
+	return hashPair(&left, &right)
+}
+
+func (t *partialTree) build(height uint, pos int, txids []chainhash.Hash, matched []bool) {
+	parentOfMatch := false
+	for i := pos << height; i < (pos+1)<<height && i < t.txs; i++ {
+		parentOfMatch = parentOfMatch || matched[i]
+	}
+	t.bits = append(t.bits, parentOfMatch)
+	if height == 0 || !parentOfMatch {
+		t.hashes = append(t.hashes, t.hash(height, pos, txids))
+		return
+	}
+	t.build(height-1, pos*2, txids, matched)
+	if pos*2+1 < t.width(height-1) {
+		t.build(height-1, pos*2+1, txids, matched)
+	}
+}
+
+func (t *partialTree) extract(height uint, pos int) (chainhash.Hash, error) {
+	if t.bitsUsed >= len(t.bits) {
+		return chainhash.Hash{}, fmt.Errorf("merkleblock runs out of flag bits")
+	}
+	parentOfMatch := t.bits[t.bitsUsed]
+	t.bitsUsed++
+	if height == 0 || !parentOfMatch {
+		if t.hashesUsed >= len(t.hashes) {
+			return chainhash.Hash{}, fmt.Errorf("merkleblock runs out of hashes")
+		}
+		hash := t.hashes[t.hashesUsed]
+		t.hashesUsed++
+		if height == 0 && parentOfMatch {
+			t.matches = append(t.matches, Match{Txid: hash, Index: pos})
+		}
+		return hash, nil
+	}
+
+	left, err := t.extract(height-1, pos*2)
+	if err != nil {
+		return left, err
+	}
+	right := left
+	if pos*2+1 < t.width(height-1) {
+		if right, err = t.extract(height-1, pos*2+1); err != nil {
+			return right, err
+		}
+		// identical children would allow proving a duplicated
+		// transaction (CVE-2012-2459)
+		if right == left {
+			return right, fmt.Errorf("merkleblock has identical left and right nodes")
+		}
+	}
+	return hashPair(&left, &right), nil
+}
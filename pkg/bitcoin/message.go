@@ -0,0 +1,281 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// MessageFormat is the encoding of a signed message proof
+type MessageFormat string
+
+const (
+	MessageFormatLegacy       MessageFormat = "legacy"        // "Bitcoin Signed Message" compact signature
+	MessageFormatBIP322Simple MessageFormat = "bip322-simple" // BIP322 witness stack
+	MessageFormatBIP322Full   MessageFormat = "bip322-full"   // BIP322 to_sign transaction
+
+	messageMagic     = "Bitcoin Signed Message:\n"
+	bip322MessageTag = "BIP0322-signed-message"
+
+	// compactSigHeader is the first byte of a legacy signature: 27 plus the
+	// recovery id, plus 4 for compressed keys, plus 4 or 8 for the BIP137
+	// P2SH-P2WPKH and P2WPKH variants
+	compactSigHeader = 27
+	compactSigSize   = 65
+)
+
+// LegacyMessageHash returns the double SHA-256 of the message prefixed with
+// "Bitcoin Signed Message:\n", as signed by the legacy format
+func LegacyMessageHash(message string) []byte {
+	var buf bytes.Buffer
+	wire.WriteVarString(&buf, 0, messageMagic)
+	wire.WriteVarString(&buf, 0, message)
+	return chainhash.DoubleHashB(buf.Bytes())
+}
+
+// BIP322MessageHash returns the tagged hash committed to by BIP322 proofs
+func BIP322MessageHash(message string) []byte {
+	hash := chainhash.TaggedHash([]byte(bip322MessageTag), []byte(message))
+	return hash[:]
+}
+
+// SignMessageLegacy signs a message with the compact recoverable format
+// understood by Bitcoin Core's signmessage, returning it base64 encoded.
+// P2SH-P2WPKH and P2WPKH signatures carry the header of their BIP137
+// variant; taproot addresses have no legacy format.
+func SignMessageLegacy(privKey *btcec.PrivateKey, addrType AddressType, message string, compressed bool) (string, error) {
+	signature := ecdsa.SignCompact(privKey, LegacyMessageHash(message), compressed)
+	recoveryID := (signature[0] - compactSigHeader) % 4
+	switch addrType {
+	case AddressTypeP2PKH:
+	case AddressTypeP2SHP2WPKH, AddressTypeP2WPKH:
+		if !compressed {
+			return "", fmt.Errorf("uncompressed keys can only sign for %s addresses", AddressTypeP2PKH)
+		}
+		offset := byte(8)
+		if addrType == AddressTypeP2WPKH {
+			offset = 12
+		}
+		signature[0] = compactSigHeader + offset + recoveryID
+	default:
+		return "", fmt.Errorf("legacy message signatures are only supported for %s, %s and %s addresses",
+			AddressTypeP2PKH, AddressTypeP2SHP2WPKH, AddressTypeP2WPKH)
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// SignMessageBIP322 produces a BIP322 proof that the key controls the
+// P2WPKH or P2TR (key-path) address of the given type
+func SignMessageBIP322(privKey *btcec.PrivateKey, addrType AddressType, message string, format MessageFormat, net *chaincfg.Params) (string, error) {
+	addr, err := AddressFromPublicKey(privKey.PubKey(), addrType, net)
+	if err != nil {
+		return "", err
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return "", err
+	}
+
+	toSpend, err := bip322ToSpend(pkScript, message)
+	if err != nil {
+		return "", err
+	}
+	toSign := bip322ToSign(toSpend)
+	prevOut := toSpend.TxOut[0]
+	fetcher := txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value)
+	sigHashes := txscript.NewTxSigHashes(toSign, fetcher)
+
+	switch addrType {
+	case AddressTypeP2WPKH:
+		toSign.TxIn[0].Witness, err = txscript.WitnessSignature(toSign, sigHashes, 0, prevOut.Value,
+			pkScript, txscript.SigHashAll, privKey, true)
+	case AddressTypeP2TR:
+		toSign.TxIn[0].Witness, err = txscript.TaprootWitnessSignature(toSign, sigHashes, 0, prevOut.Value,
+			pkScript, txscript.SigHashDefault, privKey)
+	default:
+		return "", fmt.Errorf("BIP322 signing is only supported for %s and %s addresses", AddressTypeP2WPKH, AddressTypeP2TR)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to sign message: %v", err)
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case MessageFormatBIP322Simple:
+		err = writeWitness(&buf, toSign.TxIn[0].Witness)
+	case MessageFormatBIP322Full:
+		err = toSign.Serialize(&buf)
+	default:
+		return "", fmt.Errorf("invalid BIP322 format: %s", format)
+	}
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// VerifyMessage checks a signature of a message by an address. Legacy
+// signatures are accepted for P2PKH, P2SH-P2WPKH and P2WPKH addresses;
+// BIP322 simple and full proofs for any address the script interpreter can
+// validate. The detected format is returned along with the result.
+func VerifyMessage(address, message, signature string, net *chaincfg.Params) (bool, MessageFormat, error) {
+	addr, err := btcutil.DecodeAddress(address, net)
+	if err != nil || !addr.IsForNet(net) {
+		return false, "", fmt.Errorf("invalid address %s for %s", address, net.Name)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false, "", fmt.Errorf("signature is not base64: %v", err)
+	}
+
+	if len(sig) == compactSigSize && sig[0] >= compactSigHeader && sig[0] < compactSigHeader+16 {
+		valid, err := verifyMessageLegacy(addr, message, sig, net)
+		return valid, MessageFormatLegacy, err
+	}
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return false, "", err
+	}
+	toSpend, err := bip322ToSpend(pkScript, message)
+	if err != nil {
+		return false, "", err
+	}
+
+	format := MessageFormatBIP322Simple
+	toSign := bip322ToSign(toSpend)
+	if witness, err := readWitness(bytes.NewReader(sig)); err == nil {
+		toSign.TxIn[0].Witness = witness
+	} else {
+		var full wire.MsgTx
+		if err := full.Deserialize(bytes.NewReader(sig)); err != nil {
+			return false, "", fmt.Errorf("signature is neither a BIP322 witness nor a transaction")
+		}
+		format = MessageFormatBIP322Full
+		// The full format may only differ from the template by its
+		// version, locktime, input sequence and signatures, which segwit
+		// and taproot inputs carry in the witness alone
+		toSign.Version = full.Version
+		toSign.LockTime = full.LockTime
+		if len(full.TxIn) != 1 || len(full.TxOut) != 1 ||
+			full.TxIn[0].PreviousOutPoint != toSign.TxIn[0].PreviousOutPoint ||
+			full.TxOut[0].Value != 0 || !bytes.Equal(full.TxOut[0].PkScript, toSign.TxOut[0].PkScript) ||
+			(txscript.IsWitnessProgram(pkScript) && len(full.TxIn[0].SignatureScript) != 0) {
+			return false, format, nil
+		}
+		toSign = &full
+	}
+
+	prevOut := toSpend.TxOut[0]
+	fetcher := txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value)
+	vm, err := txscript.NewEngine(prevOut.PkScript, toSign, 0, txscript.StandardVerifyFlags, nil,
+		txscript.NewTxSigHashes(toSign, fetcher), prevOut.Value, fetcher)
+	if err != nil {
+		return false, format, nil
+	}
+	return vm.Execute() == nil, format, nil
+}
+
+func verifyMessageLegacy(addr btcutil.Address, message string, sig []byte, net *chaincfg.Params) (bool, error) {
+	// Normalize the BIP137 segwit headers to the standard compressed one
+	// understood by RecoverCompact
+	header := sig[0] - compactSigHeader
+	if header >= 8 {
+		sig = append([]byte{compactSigHeader + 4 + header%4}, sig[1:]...)
+	}
+	pubKey, compressed, err := ecdsa.RecoverCompact(sig, LegacyMessageHash(message))
+	if err != nil {
+		return false, nil
+	}
+
+	var candidates []btcutil.Address
+	if compressed {
+		for _, addrType := range []AddressType{AddressTypeP2PKH, AddressTypeP2SHP2WPKH, AddressTypeP2WPKH} {
+			candidate, err := AddressFromPublicKey(pubKey, addrType, net)
+			if err != nil {
+				return false, err
+			}
+			candidates = append(candidates, candidate)
+		}
+	} else {
+		candidate, err := UncompressedP2PKHAddress(pubKey, net)
+		if err != nil {
+			return false, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	for _, candidate := range candidates {
+		if candidate.EncodeAddress() == addr.EncodeAddress() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// bip322ToSpend builds the virtual transaction whose output is spent by
+// BIP322 proofs
+func bip322ToSpend(pkScript []byte, message string) (*wire.MsgTx, error) {
+	sigScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(BIP322MessageHash(message)).Script()
+	if err != nil {
+		return nil, err
+	}
+	tx := wire.NewMsgTx(0)
+	txIn := wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex), sigScript, nil)
+	txIn.Sequence = 0
+	tx.AddTxIn(txIn)
+	tx.AddTxOut(wire.NewTxOut(0, pkScript))
+	return tx, nil
+}
+
+// bip322ToSign builds the unsigned virtual transaction spending toSpend
+func bip322ToSign(toSpend *wire.MsgTx) *wire.MsgTx {
+	hash := toSpend.TxHash()
+	tx := wire.NewMsgTx(0)
+	txIn := wire.NewTxIn(wire.NewOutPoint(&hash, 0), nil, nil)
+	txIn.Sequence = 0
+	tx.AddTxIn(txIn)
+	tx.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+	return tx
+}
+
+func writeWitness(buf *bytes.Buffer, witness wire.TxWitness) error {
+	if err := wire.WriteVarInt(buf, 0, uint64(len(witness))); err != nil {
+		return err
+	}
+	for _, item := range witness {
+		if err := wire.WriteVarBytes(buf, 0, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readWitness parses a serialized witness stack, which must span the
+// whole reader
+func readWitness(r *bytes.Reader) (wire.TxWitness, error) {
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 || count > uint64(r.Len()) {
+		return nil, fmt.Errorf("invalid witness item count %d", count)
+	}
+	witness := make(wire.TxWitness, count)
+	for i := range witness {
+		if witness[i], err = wire.ReadVarBytes(r, 0, wire.MaxMessagePayload, "witness item"); err != nil {
+			return nil, err
+		}
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("trailing data after witness")
+	}
+	return witness, nil
+}
@@ -0,0 +1,120 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors from BIP322
+const (
+	bip322TestKey    = "L3VFeEujGtevx9w18HD1fhRbCH67Az2dpCymeRE1SoPK6XQtaN2k"
+	bip322TestP2WPKH = "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l"
+	bip322TestP2TR   = "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3"
+)
+
+func TestBIP322MessageHash(t *testing.T) {
+	require.Equal(t, "c90c269c4f8fcbe6880f72a721ddfbf1914268a794cbb21cfafee13770ae19f1",
+		hex.EncodeToString(BIP322MessageHash("")))
+	require.Equal(t, "f0eb03b1a75ac6d9847f55c624a99169b5dccba2a31f5b23bea77ba270de0a7a",
+		hex.EncodeToString(BIP322MessageHash("Hello World")))
+}
+
+func TestVerifyMessageBIP322Vectors(t *testing.T) {
+	tests := []struct {
+		address   string
+		message   string
+		signature string
+	}{
+		{bip322TestP2WPKH, "", "AkcwRAIgM2gBAQqvZX15ZiysmKmQpDrG83avLIT492QBzLnQIxYCIBaTpOaD20qRlEylyxFSeEA2ba9YOixpX8z46TSDtS40ASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI="},
+		{bip322TestP2WPKH, "Hello World", "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI="},
+		{bip322TestP2TR, "Hello World", "AUHd69PrJQEv+oKTfZ8l+WROBHuy9HKrbFCJu7U1iK2iiEy1vMU5EfMtjc+VSHM7aU0SDbak5IUZRVno2P5mjSafAQ=="},
+	}
+	for _, tt := range tests {
+		valid, format, err := VerifyMessage(tt.address, tt.message, tt.signature, &chaincfg.MainNetParams)
+		require.NoError(t, err)
+		require.Equal(t, MessageFormatBIP322Simple, format)
+		require.True(t, valid, "%s %q", tt.address, tt.message)
+
+		valid, _, err = VerifyMessage(tt.address, tt.message+"!", tt.signature, &chaincfg.MainNetParams)
+		require.NoError(t, err)
+		require.False(t, valid)
+	}
+}
+
+func TestSignMessageRoundTrip(t *testing.T) {
+	wif, err := btcutil.DecodeWIF(bip322TestKey)
+	require.NoError(t, err)
+	net := &chaincfg.MainNetParams
+
+	for _, addrType := range []AddressType{AddressTypeP2WPKH, AddressTypeP2TR} {
+		addr, err := AddressFromPublicKey(wif.PrivKey.PubKey(), addrType, net)
+		require.NoError(t, err)
+		for _, format := range []MessageFormat{MessageFormatBIP322Simple, MessageFormatBIP322Full} {
+			signature, err := SignMessageBIP322(wif.PrivKey, addrType, "Hello World", format, net)
+			require.NoError(t, err)
+			valid, gotFormat, err := VerifyMessage(addr.EncodeAddress(), "Hello World", signature, net)
+			require.NoError(t, err)
+			require.Equal(t, format, gotFormat)
+			require.True(t, valid, "%s %s", addrType, format)
+		}
+	}
+	_, err = SignMessageBIP322(wif.PrivKey, AddressTypeP2PKH, "Hello World", MessageFormatBIP322Simple, net)
+	require.Error(t, err)
+
+	// BIP137 headers: 31-34 compressed P2PKH, 35-38 P2SH-P2WPKH, 39-42 P2WPKH
+	var signature string
+	for addrType, header := range map[AddressType]byte{AddressTypeP2PKH: 31, AddressTypeP2SHP2WPKH: 35, AddressTypeP2WPKH: 39} {
+		signature, err = SignMessageLegacy(wif.PrivKey, addrType, "Hello World", true)
+		require.NoError(t, err)
+		raw, err := base64.StdEncoding.DecodeString(signature)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, raw[0], header, addrType)
+		require.Less(t, raw[0], header+4, addrType)
+
+		addr, err := AddressFromPublicKey(wif.PrivKey.PubKey(), addrType, net)
+		require.NoError(t, err)
+		valid, format, err := VerifyMessage(addr.EncodeAddress(), "Hello World", signature, net)
+		require.NoError(t, err)
+		require.Equal(t, MessageFormatLegacy, format)
+		require.True(t, valid)
+	}
+	_, err = SignMessageLegacy(wif.PrivKey, AddressTypeP2TR, "Hello World", true)
+	require.Error(t, err)
+	_, err = SignMessageLegacy(wif.PrivKey, AddressTypeP2WPKH, "Hello World", false)
+	require.Error(t, err)
+	valid, _, err := VerifyMessage(bip322TestP2TR, "Hello World", signature, net)
+	require.NoError(t, err)
+	require.False(t, valid)
+}
+
+func TestVerifyMessageBIP322FullScriptSig(t *testing.T) {
+	wif, err := btcutil.DecodeWIF(bip322TestKey)
+	require.NoError(t, err)
+	net := &chaincfg.MainNetParams
+	addr, err := AddressFromPublicKey(wif.PrivKey.PubKey(), AddressTypeP2WPKH, net)
+	require.NoError(t, err)
+
+	signature, err := SignMessageBIP322(wif.PrivKey, AddressTypeP2WPKH, "Hello World", MessageFormatBIP322Full, net)
+	require.NoError(t, err)
+	raw, err := base64.StdEncoding.DecodeString(signature)
+	require.NoError(t, err)
+	var toSign wire.MsgTx
+	require.NoError(t, toSign.Deserialize(bytes.NewReader(raw)))
+
+	// a segwit input with a scriptSig does not match the to_sign template
+	toSign.TxIn[0].SignatureScript = []byte{txscript.OP_TRUE}
+	var buf bytes.Buffer
+	require.NoError(t, toSign.Serialize(&buf))
+	valid, format, err := VerifyMessage(addr.EncodeAddress(), "Hello World", base64.StdEncoding.EncodeToString(buf.Bytes()), net)
+	require.NoError(t, err)
+	require.Equal(t, MessageFormatBIP322Full, format)
+	require.False(t, valid)
+}
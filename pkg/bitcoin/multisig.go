@@ -0,0 +1,270 @@
+package bitcoin
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin/descriptor"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin/taproot"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// MultisigType is the output type of a multisig wallet
+type MultisigType string
+
+const (
+	MultisigP2WSH     MultisigType = "p2wsh"
+	MultisigP2SHP2WSH MultisigType = "p2sh-p2wsh"
+	MultisigP2TR      MultisigType = "p2tr"
+)
+
+// MultisigTypes lists the supported multisig output types
+var MultisigTypes = []MultisigType{MultisigP2WSH, MultisigP2SHP2WSH, MultisigP2TR}
+
+// Multisig is a sorted M-of-N multisig output
+type Multisig struct {
+	Type         MultisigType
+	Required     int
+	Descriptor   string
+	Address      string
+	ScriptPubKey []byte
+	// RedeemScript is set for P2SH-P2WSH, WitnessScript for P2WSH and P2SH-P2WSH
+	RedeemScript  []byte
+	WitnessScript []byte
+	// InternalKey, TapLeafScript and ControlBlock describe the multi_a leaf
+	// of P2TR outputs
+	InternalKey   []byte
+	TapLeafScript []byte
+	ControlBlock  []byte
+}
+
+// ParseMultisigType parses a multisig output type name
+func ParseMultisigType(s string) (MultisigType, error) {
+	for _, t := range MultisigTypes {
+		if strings.EqualFold(s, string(t)) {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported multisig type %s", s)
+}
+
+// NewMultisig builds the required-of-len(keys) multisig output. Keys are
+// sorted (BIP67) and can be hex public keys or descriptor key expressions
+// with xpubs; an xpub without derivation path is derived at xpub/0/index.
+// Taproot outputs use a sortedmulti_a leaf behind an unspendable internal key.
+func NewMultisig(msType MultisigType, required int, keys []string, index uint32, net *chaincfg.Params) (*Multisig, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one key is required")
+	}
+	if required < 1 || required > len(keys) {
+		return nil, fmt.Errorf("required signatures must be between 1 and %d", len(keys))
+	}
+
+	exprs := make([]string, len(keys))
+	for i, key := range keys {
+		exprs[i] = multisigKeyExpr(strings.TrimSpace(key))
+	}
+	multi := fmt.Sprintf("%d,%s)", required, strings.Join(exprs, ","))
+
+	var desc string
+	switch msType {
+	case MultisigP2WSH:
+		desc = "wsh(sortedmulti(" + multi + ")"
+	case MultisigP2SHP2WSH:
+		desc = "sh(wsh(sortedmulti(" + multi + "))"
+	case MultisigP2TR:
+		desc = "tr(" + taproot.UnspendableKey + ",sortedmulti_a(" + multi + ")"
+	default:
+		return nil, fmt.Errorf("unsupported multisig type %s", msType)
+	}
+
+	parsed, err := descriptor.Parse(desc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build multisig descriptor: %v", err)
+	}
+	out, err := parsed.Derive(index, net)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive multisig script: %v", err)
+	}
+
+	ms := &Multisig{
+		Type:          msType,
+		Required:      required,
+		Descriptor:    parsed.String(),
+		Address:       out.Address,
+		ScriptPubKey:  out.ScriptPubKey,
+		RedeemScript:  out.RedeemScript,
+		WitnessScript: out.WitnessScript,
+	}
+	if msType == MultisigP2TR {
+		internalKey, err := schnorr.ParsePubKey(out.TaprootInternalKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse internal key: %v", err)
+		}
+		leaf := txscript.NewBaseTapLeaf(out.TapLeafScripts[0])
+		tree := txscript.AssembleTaprootScriptTree(leaf)
+		controlBlock := tree.LeafMerkleProofs[0].ToControlBlock(internalKey)
+		ms.ControlBlock, err = controlBlock.ToBytes()
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize control block: %v", err)
+		}
+		ms.InternalKey = out.TaprootInternalKey
+		ms.TapLeafScript = leaf.Script
+	}
+	return ms, nil
+}
+
+// multisigKeyExpr completes a bare xpub with the receive chain wildcard
+func multisigKeyExpr(key string) string {
+	bare := key
+	if end := strings.Index(bare, "]"); end >= 0 {
+		bare = bare[end+1:]
+	}
+	isExtended := strings.HasPrefix(bare, "xpub") || strings.HasPrefix(bare, "tpub") ||
+		strings.HasPrefix(bare, "xprv") || strings.HasPrefix(bare, "tprv")
+	if isExtended && !strings.Contains(bare, "/") {
+		return key + "/0/*"
+	}
+	return key
+}
+
+// UpdateMultisigPSBT adds the scripts needed to sign and finalize to every
+// input of the PSBT spending the multisig output. It returns the indexes of
+// the updated inputs.
+func UpdateMultisigPSBT(packet *psbt.Packet, ms *Multisig) ([]int, error) {
+	var updated []int
+	for i := range packet.Inputs {
+		utxo, err := psbtInputUtxo(packet, i)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(utxo.PkScript, ms.ScriptPubKey) {
+			continue
+		}
+
+		pInput := &packet.Inputs[i]
+		switch ms.Type {
+		case MultisigP2WSH, MultisigP2SHP2WSH:
+			pInput.RedeemScript = ms.RedeemScript
+			pInput.WitnessScript = ms.WitnessScript
+		case MultisigP2TR:
+			leaf := txscript.NewBaseTapLeaf(ms.TapLeafScript)
+			leafHash := leaf.TapHash()
+			pInput.TaprootInternalKey = ms.InternalKey
+			pInput.TaprootMerkleRoot = leafHash[:]
+			if _, err := psbt.FindLeafScript(pInput, leafHash[:]); err != nil {
+				pInput.TaprootLeafScript = append(pInput.TaprootLeafScript, &psbt.TaprootTapLeafScript{
+					ControlBlock: ms.ControlBlock,
+					Script:       ms.TapLeafScript,
+					LeafVersion:  txscript.BaseLeafVersion,
+				})
+			}
+		}
+		updated = append(updated, i)
+	}
+	return updated, nil
+}
+
+// finalizeTapscriptMultisig builds the final witness of a taproot input
+// spending a multi_a leaf once it holds enough signatures. The witness has one
+// item per key of the leaf, in reverse order, empty for keys that did not
+// sign. Inputs that are not multi_a spends are left untouched, so that the
+// psbt finalizer does not build an invalid witness from too few signatures.
+func finalizeTapscriptMultisig(pInput *psbt.PInput) error {
+	if len(pInput.TaprootScriptSpendSig) == 0 || pInput.FinalScriptWitness != nil {
+		return nil
+	}
+	leafHash := pInput.TaprootScriptSpendSig[0].LeafHash
+	leafScript, err := psbt.FindLeafScript(pInput, leafHash)
+	if err != nil {
+		return nil
+	}
+	required, keys, ok := parseMultiA(leafScript.Script)
+	if !ok {
+		return nil
+	}
+
+	// multi_a fails when more than the required signatures are given
+	witness := make([][]byte, len(keys))
+	signatures := 0
+	for i, key := range keys {
+		if signatures == required {
+			break
+		}
+		for _, sig := range pInput.TaprootScriptSpendSig {
+			if bytes.Equal(sig.LeafHash, leafHash) && bytes.Equal(sig.XOnlyPubKey, key) {
+				witness[len(keys)-1-i] = append([]byte{}, sig.Signature...)
+				if sig.SigHash != txscript.SigHashDefault {
+					witness[len(keys)-1-i] = append(witness[len(keys)-1-i], byte(sig.SigHash))
+				}
+				signatures++
+				break
+			}
+		}
+	}
+	if signatures < required {
+		return fmt.Errorf("%d of %d required signatures", signatures, required)
+	}
+	for i, item := range witness {
+		if item == nil {
+			witness[i] = []byte{}
+		}
+	}
+	witness = append(witness, leafScript.Script, leafScript.ControlBlock)
+
+	var buf bytes.Buffer
+	if err := psbt.WriteTxWitness(&buf, witness); err != nil {
+		return fmt.Errorf("failed to serialize witness: %v", err)
+	}
+	*pInput = *psbt.NewPsbtInput(nil, pInput.WitnessUtxo)
+	pInput.FinalScriptWitness = buf.Bytes()
+	return nil
+}
+
+// parseMultiA reads the threshold and keys of a
+// "<key1> CHECKSIG <key2> CHECKSIGADD ... <k> NUMEQUAL" tapscript
+func parseMultiA(script []byte) (int, [][]byte, bool) {
+	var (
+		keys      [][]byte
+		ops       []byte
+		pushes    [][]byte
+		tokenizer = txscript.MakeScriptTokenizer(0, script)
+	)
+	for tokenizer.Next() {
+		ops = append(ops, tokenizer.Opcode())
+		pushes = append(pushes, tokenizer.Data())
+	}
+	if tokenizer.Err() != nil || len(ops) < 4 || len(ops)%2 != 0 {
+		return 0, nil, false
+	}
+	for i := 0; i < len(ops)-2; i += 2 {
+		want := byte(txscript.OP_CHECKSIGADD)
+		if i == 0 {
+			want = txscript.OP_CHECKSIG
+		}
+		if len(pushes[i]) != 32 || ops[i+1] != want {
+			return 0, nil, false
+		}
+		keys = append(keys, pushes[i])
+	}
+	if ops[len(ops)-1] != txscript.OP_NUMEQUAL {
+		return 0, nil, false
+	}
+	required, err := txscript.MakeScriptNum(scriptNumBytes(ops[len(ops)-2], pushes[len(ops)-2]), true, 4)
+	if err != nil || required < 1 || int(required) > len(keys) {
+		return 0, nil, false
+	}
+	return int(required), keys, true
+}
+
+// scriptNumBytes returns the number pushed by a small integer opcode or data push
+func scriptNumBytes(opcode byte, data []byte) []byte {
+	if opcode >= txscript.OP_1 && opcode <= txscript.OP_16 {
+		return []byte{opcode - txscript.OP_1 + 1}
+	}
+	return data
+}
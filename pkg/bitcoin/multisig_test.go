@@ -0,0 +1,103 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultisigSignAndFinalize(t *testing.T) {
+	keys := make([]*btcec.PrivateKey, 3)
+	pubKeys := make([]string, 3)
+	for i := range keys {
+		var err error
+		keys[i], err = btcec.NewPrivateKey()
+		require.NoError(t, err)
+		pubKeys[i] = hex.EncodeToString(keys[i].PubKey().SerializeCompressed())
+	}
+
+	for _, msType := range MultisigTypes {
+		ms, err := NewMultisig(msType, 2, pubKeys, 0, &chaincfg.RegressionNetParams)
+		require.NoError(t, err, msType)
+		require.NotEmpty(t, ms.Address)
+
+		// the keys are sorted, so their order does not change the address
+		reversed, err := NewMultisig(msType, 2, []string{pubKeys[2], pubKeys[1], pubKeys[0]}, 0,
+			&chaincfg.RegressionNetParams)
+		require.NoError(t, err)
+		require.Equal(t, ms.Address, reversed.Address)
+
+		prevHash, _ := chainhash.NewHashFromStr("1234567890123456789012345678901234567890123456789012345678901234")
+		prevOut := wire.NewTxOut(100000, ms.ScriptPubKey)
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(prevHash, 0), nil, nil))
+		tx.AddTxOut(wire.NewTxOut(99000, ms.ScriptPubKey))
+		packet, err := CreatePSBT(tx)
+		require.NoError(t, err)
+		packet.Inputs[0].WitnessUtxo = prevOut
+
+		updated, err := UpdateMultisigPSBT(packet, ms)
+		require.NoError(t, err)
+		require.Equal(t, []int{0}, updated)
+		encoded, err := EncodePSBT(packet)
+		require.NoError(t, err)
+
+		// cosigners 1 and 3 sign their own copy
+		var signedPackets []*psbt.Packet
+		for _, key := range []*btcec.PrivateKey{keys[0], keys[2]} {
+			cosigner, err := DecodePSBT(encoded)
+			require.NoError(t, err)
+			signed, err := SignPSBT(cosigner, key)
+			require.NoError(t, err)
+			require.Equal(t, []int{0}, signed, msType)
+			signedPackets = append(signedPackets, cosigner)
+		}
+
+		// a single signature is not enough
+		single, err := DecodePSBT(encoded)
+		require.NoError(t, err)
+		_, err = SignPSBT(single, keys[1])
+		require.NoError(t, err)
+		require.Error(t, FinalizePSBT(single), msType)
+		require.False(t, single.IsComplete(), msType)
+
+		combined, err := CombinePSBTs(signedPackets)
+		require.NoError(t, err)
+		require.NoError(t, FinalizePSBT(combined), msType)
+		require.True(t, combined.IsComplete(), msType)
+
+		finalTx, err := ExtractPSBTTx(combined)
+		require.NoError(t, err)
+		prevOuts := txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value)
+		vm, err := txscript.NewEngine(prevOut.PkScript, finalTx, 0, txscript.StandardVerifyFlags, nil,
+			txscript.NewTxSigHashes(finalTx, prevOuts), prevOut.Value, prevOuts)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute(), msType)
+	}
+}
+
+func TestMultisigXpubs(t *testing.T) {
+	xpubs := []string{
+		"xpub6ERApfZwUNrhLCkDtcHTcxd75RbzS1ed54G1LkBUHQVHQKqhMkhgbmJbZRkrgZw4koxb5JaHWkY4ALHY2grBGRjaDMzQLcgJvLJuZZvRcEL",
+		"xpub68NZiKmJWnxxS6aaHmn81bvJeTESw724CRDs6HbuccFQN9Ku14VQrADWgqbhhTHBaohPX4CjNLf9fq9MYo6oDaPPLPxSb7gwQN3ih19Zm4Y",
+	}
+	ms, err := NewMultisig(MultisigP2WSH, 2, xpubs, 0, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(ms.Descriptor, "wsh(sortedmulti(2,"+xpubs[0]+"/0/*,"))
+	next, err := NewMultisig(MultisigP2WSH, 2, xpubs, 1, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.NotEqual(t, ms.Address, next.Address)
+
+	_, err = NewMultisig(MultisigP2WSH, 3, xpubs, 0, &chaincfg.MainNetParams)
+	require.Error(t, err)
+	_, err = NewMultisig("p2pkh", 1, xpubs, 0, &chaincfg.MainNetParams)
+	require.Error(t, err)
+}
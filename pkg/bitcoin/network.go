@@ -0,0 +1,221 @@
+package bitcoin
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Coins of the supported networks
+const (
+	CoinBitcoin  = "bitcoin"
+	CoinLitecoin = "litecoin"
+	CoinDogecoin = "dogecoin"
+)
+
+// Network is a chain known to the bitcoin commands, with the defaults of its
+// reference node
+type Network struct {
+	// Coin is the chain family, one of the Coin constants
+	Coin string
+	// Params are the chain parameters, Params.Name is the name of the network
+	Params *chaincfg.Params
+	// Aliases are other names accepted for the network
+	Aliases []string
+	// RPCPort is the default JSON-RPC port of the node
+	RPCPort int
+	// DataDir is the default data directory of the node, relative to the
+	// home directory
+	DataDir string
+}
+
+// Name returns the name of the network
+func (n *Network) Name() string {
+	return n.Params.Name
+}
+
+// TestNet4Params are the chain parameters of the bitcoin test network
+// defined in BIP94
+var TestNet4Params = func() chaincfg.Params {
+	params := chaincfg.TestNet3Params
+	params.Name = "testnet4"
+	params.Net = wire.BitcoinNet(0x283f161c)
+	params.DefaultPort = "48333"
+	params.DNSSeeds = []chaincfg.DNSSeed{
+		{Host: "seed.testnet4.bitcoin.sprovoost.nl", HasFiltering: true},
+		{Host: "seed.testnet4.wiz.biz", HasFiltering: true},
+	}
+	params.GenesisBlock = nil
+	params.GenesisHash = mustHash("00000000da84f2bafbbc53dee25a72ae507ff4914b867c565be350b0da8bf043")
+	params.Checkpoints = nil
+	return params
+}()
+
+// LitecoinParams are the chain parameters of the Litecoin main network
+var LitecoinParams = func() chaincfg.Params {
+	params := altcoinParams(chaincfg.MainNetParams)
+	params.Name = "litecoin"
+	params.Net = wire.BitcoinNet(0xdbb6c0fb)
+	params.DefaultPort = "9333"
+	params.GenesisHash = mustHash("12a765e31ffd4059bada1e25190f6e98c99d9714d334efa41a195a7e7e04bfe2")
+	params.PowLimit = litecoinPowLimit
+	params.PowLimitBits = 0x1e0fffff
+	params.TargetTimespan = time.Hour * 84
+	params.TargetTimePerBlock = time.Second * 150
+	params.Bech32HRPSegwit = "ltc"
+	params.PubKeyHashAddrID = 0x30
+	params.ScriptHashAddrID = 0x32
+	params.PrivateKeyID = 0xb0
+	params.HDCoinType = 2
+	return params
+}()
+
+// LitecoinTestNetParams are the chain parameters of the Litecoin test network
+var LitecoinTestNetParams = func() chaincfg.Params {
+	params := altcoinParams(chaincfg.TestNet3Params)
+	params.Name = "litecoin-testnet"
+	params.Net = wire.BitcoinNet(0xf1c8d2fd)
+	params.DefaultPort = "19335"
+	params.GenesisHash = mustHash("4966625a4b2851d9fdee139e56211a0d88575f59ed816ff5e6a63deb4e3e29a0")
+	params.PowLimit = litecoinPowLimit
+	params.PowLimitBits = 0x1e0fffff
+	params.TargetTimespan = time.Hour * 84
+	params.TargetTimePerBlock = time.Second * 150
+	params.Bech32HRPSegwit = "tltc"
+	params.ScriptHashAddrID = 0x3a
+	return params
+}()
+
+// DogecoinParams are the chain parameters of the Dogecoin main network.
+// Dogecoin has no segwit and its own extended key versions.
+var DogecoinParams = func() chaincfg.Params {
+	params := altcoinParams(chaincfg.MainNetParams)
+	params.Name = "dogecoin"
+	params.Net = wire.BitcoinNet(0xc0c0c0c0)
+	params.DefaultPort = "22556"
+	params.GenesisHash = mustHash("1a91e3dace36e2be3bf030a65679fe821aa1d6ef92e7c9902eb318182c355691")
+	params.PowLimit = litecoinPowLimit
+	params.PowLimitBits = 0x1e0fffff
+	params.TargetTimespan = time.Minute
+	params.TargetTimePerBlock = time.Minute
+	params.Bech32HRPSegwit = ""
+	params.PubKeyHashAddrID = 0x1e
+	params.ScriptHashAddrID = 0x16
+	params.PrivateKeyID = 0x9e
+	params.HDPrivateKeyID = [4]byte{0x02, 0xfa, 0xc3, 0x98} // dgpv
+	params.HDPublicKeyID = [4]byte{0x02, 0xfa, 0xca, 0xfd}  // dgub
+	params.HDCoinType = 3
+	return params
+}()
+
+// DogecoinTestNetParams are the chain parameters of the Dogecoin test network
+var DogecoinTestNetParams = func() chaincfg.Params {
+	params := altcoinParams(chaincfg.TestNet3Params)
+	params.Name = "dogecoin-testnet"
+	params.Net = wire.BitcoinNet(0xdcb7c1fc)
+	params.DefaultPort = "44556"
+	params.GenesisHash = mustHash("bb0a78264637406b6360aad926284d544d7049f45189db5664f3c4d07350559e")
+	params.PowLimit = litecoinPowLimit
+	params.PowLimitBits = 0x1e0fffff
+	params.TargetTimespan = time.Minute
+	params.TargetTimePerBlock = time.Minute
+	params.Bech32HRPSegwit = ""
+	params.PubKeyHashAddrID = 0x71
+	params.ScriptHashAddrID = 0xc4
+	params.PrivateKeyID = 0xf1
+	return params
+}()
+
+// litecoinPowLimit is the highest scrypt target of Litecoin and Dogecoin,
+// 2^236 - 1
+var litecoinPowLimit = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 236), big.NewInt(1))
+
+// networks are the supported networks, in display order
+var networks = []*Network{
+	{Coin: CoinBitcoin, Params: &chaincfg.MainNetParams, Aliases: []string{"main", "bitcoin"}, RPCPort: 8332, DataDir: ".bitcoin"},
+	{Coin: CoinBitcoin, Params: &chaincfg.TestNet3Params, Aliases: []string{"testnet", "test"}, RPCPort: 18332, DataDir: ".bitcoin/testnet3"},
+	{Coin: CoinBitcoin, Params: &TestNet4Params, RPCPort: 48332, DataDir: ".bitcoin/testnet4"},
+	{Coin: CoinBitcoin, Params: &chaincfg.SigNetParams, RPCPort: 38332, DataDir: ".bitcoin/signet"},
+	{Coin: CoinBitcoin, Params: &chaincfg.RegressionNetParams, RPCPort: 18443, DataDir: ".bitcoin/regtest"},
+	{Coin: CoinLitecoin, Params: &LitecoinParams, Aliases: []string{"ltc"}, RPCPort: 9332, DataDir: ".litecoin"},
+	{Coin: CoinLitecoin, Params: &LitecoinTestNetParams, Aliases: []string{"ltc-testnet"}, RPCPort: 19332, DataDir: ".litecoin/testnet4"},
+	{Coin: CoinDogecoin, Params: &DogecoinParams, Aliases: []string{"doge"}, RPCPort: 22555, DataDir: ".dogecoin"},
+	{Coin: CoinDogecoin, Params: &DogecoinTestNetParams, Aliases: []string{"doge-testnet"}, RPCPort: 44555, DataDir: ".dogecoin/testnet3"},
+}
+
+func init() {
+	// registering the networks lets btcutil and hdkeychain recognize their
+	// segwit prefixes and extended key versions
+	for _, params := range []*chaincfg.Params{&TestNet4Params, &LitecoinParams, &LitecoinTestNetParams, &DogecoinParams, &DogecoinTestNetParams} {
+		if err := chaincfg.Register(params); err != nil {
+			panic(fmt.Sprintf("failed to register %s: %v", params.Name, err))
+		}
+	}
+}
+
+// altcoinParams copies base without the bitcoin specific seeds, checkpoints,
+// genesis block and soft fork deployments
+func altcoinParams(base chaincfg.Params) chaincfg.Params {
+	base.DNSSeeds = nil
+	base.Checkpoints = nil
+	base.GenesisBlock = nil
+	base.BIP0034Height, base.BIP0065Height, base.BIP0066Height = 0, 0, 0
+	base.Deployments = [chaincfg.DefinedDeployments]chaincfg.ConsensusDeployment{}
+	return base
+}
+
+func mustHash(s string) *chainhash.Hash {
+	hash, err := chainhash.NewHashFromStr(s)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+}
+
+// Networks returns the supported networks
+func Networks() []*Network {
+	return networks
+}
+
+// NetworkNames returns the names of the supported networks
+func NetworkNames() []string {
+	names := make([]string, len(networks))
+	for i, n := range networks {
+		names[i] = n.Name()
+	}
+	return names
+}
+
+// LookupNetwork returns the network with the given name or alias, mainnet
+// when the name is empty
+func LookupNetwork(name string) (*Network, error) {
+	name = strings.ToLower(name)
+	if name == "" {
+		return networks[0], nil
+	}
+	for _, n := range networks {
+		if n.Name() == name {
+			return n, nil
+		}
+		for _, alias := range n.Aliases {
+			if alias == name {
+				return n, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unsupported network: %s (supported: %s)", name, strings.Join(NetworkNames(), ", "))
+}
+
+// GetNetworkParams returns the chain parameters for a network name or alias
+func GetNetworkParams(name string) (*chaincfg.Params, error) {
+	n, err := LookupNetwork(name)
+	if err != nil {
+		return nil, err
+	}
+	return n.Params, nil
+}
@@ -0,0 +1,119 @@
+package bitcoin
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupNetwork(t *testing.T) {
+	testCases := []struct {
+		name   string
+		params *chaincfg.Params
+		coin   string
+	}{
+		{"", &chaincfg.MainNetParams, CoinBitcoin},
+		{"main", &chaincfg.MainNetParams, CoinBitcoin},
+		{"testnet", &chaincfg.TestNet3Params, CoinBitcoin},
+		{"testnet3", &chaincfg.TestNet3Params, CoinBitcoin},
+		{"testnet4", &TestNet4Params, CoinBitcoin},
+		{"Signet", &chaincfg.SigNetParams, CoinBitcoin},
+		{"regtest", &chaincfg.RegressionNetParams, CoinBitcoin},
+		{"ltc", &LitecoinParams, CoinLitecoin},
+		{"litecoin-testnet", &LitecoinTestNetParams, CoinLitecoin},
+		{"doge", &DogecoinParams, CoinDogecoin},
+		{"dogecoin-testnet", &DogecoinTestNetParams, CoinDogecoin},
+	}
+	for _, tc := range testCases {
+		network, err := LookupNetwork(tc.name)
+		require.NoError(t, err)
+		require.Equal(t, tc.params, network.Params)
+		require.Equal(t, tc.coin, network.Coin)
+	}
+
+	_, err := GetNetworkParams("testnet5")
+	require.ErrorContains(t, err, "unsupported network")
+	require.Len(t, NetworkNames(), len(Networks()))
+}
+
+func TestNetworkAddresses(t *testing.T) {
+	// the compressed public key of the private key 1
+	const privKey = "0000000000000000000000000000000000000000000000000000000000000001"
+	testCases := []struct {
+		name     string
+		net      *chaincfg.Params
+		addrType AddressType
+		address  string
+		wif      string
+	}{
+		{
+			name:     "testnet4 p2pkh",
+			net:      &TestNet4Params,
+			addrType: AddressTypeP2PKH,
+			address:  "mrCDrCybB6J1vRfbwM5hemdJz73FwDBC8r",
+			wif:      "cMahea7zqjxrtgAbB7LSGbcQUr1uX1ojuat9jZodMN87JcbXMTcA",
+		},
+		{
+			name:     "litecoin p2pkh",
+			net:      &LitecoinParams,
+			addrType: AddressTypeP2PKH,
+			address:  "LVuDpNCSSj6pQ7t9Pv6d6sUkLKoqDEVUnJ",
+			wif:      "T33ydQRKp4FCW5LCLLUB7deioUMoveiwekdwUwyfRDeGZm76aUjV",
+		},
+		{
+			name:     "litecoin p2wpkh",
+			net:      &LitecoinParams,
+			addrType: AddressTypeP2WPKH,
+			address:  "ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9",
+		},
+		{
+			name:     "litecoin testnet p2wpkh",
+			net:      &LitecoinTestNetParams,
+			addrType: AddressTypeP2WPKH,
+			address:  "tltc1qw508d6qejxtdg4y5r3zarvary0c5xw7klfsuq0",
+		},
+		{
+			name:     "dogecoin p2pkh",
+			net:      &DogecoinParams,
+			addrType: AddressTypeP2PKH,
+			address:  "DFpN6QqFfUm3gKNaxN6tNcab1FArL9cZLE",
+			wif:      "QNcdLVw8fHkixm6NNyN6nVwxKek4u7qrioRbQmjxac5TVoTtZuot",
+		},
+		{
+			name:     "dogecoin testnet p2pkh",
+			net:      &DogecoinTestNetParams,
+			addrType: AddressTypeP2PKH,
+			address:  "nesRpRaAbTDmZHwmzBkLd2AtF7Z9L9z5S2",
+			wif:      "cejxntqoC3o8qiC8HG8DrwoNyiRDBrMCEU8QrUVpLKdXsGy8LpTM",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			address, err := GenerateAddress(privKey, tc.addrType, tc.net)
+			require.NoError(t, err)
+			require.Equal(t, tc.address, address)
+			decoded, err := btcutil.DecodeAddress(address, tc.net)
+			require.NoError(t, err)
+			require.True(t, decoded.IsForNet(tc.net))
+
+			if tc.wif != "" {
+				wif, err := ConvertKeyTo(privKey, KeyFormatWIF, "", tc.net)
+				require.NoError(t, err)
+				require.Equal(t, tc.wif, wif)
+				// keys of every network are accepted
+				address, err = GenerateAddress(wif, tc.addrType, tc.net)
+				require.NoError(t, err)
+				require.Equal(t, tc.address, address)
+			}
+		})
+	}
+
+	// dogecoin has no segwit
+	for _, addrType := range []AddressType{AddressTypeP2SHP2WPKH, AddressTypeP2WPKH, AddressTypeP2TR} {
+		_, err := GenerateAddress(privKey, addrType, &DogecoinParams)
+		require.ErrorContains(t, err, "no segwit")
+	}
+}
@@ -0,0 +1,155 @@
+package pow
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// RetargetInfo is a difficulty adjustment found in a header series
+type RetargetInfo struct {
+	Height       int32   `json:"height"`
+	PreviousBits string  `json:"previousBits"`
+	Bits         string  `json:"bits"`
+	Difficulty   float64 `json:"difficulty"`
+	// Change is the ratio between the new and the previous difficulty
+	Change float64 `json:"change"`
+}
+
+// ChainSummary is the result of validating a header series
+type ChainSummary struct {
+	StartHeight int32  `json:"startHeight"`
+	TipHeight   int32  `json:"tipHeight"`
+	TipHash     string `json:"tipHash"`
+	// ChainWork is the work of the series only, not of the blocks before it
+	ChainWork  *big.Int       `json:"-"`
+	Difficulty float64        `json:"difficulty"`
+	Retargets  []RetargetInfo `json:"retargets"`
+}
+
+// ParseHeaders decodes consecutive 80-byte block headers
+func ParseHeaders(b []byte) ([]*wire.BlockHeader, error) {
+	if len(b) == 0 || len(b)%HeaderSize != 0 {
+		return nil, fmt.Errorf("invalid headers size %d, expected a multiple of %d bytes", len(b), HeaderSize)
+	}
+	headers := make([]*wire.BlockHeader, 0, len(b)/HeaderSize)
+	for i := 0; i < len(b); i += HeaderSize {
+		header, err := ParseHeader(b[i : i+HeaderSize])
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
+
+// ValidateChain checks that headers, the first one at startHeight, link to
+// each other, have valid proof of work and the bits required by the
+// difficulty rules of net. Retargets that depend on headers before the
+// series cannot be recomputed and only the proof of work of their bits is
+// checked.
+func ValidateChain(headers []*wire.BlockHeader, startHeight int32, net *chaincfg.Params) (*ChainSummary, error) {
+	if len(headers) == 0 {
+		return nil, fmt.Errorf("no headers to validate")
+	}
+	interval := BlocksPerRetarget(net)
+	summary := &ChainSummary{StartHeight: startHeight, ChainWork: new(big.Int), Retargets: []RetargetInfo{}}
+	for i, header := range headers {
+		height := startHeight + int32(i)
+		if err := CheckProofOfWork(header, net); err != nil {
+			return nil, fmt.Errorf("block %d: %v", height, err)
+		}
+		work, err := Work(header.Bits)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %v", height, err)
+		}
+		summary.ChainWork.Add(summary.ChainWork, work)
+		if i == 0 {
+			continue
+		}
+
+		prev := headers[i-1]
+		if prevHash := prev.BlockHash(); header.PrevBlock != prevHash {
+			return nil, fmt.Errorf("block %d: previous block %s does not match %s", height, header.PrevBlock, prevHash)
+		}
+		bits, known, err := requiredBits(headers, i, height, net)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %v", height, err)
+		}
+		if known && header.Bits != bits {
+			return nil, fmt.Errorf("block %d: bits %08x do not match the required %08x", height, header.Bits, bits)
+		}
+		if height%interval == 0 {
+			retarget, err := newRetargetInfo(height, prev.Bits, header.Bits)
+			if err != nil {
+				return nil, err
+			}
+			summary.Retargets = append(summary.Retargets, *retarget)
+		}
+	}
+
+	tip := headers[len(headers)-1]
+	summary.TipHeight = startHeight + int32(len(headers)) - 1
+	summary.TipHash = tip.BlockHash().String()
+	summary.Difficulty, _ = Difficulty(tip.Bits)
+	return summary, nil
+}
+
+// ChainWorkHex formats the chainwork as Bitcoin Core does, 64 hex digits
+func (s *ChainSummary) ChainWorkHex() string {
+	return fmt.Sprintf("%064x", s.ChainWork)
+}
+
+// requiredBits returns the bits headers[i] at height must have, following
+// Bitcoin Core's GetNextWorkRequired. known is false when the rule needs
+// headers before the series.
+func requiredBits(headers []*wire.BlockHeader, i int, height int32, net *chaincfg.Params) (bits uint32, known bool, err error) {
+	interval := BlocksPerRetarget(net)
+	prev := headers[i-1]
+	if height%interval != 0 {
+		if !net.ReduceMinDifficulty {
+			return prev.Bits, true, nil
+		}
+		// a block may use the minimum difficulty when found long after the
+		// previous one, the next ones go back to the last real difficulty
+		if headers[i].Timestamp.After(prev.Timestamp.Add(2 * net.TargetTimePerBlock)) {
+			return net.PowLimitBits, true, nil
+		}
+		for j := i - 1; j >= 0; j-- {
+			if (height-int32(i-j))%interval == 0 || headers[j].Bits != net.PowLimitBits {
+				return headers[j].Bits, true, nil
+			}
+		}
+		return 0, false, nil
+	}
+
+	if net.PoWNoRetargeting {
+		return prev.Bits, true, nil
+	}
+	first := i - int(interval)
+	if first < 0 {
+		return 0, false, nil
+	}
+	bits, err = Retarget(headers[first].Timestamp, prev.Timestamp, prev.Bits, net)
+	return bits, err == nil, err
+}
+
+func newRetargetInfo(height int32, previousBits, bits uint32) (*RetargetInfo, error) {
+	previous, err := Difficulty(previousBits)
+	if err != nil {
+		return nil, err
+	}
+	difficulty, err := Difficulty(bits)
+	if err != nil {
+		return nil, err
+	}
+	return &RetargetInfo{
+		Height:       height,
+		PreviousBits: fmt.Sprintf("%08x", previousBits),
+		Bits:         fmt.Sprintf("%08x", bits),
+		Difficulty:   difficulty,
+		Change:       difficulty / previous,
+	}, nil
+}
@@ -0,0 +1,163 @@
+package pow
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// hashBatch is the number of hashes a worker tries between reports
+const hashBatch = 1 << 12
+
+// MineResult is a header whose hash meets its target
+type MineResult struct {
+	Header  wire.BlockHeader
+	Hash    chainhash.Hash
+	Hashes  uint64
+	Elapsed time.Duration
+}
+
+// HashRate returns the hashes per second it took to find the result
+func (r *MineResult) HashRate() float64 {
+	return hashRate(r.Hashes, r.Elapsed)
+}
+
+// Miner grinds header nonces across worker goroutines
+type Miner struct {
+	workers int
+	hashes  atomic.Uint64
+	// start is the unix time in nanoseconds the search began
+	start atomic.Int64
+}
+
+// NewMiner creates a miner running workers goroutines
+func NewMiner(workers int) *Miner {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Miner{workers: workers}
+}
+
+// TargetBits returns the bits of the target requiring zeroBits leading zero
+// bits in the block hash
+func TargetBits(zeroBits int) (uint32, error) {
+	if zeroBits < 0 || zeroBits > 255 {
+		return 0, fmt.Errorf("invalid number of zero bits %d, expected 0 to 255", zeroBits)
+	}
+	target := new(big.Int).Lsh(bigOne, uint(256-zeroBits))
+	return TargetToCompact(target.Sub(target, bigOne)), nil
+}
+
+// Mine searches a nonce making the hash of header meet the target of its
+// bits. Each worker tries the nonces congruent to its index and, when the
+// nonce space is exhausted, increments the header timestamp.
+func (m *Miner) Mine(ctx context.Context, header wire.BlockHeader) (*MineResult, error) {
+	target, err := CompactToTarget(header.Bits)
+	if err != nil {
+		return nil, err
+	}
+	var targetBytes [chainhash.HashSize]byte
+	target.FillBytes(targetBytes[:])
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	m.hashes.Store(0)
+	start := time.Now()
+	m.start.Store(start.UnixNano())
+	results := make(chan MineResult, 1)
+	var wg sync.WaitGroup
+	for w := 0; w < m.workers; w++ {
+		wg.Add(1)
+		go func(w uint32) {
+			defer wg.Done()
+			m.work(ctx, header, w, &targetBytes, results)
+		}(uint32(w))
+	}
+
+	select {
+	case result := <-results:
+		cancel()
+		wg.Wait()
+		result.Hashes = m.hashes.Load()
+		result.Elapsed = time.Since(start)
+		return &result, nil
+	case <-ctx.Done():
+		wg.Wait()
+		return nil, ctx.Err()
+	}
+}
+
+// Hashes returns the number of hashes tried by the current or last search
+func (m *Miner) Hashes() uint64 {
+	return m.hashes.Load()
+}
+
+// HashRate returns the hashes per second of the current or last search
+func (m *Miner) HashRate() float64 {
+	start := m.start.Load()
+	if start == 0 {
+		return 0
+	}
+	return hashRate(m.hashes.Load(), time.Since(time.Unix(0, start)))
+}
+
+func (m *Miner) work(ctx context.Context, header wire.BlockHeader, w uint32, target *[chainhash.HashSize]byte, results chan<- MineResult) {
+	step := uint32(m.workers)
+	buf := SerializeHeader(&header)
+	var count uint64
+	defer func() { m.hashes.Add(count % hashBatch) }()
+	for nonce := w; ; {
+		binary.LittleEndian.PutUint32(buf[76:], nonce)
+		hash := chainhash.DoubleHashH(buf)
+		count++
+		if hashMeetsTarget(&hash, target) {
+			found, _ := ParseHeader(buf)
+			select {
+			case results <- MineResult{Header: *found, Hash: hash}:
+			default:
+			}
+			return
+		}
+		if count%hashBatch == 0 {
+			m.hashes.Add(hashBatch)
+			if ctx.Err() != nil {
+				return
+			}
+		}
+		if uint64(nonce)+uint64(step) > math.MaxUint32 {
+			// every worker moves to the next second, keeping their nonces apart
+			timestamp := binary.LittleEndian.Uint32(buf[68:])
+			binary.LittleEndian.PutUint32(buf[68:], timestamp+1)
+			nonce = w
+			continue
+		}
+		nonce += step
+	}
+}
+
+// hashMeetsTarget compares the little endian hash against the big endian
+// target without allocating
+func hashMeetsTarget(hash *chainhash.Hash, target *[chainhash.HashSize]byte) bool {
+	for i := 0; i < chainhash.HashSize; i++ {
+		h := hash[chainhash.HashSize-1-i]
+		if h != target[i] {
+			return h < target[i]
+		}
+	}
+	return true
+}
+
+func hashRate(hashes uint64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(hashes) / elapsed.Seconds()
+}
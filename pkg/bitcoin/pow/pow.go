@@ -0,0 +1,184 @@
+// Package pow decodes Bitcoin block headers and validates their proof of
+// work: compact nBits targets, hash below target, chainwork and difficulty
+// retargets
+package pow
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// HeaderSize is the size of a serialized block header
+const HeaderSize = wire.MaxBlockHeaderPayload
+
+// DifficultyOneBits is the target of difficulty 1, the mainnet proof of work
+// limit. Difficulty is reported relative to it on every network.
+const DifficultyOneBits = 0x1d00ffff
+
+var (
+	bigOne = big.NewInt(1)
+	// oneLsh256 is 2^256, used to compute the work of a target
+	oneLsh256 = new(big.Int).Lsh(bigOne, 256)
+)
+
+// ParseHeader decodes an 80-byte block header
+func ParseHeader(b []byte) (*wire.BlockHeader, error) {
+	if len(b) != HeaderSize {
+		return nil, fmt.Errorf("invalid header size %d, expected %d bytes", len(b), HeaderSize)
+	}
+	var header wire.BlockHeader
+	if err := header.Deserialize(bytes.NewReader(b)); err != nil {
+		return nil, fmt.Errorf("failed to decode header: %v", err)
+	}
+	return &header, nil
+}
+
+// DecodeHeader decodes a hex encoded block header
+func DecodeHeader(headerHex string) (*wire.BlockHeader, error) {
+	b, err := hex.DecodeString(headerHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid header hex: %v", err)
+	}
+	return ParseHeader(b)
+}
+
+// SerializeHeader encodes a block header in its 80-byte wire format
+func SerializeHeader(header *wire.BlockHeader) []byte {
+	var buf bytes.Buffer
+	buf.Grow(HeaderSize)
+	// writing to a bytes.Buffer does not fail
+	header.Serialize(&buf)
+	return buf.Bytes()
+}
+
+// CompactToTarget decodes the nBits compact encoding of a target, rejecting
+// negative, zero and overflowing values as Bitcoin Core does
+func CompactToTarget(bits uint32) (*big.Int, error) {
+	exponent := uint(bits >> 24)
+	mantissa := bits & 0x007fffff
+	if mantissa != 0 && bits&0x00800000 != 0 {
+		return nil, fmt.Errorf("negative target in bits %08x", bits)
+	}
+	if mantissa != 0 && (exponent > 34 ||
+		(mantissa > 0xff && exponent > 33) ||
+		(mantissa > 0xffff && exponent > 32)) {
+		return nil, fmt.Errorf("target overflow in bits %08x", bits)
+	}
+	target := big.NewInt(int64(mantissa))
+	if exponent <= 3 {
+		target.Rsh(target, 8*(3-exponent))
+	} else {
+		target.Lsh(target, 8*(exponent-3))
+	}
+	if target.Sign() == 0 {
+		return nil, fmt.Errorf("zero target in bits %08x", bits)
+	}
+	return target, nil
+}
+
+// TargetToCompact encodes a positive target in the nBits compact encoding,
+// truncating it to 3 bytes of precision
+func TargetToCompact(target *big.Int) uint32 {
+	size := uint32(len(target.Bytes()))
+	var mantissa uint32
+	if size <= 3 {
+		mantissa = uint32(target.Uint64() << (8 * (3 - size)))
+	} else {
+		mantissa = uint32(new(big.Int).Rsh(target, uint(8*(size-3))).Uint64())
+	}
+	// the sign bit of the mantissa must stay clear
+	if mantissa&0x00800000 != 0 {
+		mantissa >>= 8
+		size++
+	}
+	return size<<24 | mantissa
+}
+
+// HashToBig interprets a block hash as the little endian number compared
+// against the target
+func HashToBig(hash *chainhash.Hash) *big.Int {
+	b := make([]byte, chainhash.HashSize)
+	for i := range hash {
+		b[chainhash.HashSize-1-i] = hash[i]
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+// CheckProofOfWork checks that the header target is valid for net and that
+// the header hash is not above it
+func CheckProofOfWork(header *wire.BlockHeader, net *chaincfg.Params) error {
+	target, err := CompactToTarget(header.Bits)
+	if err != nil {
+		return err
+	}
+	if target.Cmp(net.PowLimit) > 0 {
+		return fmt.Errorf("target of bits %08x is above the %s proof of work limit", header.Bits, net.Name)
+	}
+	hash := header.BlockHash()
+	if HashToBig(&hash).Cmp(target) > 0 {
+		return fmt.Errorf("hash %s is above the target of bits %08x", hash, header.Bits)
+	}
+	return nil
+}
+
+// Work returns the expected number of hashes to find a block with the given
+// bits, 2^256 / (target + 1)
+func Work(bits uint32) (*big.Int, error) {
+	target, err := CompactToTarget(bits)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Div(oneLsh256, target.Add(target, bigOne)), nil
+}
+
+// Difficulty returns how many times harder the target of bits is than the
+// target of difficulty 1
+func Difficulty(bits uint32) (float64, error) {
+	target, err := CompactToTarget(bits)
+	if err != nil {
+		return 0, err
+	}
+	// DifficultyOneBits is a valid encoding
+	one, _ := CompactToTarget(DifficultyOneBits)
+	difficulty, _ := new(big.Rat).SetFrac(one, target).Float64()
+	return difficulty, nil
+}
+
+// Retarget returns the bits of the block following a difficulty period that
+// started at firstTime and whose last block has lastTime and lastBits. The
+// actual timespan is clamped to a factor of net.RetargetAdjustmentFactor of
+// the target timespan and the result to net.PowLimit.
+func Retarget(firstTime, lastTime time.Time, lastBits uint32, net *chaincfg.Params) (uint32, error) {
+	target, err := CompactToTarget(lastBits)
+	if err != nil {
+		return 0, err
+	}
+	targetTimespan := int64(net.TargetTimespan / time.Second)
+	minTimespan := targetTimespan / net.RetargetAdjustmentFactor
+	maxTimespan := targetTimespan * net.RetargetAdjustmentFactor
+	timespan := lastTime.Unix() - firstTime.Unix()
+	if timespan < minTimespan {
+		timespan = minTimespan
+	} else if timespan > maxTimespan {
+		timespan = maxTimespan
+	}
+
+	target.Mul(target, big.NewInt(timespan))
+	target.Div(target, big.NewInt(targetTimespan))
+	if target.Cmp(net.PowLimit) > 0 {
+		target.Set(net.PowLimit)
+	}
+	return TargetToCompact(target), nil
+}
+
+// BlocksPerRetarget returns the length of the difficulty periods of net
+func BlocksPerRetarget(net *chaincfg.Params) int32 {
+	return int32(net.TargetTimespan / net.TargetTimePerBlock)
+}
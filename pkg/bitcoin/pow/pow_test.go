@@ -0,0 +1,170 @@
+package pow
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	block1Header = "010000006fe28c0ab6f1b372c1a6a246ae63f74f931e8365e15a089c68d6190000000000982051fd1e4ba744bbbe680e1fee14677ba1a3c3540bf7b1cdb606e857233e0e61bc6649ffff001d01e36299"
+	block1Hash   = "00000000839a8e6886ab5951d76f411475428afc90947ee320161bbf18eb6048"
+)
+
+func TestHeaderProofOfWork(t *testing.T) {
+	genesis := chaincfg.MainNetParams.GenesisBlock.Header
+	header, err := ParseHeader(SerializeHeader(&genesis))
+	require.NoError(t, err)
+	require.Equal(t, *chaincfg.MainNetParams.GenesisHash, header.BlockHash())
+	require.NoError(t, CheckProofOfWork(header, &chaincfg.MainNetParams))
+
+	difficulty, err := Difficulty(header.Bits)
+	require.NoError(t, err)
+	require.Equal(t, 1.0, difficulty)
+	work, err := Work(header.Bits)
+	require.NoError(t, err)
+	require.Equal(t, "100010001", work.Text(16))
+
+	header.Nonce++
+	require.ErrorContains(t, CheckProofOfWork(header, &chaincfg.MainNetParams), "above the target")
+	regtest := chaincfg.RegressionNetParams.GenesisBlock.Header
+	require.ErrorContains(t, CheckProofOfWork(&regtest, &chaincfg.MainNetParams), "proof of work limit")
+
+	_, err = DecodeHeader(block1Header[2:])
+	require.Error(t, err)
+}
+
+func TestCompactTarget(t *testing.T) {
+	for _, bits := range []uint32{0x1d00ffff, 0x1b0404cb, 0x207fffff, 0x17034219, 0x03123456} {
+		target, err := CompactToTarget(bits)
+		require.NoError(t, err)
+		require.Equal(t, bits, TargetToCompact(target), "%08x", bits)
+	}
+	target, err := CompactToTarget(0x1b0404cb)
+	require.NoError(t, err)
+	require.Equal(t, "404cb000000000000000000000000000000000000000000000000", target.Text(16))
+
+	for bits, msg := range map[uint32]string{
+		0x04923456: "negative",
+		0x23000001: "overflow",
+		0x01003456: "zero",
+		0x00000000: "zero",
+	} {
+		_, err := CompactToTarget(bits)
+		require.ErrorContains(t, err, msg, "%08x", bits)
+	}
+
+	bits, err := TargetBits(0)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0x2100ffff), bits)
+	bits, err = TargetBits(32)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0x1d00ffff), bits)
+}
+
+func TestRetarget(t *testing.T) {
+	net := &chaincfg.MainNetParams
+	start := time.Unix(1600000000, 0)
+	for _, test := range []struct {
+		timespan time.Duration
+		bits     uint32
+	}{
+		{net.TargetTimespan, 0x1b0404cb},
+		{net.TargetTimespan / 2, 0x1b020265},
+		// clamped to a quarter and to four times the target timespan
+		{net.TargetTimespan / 8, 0x1b010132},
+		{net.TargetTimespan * 8, 0x1b10132c},
+	} {
+		bits, err := Retarget(start, start.Add(test.timespan), 0x1b0404cb, net)
+		require.NoError(t, err)
+		require.Equal(t, test.bits, bits, "%08x", bits)
+	}
+	bits, err := Retarget(start, start.Add(net.TargetTimespan*2), 0x1d00ffff, net)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0x1d00ffff), bits)
+}
+
+func TestValidateChain(t *testing.T) {
+	genesis := chaincfg.MainNetParams.GenesisBlock.Header
+	block1, err := DecodeHeader(block1Header)
+	require.NoError(t, err)
+	require.Equal(t, block1Hash, block1.BlockHash().String())
+
+	summary, err := ValidateChain([]*wire.BlockHeader{&genesis, block1}, 0, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), summary.TipHeight)
+	require.Equal(t, block1Hash, summary.TipHash)
+	require.Equal(t, "0000000000000000000000000000000000000000000000000000000200020002", summary.ChainWorkHex())
+
+	_, err = ValidateChain([]*wire.BlockHeader{block1, &genesis}, 0, &chaincfg.MainNetParams)
+	require.ErrorContains(t, err, "block 1: previous block")
+}
+
+func TestMineAndRetarget(t *testing.T) {
+	// regtest with a retarget every 10 blocks
+	net := chaincfg.RegressionNetParams
+	net.PoWNoRetargeting = false
+	net.ReduceMinDifficulty = false
+	net.TargetTimespan = 10 * net.TargetTimePerBlock
+
+	miner := NewMiner(4)
+	mine := func(prev *wire.BlockHeader, bits uint32) *wire.BlockHeader {
+		header := wire.BlockHeader{
+			Version:   4,
+			PrevBlock: prev.BlockHash(),
+			Timestamp: prev.Timestamp.Add(2 * net.TargetTimePerBlock),
+			Bits:      bits,
+		}
+		result, err := miner.Mine(context.Background(), header)
+		require.NoError(t, err)
+		require.NoError(t, CheckProofOfWork(&result.Header, &net))
+		require.Equal(t, result.Hash, result.Header.BlockHash())
+		require.NotZero(t, result.Hashes)
+		return &result.Header
+	}
+
+	// blocks twice as slow as expected lower the difficulty at height 20 by
+	// 18/10, the timespan of a period covers one block less than its length.
+	// The series starts at the previous retarget.
+	headers := []*wire.BlockHeader{mine(&net.GenesisBlock.Header, 0x2000ffff)}
+	for i := 1; i <= 10; i++ {
+		bits := uint32(0x2000ffff)
+		if i == 10 {
+			bits = 0x2001cccb
+		}
+		headers = append(headers, mine(headers[i-1], bits))
+	}
+	summary, err := ValidateChain(headers, 10, &net)
+	require.NoError(t, err)
+	require.Equal(t, int32(20), summary.TipHeight)
+	require.Len(t, summary.Retargets, 1)
+	retarget := summary.Retargets[0]
+	require.Equal(t, int32(20), retarget.Height)
+	require.Equal(t, "2000ffff", retarget.PreviousBits)
+	require.Equal(t, "2001cccb", retarget.Bits)
+	require.InDelta(t, 1/1.8, retarget.Change, 1e-4)
+	work := new(big.Int)
+	for _, header := range headers {
+		w, err := Work(header.Bits)
+		require.NoError(t, err)
+		work.Add(work, w)
+	}
+	require.Equal(t, work, summary.ChainWork)
+
+	headers[10] = mine(headers[9], 0x2000ffff)
+	_, err = ValidateChain(headers, 10, &net)
+	require.ErrorContains(t, err, "block 20: bits 2000ffff do not match the required 2001cccb")
+	// without the first block of the period the retarget is not recomputed
+	_, err = ValidateChain(headers[1:], 11, &net)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = miner.Mine(ctx, wire.BlockHeader{Bits: 0x03000001})
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,706 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// psbtMagic is the 5 byte prefix of every serialized PSBT ("psbt" + 0xff)
+var psbtMagic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+// BIP370 (PSBT version 2) key types that have no version 0 equivalent
+const (
+	psbtGlobalUnsignedTx         = 0x00
+	psbtGlobalTxVersion          = 0x02
+	psbtGlobalFallbackLocktime   = 0x03
+	psbtGlobalInputCount         = 0x04
+	psbtGlobalOutputCount        = 0x05
+	psbtGlobalTxModifiable       = 0x06
+	psbtGlobalVersion            = 0xfb
+	psbtInPreviousTxid           = 0x0e
+	psbtInOutputIndex            = 0x0f
+	psbtInSequence               = 0x10
+	psbtInRequiredTimeLocktime   = 0x11
+	psbtInRequiredHeightLocktime = 0x12
+	psbtOutAmount                = 0x03
+	psbtOutScript                = 0x04
+)
+
+// CreatePSBT creates a new PSBT from an unsigned transaction
+func CreatePSBT(tx *wire.MsgTx) (*psbt.Packet, error) {
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create psbt: %v", err)
+	}
+	return packet, nil
+}
+
+// DecodePSBT parses a PSBT given in base64 or hex encoding.
+// Version 2 packets (BIP370) are converted to their version 0 (BIP174) form.
+func DecodePSBT(encoded string) (*psbt.Packet, error) {
+	encoded = strings.TrimSpace(encoded)
+	raw, err := hex.DecodeString(encoded)
+	if err != nil {
+		raw, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("psbt must be base64 or hex encoded")
+		}
+	}
+	if !bytes.HasPrefix(raw, psbtMagic) {
+		return nil, fmt.Errorf("invalid psbt magic bytes")
+	}
+
+	raw, err = convertPSBTv2(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	packet, err := psbt.NewFromRawBytes(bytes.NewReader(raw), false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode psbt: %v", err)
+	}
+	return packet, nil
+}
+
+// EncodePSBT serializes a PSBT to base64
+func EncodePSBT(packet *psbt.Packet) (string, error) {
+	encoded, err := packet.B64Encode()
+	if err != nil {
+		return "", fmt.Errorf("failed to encode psbt: %v", err)
+	}
+	return encoded, nil
+}
+
+// SignPSBT adds a signature from privKey to every input of the PSBT that the
+// key is able to spend. Supported inputs are P2PKH, P2WPKH, P2SH-P2WPKH,
+// P2SH / P2WSH / P2SH-P2WSH scripts containing the key (e.g. multisig), P2TR
+// key-path spends and P2TR script-path spends of the leaves listed in the
+// input that contain the key. It returns the indexes of the signed inputs.
+func SignPSBT(packet *psbt.Packet, privKey *btcec.PrivateKey) ([]int, error) {
+	updater, err := psbt.NewUpdater(packet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create psbt updater: %v", err)
+	}
+
+	prevOuts := txscript.NewMultiPrevOutFetcher(nil)
+	for i, txIn := range packet.UnsignedTx.TxIn {
+		utxo, err := psbtInputUtxo(packet, i)
+		if err != nil {
+			return nil, err
+		}
+		prevOuts.AddPrevOut(txIn.PreviousOutPoint, utxo)
+	}
+
+	tx := packet.UnsignedTx
+	sigHashes := txscript.NewTxSigHashes(tx, prevOuts)
+	pubKey := privKey.PubKey().SerializeCompressed()
+	pubKeyHash := btcutil.Hash160(pubKey)
+
+	var signed []int
+	for i := range packet.Inputs {
+		pInput := &packet.Inputs[i]
+		if pInput.FinalScriptSig != nil || pInput.FinalScriptWitness != nil ||
+			hasPartialSig(pInput, pubKey) {
+			continue
+		}
+
+		utxo := prevOuts.FetchPrevOutput(tx.TxIn[i].PreviousOutPoint)
+		pkScript := utxo.PkScript
+		hashType := pInput.SighashType
+
+		var (
+			sig           []byte
+			redeemScript  []byte
+			witnessScript []byte
+		)
+		switch {
+		case txscript.IsPayToTaproot(pkScript):
+			outputKey := txscript.ComputeTaprootOutputKey(privKey.PubKey(), pInput.TaprootMerkleRoot)
+			if !bytes.Equal(schnorr.SerializePubKey(outputKey), pkScript[2:]) {
+				leafSigned, err := signTapLeaves(pInput, tx, sigHashes, i, utxo, privKey)
+				if err != nil {
+					return nil, err
+				}
+				if leafSigned {
+					signed = append(signed, i)
+				}
+				continue
+			}
+			if pInput.TaprootKeySpendSig != nil {
+				continue
+			}
+			sig, err = txscript.RawTxInTaprootSignature(tx, sigHashes, i, utxo.Value, pkScript,
+				pInput.TaprootMerkleRoot, hashType, privKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to sign input %d: %v", i, err)
+			}
+			pInput.TaprootKeySpendSig = sig
+			signed = append(signed, i)
+			continue
+
+		case txscript.IsPayToWitnessPubKeyHash(pkScript):
+			if !bytes.Equal(pkScript[2:], pubKeyHash) {
+				continue
+			}
+			sig, err = txscript.RawTxInWitnessSignature(tx, sigHashes, i, utxo.Value, pkScript,
+				sigHashTypeOrAll(hashType), privKey)
+
+		case txscript.IsPayToWitnessScriptHash(pkScript):
+			witnessScript = pInput.WitnessScript
+			if witnessScript == nil || !scriptHasPubKey(witnessScript, pubKey) {
+				continue
+			}
+			sig, err = txscript.RawTxInWitnessSignature(tx, sigHashes, i, utxo.Value, witnessScript,
+				sigHashTypeOrAll(hashType), privKey)
+
+		case txscript.IsPayToScriptHash(pkScript):
+			redeemScript = pInput.RedeemScript
+			if redeemScript == nil {
+				continue
+			}
+			switch {
+			case txscript.IsPayToWitnessPubKeyHash(redeemScript):
+				if !bytes.Equal(redeemScript[2:], pubKeyHash) {
+					continue
+				}
+				sig, err = txscript.RawTxInWitnessSignature(tx, sigHashes, i, utxo.Value, redeemScript,
+					sigHashTypeOrAll(hashType), privKey)
+			case txscript.IsPayToWitnessScriptHash(redeemScript):
+				witnessScript = pInput.WitnessScript
+				if witnessScript == nil || !scriptHasPubKey(witnessScript, pubKey) {
+					continue
+				}
+				sig, err = txscript.RawTxInWitnessSignature(tx, sigHashes, i, utxo.Value, witnessScript,
+					sigHashTypeOrAll(hashType), privKey)
+			default:
+				if !scriptHasPubKey(redeemScript, pubKey) {
+					continue
+				}
+				sig, err = txscript.RawTxInSignature(tx, i, redeemScript, sigHashTypeOrAll(hashType), privKey)
+			}
+
+		case txscript.IsPayToPubKeyHash(pkScript):
+			if !bytes.Equal(pkScript[3:23], pubKeyHash) {
+				continue
+			}
+			sig, err = txscript.RawTxInSignature(tx, i, pkScript, sigHashTypeOrAll(hashType), privKey)
+
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign input %d: %v", i, err)
+		}
+
+		if _, err := updater.Sign(i, sig, pubKey, redeemScript, witnessScript); err != nil {
+			return nil, fmt.Errorf("failed to add signature to input %d: %v", i, err)
+		}
+		signed = append(signed, i)
+	}
+
+	return signed, nil
+}
+
+// CombinePSBTs merges the signatures and metadata of several PSBTs for the
+// same unsigned transaction into a single PSBT (BIP174 Combiner role)
+func CombinePSBTs(packets []*psbt.Packet) (*psbt.Packet, error) {
+	if len(packets) == 0 {
+		return nil, fmt.Errorf("no psbt to combine")
+	}
+
+	combined := packets[0]
+	txHash := combined.UnsignedTx.TxHash()
+	for _, other := range packets[1:] {
+		if other.UnsignedTx.TxHash() != txHash {
+			return nil, fmt.Errorf("cannot combine psbts for different transactions")
+		}
+
+		for i := range combined.Inputs {
+			combinePSBTInput(&combined.Inputs[i], &other.Inputs[i])
+		}
+		for i := range combined.Outputs {
+			combinePSBTOutput(&combined.Outputs[i], &other.Outputs[i])
+		}
+		combined.Unknowns = combineUnknowns(combined.Unknowns, other.Unknowns)
+	}
+
+	return combined, nil
+}
+
+// FinalizePSBT builds the final scriptSig / witness for every input that has
+// enough signatures
+func FinalizePSBT(packet *psbt.Packet) error {
+	// multi_a leaves need an empty witness item per missing signature,
+	// which the psbt finalizer does not know about
+	for i := range packet.Inputs {
+		if err := finalizeTapscriptMultisig(&packet.Inputs[i]); err != nil {
+			return fmt.Errorf("failed to finalize input %d: %v", i, err)
+		}
+	}
+	if err := psbt.MaybeFinalizeAll(packet); err != nil {
+		return fmt.Errorf("failed to finalize psbt: %v", err)
+	}
+	return nil
+}
+
+// ExtractPSBTTx returns the network serialized transaction of a finalized PSBT
+func ExtractPSBTTx(packet *psbt.Packet) (*wire.MsgTx, error) {
+	tx, err := psbt.Extract(packet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract transaction: %v", err)
+	}
+	return tx, nil
+}
+
+// psbtInputUtxo returns the output spent by the input at the given index
+func psbtInputUtxo(packet *psbt.Packet, index int) (*wire.TxOut, error) {
+	pInput := packet.Inputs[index]
+	if pInput.WitnessUtxo != nil {
+		return pInput.WitnessUtxo, nil
+	}
+	if pInput.NonWitnessUtxo != nil {
+		outIndex := packet.UnsignedTx.TxIn[index].PreviousOutPoint.Index
+		if int(outIndex) >= len(pInput.NonWitnessUtxo.TxOut) {
+			return nil, fmt.Errorf("input %d: previous output index out of range", index)
+		}
+		return pInput.NonWitnessUtxo.TxOut[outIndex], nil
+	}
+	return nil, fmt.Errorf("input %d: missing utxo information", index)
+}
+
+// signTapLeaves adds a script-path signature for every leaf of the input
+// that contains the x-only key of privKey
+func signTapLeaves(pInput *psbt.PInput, tx *wire.MsgTx, sigHashes *txscript.TxSigHashes, index int,
+	utxo *wire.TxOut, privKey *btcec.PrivateKey) (bool, error) {
+
+	xOnlyKey := schnorr.SerializePubKey(privKey.PubKey())
+	signed := false
+	for _, leafScript := range pInput.TaprootLeafScript {
+		leaf := txscript.NewTapLeaf(leafScript.LeafVersion, leafScript.Script)
+		leafHash := leaf.TapHash()
+		if !scriptHasPubKey(leafScript.Script, xOnlyKey) || hasTapScriptSig(pInput, xOnlyKey, leafHash[:]) {
+			continue
+		}
+		sig, err := txscript.RawTxInTapscriptSignature(tx, sigHashes, index, utxo.Value, utxo.PkScript,
+			leaf, pInput.SighashType, privKey)
+		if err != nil {
+			return false, fmt.Errorf("failed to sign input %d: %v", index, err)
+		}
+		pInput.TaprootScriptSpendSig = append(pInput.TaprootScriptSpendSig, &psbt.TaprootScriptSpendSig{
+			XOnlyPubKey: xOnlyKey,
+			LeafHash:    leafHash[:],
+			Signature:   sig[:schnorr.SignatureSize],
+			SigHash:     pInput.SighashType,
+		})
+		signed = true
+	}
+	return signed, nil
+}
+
+// hasTapScriptSig reports whether the input already carries a script-path
+// signature for the key and leaf
+func hasTapScriptSig(pInput *psbt.PInput, xOnlyKey, leafHash []byte) bool {
+	for _, sig := range pInput.TaprootScriptSpendSig {
+		if bytes.Equal(sig.XOnlyPubKey, xOnlyKey) && bytes.Equal(sig.LeafHash, leafHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPartialSig reports whether the input already carries a signature for pubKey
+func hasPartialSig(pInput *psbt.PInput, pubKey []byte) bool {
+	for _, sig := range pInput.PartialSigs {
+		if bytes.Equal(sig.PubKey, pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func sigHashTypeOrAll(hashType txscript.SigHashType) txscript.SigHashType {
+	if hashType == txscript.SigHashDefault {
+		return txscript.SigHashAll
+	}
+	return hashType
+}
+
+// scriptHasPubKey reports whether pubKey is pushed as data in script
+func scriptHasPubKey(script, pubKey []byte) bool {
+	tokenizer := txscript.MakeScriptTokenizer(0, script)
+	for tokenizer.Next() {
+		if bytes.Equal(tokenizer.Data(), pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func combinePSBTInput(dst, src *psbt.PInput) {
+	if dst.NonWitnessUtxo == nil {
+		dst.NonWitnessUtxo = src.NonWitnessUtxo
+	}
+	if dst.WitnessUtxo == nil {
+		dst.WitnessUtxo = src.WitnessUtxo
+	}
+	if dst.SighashType == 0 {
+		dst.SighashType = src.SighashType
+	}
+	if dst.RedeemScript == nil {
+		dst.RedeemScript = src.RedeemScript
+	}
+	if dst.WitnessScript == nil {
+		dst.WitnessScript = src.WitnessScript
+	}
+	if dst.FinalScriptSig == nil {
+		dst.FinalScriptSig = src.FinalScriptSig
+	}
+	if dst.FinalScriptWitness == nil {
+		dst.FinalScriptWitness = src.FinalScriptWitness
+	}
+	if dst.TaprootKeySpendSig == nil {
+		dst.TaprootKeySpendSig = src.TaprootKeySpendSig
+	}
+	if dst.TaprootInternalKey == nil {
+		dst.TaprootInternalKey = src.TaprootInternalKey
+	}
+	if dst.TaprootMerkleRoot == nil {
+		dst.TaprootMerkleRoot = src.TaprootMerkleRoot
+	}
+
+	for _, sig := range src.PartialSigs {
+		found := false
+		for _, existing := range dst.PartialSigs {
+			if bytes.Equal(existing.PubKey, sig.PubKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst.PartialSigs = append(dst.PartialSigs, sig)
+		}
+	}
+	for _, deriv := range src.Bip32Derivation {
+		found := false
+		for _, existing := range dst.Bip32Derivation {
+			if bytes.Equal(existing.PubKey, deriv.PubKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst.Bip32Derivation = append(dst.Bip32Derivation, deriv)
+		}
+	}
+	for _, sig := range src.TaprootScriptSpendSig {
+		found := false
+		for _, existing := range dst.TaprootScriptSpendSig {
+			if existing.EqualKey(sig) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst.TaprootScriptSpendSig = append(dst.TaprootScriptSpendSig, sig)
+		}
+	}
+	for _, leaf := range src.TaprootLeafScript {
+		found := false
+		for _, existing := range dst.TaprootLeafScript {
+			if bytes.Equal(existing.ControlBlock, leaf.ControlBlock) &&
+				bytes.Equal(existing.Script, leaf.Script) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst.TaprootLeafScript = append(dst.TaprootLeafScript, leaf)
+		}
+	}
+	for _, deriv := range src.TaprootBip32Derivation {
+		found := false
+		for _, existing := range dst.TaprootBip32Derivation {
+			if bytes.Equal(existing.XOnlyPubKey, deriv.XOnlyPubKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst.TaprootBip32Derivation = append(dst.TaprootBip32Derivation, deriv)
+		}
+	}
+	dst.Unknowns = combineUnknowns(dst.Unknowns, src.Unknowns)
+}
+
+func combinePSBTOutput(dst, src *psbt.POutput) {
+	if dst.RedeemScript == nil {
+		dst.RedeemScript = src.RedeemScript
+	}
+	if dst.WitnessScript == nil {
+		dst.WitnessScript = src.WitnessScript
+	}
+	if dst.TaprootInternalKey == nil {
+		dst.TaprootInternalKey = src.TaprootInternalKey
+	}
+	if dst.TaprootTapTree == nil {
+		dst.TaprootTapTree = src.TaprootTapTree
+	}
+	for _, deriv := range src.Bip32Derivation {
+		found := false
+		for _, existing := range dst.Bip32Derivation {
+			if bytes.Equal(existing.PubKey, deriv.PubKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst.Bip32Derivation = append(dst.Bip32Derivation, deriv)
+		}
+	}
+	dst.Unknowns = combineUnknowns(dst.Unknowns, src.Unknowns)
+}
+
+func combineUnknowns(dst, src []*psbt.Unknown) []*psbt.Unknown {
+	for _, u := range src {
+		found := false
+		for _, existing := range dst {
+			if bytes.Equal(existing.Key, u.Key) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, u)
+		}
+	}
+	return dst
+}
+
+// psbtKeyValue is a raw key-value pair of a PSBT map
+type psbtKeyValue struct {
+	key   []byte
+	value []byte
+}
+
+// convertPSBTv2 rewrites a serialized BIP370 (version 2) PSBT into the
+// equivalent BIP174 (version 0) serialization by rebuilding the global
+// unsigned transaction from the per-input and per-output fields.
+// Version 0 packets are returned unchanged.
+func convertPSBTv2(raw []byte) ([]byte, error) {
+	r := bytes.NewReader(raw[len(psbtMagic):])
+	global, err := readPSBTMap(r)
+	if err != nil {
+		return nil, err
+	}
+
+	version := uint32(0)
+	if v := findPSBTValue(global, psbtGlobalVersion); v != nil {
+		if len(v) != 4 {
+			return nil, fmt.Errorf("invalid psbt version field")
+		}
+		version = binary.LittleEndian.Uint32(v)
+	}
+	if version == 0 {
+		return raw, nil
+	}
+	if version != 2 {
+		return nil, fmt.Errorf("unsupported psbt version: %d", version)
+	}
+
+	txVersion := findPSBTValue(global, psbtGlobalTxVersion)
+	inputCount := findPSBTValue(global, psbtGlobalInputCount)
+	outputCount := findPSBTValue(global, psbtGlobalOutputCount)
+	if len(txVersion) != 4 || inputCount == nil || outputCount == nil {
+		return nil, fmt.Errorf("psbt v2 is missing required global fields")
+	}
+	numInputs, err := wire.ReadVarInt(bytes.NewReader(inputCount), 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid psbt v2 input count: %v", err)
+	}
+	numOutputs, err := wire.ReadVarInt(bytes.NewReader(outputCount), 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid psbt v2 output count: %v", err)
+	}
+	// Every map ends with at least a one byte separator, so counts larger
+	// than the remaining bytes cannot be honest
+	if remaining := uint64(r.Len()); numInputs > remaining || numOutputs > remaining-numInputs {
+		return nil, fmt.Errorf("psbt v2 declares %d inputs and %d outputs but only %d bytes remain", numInputs, numOutputs, remaining)
+	}
+
+	tx := wire.NewMsgTx(int32(binary.LittleEndian.Uint32(txVersion)))
+	inputs := make([][]psbtKeyValue, numInputs)
+	var (
+		heightLocks, timeLocks []uint32
+		timeOnly, heightOnly   bool
+	)
+	for i := range inputs {
+		if inputs[i], err = readPSBTMap(r); err != nil {
+			return nil, err
+		}
+		txid := findPSBTValue(inputs[i], psbtInPreviousTxid)
+		vout := findPSBTValue(inputs[i], psbtInOutputIndex)
+		if len(txid) != chainhash.HashSize || len(vout) != 4 {
+			return nil, fmt.Errorf("psbt v2 input %d is missing its previous outpoint", i)
+		}
+		hash, _ := chainhash.NewHash(txid)
+		txIn := wire.NewTxIn(wire.NewOutPoint(hash, binary.LittleEndian.Uint32(vout)), nil, nil)
+		if seq := findPSBTValue(inputs[i], psbtInSequence); len(seq) == 4 {
+			txIn.Sequence = binary.LittleEndian.Uint32(seq)
+		}
+		tx.AddTxIn(txIn)
+
+		timeLock := findPSBTValue(inputs[i], psbtInRequiredTimeLocktime)
+		heightLock := findPSBTValue(inputs[i], psbtInRequiredHeightLocktime)
+		if len(timeLock) == 4 {
+			timeLocks = append(timeLocks, binary.LittleEndian.Uint32(timeLock))
+		}
+		if len(heightLock) == 4 {
+			heightLocks = append(heightLocks, binary.LittleEndian.Uint32(heightLock))
+		}
+		timeOnly = timeOnly || (timeLock != nil && heightLock == nil)
+		heightOnly = heightOnly || (heightLock != nil && timeLock == nil)
+	}
+
+	outputs := make([][]psbtKeyValue, numOutputs)
+	for i := range outputs {
+		if outputs[i], err = readPSBTMap(r); err != nil {
+			return nil, err
+		}
+		amount := findPSBTValue(outputs[i], psbtOutAmount)
+		script := findPSBTValue(outputs[i], psbtOutScript)
+		if len(amount) != 8 || script == nil {
+			return nil, fmt.Errorf("psbt v2 output %d is missing its amount or script", i)
+		}
+		tx.AddTxOut(wire.NewTxOut(int64(binary.LittleEndian.Uint64(amount)), script))
+	}
+
+	// Locktime determination algorithm from BIP370: prefer height based
+	// locks unless some input can only be satisfied by a time based one
+	switch {
+	case len(heightLocks) > 0 && !timeOnly:
+		tx.LockTime = maxUint32(heightLocks)
+	case len(timeLocks) > 0 && !heightOnly:
+		tx.LockTime = maxUint32(timeLocks)
+	case len(heightLocks) > 0 || len(timeLocks) > 0:
+		return nil, fmt.Errorf("psbt v2 inputs have incompatible locktime requirements")
+	default:
+		if fallback := findPSBTValue(global, psbtGlobalFallbackLocktime); len(fallback) == 4 {
+			tx.LockTime = binary.LittleEndian.Uint32(fallback)
+		}
+	}
+
+	var unsignedTx bytes.Buffer
+	if err := tx.SerializeNoWitness(&unsignedTx); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(psbtMagic)
+	v0Global := []psbtKeyValue{{key: []byte{psbtGlobalUnsignedTx}, value: unsignedTx.Bytes()}}
+	v0Global = append(v0Global, filterPSBTMap(global, psbtGlobalTxVersion, psbtGlobalFallbackLocktime,
+		psbtGlobalInputCount, psbtGlobalOutputCount, psbtGlobalTxModifiable, psbtGlobalVersion)...)
+	if err := writePSBTMap(&buf, v0Global); err != nil {
+		return nil, err
+	}
+	for _, in := range inputs {
+		err := writePSBTMap(&buf, filterPSBTMap(in, psbtInPreviousTxid, psbtInOutputIndex,
+			psbtInSequence, psbtInRequiredTimeLocktime, psbtInRequiredHeightLocktime))
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, out := range outputs {
+		if err := writePSBTMap(&buf, filterPSBTMap(out, psbtOutAmount, psbtOutScript)); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func readPSBTMap(r io.Reader) ([]psbtKeyValue, error) {
+	var kvs []psbtKeyValue
+	for {
+		keyLen, err := wire.ReadVarInt(r, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read psbt key: %v", err)
+		}
+		if keyLen == 0 {
+			return kvs, nil
+		}
+		if keyLen > psbt.MaxPsbtKeyLength {
+			return nil, fmt.Errorf("psbt key length %d exceeds maximum of %d", keyLen, psbt.MaxPsbtKeyLength)
+		}
+		key := make([]byte, keyLen)
+		if _, err := io.ReadFull(r, key); err != nil {
+			return nil, fmt.Errorf("failed to read psbt key: %v", err)
+		}
+		value, err := wire.ReadVarBytes(r, 0, psbt.MaxPsbtValueLength, "psbt value")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read psbt value: %v", err)
+		}
+		kvs = append(kvs, psbtKeyValue{key: key, value: value})
+	}
+}
+
+func writePSBTMap(w io.Writer, kvs []psbtKeyValue) error {
+	for _, kv := range kvs {
+		if err := wire.WriteVarBytes(w, 0, kv.key); err != nil {
+			return err
+		}
+		if err := wire.WriteVarBytes(w, 0, kv.value); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write([]byte{0x00})
+	return err
+}
+
+func findPSBTValue(kvs []psbtKeyValue, keyType byte) []byte {
+	for _, kv := range kvs {
+		if len(kv.key) == 1 && kv.key[0] == keyType {
+			return kv.value
+		}
+	}
+	return nil
+}
+
+// filterPSBTMap drops the single byte keys of the given types
+func filterPSBTMap(kvs []psbtKeyValue, keyTypes ...byte) []psbtKeyValue {
+	var filtered []psbtKeyValue
+	for _, kv := range kvs {
+		drop := false
+		for _, keyType := range keyTypes {
+			if len(kv.key) == 1 && kv.key[0] == keyType {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			filtered = append(filtered, kv)
+		}
+	}
+	return filtered
+}
+
+func maxUint32(values []uint32) uint32 {
+	var max uint32
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
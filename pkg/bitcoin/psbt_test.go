@@ -0,0 +1,217 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPrevTx returns a funding transaction paying to P2WPKH(keyA),
+// P2TR(keyB) and a 2-of-2 P2SH multisig of keyC and keyD
+func newTestPrevTx(t *testing.T, keys []*btcec.PrivateKey) (*wire.MsgTx, []byte) {
+	t.Helper()
+
+	p2wpkh, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_0).
+		AddData(btcutil.Hash160(keys[0].PubKey().SerializeCompressed())).
+		Script()
+	require.NoError(t, err)
+
+	outputKey := txscript.ComputeTaprootKeyNoScript(keys[1].PubKey())
+	p2tr, err := txscript.PayToTaprootScript(outputKey)
+	require.NoError(t, err)
+
+	redeemScript, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_2).
+		AddData(keys[2].PubKey().SerializeCompressed()).
+		AddData(keys[3].PubKey().SerializeCompressed()).
+		AddOp(txscript.OP_2).
+		AddOp(txscript.OP_CHECKMULTISIG).
+		Script()
+	require.NoError(t, err)
+	p2sh, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_HASH160).
+		AddData(btcutil.Hash160(redeemScript)).
+		AddOp(txscript.OP_EQUAL).
+		Script()
+	require.NoError(t, err)
+
+	prevTx := wire.NewMsgTx(wire.TxVersion)
+	prevHash, _ := chainhash.NewHashFromStr("1234567890123456789012345678901234567890123456789012345678901234")
+	prevTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(prevHash, 0), nil, nil))
+	prevTx.AddTxOut(wire.NewTxOut(50000, p2wpkh))
+	prevTx.AddTxOut(wire.NewTxOut(60000, p2tr))
+	prevTx.AddTxOut(wire.NewTxOut(70000, p2sh))
+	return prevTx, redeemScript
+}
+
+func TestPSBTSignCombineFinalize(t *testing.T) {
+	keys := make([]*btcec.PrivateKey, 4)
+	for i := range keys {
+		var err error
+		keys[i], err = btcec.NewPrivateKey()
+		require.NoError(t, err)
+	}
+	prevTx, redeemScript := newTestPrevTx(t, keys)
+	prevHash := prevTx.TxHash()
+
+	tx := wire.NewMsgTx(2)
+	for i := range prevTx.TxOut {
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, uint32(i)), nil, nil))
+	}
+	tx.AddTxOut(wire.NewTxOut(170000, prevTx.TxOut[0].PkScript))
+
+	packet, err := CreatePSBT(tx)
+	require.NoError(t, err)
+	packet.Inputs[0].WitnessUtxo = prevTx.TxOut[0]
+	packet.Inputs[1].WitnessUtxo = prevTx.TxOut[1]
+	packet.Inputs[2].NonWitnessUtxo = prevTx
+	packet.Inputs[2].RedeemScript = redeemScript
+
+	encoded, err := EncodePSBT(packet)
+	require.NoError(t, err)
+
+	// first signer holds keys A, B and C
+	first, err := DecodePSBT(encoded)
+	require.NoError(t, err)
+	for i, key := range keys[:3] {
+		signed, err := SignPSBT(first, key)
+		require.NoError(t, err)
+		require.Equal(t, []int{i}, signed)
+	}
+	require.False(t, first.IsComplete())
+
+	// second signer holds key D, given as hex
+	var raw bytes.Buffer
+	require.NoError(t, packet.Serialize(&raw))
+	second, err := DecodePSBT(hex.EncodeToString(raw.Bytes()))
+	require.NoError(t, err)
+	signed, err := SignPSBT(second, keys[3])
+	require.NoError(t, err)
+	require.Equal(t, []int{2}, signed)
+
+	combined, err := CombinePSBTs([]*psbt.Packet{first, second})
+	require.NoError(t, err)
+	require.Len(t, combined.Inputs[2].PartialSigs, 2)
+
+	require.NoError(t, FinalizePSBT(combined))
+	require.True(t, combined.IsComplete())
+
+	finalTx, err := ExtractPSBTTx(combined)
+	require.NoError(t, err)
+
+	prevOuts := txscript.NewMultiPrevOutFetcher(nil)
+	for i, txIn := range finalTx.TxIn {
+		prevOuts.AddPrevOut(txIn.PreviousOutPoint, prevTx.TxOut[i])
+	}
+	sigHashes := txscript.NewTxSigHashes(finalTx, prevOuts)
+	for i := range finalTx.TxIn {
+		vm, err := txscript.NewEngine(prevTx.TxOut[i].PkScript, finalTx, i,
+			txscript.StandardVerifyFlags, nil, sigHashes, prevTx.TxOut[i].Value, prevOuts)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute(), "input %d", i)
+	}
+}
+
+func TestCombinePSBTsDifferentTx(t *testing.T) {
+	prevHash, _ := chainhash.NewHashFromStr("1234567890123456789012345678901234567890123456789012345678901234")
+	txA := wire.NewMsgTx(2)
+	txA.AddTxIn(wire.NewTxIn(wire.NewOutPoint(prevHash, 0), nil, nil))
+	txA.AddTxOut(wire.NewTxOut(1000, []byte{txscript.OP_TRUE}))
+	txB := txA.Copy()
+	txB.TxOut[0].Value = 2000
+
+	a, err := CreatePSBT(txA)
+	require.NoError(t, err)
+	b, err := CreatePSBT(txB)
+	require.NoError(t, err)
+	_, err = CombinePSBTs([]*psbt.Packet{a, b})
+	require.Error(t, err)
+}
+
+func TestDecodePSBTv2(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToTaprootScript(txscript.ComputeTaprootKeyNoScript(key.PubKey()))
+	require.NoError(t, err)
+	prevHash, _ := chainhash.NewHashFromStr("1234567890123456789012345678901234567890123456789012345678901234")
+
+	u32 := func(v uint32) []byte {
+		b := make([]byte, 4)
+		binary.LittleEndian.PutUint32(b, v)
+		return b
+	}
+	u64 := func(v uint64) []byte {
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, v)
+		return b
+	}
+	var witnessUtxo bytes.Buffer
+	require.NoError(t, wire.WriteTxOut(&witnessUtxo, 0, 0, wire.NewTxOut(10000, pkScript)))
+
+	var buf bytes.Buffer
+	buf.Write(psbtMagic)
+	require.NoError(t, writePSBTMap(&buf, []psbtKeyValue{
+		{key: []byte{psbtGlobalTxVersion}, value: u32(2)},
+		{key: []byte{psbtGlobalFallbackLocktime}, value: u32(0)},
+		{key: []byte{psbtGlobalInputCount}, value: []byte{1}},
+		{key: []byte{psbtGlobalOutputCount}, value: []byte{1}},
+		{key: []byte{psbtGlobalVersion}, value: u32(2)},
+	}))
+	require.NoError(t, writePSBTMap(&buf, []psbtKeyValue{
+		{key: []byte{0x01}, value: witnessUtxo.Bytes()},
+		{key: []byte{psbtInPreviousTxid}, value: prevHash[:]},
+		{key: []byte{psbtInOutputIndex}, value: u32(1)},
+		{key: []byte{psbtInSequence}, value: u32(0xfffffffd)},
+		{key: []byte{psbtInRequiredHeightLocktime}, value: u32(800000)},
+	}))
+	require.NoError(t, writePSBTMap(&buf, []psbtKeyValue{
+		{key: []byte{psbtOutAmount}, value: u64(9000)},
+		{key: []byte{psbtOutScript}, value: pkScript},
+	}))
+
+	packet, err := DecodePSBT(hex.EncodeToString(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, int32(2), packet.UnsignedTx.Version)
+	require.Equal(t, uint32(800000), packet.UnsignedTx.LockTime)
+	require.Equal(t, *prevHash, packet.UnsignedTx.TxIn[0].PreviousOutPoint.Hash)
+	require.Equal(t, uint32(1), packet.UnsignedTx.TxIn[0].PreviousOutPoint.Index)
+	require.Equal(t, uint32(0xfffffffd), packet.UnsignedTx.TxIn[0].Sequence)
+	require.Equal(t, int64(9000), packet.UnsignedTx.TxOut[0].Value)
+	require.Equal(t, int64(10000), packet.Inputs[0].WitnessUtxo.Value)
+
+	signed, err := SignPSBT(packet, key)
+	require.NoError(t, err)
+	require.Equal(t, []int{0}, signed)
+	require.Len(t, packet.Inputs[0].TaprootKeySpendSig, schnorr.SignatureSize)
+}
+
+func TestDecodePSBTv2OversizedCounts(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(psbtMagic)
+	require.NoError(t, writePSBTMap(&buf, []psbtKeyValue{
+		{key: []byte{psbtGlobalTxVersion}, value: []byte{2, 0, 0, 0}},
+		{key: []byte{psbtGlobalInputCount}, value: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}},
+		{key: []byte{psbtGlobalOutputCount}, value: []byte{0}},
+		{key: []byte{psbtGlobalVersion}, value: []byte{2, 0, 0, 0}},
+	}))
+	require.Len(t, buf.Bytes(), 36)
+
+	_, err := DecodePSBT(hex.EncodeToString(buf.Bytes()))
+	require.ErrorContains(t, err, "bytes remain")
+
+	// a key length far beyond the remaining input must not be allocated
+	oversizedKey := append(append([]byte{}, psbtMagic...), 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f)
+	_, err = DecodePSBT(hex.EncodeToString(oversizedKey))
+	require.Error(t, err)
+}
@@ -0,0 +1,280 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// rpcTimeout bounds every request; scantxoutset can take minutes on mainnet
+const rpcTimeout = 5 * time.Minute
+
+// RPCConfig holds the endpoint and credentials of a Bitcoin Core node.
+// CookieFile, when set, takes precedence over User and Password.
+type RPCConfig struct {
+	URL        string
+	User       string
+	Password   string
+	CookieFile string
+}
+
+// RPCClient is a Bitcoin Core JSON-RPC client
+type RPCClient struct {
+	url        string
+	user       string
+	password   string
+	httpClient *http.Client
+	id         atomic.Uint64
+}
+
+// RPCError is an error returned by the node
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// BlockchainInfo is the result of getblockchaininfo
+type BlockchainInfo struct {
+	Chain                string      `json:"chain"`
+	Blocks               int64       `json:"blocks"`
+	Headers              int64       `json:"headers"`
+	BestBlockHash        string      `json:"bestblockhash"`
+	Difficulty           float64     `json:"difficulty"`
+	Time                 int64       `json:"time"`
+	MedianTime           int64       `json:"mediantime"`
+	VerificationProgress float64     `json:"verificationprogress"`
+	InitialBlockDownload bool        `json:"initialblockdownload"`
+	ChainWork            string      `json:"chainwork"`
+	SizeOnDisk           int64       `json:"size_on_disk"`
+	Pruned               bool        `json:"pruned"`
+	Warnings             interface{} `json:"warnings"`
+}
+
+// MempoolAcceptResult is the result of testmempoolaccept for a transaction
+type MempoolAcceptResult struct {
+	TxID         string `json:"txid"`
+	WTxID        string `json:"wtxid"`
+	Allowed      bool   `json:"allowed"`
+	VSize        int64  `json:"vsize,omitempty"`
+	RejectReason string `json:"reject-reason,omitempty"`
+	Fees         *struct {
+		Base float64 `json:"base"`
+	} `json:"fees,omitempty"`
+}
+
+// ScanTxOutSetResult is the result of scantxoutset. Its unspent outputs
+// can be used to fund transactions.
+type ScanTxOutSetResult struct {
+	Success     bool    `json:"success"`
+	TxOuts      int64   `json:"txouts"`
+	Height      int64   `json:"height"`
+	BestBlock   string  `json:"bestblock"`
+	Unspents    []UTXO  `json:"unspents"`
+	TotalAmount float64 `json:"total_amount"`
+}
+
+// FeeEstimate is the result of estimatesmartfee. FeeRate is in BTC/kvB.
+type FeeEstimate struct {
+	FeeRate float64  `json:"feerate"`
+	Errors  []string `json:"errors,omitempty"`
+	Blocks  int64    `json:"blocks"`
+}
+
+// SatPerVByte returns the fee rate in sat/vB
+func (f *FeeEstimate) SatPerVByte() float64 {
+	return f.FeeRate * 1e8 / 1000
+}
+
+// NewRPCClient creates a client for the node at cfg.URL, authenticated
+// with the cookie file or the user and password of cfg
+func NewRPCClient(cfg RPCConfig) (*RPCClient, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("rpc url is required")
+	}
+	client := &RPCClient{
+		url:        cfg.URL,
+		user:       cfg.User,
+		password:   cfg.Password,
+		httpClient: &http.Client{Timeout: rpcTimeout},
+	}
+	if cfg.CookieFile != "" {
+		cookie, err := os.ReadFile(cfg.CookieFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cookie file: %v", err)
+		}
+		user, password, found := strings.Cut(strings.TrimSpace(string(cookie)), ":")
+		if !found {
+			return nil, fmt.Errorf("invalid cookie file %s", cfg.CookieFile)
+		}
+		client.user, client.password = user, password
+	}
+	return client, nil
+}
+
+// Call invokes method with params and decodes its result into result,
+// which may be nil to ignore it
+func (c *RPCClient) Call(method string, params []interface{}, result interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(rpcRequest{JSONRPC: "1.0", ID: c.id.Add(1), Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %v", method, err)
+	}
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %v", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.user != "" || c.password != "" {
+		req.SetBasicAuth(c.user, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %v", method, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %v", method, err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("failed to call %s: invalid rpc credentials", method)
+	}
+
+	// Bitcoin Core answers errors with a 4xx/5xx status and a JSON body
+	var rpcResp rpcResponse
+	if err := json.Unmarshal(data, &rpcResp); err != nil {
+		return fmt.Errorf("failed to call %s: %s", method, resp.Status)
+	}
+	if rpcResp.Error != nil {
+		return rpcResp.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(rpcResp.Result, result); err != nil {
+		return fmt.Errorf("failed to decode %s result: %v", method, err)
+	}
+	return nil
+}
+
+// GetBlockchainInfo returns the state of the node's chain
+func (c *RPCClient) GetBlockchainInfo() (*BlockchainInfo, error) {
+	var info BlockchainInfo
+	if err := c.Call("getblockchaininfo", nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// GetBestBlockHash returns the hash of the chain tip
+func (c *RPCClient) GetBestBlockHash() (string, error) {
+	var hash string
+	if err := c.Call("getbestblockhash", nil, &hash); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// GetRawMempool returns the ids of the transactions in the mempool
+func (c *RPCClient) GetRawMempool() ([]string, error) {
+	var txids []string
+	if err := c.Call("getrawmempool", nil, &txids); err != nil {
+		return nil, err
+	}
+	return txids, nil
+}
+
+// GetRawTransaction returns a transaction by id, in hex and decoded.
+// Transactions outside the mempool require -txindex or blockHash.
+func (c *RPCClient) GetRawTransaction(txid, blockHash string) (string, *wire.MsgTx, error) {
+	params := []interface{}{txid, false}
+	if blockHash != "" {
+		params = append(params, blockHash)
+	}
+	var rawTx string
+	if err := c.Call("getrawtransaction", params, &rawTx); err != nil {
+		return "", nil, err
+	}
+	tx, err := DecodeBitcoinRawTx(rawTx)
+	if err != nil {
+		return "", nil, err
+	}
+	return rawTx, tx, nil
+}
+
+// SendRawTransaction broadcasts a raw transaction and returns its id
+func (c *RPCClient) SendRawTransaction(rawTx string) (string, error) {
+	var txid string
+	if err := c.Call("sendrawtransaction", []interface{}{rawTx}, &txid); err != nil {
+		return "", err
+	}
+	return txid, nil
+}
+
+// TestMempoolAccept checks whether raw transactions would be accepted by
+// the mempool without broadcasting them
+func (c *RPCClient) TestMempoolAccept(rawTxs []string) ([]MempoolAcceptResult, error) {
+	var results []MempoolAcceptResult
+	if err := c.Call("testmempoolaccept", []interface{}{rawTxs}, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ScanTxOutSet searches the UTXO set for outputs matching descriptors
+// (addresses given as addr(<address>))
+func (c *RPCClient) ScanTxOutSet(descriptors []string) (*ScanTxOutSetResult, error) {
+	var result ScanTxOutSetResult
+	if err := c.Call("scantxoutset", []interface{}{"start", descriptors}, &result); err != nil {
+		return nil, err
+	}
+	if err := setUTXOValues(result.Unspents); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// EstimateSmartFee estimates the fee rate needed to confirm within
+// confTarget blocks. mode is "economical", "conservative" or empty for the
+// node's default.
+func (c *RPCClient) EstimateSmartFee(confTarget int, mode string) (*FeeEstimate, error) {
+	params := []interface{}{confTarget}
+	if mode != "" {
+		params = append(params, mode)
+	}
+	var estimate FeeEstimate
+	if err := c.Call("estimatesmartfee", params, &estimate); err != nil {
+		return nil, err
+	}
+	if estimate.FeeRate == 0 && len(estimate.Errors) > 0 {
+		return nil, fmt.Errorf("failed to estimate fee: %s", strings.Join(estimate.Errors, ", "))
+	}
+	return &estimate, nil
+}
@@ -0,0 +1,127 @@
+package script
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ExecOptions sets the context a script is executed in
+type ExecOptions struct {
+	// Tx is the spending transaction. When nil a transaction with a single
+	// input spending the script is made up from Version, Sequence and
+	// LockTime, so signature checks will fail but timelocks can be tested.
+	Tx         *wire.MsgTx
+	InputIndex int
+	Version    int32
+	Sequence   uint32
+	LockTime   uint32
+
+	// Amount is the value of the spent output
+	Amount int64
+	// PrevOuts holds the outputs spent by the other inputs of Tx, needed
+	// to check taproot signatures
+	PrevOuts map[wire.OutPoint]*wire.TxOut
+	// Flags defaults to the standard verification flags
+	Flags txscript.ScriptFlags
+}
+
+// Step is the state of the interpreter after executing an opcode
+type Step struct {
+	// Opcode is the executed opcode, as "<script index>:<offset>: <asm>"
+	Opcode   string
+	Stack    [][]byte
+	AltStack [][]byte
+}
+
+// Trace records the execution of a script
+type Trace struct {
+	Steps []Step
+	// FailedAt is the opcode whose execution failed, empty when the
+	// failure happened outside opcode execution (e.g. final stack checks)
+	FailedAt string
+	Err      error
+}
+
+// Valid reports whether the execution succeeded
+func (t *Trace) Valid() bool {
+	return t.Err == nil
+}
+
+// Execute runs sigScript and witness against pkScript one opcode at a time,
+// recording the stacks after each step. Script failures are reported in the
+// trace; the error is only set when the engine cannot be created.
+func Execute(pkScript, sigScript []byte, witness wire.TxWitness, opts ExecOptions) (*Trace, error) {
+	tx := opts.Tx
+	if tx == nil {
+		tx = newSpendingTx(pkScript, opts)
+	} else {
+		tx = tx.Copy()
+	}
+	if opts.InputIndex < 0 || opts.InputIndex >= len(tx.TxIn) {
+		return nil, fmt.Errorf("input index %d out of range", opts.InputIndex)
+	}
+	tx.TxIn[opts.InputIndex].SignatureScript = sigScript
+	tx.TxIn[opts.InputIndex].Witness = witness
+
+	fetcher := txscript.NewMultiPrevOutFetcher(opts.PrevOuts)
+	fetcher.AddPrevOut(tx.TxIn[opts.InputIndex].PreviousOutPoint, wire.NewTxOut(opts.Amount, pkScript))
+	flags := opts.Flags
+	if flags == 0 {
+		flags = txscript.StandardVerifyFlags
+	}
+
+	vm, err := txscript.NewEngine(pkScript, tx, opts.InputIndex, flags, nil,
+		txscript.NewTxSigHashes(tx, fetcher), opts.Amount, fetcher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create script engine: %v", err)
+	}
+
+	trace := &Trace{}
+	for {
+		// The program counter is invalid once the last opcode of a script
+		// ran and the engine moves on to the next one
+		opcode, _ := vm.DisasmPC()
+		done, err := vm.Step()
+		if err != nil {
+			trace.FailedAt = opcode
+			trace.Err = err
+			return trace, nil
+		}
+		if opcode != "" {
+			trace.Steps = append(trace.Steps, Step{
+				Opcode:   opcode,
+				Stack:    vm.GetStack(),
+				AltStack: vm.GetAltStack(),
+			})
+		}
+		if done {
+			break
+		}
+	}
+	trace.Err = vm.CheckErrorCondition(true)
+	return trace, nil
+}
+
+// newSpendingTx makes up a transaction spending a single output paying to
+// pkScript, in the fashion of BIP322
+func newSpendingTx(pkScript []byte, opts ExecOptions) *wire.MsgTx {
+	credit := wire.NewMsgTx(0)
+	credit.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex), nil, nil))
+	credit.AddTxOut(wire.NewTxOut(opts.Amount, pkScript))
+	creditHash := credit.TxHash()
+
+	version := opts.Version
+	if version == 0 {
+		version = 2
+	}
+	spend := wire.NewMsgTx(version)
+	txIn := wire.NewTxIn(wire.NewOutPoint(&creditHash, 0), nil, nil)
+	txIn.Sequence = opts.Sequence
+	spend.AddTxIn(txIn)
+	spend.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+	spend.LockTime = opts.LockTime
+	return spend
+}
@@ -0,0 +1,186 @@
+// Package script assembles, disassembles and executes Bitcoin scripts
+package script
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// maxNumberDigits is the longest all-digit token read as a number rather
+// than as hex data: script numbers are at most 5 bytes (locktimes)
+const maxNumberDigits = 10
+
+// opcodesByName maps opcode names, with and without the OP_ prefix, to
+// their value. The common CLTV and CSV abbreviations are accepted too.
+var opcodesByName = func() map[string]byte {
+	names := map[string]byte{
+		"OP_CLTV": txscript.OP_CHECKLOCKTIMEVERIFY,
+		"CLTV":    txscript.OP_CHECKLOCKTIMEVERIFY,
+		"OP_CSV":  txscript.OP_CHECKSEQUENCEVERIFY,
+		"CSV":     txscript.OP_CHECKSEQUENCEVERIFY,
+	}
+	for name, op := range txscript.OpcodeByName {
+		names[name] = op
+		names[strings.TrimPrefix(name, "OP_")] = op
+	}
+	return names
+}()
+
+// Assemble compiles a script written in ASM. Tokens are separated by
+// whitespace and can be:
+//   - opcode names, with or without the OP_ prefix (OP_DUP, CHECKSIG)
+//   - decimal numbers of up to 10 digits, pushed with minimal encoding (144, -1)
+//   - hex data, pushed with the smallest push opcode (76a9...)
+//   - 0x prefixed hex, inserted verbatim without a push opcode (0x4c02abcd)
+//   - single quoted text, pushed as bytes ('hello')
+func Assemble(asm string) ([]byte, error) {
+	builder := txscript.NewScriptBuilder()
+	var raw []byte
+	flush := func() ([]byte, error) {
+		script, err := builder.Script()
+		if err != nil {
+			return nil, err
+		}
+		return append(raw, script...), nil
+	}
+
+	for _, token := range tokenize(asm) {
+		upper := strings.ToUpper(token)
+		switch {
+		case strings.HasPrefix(token, "'"):
+			if len(token) < 2 || !strings.HasSuffix(token, "'") {
+				return nil, fmt.Errorf("unterminated string %s", token)
+			}
+			builder.AddData([]byte(token[1 : len(token)-1]))
+		case strings.HasPrefix(upper, "0X"):
+			data, err := hex.DecodeString(token[2:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid hex %s: %v", token, err)
+			}
+			// Raw bytes bypass the builder, so flush what it holds first
+			if raw, err = flush(); err != nil {
+				return nil, err
+			}
+			builder = txscript.NewScriptBuilder()
+			raw = append(raw, data...)
+		case isNumber(token):
+			n, err := strconv.ParseInt(token, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %s: %v", token, err)
+			}
+			builder.AddInt64(n)
+		default:
+			if op, ok := opcodesByName[upper]; ok {
+				builder.AddOp(op)
+				continue
+			}
+			data, err := hex.DecodeString(token)
+			if err != nil {
+				return nil, fmt.Errorf("unknown opcode or invalid data: %s", token)
+			}
+			builder.AddData(data)
+		}
+	}
+	return flush()
+}
+
+// Disassemble returns the ASM of a script, failing on malformed scripts.
+// The output is read back by Assemble and Parse as the same script: data
+// pushes that would be mistaken for a number are written as that number
+// when it encodes to the same push, or as 0x prefixed raw bytes otherwise.
+func Disassemble(script []byte) (string, error) {
+	var tokens []string
+	tokenizer := txscript.MakeScriptTokenizer(0, script)
+	start := int32(0)
+	for tokenizer.Next() {
+		op := script[start:tokenizer.ByteIndex()]
+		start = tokenizer.ByteIndex()
+		asm, err := txscript.DisasmString(op)
+		if err != nil {
+			return "", fmt.Errorf("failed to disassemble script: %v", err)
+		}
+		if isNumber(asm) && tokenizer.Opcode() > txscript.OP_0 && tokenizer.Opcode() <= txscript.OP_PUSHDATA4 {
+			asm = disassembleNumericPush(op, tokenizer.Data())
+		}
+		tokens = append(tokens, asm)
+	}
+	if err := tokenizer.Err(); err != nil {
+		asm, _ := txscript.DisasmString(script)
+		return asm, fmt.Errorf("failed to disassemble script: %v", err)
+	}
+
+	// A lone token that is valid hex would be read by Parse as a raw script
+	if len(tokens) == 1 {
+		if _, err := hex.DecodeString(tokens[0]); err == nil {
+			return "0x" + hex.EncodeToString(script), nil
+		}
+	}
+	return strings.Join(tokens, " "), nil
+}
+
+// disassembleNumericPush returns the ASM of a data push whose hex has only
+// digits, which Assemble would otherwise read as a decimal number
+func disassembleNumericPush(op, data []byte) string {
+	if n, err := txscript.MakeScriptNum(data, true, 5); err == nil {
+		decimal := strconv.FormatInt(int64(n), 10)
+		if script, err := txscript.NewScriptBuilder().AddInt64(int64(n)).Script(); err == nil &&
+			isNumber(decimal) && bytes.Equal(script, op) {
+			return decimal
+		}
+	}
+	return "0x" + hex.EncodeToString(op)
+}
+
+// Parse reads a script given either as hex or as ASM
+func Parse(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if script, err := hex.DecodeString(s); err == nil {
+		return script, nil
+	}
+	return Assemble(s)
+}
+
+// tokenize splits ASM on whitespace, keeping quoted strings together
+func tokenize(asm string) []string {
+	var (
+		tokens  []string
+		current strings.Builder
+		quoted  bool
+	)
+	for _, r := range asm {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+			current.WriteRune(r)
+		case !quoted && (r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+func isNumber(token string) bool {
+	digits := strings.TrimPrefix(token, "-")
+	if digits == "" || len(digits) > maxNumberDigits {
+		return false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
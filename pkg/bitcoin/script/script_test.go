@@ -0,0 +1,144 @@
+package script
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssemble(t *testing.T) {
+	tests := []struct {
+		asm  string
+		want string
+	}{
+		{"OP_DUP OP_HASH160 62e907b15cbf27d5425399ebf6f0fb50ebb88f18 OP_EQUALVERIFY OP_CHECKSIG", "76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac"},
+		{"dup hash160 62e907b15cbf27d5425399ebf6f0fb50ebb88f18 equalverify checksig", "76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac"},
+		{"0 1 16 -1 17 144 1000 800000", "0051604f011102900002e8030300350c"},
+		{"'hello world' OP_DROP", "0b68656c6c6f20776f726c6475"},
+		{"OP_1 0x4c01ff OP_2", "514c01ff52"},
+		{"144 OP_CSV OP_DROP 800000 CLTV", "029000b2750300350cb1"},
+	}
+	for _, tt := range tests {
+		script, err := Assemble(tt.asm)
+		require.NoError(t, err, tt.asm)
+		want, err := hex.DecodeString(tt.want)
+		require.NoError(t, err)
+		require.Equal(t, want, script, tt.asm)
+	}
+
+	_, err := Assemble("OP_NOTANOPCODE")
+	require.Error(t, err)
+	_, err = Assemble("'unterminated")
+	require.Error(t, err)
+}
+
+func TestDisassembleRoundTrip(t *testing.T) {
+	script, err := hex.DecodeString("76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac")
+	require.NoError(t, err)
+	asm, err := Disassemble(script)
+	require.NoError(t, err)
+	require.Equal(t, "OP_DUP OP_HASH160 62e907b15cbf27d5425399ebf6f0fb50ebb88f18 OP_EQUALVERIFY OP_CHECKSIG", asm)
+
+	parsed, err := Parse(asm)
+	require.NoError(t, err)
+	require.Equal(t, script, parsed)
+	parsed, err = Parse("76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac")
+	require.NoError(t, err)
+	require.Equal(t, script, parsed)
+
+	_, err = Disassemble([]byte{txscript.OP_PUSHDATA1, 0x05})
+	require.Error(t, err)
+}
+
+func TestDisassembleNumericPushes(t *testing.T) {
+	tests := []struct {
+		script string
+		asm    string
+	}{
+		{"029000b275", "144 OP_CHECKSEQUENCEVERIFY OP_DROP"},
+		{"0300350cb175", "00350c OP_CHECKLOCKTIMEVERIFY OP_DROP"},
+		{"022823b175", "9000 OP_CHECKLOCKTIMEVERIFY OP_DROP"},
+		// non-minimal number encodings and pushes keep their exact bytes
+		{"03900000b275", "0x03900000 OP_CHECKSEQUENCEVERIFY OP_DROP"},
+		{"4c029000b275", "0x4c029000 OP_CHECKSEQUENCEVERIFY OP_DROP"},
+		{"0512345678907551", "0x051234567890 OP_DROP 1"},
+		{"0a12345678901234567890", "0x0a12345678901234567890"},
+		{"02abcd", "0x02abcd"},
+		{"60", "0x60"},
+	}
+	for _, tt := range tests {
+		script, err := hex.DecodeString(tt.script)
+		require.NoError(t, err)
+		asm, err := Disassemble(script)
+		require.NoError(t, err, tt.script)
+		require.Equal(t, tt.asm, asm, tt.script)
+
+		assembled, err := Assemble(asm)
+		require.NoError(t, err, asm)
+		require.Equal(t, script, assembled, asm)
+		parsed, err := Parse(asm)
+		require.NoError(t, err, asm)
+		require.Equal(t, script, parsed, asm)
+	}
+}
+
+func TestExecuteP2PKH(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKey := key.PubKey().SerializeCompressed()
+	pkScript, err := Assemble("OP_DUP OP_HASH160 " + hex.EncodeToString(btcutil.Hash160(pubKey)) + " OP_EQUALVERIFY OP_CHECKSIG")
+	require.NoError(t, err)
+
+	prevHash, _ := chainhash.NewHashFromStr("1234567890123456789012345678901234567890123456789012345678901234")
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(prevHash, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{txscript.OP_TRUE}))
+	sigScript, err := txscript.SignatureScript(tx, 0, pkScript, txscript.SigHashAll, key, true)
+	require.NoError(t, err)
+
+	trace, err := Execute(pkScript, sigScript, nil, ExecOptions{Tx: tx, Amount: 2000})
+	require.NoError(t, err)
+	require.NoError(t, trace.Err)
+	require.True(t, trace.Valid())
+	// 2 pushes in the scriptSig and 5 opcodes in the scriptPubKey
+	require.Len(t, trace.Steps, 7)
+	require.Equal(t, "01:0000: OP_DUP", trace.Steps[2].Opcode)
+	require.Len(t, trace.Steps[2].Stack, 3)
+	require.Equal(t, [][]byte{{0x01}}, trace.Steps[6].Stack)
+
+	// a signature for another key fails at OP_EQUALVERIFY
+	otherKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	sigScript, err = txscript.SignatureScript(tx, 0, pkScript, txscript.SigHashAll, otherKey, true)
+	require.NoError(t, err)
+	trace, err = Execute(pkScript, sigScript, nil, ExecOptions{Tx: tx, Amount: 2000})
+	require.NoError(t, err)
+	require.False(t, trace.Valid())
+	require.Equal(t, "01:0003: OP_EQUALVERIFY", trace.FailedAt)
+}
+
+func TestExecuteTimelock(t *testing.T) {
+	pkScript, err := Assemble("800000 OP_CHECKLOCKTIMEVERIFY OP_DROP OP_TRUE")
+	require.NoError(t, err)
+
+	trace, err := Execute(pkScript, nil, nil, ExecOptions{LockTime: 800000})
+	require.NoError(t, err)
+	require.NoError(t, trace.Err)
+
+	trace, err = Execute(pkScript, nil, nil, ExecOptions{LockTime: 799999})
+	require.NoError(t, err)
+	require.Error(t, trace.Err)
+	require.Equal(t, "01:0001: OP_CHECKLOCKTIMEVERIFY", trace.FailedAt)
+
+	// the final stack check fails outside of any opcode
+	trace, err = Execute([]byte{txscript.OP_FALSE}, nil, nil, ExecOptions{})
+	require.NoError(t, err)
+	require.Error(t, trace.Err)
+	require.Empty(t, trace.FailedAt)
+}
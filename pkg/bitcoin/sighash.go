@@ -0,0 +1,394 @@
+package bitcoin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// SigHashVersion is the signature hashing algorithm used by an input
+type SigHashVersion string
+
+const (
+	SigHashLegacy   SigHashVersion = "legacy"   // pre-segwit
+	SigHashSegwitV0 SigHashVersion = "segwitv0" // BIP143
+	SigHashTaproot  SigHashVersion = "taproot"  // BIP341 / BIP342
+
+	sigHashMask = 0x1f
+	// taprootAnnexTag marks the optional last witness element of taproot inputs
+	taprootAnnexTag = 0x50
+	// codeSeparatorNone is the codesep_pos committed when no
+	// OP_CODESEPARATOR was executed
+	codeSeparatorNone = 0xffffffff
+)
+
+// SigHashComponent is a named field of a signature hash preimage
+type SigHashComponent struct {
+	Name  string
+	Value string
+}
+
+// SigHash is a signature digest together with the preimage it commits to
+type SigHash struct {
+	Version    SigHashVersion
+	HashType   txscript.SigHashType
+	Components []SigHashComponent
+	Preimage   []byte
+	Digest     []byte
+}
+
+func (s *SigHash) add(name string, value []byte) {
+	s.Components = append(s.Components, SigHashComponent{Name: name, Value: hex.EncodeToString(value)})
+	s.Preimage = append(s.Preimage, value...)
+}
+
+var sigHashTypeNames = map[string]txscript.SigHashType{
+	"DEFAULT":      txscript.SigHashDefault,
+	"ALL":          txscript.SigHashAll,
+	"NONE":         txscript.SigHashNone,
+	"SINGLE":       txscript.SigHashSingle,
+	"ANYONECANPAY": txscript.SigHashAnyOneCanPay,
+}
+
+// ParseSigHashType parses a sighash type given by name ("ALL",
+// "SINGLE|ANYONECANPAY", "DEFAULT"...) or as a number ("0x83", "131")
+func ParseSigHashType(s string) (txscript.SigHashType, error) {
+	if value, err := strconv.ParseUint(s, 0, 8); err == nil {
+		return txscript.SigHashType(value), nil
+	}
+	var hashType txscript.SigHashType
+	for _, name := range strings.Split(strings.ToUpper(s), "|") {
+		flag, ok := sigHashTypeNames[strings.TrimPrefix(strings.TrimSpace(name), "SIGHASH_")]
+		if !ok {
+			return 0, fmt.Errorf("invalid sighash type: %s", s)
+		}
+		hashType |= flag
+	}
+	return hashType, nil
+}
+
+// SigHashTypeString returns the name of a sighash type
+func SigHashTypeString(hashType txscript.SigHashType) string {
+	var name string
+	switch hashType & sigHashMask {
+	case txscript.SigHashDefault:
+		if hashType == txscript.SigHashDefault {
+			return "DEFAULT"
+		}
+		name = "0x00"
+	case txscript.SigHashAll:
+		name = "ALL"
+	case txscript.SigHashNone:
+		name = "NONE"
+	case txscript.SigHashSingle:
+		name = "SINGLE"
+	default:
+		name = fmt.Sprintf("0x%02x", uint32(hashType&sigHashMask))
+	}
+	if hashType&txscript.SigHashAnyOneCanPay != 0 {
+		name += "|ANYONECANPAY"
+	}
+	return name
+}
+
+// SigHashVersionForScript returns the algorithm used to sign an input
+// spending pkScript. Nested segwit outputs (P2SH) cannot be told apart from
+// legacy P2SH and are reported as legacy.
+func SigHashVersionForScript(pkScript []byte) SigHashVersion {
+	switch {
+	case txscript.IsPayToTaproot(pkScript):
+		return SigHashTaproot
+	case txscript.IsPayToWitnessPubKeyHash(pkScript), txscript.IsPayToWitnessScriptHash(pkScript):
+		return SigHashSegwitV0
+	}
+	return SigHashLegacy
+}
+
+// CalcLegacySigHash computes the original signature hash of input idx,
+// signing subScript (the spent script, or the redeem script of P2SH inputs)
+func CalcLegacySigHash(tx *wire.MsgTx, idx int, subScript []byte, hashType txscript.SigHashType) (*SigHash, error) {
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return nil, fmt.Errorf("input index %d out of range", idx)
+	}
+	result := &SigHash{Version: SigHashLegacy, HashType: hashType}
+
+	// The infamous SIGHASH_SINGLE bug: signing an input without a
+	// matching output signs the number one
+	if hashType&sigHashMask == txscript.SigHashSingle && idx >= len(tx.TxOut) {
+		result.Digest = make([]byte, chainhash.HashSize)
+		result.Digest[0] = 0x01
+		return result, nil
+	}
+
+	script, err := removeCodeSeparators(subScript)
+	if err != nil {
+		return nil, err
+	}
+
+	txCopy := tx.Copy()
+	for i := range txCopy.TxIn {
+		txCopy.TxIn[i].SignatureScript = nil
+		txCopy.TxIn[i].Witness = nil
+	}
+	txCopy.TxIn[idx].SignatureScript = script
+
+	switch hashType & sigHashMask {
+	case txscript.SigHashNone:
+		txCopy.TxOut = nil
+		zeroOtherSequences(txCopy, idx)
+	case txscript.SigHashSingle:
+		txCopy.TxOut = txCopy.TxOut[:idx+1]
+		for i := 0; i < idx; i++ {
+			txCopy.TxOut[i] = wire.NewTxOut(-1, nil)
+		}
+		zeroOtherSequences(txCopy, idx)
+	}
+	if hashType&txscript.SigHashAnyOneCanPay != 0 {
+		txCopy.TxIn = txCopy.TxIn[idx : idx+1]
+	}
+
+	var buf bytes.Buffer
+	if err := txCopy.SerializeNoWitness(&buf); err != nil {
+		return nil, fmt.Errorf("failed to serialize transaction: %v", err)
+	}
+	result.add("transaction", buf.Bytes())
+	result.add("hash_type", uint32LE(uint32(hashType)))
+	result.Digest = chainhash.DoubleHashB(result.Preimage)
+	return result, nil
+}
+
+// CalcSegwitV0SigHash computes the BIP143 signature hash of input idx.
+// scriptCode is the witness script of P2WSH inputs; P2WPKH programs are
+// expanded to their P2PKH script code.
+func CalcSegwitV0SigHash(tx *wire.MsgTx, idx int, scriptCode []byte, amount int64, hashType txscript.SigHashType) (*SigHash, error) {
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return nil, fmt.Errorf("input index %d out of range", idx)
+	}
+	if txscript.IsPayToWitnessPubKeyHash(scriptCode) {
+		var err error
+		scriptCode, err = txscript.NewScriptBuilder().AddOp(txscript.OP_DUP).AddOp(txscript.OP_HASH160).
+			AddData(scriptCode[2:]).AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG).Script()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := &SigHash{Version: SigHashSegwitV0, HashType: hashType}
+	base := hashType & sigHashMask
+	anyoneCanPay := hashType&txscript.SigHashAnyOneCanPay != 0
+	zero := make([]byte, chainhash.HashSize)
+
+	hashPrevouts, hashSequence, hashOutputs := zero, zero, zero
+	if !anyoneCanPay {
+		hashPrevouts = chainhash.DoubleHashB(serializePrevouts(tx))
+		if base != txscript.SigHashSingle && base != txscript.SigHashNone {
+			hashSequence = chainhash.DoubleHashB(serializeSequences(tx))
+		}
+	}
+	if base != txscript.SigHashSingle && base != txscript.SigHashNone {
+		hashOutputs = chainhash.DoubleHashB(serializeOutputs(tx.TxOut))
+	} else if base == txscript.SigHashSingle && idx < len(tx.TxOut) {
+		hashOutputs = chainhash.DoubleHashB(serializeOutputs(tx.TxOut[idx : idx+1]))
+	}
+
+	txIn := tx.TxIn[idx]
+	result.add("nVersion", uint32LE(uint32(tx.Version)))
+	result.add("hashPrevouts", hashPrevouts)
+	result.add("hashSequence", hashSequence)
+	result.add("outpoint", serializeOutPoint(&txIn.PreviousOutPoint))
+	result.add("scriptCode", serializeVarBytes(scriptCode))
+	result.add("amount", uint64LE(uint64(amount)))
+	result.add("nSequence", uint32LE(txIn.Sequence))
+	result.add("hashOutputs", hashOutputs)
+	result.add("nLockTime", uint32LE(tx.LockTime))
+	result.add("hash_type", uint32LE(uint32(hashType)))
+	result.Digest = chainhash.DoubleHashB(result.Preimage)
+	return result, nil
+}
+
+// CalcTaprootSigHash computes the BIP341 signature hash of input idx.
+// prevOuts are the outputs spent by every input, in input order; only the
+// spent output of idx is needed with ANYONECANPAY, others may be nil.
+// leafHash selects a BIP342 script-path spend and is nil for key-path
+// spends. An annex present in the input witness is committed to.
+func CalcTaprootSigHash(tx *wire.MsgTx, idx int, prevOuts []*wire.TxOut, hashType txscript.SigHashType, leafHash []byte) (*SigHash, error) {
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return nil, fmt.Errorf("input index %d out of range", idx)
+	}
+	if len(prevOuts) != len(tx.TxIn) {
+		return nil, fmt.Errorf("expected %d previous outputs, got %d", len(tx.TxIn), len(prevOuts))
+	}
+	switch hashType {
+	case txscript.SigHashDefault, txscript.SigHashAll, txscript.SigHashNone, txscript.SigHashSingle,
+		txscript.SigHashAll | txscript.SigHashAnyOneCanPay,
+		txscript.SigHashNone | txscript.SigHashAnyOneCanPay,
+		txscript.SigHashSingle | txscript.SigHashAnyOneCanPay:
+	default:
+		return nil, fmt.Errorf("invalid taproot sighash type 0x%02x", uint32(hashType))
+	}
+	if leafHash != nil && len(leafHash) != chainhash.HashSize {
+		return nil, fmt.Errorf("leaf hash must be %d bytes", chainhash.HashSize)
+	}
+
+	base := hashType & 0x03
+	anyoneCanPay := hashType&txscript.SigHashAnyOneCanPay != 0
+	if base == txscript.SigHashSingle && idx >= len(tx.TxOut) {
+		return nil, fmt.Errorf("SIGHASH_SINGLE input %d has no matching output", idx)
+	}
+	for i, prevOut := range prevOuts {
+		if prevOut == nil && (!anyoneCanPay || i == idx) {
+			return nil, fmt.Errorf("missing previous output for input %d", i)
+		}
+	}
+
+	result := &SigHash{Version: SigHashTaproot, HashType: hashType}
+	result.add("epoch", []byte{0x00})
+	result.add("hash_type", []byte{byte(hashType)})
+	result.add("nVersion", uint32LE(uint32(tx.Version)))
+	result.add("nLockTime", uint32LE(tx.LockTime))
+
+	if !anyoneCanPay {
+		var amounts, scripts bytes.Buffer
+		for _, prevOut := range prevOuts {
+			amounts.Write(uint64LE(uint64(prevOut.Value)))
+			scripts.Write(serializeVarBytes(prevOut.PkScript))
+		}
+		result.add("sha_prevouts", sha256Bytes(serializePrevouts(tx)))
+		result.add("sha_amounts", sha256Bytes(amounts.Bytes()))
+		result.add("sha_scriptpubkeys", sha256Bytes(scripts.Bytes()))
+		result.add("sha_sequences", sha256Bytes(serializeSequences(tx)))
+	}
+	if base != txscript.SigHashNone && base != txscript.SigHashSingle {
+		result.add("sha_outputs", sha256Bytes(serializeOutputs(tx.TxOut)))
+	}
+
+	var annex []byte
+	if witness := tx.TxIn[idx].Witness; len(witness) > 1 {
+		if last := witness[len(witness)-1]; len(last) > 0 && last[0] == taprootAnnexTag {
+			annex = last
+		}
+	}
+	var spendType byte
+	if leafHash != nil {
+		spendType |= 0x02
+	}
+	if annex != nil {
+		spendType |= 0x01
+	}
+	result.add("spend_type", []byte{spendType})
+
+	txIn := tx.TxIn[idx]
+	if anyoneCanPay {
+		result.add("outpoint", serializeOutPoint(&txIn.PreviousOutPoint))
+		result.add("amount", uint64LE(uint64(prevOuts[idx].Value)))
+		result.add("scriptPubKey", serializeVarBytes(prevOuts[idx].PkScript))
+		result.add("nSequence", uint32LE(txIn.Sequence))
+	} else {
+		result.add("input_index", uint32LE(uint32(idx)))
+	}
+	if annex != nil {
+		result.add("sha_annex", sha256Bytes(serializeVarBytes(annex)))
+	}
+	if base == txscript.SigHashSingle {
+		result.add("sha_single_output", sha256Bytes(serializeOutputs(tx.TxOut[idx:idx+1])))
+	}
+	if leafHash != nil {
+		result.add("tapleaf_hash", leafHash)
+		result.add("key_version", []byte{0x00})
+		result.add("codesep_pos", uint32LE(codeSeparatorNone))
+	}
+
+	result.Digest = chainhash.TaggedHash(chainhash.TagTapSighash, result.Preimage)[:]
+	return result, nil
+}
+
+// TapLeafHash returns the BIP341 hash of a tapscript leaf with the default
+// leaf version
+func TapLeafHash(script []byte) []byte {
+	hash := txscript.NewBaseTapLeaf(script).TapHash()
+	return hash[:]
+}
+
+func removeCodeSeparators(script []byte) ([]byte, error) {
+	var result []byte
+	tokenizer := txscript.MakeScriptTokenizer(0, script)
+	prev := 0
+	for tokenizer.Next() {
+		offset := int(tokenizer.ByteIndex())
+		if tokenizer.Opcode() != txscript.OP_CODESEPARATOR {
+			result = append(result, script[prev:offset]...)
+		}
+		prev = offset
+	}
+	if err := tokenizer.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse script: %v", err)
+	}
+	return result, nil
+}
+
+func zeroOtherSequences(tx *wire.MsgTx, idx int) {
+	for i := range tx.TxIn {
+		if i != idx {
+			tx.TxIn[i].Sequence = 0
+		}
+	}
+}
+
+func serializeOutPoint(op *wire.OutPoint) []byte {
+	return append(op.Hash.CloneBytes(), uint32LE(op.Index)...)
+}
+
+func serializePrevouts(tx *wire.MsgTx) []byte {
+	var buf bytes.Buffer
+	for _, txIn := range tx.TxIn {
+		buf.Write(serializeOutPoint(&txIn.PreviousOutPoint))
+	}
+	return buf.Bytes()
+}
+
+func serializeSequences(tx *wire.MsgTx) []byte {
+	var buf bytes.Buffer
+	for _, txIn := range tx.TxIn {
+		buf.Write(uint32LE(txIn.Sequence))
+	}
+	return buf.Bytes()
+}
+
+func serializeOutputs(outputs []*wire.TxOut) []byte {
+	var buf bytes.Buffer
+	for _, txOut := range outputs {
+		wire.WriteTxOut(&buf, 0, 0, txOut)
+	}
+	return buf.Bytes()
+}
+
+func serializeVarBytes(data []byte) []byte {
+	var buf bytes.Buffer
+	wire.WriteVarBytes(&buf, 0, data)
+	return buf.Bytes()
+}
+
+func sha256Bytes(data []byte) []byte {
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+func uint32LE(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+func uint64LE(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+	return b
+}
@@ -0,0 +1,143 @@
+package bitcoin
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+var testSigHashTypes = []txscript.SigHashType{
+	txscript.SigHashAll,
+	txscript.SigHashNone,
+	txscript.SigHashSingle,
+	txscript.SigHashAll | txscript.SigHashAnyOneCanPay,
+	txscript.SigHashNone | txscript.SigHashAnyOneCanPay,
+	txscript.SigHashSingle | txscript.SigHashAnyOneCanPay,
+}
+
+// newSigHashTestTx returns a transaction with three inputs and two outputs
+// along with the outputs it spends
+func newSigHashTestTx(t *testing.T, pkScript []byte) (*wire.MsgTx, []*wire.TxOut) {
+	t.Helper()
+	prevHash, _ := chainhash.NewHashFromStr("1234567890123456789012345678901234567890123456789012345678901234")
+	tx := wire.NewMsgTx(2)
+	tx.LockTime = 123456
+	var prevOuts []*wire.TxOut
+	for i := 0; i < 3; i++ {
+		txIn := wire.NewTxIn(wire.NewOutPoint(prevHash, uint32(i)), nil, nil)
+		txIn.Sequence = SequenceRBF - uint32(i)
+		tx.AddTxIn(txIn)
+		prevOuts = append(prevOuts, wire.NewTxOut(int64(10000*(i+1)), pkScript))
+	}
+	tx.AddTxOut(wire.NewTxOut(15000, pkScript))
+	tx.AddTxOut(wire.NewTxOut(14000, []byte{txscript.OP_TRUE}))
+	return tx, prevOuts
+}
+
+func TestParseSigHashType(t *testing.T) {
+	for input, want := range map[string]txscript.SigHashType{
+		"ALL":                 txscript.SigHashAll,
+		"default":             txscript.SigHashDefault,
+		"SINGLE|ANYONECANPAY": txscript.SigHashSingle | txscript.SigHashAnyOneCanPay,
+		"SIGHASH_NONE":        txscript.SigHashNone,
+		"0x82":                txscript.SigHashNone | txscript.SigHashAnyOneCanPay,
+	} {
+		got, err := ParseSigHashType(input)
+		require.NoError(t, err, input)
+		require.Equal(t, want, got, input)
+	}
+	_, err := ParseSigHashType("EVERYTHING")
+	require.Error(t, err)
+
+	require.Equal(t, "SINGLE|ANYONECANPAY", SigHashTypeString(txscript.SigHashSingle|txscript.SigHashAnyOneCanPay))
+	require.Equal(t, "DEFAULT", SigHashTypeString(txscript.SigHashDefault))
+}
+
+func TestCalcLegacySigHash(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pkScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_DUP).AddOp(txscript.OP_HASH160).
+		AddData(btcutil.Hash160(key.PubKey().SerializeCompressed())).
+		AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CODESEPARATOR).AddOp(txscript.OP_CHECKSIG).Script()
+	require.NoError(t, err)
+	tx, _ := newSigHashTestTx(t, pkScript)
+
+	for _, hashType := range testSigHashTypes {
+		for idx := range tx.TxIn {
+			want, err := txscript.CalcSignatureHash(pkScript, hashType, tx, idx)
+			require.NoError(t, err)
+			got, err := CalcLegacySigHash(tx, idx, pkScript, hashType)
+			require.NoError(t, err)
+			require.Equal(t, want, got.Digest, "hash type %s, input %d", SigHashTypeString(hashType), idx)
+		}
+	}
+}
+
+func TestCalcSegwitV0SigHash(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pkScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+		AddData(btcutil.Hash160(key.PubKey().SerializeCompressed())).Script()
+	require.NoError(t, err)
+	tx, prevOuts := newSigHashTestTx(t, pkScript)
+	fetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for i, txIn := range tx.TxIn {
+		fetcher.AddPrevOut(txIn.PreviousOutPoint, prevOuts[i])
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, fetcher)
+
+	for _, hashType := range testSigHashTypes {
+		for idx := range tx.TxIn {
+			want, err := txscript.CalcWitnessSigHash(pkScript, sigHashes, hashType, tx, idx, prevOuts[idx].Value)
+			require.NoError(t, err)
+			got, err := CalcSegwitV0SigHash(tx, idx, pkScript, prevOuts[idx].Value, hashType)
+			require.NoError(t, err)
+			require.Equal(t, want, got.Digest, "hash type %s, input %d", SigHashTypeString(hashType), idx)
+			require.Len(t, got.Components, 10)
+		}
+	}
+}
+
+func TestCalcTaprootSigHash(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToTaprootScript(txscript.ComputeTaprootKeyNoScript(key.PubKey()))
+	require.NoError(t, err)
+	tx, prevOuts := newSigHashTestTx(t, pkScript)
+	fetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for i, txIn := range tx.TxIn {
+		fetcher.AddPrevOut(txIn.PreviousOutPoint, prevOuts[i])
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, fetcher)
+
+	leaf := txscript.NewBaseTapLeaf([]byte{txscript.OP_TRUE})
+	leafHash := leaf.TapHash()
+	require.Equal(t, leafHash[:], TapLeafHash([]byte{txscript.OP_TRUE}))
+
+	for _, hashType := range append([]txscript.SigHashType{txscript.SigHashDefault}, testSigHashTypes...) {
+		for idx := range tx.TxIn {
+			if hashType&0x03 == txscript.SigHashSingle && idx >= len(tx.TxOut) {
+				_, err := CalcTaprootSigHash(tx, idx, prevOuts, hashType, nil)
+				require.Error(t, err)
+				continue
+			}
+
+			want, err := txscript.CalcTaprootSignatureHash(sigHashes, hashType, tx, idx, fetcher)
+			require.NoError(t, err)
+			got, err := CalcTaprootSigHash(tx, idx, prevOuts, hashType, nil)
+			require.NoError(t, err)
+			require.Equal(t, want, got.Digest, "key path, hash type %s, input %d", SigHashTypeString(hashType), idx)
+
+			want, err = txscript.CalcTapscriptSignaturehash(sigHashes, hashType, tx, idx, fetcher, leaf)
+			require.NoError(t, err)
+			got, err = CalcTaprootSigHash(tx, idx, prevOuts, hashType, leafHash[:])
+			require.NoError(t, err)
+			require.Equal(t, want, got.Digest, "script path, hash type %s, input %d", SigHashTypeString(hashType), idx)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Package taproot builds taproot script trees (BIP341) and spends their
+// leaves through the script path (BIP342)
+package taproot
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/alejoacosta74/cryptonaut/pkg/crypto/schnorr"
+	"github.com/btcsuite/btcd/btcec/v2"
+	btcschnorr "github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// UnspendableKey is the BIP341 NUMS point, an internal key without known
+// private key that disables key-path spends
+const UnspendableKey = "50929b74c1a04954b78b4b6035e97a5e078a5a0f28ec96d547bfee9ace803ac0"
+
+// maxDepth is the deepest leaf a control block can prove (BIP341)
+const maxDepth = txscript.ControlBlockMaxNodeCount
+
+// Leaf is a tapscript with the relative probability of being spent, used to
+// place likely leaves closer to the root
+type Leaf struct {
+	Script []byte
+	Weight int
+}
+
+// Tree is a taproot output committing to an internal key and a tree of
+// tapscript leaves
+type Tree struct {
+	InternalKey *btcec.PublicKey
+	Leaves      []Leaf
+	// MerkleRoot is nil for outputs without script tree
+	MerkleRoot []byte
+	OutputKey  *btcec.PublicKey
+	// paths holds the inclusion proof of each leaf, from the leaf up
+	paths [][]chainhash.Hash
+}
+
+// treeNode is a subtree being merged while building the Huffman tree
+type treeNode struct {
+	hash   chainhash.Hash
+	weight int
+	leaves []int
+}
+
+// New builds the taproot output of internalKey (x-only or compressed hex,
+// UnspendableKey when empty) and leaves. The tree is built like a Huffman
+// code: the two lightest subtrees are merged until one is left, so the
+// expected control block size is minimal. Leaves of equal weight keep
+// their order.
+func New(internalKey string, leaves []Leaf) (*Tree, error) {
+	if internalKey == "" {
+		internalKey = UnspendableKey
+	}
+	key, err := ParseXOnlyKey(internalKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid internal key: %v", err)
+	}
+
+	tree := &Tree{InternalKey: key, Leaves: leaves, paths: make([][]chainhash.Hash, len(leaves))}
+	nodes := make([]treeNode, len(leaves))
+	for i, leaf := range leaves {
+		if len(leaf.Script) == 0 {
+			return nil, fmt.Errorf("leaf %d has an empty script", i)
+		}
+		if leaf.Weight <= 0 {
+			return nil, fmt.Errorf("leaf %d weight must be positive", i)
+		}
+		nodes[i] = treeNode{hash: txscript.NewBaseTapLeaf(leaf.Script).TapHash(), weight: leaf.Weight, leaves: []int{i}}
+	}
+	for len(nodes) > 1 {
+		sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].weight < nodes[j].weight })
+		left, right := nodes[0], nodes[1]
+		for _, i := range left.leaves {
+			tree.paths[i] = append(tree.paths[i], right.hash)
+		}
+		for _, i := range right.leaves {
+			tree.paths[i] = append(tree.paths[i], left.hash)
+		}
+		merged := treeNode{
+			hash:   branchHash(left.hash, right.hash),
+			weight: left.weight + right.weight,
+			leaves: append(append([]int{}, left.leaves...), right.leaves...),
+		}
+		nodes = append(nodes[2:], merged)
+	}
+	for i, path := range tree.paths {
+		if len(path) > maxDepth {
+			return nil, fmt.Errorf("leaf %d is deeper than %d levels", i, maxDepth)
+		}
+	}
+
+	if len(nodes) == 1 {
+		tree.MerkleRoot = nodes[0].hash[:]
+	}
+	tree.OutputKey = txscript.ComputeTaprootOutputKey(key, tree.MerkleRoot)
+	return tree, nil
+}
+
+// ParseXOnlyKey parses a public key given as 32-byte x-only or 33-byte
+// compressed hex
+func ParseXOnlyKey(s string) (*btcec.PublicKey, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == btcec.PubKeyBytesLenCompressed {
+		b = b[1:]
+	}
+	return btcschnorr.ParsePubKey(b)
+}
+
+// branchHash returns the TapBranch hash of two nodes, sorted
+// lexicographically
+func branchHash(a, b chainhash.Hash) chainhash.Hash {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return *chainhash.TaggedHash(chainhash.TagTapBranch, a[:], b[:])
+}
+
+// ScriptPubKey returns the witness v1 output script
+func (t *Tree) ScriptPubKey() ([]byte, error) {
+	return txscript.PayToTaprootScript(t.OutputKey)
+}
+
+// Address returns the bech32m address of the output
+func (t *Tree) Address(net *chaincfg.Params) (string, error) {
+	addr, err := btcutil.NewAddressTaproot(btcschnorr.SerializePubKey(t.OutputKey), net)
+	if err != nil {
+		return "", fmt.Errorf("failed to create taproot address: %v", err)
+	}
+	return addr.EncodeAddress(), nil
+}
+
+// Depth returns the depth of leaf i, the number of hashes of its control
+// block
+func (t *Tree) Depth(i int) int {
+	return len(t.paths[i])
+}
+
+// LeafHash returns the TapLeaf hash of leaf i, committed to by script-path
+// signatures
+func (t *Tree) LeafHash(i int) []byte {
+	hash := txscript.NewBaseTapLeaf(t.Leaves[i].Script).TapHash()
+	return hash[:]
+}
+
+// ControlBlock returns the control block proving that leaf i is committed
+// to by the output key
+func (t *Tree) ControlBlock(i int) ([]byte, error) {
+	if err := t.checkLeaf(i); err != nil {
+		return nil, err
+	}
+	var proof []byte
+	for _, hash := range t.paths[i] {
+		proof = append(proof, hash[:]...)
+	}
+	controlBlock := txscript.ControlBlock{
+		InternalKey:     t.InternalKey,
+		OutputKeyYIsOdd: t.OutputKey.Y().Bit(0) == 1,
+		LeafVersion:     txscript.BaseLeafVersion,
+		InclusionProof:  proof,
+	}
+	return controlBlock.ToBytes()
+}
+
+// Witness returns the witness spending leaf i: the items satisfying the
+// script (bottom of the stack first), the script and the control block
+func (t *Tree) Witness(i int, items ...[]byte) (wire.TxWitness, error) {
+	controlBlock, err := t.ControlBlock(i)
+	if err != nil {
+		return nil, err
+	}
+	witness := make(wire.TxWitness, 0, len(items)+2)
+	witness = append(witness, items...)
+	return append(witness, t.Leaves[i].Script, controlBlock), nil
+}
+
+// SignLeaf signs input idx of tx for a script-path spend of leaf i. prevOuts
+// are the outputs spent by every input, in input order. The sighash type is
+// appended to the signature unless it is SIGHASH_DEFAULT.
+func (t *Tree) SignLeaf(tx *wire.MsgTx, idx int, prevOuts []*wire.TxOut, hashType txscript.SigHashType, i int, privKey *btcec.PrivateKey) ([]byte, error) {
+	if err := t.checkLeaf(i); err != nil {
+		return nil, err
+	}
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return nil, fmt.Errorf("input index %d out of range", idx)
+	}
+	if len(prevOuts) != len(tx.TxIn) {
+		return nil, fmt.Errorf("expected %d previous outputs, got %d", len(tx.TxIn), len(prevOuts))
+	}
+	fetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for j, txIn := range tx.TxIn {
+		if prevOuts[j] == nil {
+			return nil, fmt.Errorf("missing previous output for input %d", j)
+		}
+		fetcher.AddPrevOut(txIn.PreviousOutPoint, prevOuts[j])
+	}
+
+	leaf := txscript.NewBaseTapLeaf(t.Leaves[i].Script)
+	hash, err := txscript.CalcTapscriptSignaturehash(txscript.NewTxSigHashes(tx, fetcher), hashType, tx, idx, fetcher, leaf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute sighash: %v", err)
+	}
+	signature, err := schnorr.SignHash(privKey, hash)
+	if err != nil {
+		return nil, err
+	}
+	sig := signature.Serialize()
+	if hashType != txscript.SigHashDefault {
+		sig = append(sig, byte(hashType))
+	}
+	return sig, nil
+}
+
+func (t *Tree) checkLeaf(i int) error {
+	if i < 0 || i >= len(t.Leaves) {
+		return fmt.Errorf("leaf index %d out of range, the tree has %d leaves", i, len(t.Leaves))
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package taproot
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	btcschnorr "github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyPathOnly(t *testing.T) {
+	// BIP86 first receiving address of the "abandon ... about" mnemonic
+	tree, err := New("cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115", nil)
+	require.NoError(t, err)
+	require.Nil(t, tree.MerkleRoot)
+	addr, err := tree.Address(&chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr", addr)
+
+	_, err = New("02cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115", nil)
+	require.NoError(t, err)
+	_, err = New("zz", nil)
+	require.Error(t, err)
+}
+
+func TestHuffmanTree(t *testing.T) {
+	leaves := []Leaf{
+		{Script: []byte{txscript.OP_1}, Weight: 1},
+		{Script: []byte{txscript.OP_2}, Weight: 1},
+		{Script: []byte{txscript.OP_3}, Weight: 10},
+	}
+	tree, err := New("", leaves)
+	require.NoError(t, err)
+	require.Equal(t, 2, tree.Depth(0))
+	require.Equal(t, 2, tree.Depth(1))
+	require.Equal(t, 1, tree.Depth(2))
+	controlBlock, err := tree.ControlBlock(2)
+	require.NoError(t, err)
+	require.Len(t, controlBlock, txscript.ControlBlockBaseSize+chainhash.HashSize)
+
+	// equal weights give a balanced tree, like btcd's assembler
+	balanced, err := New("", leaves[:2])
+	require.NoError(t, err)
+	root := txscript.AssembleTaprootScriptTree(txscript.NewBaseTapLeaf(leaves[0].Script),
+		txscript.NewBaseTapLeaf(leaves[1].Script)).RootNode.TapHash()
+	require.Equal(t, root[:], balanced.MerkleRoot)
+
+	_, err = New("", []Leaf{{Script: []byte{txscript.OP_1}}})
+	require.Error(t, err)
+	_, err = tree.ControlBlock(3)
+	require.Error(t, err)
+}
+
+func TestScriptPathSpend(t *testing.T) {
+	keys := make([]*btcec.PrivateKey, 3)
+	leaves := make([]Leaf, 3)
+	for i := range keys {
+		var err error
+		keys[i], err = btcec.NewPrivateKey()
+		require.NoError(t, err)
+		script, err := txscript.NewScriptBuilder().AddData(btcschnorr.SerializePubKey(keys[i].PubKey())).
+			AddOp(txscript.OP_CHECKSIG).Script()
+		require.NoError(t, err)
+		leaves[i] = Leaf{Script: script, Weight: i + 1}
+	}
+	tree, err := New("", leaves)
+	require.NoError(t, err)
+	pkScript, err := tree.ScriptPubKey()
+	require.NoError(t, err)
+
+	prevHash, _ := chainhash.NewHashFromStr("1234567890123456789012345678901234567890123456789012345678901234")
+	prevOut := wire.NewTxOut(100000, pkScript)
+	prevOuts := txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value)
+	for i := range leaves {
+		for _, hashType := range []txscript.SigHashType{txscript.SigHashDefault, txscript.SigHashSingle} {
+			tx := wire.NewMsgTx(2)
+			tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(prevHash, 0), nil, nil))
+			tx.AddTxOut(wire.NewTxOut(99000, pkScript))
+
+			sig, err := tree.SignLeaf(tx, 0, []*wire.TxOut{prevOut}, hashType, i, keys[i])
+			require.NoError(t, err)
+			tx.TxIn[0].Witness, err = tree.Witness(i, sig)
+			require.NoError(t, err)
+
+			vm, err := txscript.NewEngine(prevOut.PkScript, tx, 0, txscript.StandardVerifyFlags, nil,
+				txscript.NewTxSigHashes(tx, prevOuts), prevOut.Value, prevOuts)
+			require.NoError(t, err)
+			require.NoError(t, vm.Execute(), "leaf %d", i)
+
+			// the key of another leaf does not satisfy the script
+			other := keys[(i+1)%len(keys)]
+			sig, err = tree.SignLeaf(tx, 0, []*wire.TxOut{prevOut}, hashType, i, other)
+			require.NoError(t, err)
+			tx.TxIn[0].Witness, err = tree.Witness(i, sig)
+			require.NoError(t, err)
+			vm, err = txscript.NewEngine(prevOut.PkScript, tx, 0, txscript.StandardVerifyFlags, nil,
+				txscript.NewTxSigHashes(tx, prevOuts), prevOut.Value, prevOuts)
+			require.NoError(t, err)
+			require.Error(t, vm.Execute())
+		}
+	}
+}
@@ -2,18 +2,24 @@ package bitcoin
 
 import (
 	"encoding/hex"
+	"fmt"
 	"strings"
 
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcutil"
-	"github.com/btcsuite/btcd/chaincfg"
 )
 
-func getChainParams(testnet bool) *chaincfg.Params {
-	if testnet {
-		return &chaincfg.TestNet3Params
+// ParsePrivateKey decodes a private key given either in WIF or hex format
+func ParsePrivateKey(privKey string) (*btcec.PrivateKey, error) {
+	if isWIF(privKey) {
+		wif, _ := btcutil.DecodeWIF(privKey)
+		return wif.PrivKey, nil
+	} else if isHex(privKey) {
+		b, _ := hex.DecodeString(strings.TrimPrefix(privKey, "0x"))
+		key, _ := btcec.PrivKeyFromBytes(b)
+		return key, nil
 	}
-	return &chaincfg.MainNetParams
+	return nil, fmt.Errorf("invalid private key format (must be WIF or hex)")
 }
 
 func isWIF(privKey string) bool {
@@ -28,8 +34,13 @@ func isWIF(privKey string) bool {
 		return false
 	}
 
-	// Ensure the decoded key is valid
-	return wif != nil && wif.IsForNet(&chaincfg.MainNetParams) || wif.IsForNet(&chaincfg.TestNet3Params)
+	// Ensure the key is for one of the supported networks
+	for _, n := range networks {
+		if wif.IsForNet(n.Params) {
+			return true
+		}
+	}
+	return false
 }
 
 func isHex(privKey string) bool {
@@ -0,0 +1,174 @@
+package bitcoin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// Minimal ZMTP 3.0 subscriber (https://rfc.zeromq.org/spec/23/), enough to
+// receive the notifications Bitcoin Core publishes with -zmqpub*. Only the
+// NULL security mechanism is supported, as used by Bitcoin Core.
+
+const (
+	zmqGreetingSize = 64
+	zmqFlagMore     = 0x01
+	zmqFlagLong     = 0x02
+	zmqFlagCommand  = 0x04
+	// zmqMaxFrameSize bounds the frames read from the publisher; blocks
+	// published with rawblock are at most 4MB
+	zmqMaxFrameSize = 8 << 20
+)
+
+// zmqSubscriber is a SUB socket connected to a single publisher
+type zmqSubscriber struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// dialZMQ connects to a publisher at endpoint (tcp://host:port) and
+// subscribes to topics
+func dialZMQ(ctx context.Context, endpoint string, topics ...string) (*zmqSubscriber, error) {
+	address, found := strings.CutPrefix(endpoint, "tcp://")
+	if !found {
+		return nil, fmt.Errorf("unsupported zmq endpoint %s, expected tcp://host:port", endpoint)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", endpoint, err)
+	}
+	sub := &zmqSubscriber{conn: conn, reader: bufio.NewReader(conn)}
+	if err := sub.handshake("SUB"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	for _, topic := range topics {
+		// ZMTP 3.0 subscriptions are messages starting with 0x01
+		if err := sub.writeFrame(0, append([]byte{0x01}, topic...)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to subscribe to %s: %v", topic, err)
+		}
+	}
+	return sub, nil
+}
+
+// zmqGreeting returns the ZMTP 3.0 greeting of a NULL mechanism client
+func zmqGreeting() []byte {
+	greeting := make([]byte, zmqGreetingSize)
+	greeting[0] = 0xff
+	greeting[9] = 0x7f
+	greeting[10] = 3 // version 3.0
+	copy(greeting[12:32], "NULL")
+	return greeting
+}
+
+// zmqReadyCommand returns the READY command announcing socketType
+func zmqReadyCommand(socketType string) []byte {
+	var body bytes.Buffer
+	body.WriteByte(byte(len("READY")))
+	body.WriteString("READY")
+	body.WriteByte(byte(len("Socket-Type")))
+	body.WriteString("Socket-Type")
+	binary.Write(&body, binary.BigEndian, uint32(len(socketType)))
+	body.WriteString(socketType)
+	return body.Bytes()
+}
+
+// handshake exchanges greetings and READY commands with the peer
+func (s *zmqSubscriber) handshake(socketType string) error {
+	if _, err := s.conn.Write(zmqGreeting()); err != nil {
+		return fmt.Errorf("failed to send zmq greeting: %v", err)
+	}
+	greeting := make([]byte, zmqGreetingSize)
+	if _, err := io.ReadFull(s.reader, greeting); err != nil {
+		return fmt.Errorf("failed to read zmq greeting: %v", err)
+	}
+	if greeting[0] != 0xff || greeting[9] != 0x7f || greeting[10] < 3 {
+		return fmt.Errorf("unsupported zmq greeting")
+	}
+	if mechanism := string(bytes.TrimRight(greeting[12:32], "\x00")); mechanism != "NULL" {
+		return fmt.Errorf("unsupported zmq security mechanism %s", mechanism)
+	}
+
+	if err := s.writeFrame(zmqFlagCommand, zmqReadyCommand(socketType)); err != nil {
+		return fmt.Errorf("failed to send zmq ready: %v", err)
+	}
+	flags, body, err := s.readFrame()
+	if err != nil {
+		return fmt.Errorf("failed to read zmq ready: %v", err)
+	}
+	if flags&zmqFlagCommand == 0 || len(body) < 6 || string(body[1:6]) != "READY" {
+		return fmt.Errorf("unexpected zmq handshake command")
+	}
+	return nil
+}
+
+// readMessage returns the frames of the next message, skipping commands
+func (s *zmqSubscriber) readMessage() ([][]byte, error) {
+	var frames [][]byte
+	for {
+		flags, body, err := s.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		if flags&zmqFlagCommand != 0 {
+			continue
+		}
+		frames = append(frames, body)
+		if flags&zmqFlagMore == 0 {
+			return frames, nil
+		}
+	}
+}
+
+// close closes the connection
+func (s *zmqSubscriber) close() error {
+	return s.conn.Close()
+}
+
+func (s *zmqSubscriber) writeFrame(flags byte, body []byte) error {
+	var frame bytes.Buffer
+	if len(body) > 255 {
+		frame.WriteByte(flags | zmqFlagLong)
+		binary.Write(&frame, binary.BigEndian, uint64(len(body)))
+	} else {
+		frame.WriteByte(flags)
+		frame.WriteByte(byte(len(body)))
+	}
+	frame.Write(body)
+	_, err := s.conn.Write(frame.Bytes())
+	return err
+}
+
+func (s *zmqSubscriber) readFrame() (byte, []byte, error) {
+	flags, err := s.reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	var size uint64
+	if flags&zmqFlagLong != 0 {
+		if err := binary.Read(s.reader, binary.BigEndian, &size); err != nil {
+			return 0, nil, err
+		}
+	} else {
+		short, err := s.reader.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		size = uint64(short)
+	}
+	if size > zmqMaxFrameSize {
+		return 0, nil, fmt.Errorf("zmq frame of %d bytes is too large", size)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(s.reader, body); err != nil {
+		return 0, nil, err
+	}
+	return flags, body, nil
+}
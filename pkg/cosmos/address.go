@@ -0,0 +1,120 @@
+package cosmos
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alejoacosta74/cryptonaut/pkg/alphabet"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+)
+
+// AddressInfo describes a bech32 address of a Cosmos SDK chain
+type AddressInfo struct {
+	Address string `json:"address"`
+	// Chain is the chain using the account prefix, empty when unknown
+	Chain       string   `json:"chain,omitempty"`
+	HRP         string   `json:"hrp"`
+	Prefix      string   `json:"prefix"`
+	Kind        string   `json:"kind"`
+	Payload     string   `json:"payload,omitempty"`
+	Valid       bool     `json:"valid"`
+	Error       string   `json:"error,omitempty"`
+	Corrections []string `json:"corrections,omitempty"`
+}
+
+// knownChains are the chains of common account prefixes
+var knownChains = map[string]string{
+	"cosmos":   "Cosmos Hub",
+	"osmo":     "Osmosis",
+	"juno":     "Juno",
+	"celestia": "Celestia",
+	"dydx":     "dYdX",
+	"inj":      "Injective",
+	"axelar":   "Axelar",
+	"akash":    "Akash",
+	"stars":    "Stargaze",
+	"evmos":    "Evmos",
+	"kava":     "Kava",
+	"secret":   "Secret Network",
+	"terra":    "Terra",
+	"noble":    "Noble",
+	"neutron":  "Neutron",
+	"stride":   "Stride",
+}
+
+// kinds are the HRP suffixes of the SDK address kinds, longest first
+var kinds = []struct{ suffix, kind string }{
+	{"valoperpub", "validator operator public key"},
+	{"valconspub", "consensus public key"},
+	{"valoper", "validator operator"},
+	{"valcons", "consensus node"},
+	{"pub", "account public key"},
+	{"", "account"},
+}
+
+// InspectAddress decodes and validates a bech32 address of any prefix. It
+// fails only when the address is not shaped as bech32, invalid addresses
+// are reported in the info.
+func InspectAddress(address string) (*AddressInfo, error) {
+	sep := strings.LastIndexByte(address, '1')
+	if sep < 1 || sep+7 > len(address) || strings.Trim(strings.ToLower(address[sep+1:]), alphabet.Bech32) != "" {
+		return nil, fmt.Errorf("not a bech32 address")
+	}
+	hrp := strings.ToLower(address[:sep])
+	info := &AddressInfo{Address: address, HRP: hrp}
+	for _, k := range kinds {
+		if prefix, ok := strings.CutSuffix(hrp, k.suffix); ok && prefix != "" {
+			info.Prefix, info.Kind = prefix, k.kind
+			break
+		}
+	}
+	info.Chain = knownChains[info.Prefix]
+
+	payload, err := decodeAddress(address, info.Kind)
+	if err != nil {
+		info.Error = err.Error()
+		isValid := func(s string) bool {
+			_, err := decodeAddress(s, info.Kind)
+			return err == nil
+		}
+		if lower := strings.ToLower(address); isValid(lower) {
+			// bech32 strings must not mix cases
+			info.Corrections = []string{lower}
+		} else {
+			info.Corrections = alphabet.Bech32Corrections(address, sep+1, isValid)
+		}
+		if len(info.Corrections) == 0 {
+			return info, nil
+		}
+		payload, _ = decodeAddress(info.Corrections[0], info.Kind)
+	}
+	info.Payload = hex.EncodeToString(payload)
+	info.Valid = err == nil
+	return info, nil
+}
+
+// decodeAddress decodes a bech32 address, checking the payload length of
+// the address kinds
+func decodeAddress(address, kind string) ([]byte, error) {
+	_, data, variant, err := bech32.DecodeNoLimitWithVersion(address)
+	if err != nil {
+		var checksumErr bech32.ErrInvalidChecksum
+		if errors.As(err, &checksumErr) {
+			return nil, fmt.Errorf("invalid bech32 checksum")
+		}
+		return nil, fmt.Errorf("invalid bech32 string: %v", err)
+	}
+	if variant != bech32.Version0 {
+		return nil, fmt.Errorf("cosmos addresses use bech32, not bech32m")
+	}
+	payload, err := bech32.ConvertBits(data, 5, 8, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %v", err)
+	}
+	if !strings.HasSuffix(kind, "public key") && len(payload) != 20 && len(payload) != 32 {
+		return nil, fmt.Errorf("invalid payload length %d, addresses are 20 or 32 bytes", len(payload))
+	}
+	return payload, nil
+}
@@ -0,0 +1,34 @@
+package cosmos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectAddress(t *testing.T) {
+	const address = "cosmos1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc5lzv7xu"
+
+	info, err := InspectAddress(address)
+	require.NoError(t, err)
+	require.True(t, info.Valid)
+	require.Equal(t, "Cosmos Hub", info.Chain)
+	require.Equal(t, "account", info.Kind)
+	require.Equal(t, "0102030405060708090a0b0c0d0e0f1011121314", info.Payload)
+
+	info, err = InspectAddress("cosmosvaloper1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc56kct20")
+	require.NoError(t, err)
+	require.True(t, info.Valid)
+	require.Equal(t, "cosmos", info.Prefix)
+	require.Equal(t, "validator operator", info.Kind)
+
+	info, err = InspectAddress("cosmos1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc5lzv7xv")
+	require.NoError(t, err)
+	require.False(t, info.Valid)
+	require.Equal(t, "invalid bech32 checksum", info.Error)
+	require.Equal(t, []string{address}, info.Corrections)
+	require.Equal(t, "0102030405060708090a0b0c0d0e0f1011121314", info.Payload)
+
+	_, err = InspectAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	require.Error(t, err)
+}
@@ -20,6 +20,19 @@ func SignMessage(privateKey *btcec.PrivateKey, message []byte) (*schnorr.Signatu
 	return signature, nil
 }
 
+// SignHash signs a 32-byte digest, such as a transaction signature hash,
+// without hashing it again
+func SignHash(privateKey *btcec.PrivateKey, hash []byte) (*schnorr.Signature, error) {
+	if len(hash) != sha256.Size {
+		return nil, fmt.Errorf("hash must be %d bytes, got %d", sha256.Size, len(hash))
+	}
+	signature, err := schnorr.Sign(privateKey, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign hash: %w", err)
+	}
+	return signature, nil
+}
+
 // VerifyMessage verifies a Schnorr signature for a given message and public key.
 func VerifyMessage(publicKey []byte, message []byte, signature []byte) (bool, error) {
 	sig, err := schnorr.ParseSignature(signature)
@@ -0,0 +1,291 @@
+// Package abi decodes calldata, return data, revert data and event logs of
+// contracts with their ABI
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// ABI is the interface of a contract
+type ABI struct {
+	gethabi.ABI
+}
+
+// Load reads an ABI file, see Parse
+func Load(path string) (*ABI, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ABI: %v", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses a JSON ABI, a compiler artifact with an "abi" field, or
+// human-readable signatures, one per line
+func Parse(data []byte) (*ABI, error) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		return parseJSON(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var artifact struct {
+			ABI json.RawMessage `json:"abi"`
+		}
+		if err := json.Unmarshal(trimmed, &artifact); err != nil {
+			return nil, fmt.Errorf("failed to parse ABI: %v", err)
+		}
+		if len(artifact.ABI) == 0 {
+			return nil, fmt.Errorf("failed to parse ABI: no abi field")
+		}
+		return parseJSON(artifact.ABI)
+	}
+	return ParseSignatures(strings.Split(string(data), "\n"))
+}
+
+func parseJSON(data []byte) (*ABI, error) {
+	var a ABI
+	if err := json.Unmarshal(data, &a.ABI); err != nil {
+		return nil, fmt.Errorf("failed to parse ABI: %v", err)
+	}
+	return &a, nil
+}
+
+// ParseSignatures parses human-readable signatures such as
+//
+//	function transfer(address to, uint256 amount) returns (bool)
+//	event Transfer(address indexed from, address indexed to, uint256 value)
+//	error InsufficientBalance(uint256 available, uint256 required)
+//	balanceOf(address)
+//
+// Signatures without a keyword are functions. Empty lines and lines starting
+// with // or # are skipped.
+func ParseSignatures(signatures []string) (*ABI, error) {
+	var entries []jsonEntry
+	for _, signature := range signatures {
+		signature = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(signature), ";"))
+		if signature == "" || strings.HasPrefix(signature, "//") || strings.HasPrefix(signature, "#") {
+			continue
+		}
+		entry, err := parseSignature(signature)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature %q: %v", signature, err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no signatures")
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ABI: %v", err)
+	}
+	return parseJSON(data)
+}
+
+// jsonEntry and jsonArg are the JSON ABI entries of the signatures
+type jsonEntry struct {
+	Type            string    `json:"type"`
+	Name            string    `json:"name,omitempty"`
+	Inputs          []jsonArg `json:"inputs"`
+	Outputs         []jsonArg `json:"outputs,omitempty"`
+	StateMutability string    `json:"stateMutability,omitempty"`
+	Anonymous       bool      `json:"anonymous,omitempty"`
+}
+
+type jsonArg struct {
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Indexed    bool      `json:"indexed,omitempty"`
+	Components []jsonArg `json:"components,omitempty"`
+}
+
+var (
+	identifierRe = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+	// intRe matches the int and uint aliases of int256 and uint256
+	intRe = regexp.MustCompile(`^(u?int)(\[.*)?$`)
+)
+
+func parseSignature(signature string) (jsonEntry, error) {
+	entry := jsonEntry{Type: "function"}
+	for _, keyword := range []string{"function", "event", "error", "constructor", "fallback", "receive"} {
+		if signature == keyword || strings.HasPrefix(signature, keyword+" ") || strings.HasPrefix(signature, keyword+"(") {
+			entry.Type = keyword
+			// the special functions keep their keyword in place of a name
+			if keyword == "function" || keyword == "event" || keyword == "error" {
+				signature = strings.TrimSpace(signature[len(keyword):])
+			}
+			break
+		}
+	}
+
+	open := strings.IndexByte(signature, '(')
+	if open < 0 {
+		if entry.Type == "fallback" || entry.Type == "receive" {
+			entry.StateMutability = modifiers(signature)
+			return entry, nil
+		}
+		return entry, fmt.Errorf("missing parameter list")
+	}
+	name := strings.TrimSpace(signature[:open])
+	switch entry.Type {
+	case "constructor", "fallback", "receive":
+	default:
+		if !identifierRe.MatchString(name) {
+			return entry, fmt.Errorf("invalid name %q", name)
+		}
+		entry.Name = name
+	}
+	closing, err := matchingParen(signature, open)
+	if err != nil {
+		return entry, err
+	}
+	if entry.Inputs, err = parseParams(signature[open+1:closing], entry.Type == "event"); err != nil {
+		return entry, err
+	}
+
+	rest := strings.TrimSpace(signature[closing+1:])
+	if i := strings.Index(rest, "returns"); i >= 0 {
+		outputs := strings.TrimSpace(rest[i+len("returns"):])
+		if !strings.HasPrefix(outputs, "(") {
+			return entry, fmt.Errorf("invalid returns")
+		}
+		end, err := matchingParen(outputs, 0)
+		if err != nil {
+			return entry, err
+		}
+		if entry.Outputs, err = parseParams(outputs[1:end], false); err != nil {
+			return entry, err
+		}
+		rest = rest[:i] + outputs[end+1:]
+	}
+	if entry.Type == "event" {
+		entry.Anonymous = strings.Contains(rest, "anonymous")
+	} else if entry.Type != "error" {
+		entry.StateMutability = modifiers(rest)
+	}
+	return entry, nil
+}
+
+// modifiers returns the state mutability given by the modifiers of a
+// function, nonpayable by default
+func modifiers(s string) string {
+	for _, modifier := range strings.Fields(s) {
+		switch modifier {
+		case "view", "pure", "payable":
+			return modifier
+		}
+	}
+	return "nonpayable"
+}
+
+// parseParams parses a comma separated parameter list
+func parseParams(s string, event bool) ([]jsonArg, error) {
+	args := []jsonArg{}
+	if strings.TrimSpace(s) == "" {
+		return args, nil
+	}
+	for _, param := range splitParams(s) {
+		arg, err := parseParam(strings.TrimSpace(param), event)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+func parseParam(s string, event bool) (jsonArg, error) {
+	var arg jsonArg
+	if s == "" {
+		return arg, fmt.Errorf("empty parameter")
+	}
+	// tuples are written (type, ...) or tuple(type, ...), with array suffixes
+	rest := s
+	if strings.HasPrefix(rest, "tuple(") {
+		rest = rest[len("tuple"):]
+	}
+	if strings.HasPrefix(rest, "(") {
+		end, err := matchingParen(rest, 0)
+		if err != nil {
+			return arg, err
+		}
+		components, err := parseParams(rest[1:end], false)
+		if err != nil {
+			return arg, err
+		}
+		// go-ethereum requires named tuple components
+		for i := range components {
+			if components[i].Name == "" {
+				components[i].Name = fmt.Sprintf("field%d", i)
+			}
+		}
+		suffix, after, _ := strings.Cut(rest[end+1:], " ")
+		arg.Type, arg.Components, rest = "tuple"+suffix, components, after
+	} else {
+		typ, after, _ := strings.Cut(rest, " ")
+		if m := intRe.FindStringSubmatch(typ); m != nil {
+			typ = m[1] + "256" + m[2]
+		}
+		arg.Type, rest = typ, after
+	}
+
+	for _, word := range strings.Fields(rest) {
+		switch {
+		case word == "indexed":
+			if !event || arg.Indexed {
+				return arg, fmt.Errorf("unexpected indexed in parameter %q", s)
+			}
+			arg.Indexed = true
+		case word == "memory" || word == "calldata" || word == "storage" || word == "payable":
+		case arg.Name == "" && identifierRe.MatchString(word):
+			arg.Name = word
+		default:
+			return arg, fmt.Errorf("unexpected %q in parameter %q", word, s)
+		}
+	}
+	return arg, nil
+}
+
+// splitParams splits s on the commas outside parentheses
+func splitParams(s string) []string {
+	var params []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				params = append(params, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(params, s[start:])
+}
+
+// matchingParen returns the index of the parenthesis closing the one at open
+func matchingParen(s string, open int) (int, error) {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unbalanced parentheses")
+}
@@ -0,0 +1,175 @@
+package abi
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+const erc20 = `
+// ERC-20 with a custom error
+function transfer(address to, uint amount) external returns (bool)
+function balanceOf(address owner) view returns (uint256 balance)
+function submit((address target, uint256[] values) order, bytes32 salt) payable
+event Transfer(address indexed from, address indexed to, uint256 value)
+event Named(string indexed name, bytes data)
+error InsufficientBalance(uint256 available, uint256 required)
+constructor(string name, string symbol)
+receive() external payable
+`
+
+var (
+	alice = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	bob   = common.HexToAddress("0x2222222222222222222222222222222222222222")
+)
+
+func TestParseSignatures(t *testing.T) {
+	a, err := Parse([]byte(erc20))
+	require.NoError(t, err)
+	require.Equal(t, "transfer(address,uint256)", a.Methods["transfer"].Sig)
+	require.Equal(t, "0xa9059cbb", hexutil.Encode(a.Methods["transfer"].ID))
+	require.Equal(t, "view", a.Methods["balanceOf"].StateMutability)
+	require.Equal(t, "submit((address,uint256[]),bytes32)", a.Methods["submit"].Sig)
+	require.True(t, a.Methods["submit"].IsPayable())
+	require.Equal(t, crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")), a.Events["Transfer"].ID)
+	require.Equal(t, "InsufficientBalance(uint256,uint256)", a.Errors["InsufficientBalance"].Sig)
+	require.Len(t, a.Constructor.Inputs, 2)
+	require.True(t, a.HasReceive())
+
+	for _, signature := range []string{"transfer", "function 1x()", "f(address to", "f(uint256 a b)", "event E(uint256 indexed indexed)"} {
+		_, err := ParseSignatures([]string{signature})
+		require.Error(t, err, signature)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	const json = `[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable"}]`
+	a, err := Parse([]byte(json))
+	require.NoError(t, err)
+	require.Contains(t, a.Methods, "transfer")
+
+	a, err = Parse([]byte(`{"contractName":"Token","abi":` + json + `}`))
+	require.NoError(t, err)
+	require.Contains(t, a.Methods, "transfer")
+
+	_, err = Parse([]byte(`{"contractName":"Token"}`))
+	require.Error(t, err)
+}
+
+func TestDecodeCalldata(t *testing.T) {
+	a, err := Parse([]byte(erc20))
+	require.NoError(t, err)
+
+	data, err := a.Pack("transfer", bob, big.NewInt(1000))
+	require.NoError(t, err)
+	call, err := a.DecodeCalldata(data)
+	require.NoError(t, err)
+	require.Equal(t, &Call{Name: "transfer", Signature: "transfer(address,uint256)", Selector: "0xa9059cbb", Args: []Arg{
+		{Name: "to", Type: "address", Value: bob.Hex()},
+		{Name: "amount", Type: "uint256", Value: "1000"},
+	}}, call)
+
+	order := struct {
+		Target common.Address
+		Values []*big.Int
+	}{alice, []*big.Int{big.NewInt(1), big.NewInt(2)}}
+	data, err = a.Pack("submit", order, [32]byte{0xab})
+	require.NoError(t, err)
+	call, err = a.DecodeCalldata(data)
+	require.NoError(t, err)
+	require.Equal(t, []Arg{
+		{Name: "target", Type: "address", Value: alice.Hex()},
+		{Name: "values", Type: "uint256[]", Value: []interface{}{"1", "2"}},
+	}, call.Args[0].Value)
+	require.Equal(t, hexutil.Encode(append([]byte{0xab}, make([]byte, 31)...)), call.Args[1].Value)
+
+	require.Equal(t, "submit(order: (target: "+alice.Hex()+", values: [1, 2]), salt: 0xab"+strings.Repeat("00", 31)+")", call.String())
+
+	_, err = a.DecodeCalldata([]byte{0xde, 0xad, 0xbe, 0xef})
+	require.Error(t, err)
+	_, err = a.DecodeCalldata(data[:10])
+	require.Error(t, err)
+}
+
+func TestDecodeOutput(t *testing.T) {
+	a, err := Parse([]byte(erc20))
+	require.NoError(t, err)
+	data := common.LeftPadBytes(big.NewInt(42).Bytes(), 32)
+	for _, function := range []string{"balanceOf", "balanceOf(address)"} {
+		call, err := a.DecodeOutput(function, data)
+		require.NoError(t, err)
+		require.Equal(t, []Arg{{Name: "balance", Type: "uint256", Value: "42"}}, call.Args)
+	}
+	_, err = a.DecodeOutput("allowance", data)
+	require.Error(t, err)
+}
+
+func TestDecodeRevert(t *testing.T) {
+	a, err := Parse([]byte(erc20))
+	require.NoError(t, err)
+
+	// require(false, "not owner")
+	data := hexutil.MustDecode("0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000009" +
+		"6e6f74206f776e65720000000000000000000000000000000000000000000000")
+	var none *ABI
+	call, err := none.DecodeRevert(data)
+	require.NoError(t, err)
+	require.Equal(t, "Error", call.Name)
+	require.Equal(t, "not owner", call.Reason)
+
+	call, err = none.DecodeRevert(append(hexutil.MustDecode("0x4e487b71"), common.LeftPadBytes([]byte{0x11}, 32)...))
+	require.NoError(t, err)
+	require.Equal(t, "Panic", call.Name)
+	require.Equal(t, "arithmetic overflow or underflow (0x11)", call.Reason)
+	require.Equal(t, "17", call.Args[0].Value)
+
+	insufficient := a.Errors["InsufficientBalance"]
+	custom, err := insufficient.Inputs.Pack(big.NewInt(1), big.NewInt(2))
+	require.NoError(t, err)
+	custom = append(insufficient.ID[:4], custom...)
+	call, err = a.DecodeRevert(custom)
+	require.NoError(t, err)
+	require.Equal(t, "InsufficientBalance", call.Name)
+	require.Equal(t, []Arg{{Name: "available", Type: "uint256", Value: "1"}, {Name: "required", Type: "uint256", Value: "2"}}, call.Args)
+
+	_, err = none.DecodeRevert(custom)
+	require.Error(t, err)
+	_, err = a.DecodeRevert([]byte{0x01})
+	require.Error(t, err)
+}
+
+func TestDecodeLog(t *testing.T) {
+	a, err := Parse([]byte(erc20))
+	require.NoError(t, err)
+
+	transfer := a.Events["Transfer"]
+	event, err := a.DecodeLog([]common.Hash{transfer.ID, common.BytesToHash(alice[:]), common.BytesToHash(bob[:])},
+		common.LeftPadBytes(big.NewInt(500).Bytes(), 32))
+	require.NoError(t, err)
+	require.Equal(t, &Event{Name: "Transfer", Signature: "Transfer(address,address,uint256)", Topic: transfer.ID.Hex(), Args: []Arg{
+		{Name: "from", Type: "address", Value: alice.Hex(), Indexed: true},
+		{Name: "to", Type: "address", Value: bob.Hex(), Indexed: true},
+		{Name: "value", Type: "uint256", Value: "500"},
+	}}, event)
+
+	named := a.Events["Named"]
+	data, err := named.Inputs.NonIndexed().Pack([]byte{0x01, 0x02})
+	require.NoError(t, err)
+	nameHash := crypto.Keccak256Hash([]byte("alice"))
+	event, err = a.DecodeLog([]common.Hash{named.ID, nameHash}, data)
+	require.NoError(t, err)
+	require.Equal(t, Arg{Name: "name", Type: "string", Value: nameHash.Hex(), Indexed: true, Hashed: true}, event.Args[0])
+	require.Equal(t, "0x0102", event.Args[1].Value)
+
+	_, err = a.DecodeLog([]common.Hash{transfer.ID, common.BytesToHash(alice[:])}, nil)
+	require.Error(t, err)
+	_, err = a.DecodeLog(nil, nil)
+	require.Error(t, err)
+}
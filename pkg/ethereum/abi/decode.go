@@ -0,0 +1,281 @@
+package abi
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Selectors of the revert data of require and assert, which need no ABI
+var (
+	ErrorSelector = []byte{0x08, 0xc3, 0x79, 0xa0} // Error(string)
+	PanicSelector = []byte{0x4e, 0x48, 0x7b, 0x71} // Panic(uint256)
+)
+
+// panicReasons describes the Solidity panic codes
+var panicReasons = map[uint64]string{
+	0x00: "generic compiler panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum value",
+	0x22: "invalid storage byte array encoding",
+	0x31: "pop on an empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to a zero-initialized function",
+}
+
+// Arg is a decoded argument. Numbers are decimal strings, addresses, bytes
+// and hashes hex strings, tuples lists of Arg and arrays lists of values.
+type Arg struct {
+	Name    string      `json:"name,omitempty"`
+	Type    string      `json:"type"`
+	Value   interface{} `json:"value"`
+	Indexed bool        `json:"indexed,omitempty"`
+	// Hashed is set for indexed dynamic arguments, of which the topic only
+	// holds the hash
+	Hashed bool `json:"hashed,omitempty"`
+}
+
+// Call is decoded calldata, return data or revert data
+type Call struct {
+	Name      string `json:"name"`
+	Signature string `json:"signature"`
+	Selector  string `json:"selector,omitempty"`
+	Args      []Arg  `json:"args"`
+	// Reason describes Error and Panic revert data
+	Reason string `json:"reason,omitempty"`
+}
+
+// Event is a decoded event log
+type Event struct {
+	Name      string `json:"name"`
+	Signature string `json:"signature"`
+	Topic     string `json:"topic"`
+	Args      []Arg  `json:"args"`
+}
+
+// DecodeCalldata decodes the call of a function
+func (a *ABI) DecodeCalldata(data []byte) (*Call, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("calldata too short, %d bytes", len(data))
+	}
+	method, err := a.MethodById(data[:4])
+	if err != nil {
+		return nil, fmt.Errorf("no function with selector %s", hexutil.Encode(data[:4]))
+	}
+	args, err := decodeArgs(method.Inputs, data[4:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s calldata: %v", method.Sig, err)
+	}
+	return &Call{Name: method.Name, Signature: method.Sig, Selector: hexutil.Encode(data[:4]), Args: args}, nil
+}
+
+// DecodeOutput decodes the return data of a function, given by name or
+// signature
+func (a *ABI) DecodeOutput(function string, data []byte) (*Call, error) {
+	method, ok := a.Methods[function]
+	if !ok {
+		for _, m := range a.Methods {
+			if m.Sig == function {
+				method, ok = m, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("no function %s", function)
+	}
+	args, err := decodeArgs(method.Outputs, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s return data: %v", method.Sig, err)
+	}
+	return &Call{Name: method.Name, Signature: method.Sig, Selector: hexutil.Encode(method.ID), Args: args}, nil
+}
+
+// DecodeRevert decodes revert data: Error(string) of require and revert,
+// Panic(uint256) of assert and the compiler checks, or a custom error of the
+// ABI. a may be nil to only decode Error and Panic.
+func (a *ABI) DecodeRevert(data []byte) (*Call, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("revert data too short, %d bytes", len(data))
+	}
+	selector := hexutil.Encode(data[:4])
+	switch {
+	case bytes.Equal(data[:4], ErrorSelector):
+		reason, err := gethabi.UnpackRevert(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode Error(string): %v", err)
+		}
+		return &Call{Name: "Error", Signature: "Error(string)", Selector: selector, Reason: reason,
+			Args: []Arg{{Type: "string", Value: reason}}}, nil
+	case bytes.Equal(data[:4], PanicSelector):
+		if len(data) != 4+32 {
+			return nil, fmt.Errorf("failed to decode Panic(uint256): invalid length")
+		}
+		code := new(big.Int).SetBytes(data[4:])
+		reason, ok := panicReasons[code.Uint64()]
+		if !ok || !code.IsUint64() {
+			reason = "unknown panic code"
+		}
+		return &Call{Name: "Panic", Signature: "Panic(uint256)", Selector: selector,
+			Reason: fmt.Sprintf("%s (%s)", reason, hexutil.EncodeBig(code)), Args: []Arg{{Type: "uint256", Value: code.String()}}}, nil
+	}
+	if a == nil {
+		return nil, fmt.Errorf("unknown error selector %s, an ABI is needed to decode custom errors", selector)
+	}
+	abiErr, err := a.ErrorByID([4]byte(data[:4]))
+	if err != nil {
+		return nil, fmt.Errorf("no error with selector %s", selector)
+	}
+	args, err := decodeArgs(abiErr.Inputs, data[4:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", abiErr.Sig, err)
+	}
+	return &Call{Name: abiErr.Name, Signature: abiErr.Sig, Selector: selector, Args: args}, nil
+}
+
+// DecodeLog decodes an event log from its topics and data. Anonymous events,
+// which have no signature topic, are not supported.
+func (a *ABI) DecodeLog(topics []common.Hash, data []byte) (*Event, error) {
+	if len(topics) == 0 {
+		return nil, fmt.Errorf("log without topics, anonymous events are not supported")
+	}
+	event, err := a.EventByID(topics[0])
+	if err != nil {
+		return nil, fmt.Errorf("no event with topic %s", topics[0].Hex())
+	}
+
+	nonIndexed, err := decodeArgs(event.Inputs.NonIndexed(), data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s data: %v", event.Sig, err)
+	}
+	args := make([]Arg, 0, len(event.Inputs))
+	topic := 1
+	for _, input := range event.Inputs {
+		if !input.Indexed {
+			args = append(args, nonIndexed[0])
+			nonIndexed = nonIndexed[1:]
+			continue
+		}
+		if topic >= len(topics) {
+			return nil, fmt.Errorf("failed to decode %s: missing topic of %s", event.Sig, input.Name)
+		}
+		arg, err := decodeTopic(input, topics[topic])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s topic of %s: %v", event.Sig, input.Name, err)
+		}
+		args = append(args, arg)
+		topic++
+	}
+	return &Event{Name: event.Name, Signature: event.Sig, Topic: topics[0].Hex(), Args: args}, nil
+}
+
+// decodeTopic decodes an indexed argument. Topics hold static values padded
+// to a word and the keccak256 hash of dynamic ones.
+func decodeTopic(input gethabi.Argument, topic common.Hash) (Arg, error) {
+	arg := Arg{Name: input.Name, Type: input.Type.String(), Indexed: true}
+	switch input.Type.T {
+	case gethabi.StringTy, gethabi.BytesTy, gethabi.SliceTy, gethabi.ArrayTy, gethabi.TupleTy:
+		arg.Value, arg.Hashed = topic.Hex(), true
+		return arg, nil
+	}
+	values, err := gethabi.Arguments{{Type: input.Type}}.Unpack(topic[:])
+	if err != nil {
+		return arg, err
+	}
+	arg.Value = jsonValue(input.Type, values[0])
+	return arg, nil
+}
+
+func decodeArgs(inputs gethabi.Arguments, data []byte) ([]Arg, error) {
+	args := []Arg{}
+	if len(inputs) == 0 {
+		return args, nil
+	}
+	values, err := inputs.Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+	for i, input := range inputs {
+		args = append(args, Arg{Name: input.Name, Type: input.Type.String(), Value: jsonValue(input.Type, values[i])})
+	}
+	return args, nil
+}
+
+// jsonValue converts a value unpacked by go-ethereum to its JSON form
+func jsonValue(t gethabi.Type, v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	switch t.T {
+	case gethabi.IntTy, gethabi.UintTy:
+		if n, ok := v.(*big.Int); ok {
+			return n.String()
+		}
+		return fmt.Sprint(v)
+	case gethabi.AddressTy:
+		return v.(common.Address).Hex()
+	case gethabi.BytesTy:
+		return hexutil.Encode(v.([]byte))
+	case gethabi.FixedBytesTy, gethabi.HashTy, gethabi.FunctionTy:
+		b := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(b), rv)
+		return hexutil.Encode(b)
+	case gethabi.SliceTy, gethabi.ArrayTy:
+		values := make([]interface{}, rv.Len())
+		for i := range values {
+			values[i] = jsonValue(*t.Elem, rv.Index(i).Interface())
+		}
+		return values
+	case gethabi.TupleTy:
+		args := make([]Arg, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			args[i] = Arg{Name: t.TupleRawNames[i], Type: elem.String(), Value: jsonValue(*elem, rv.Field(i).Interface())}
+		}
+		return args
+	}
+	return v
+}
+
+// String formats the call as name(arg: value, ...)
+func (c *Call) String() string {
+	return c.Name + formatArgs(c.Args)
+}
+
+// String formats the event as Name(arg: value, ...)
+func (e *Event) String() string {
+	return e.Name + formatArgs(e.Args)
+}
+
+func formatArgs(args []Arg) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = formatValue(arg.Value)
+		if arg.Name != "" {
+			parts[i] = arg.Name + ": " + parts[i]
+		}
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case []Arg:
+		return formatArgs(v)
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, elem := range v {
+			parts[i] = formatValue(elem)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case string:
+		return v
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,331 @@
+package abi
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// Encode ABI-encodes the arguments of a function signature such as
+// "transfer(address,uint256)" into calldata prefixed with its selector, or
+// of a parameter list such as "(address,uint256)" without selector. See
+// ParseValue for the format of the arguments.
+func Encode(signature string, args []string) ([]byte, error) {
+	method, selector, err := parseFunction(signature)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parseArgs(method.Inputs, args)
+	if err != nil {
+		return nil, err
+	}
+	data, err := method.Inputs.Pack(values...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode arguments: %v", err)
+	}
+	if selector {
+		data = append(bytes.Clone(method.ID), data...)
+	}
+	return data, nil
+}
+
+// EncodePacked encodes the arguments of a signature as Solidity's
+// abi.encodePacked: integers, addresses, booleans and fixed bytes in their
+// size, strings and bytes unpadded, array elements padded to 32 bytes.
+// Tuples and arrays of dynamic types are not supported.
+func EncodePacked(signature string, args []string) ([]byte, error) {
+	method, _, err := parseFunction(signature)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parseArgs(method.Inputs, args)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	for i, input := range method.Inputs {
+		packed, err := encodePacked(input.Type, reflect.ValueOf(values[i]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode argument %d: %v", i+1, err)
+		}
+		data = append(data, packed...)
+	}
+	return data, nil
+}
+
+// Decode decodes the calldata of a function signature, whose selector must
+// match, or the ABI-encoded values of a parameter list such as
+// "(uint256,string)"
+func Decode(signature string, data []byte) (*Call, error) {
+	method, selector, err := parseFunction(signature)
+	if err != nil {
+		return nil, err
+	}
+	if !selector {
+		args, err := decodeArgs(method.Inputs, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %v", signature, err)
+		}
+		return &Call{Signature: strings.TrimPrefix(method.Sig, method.Name), Args: args}, nil
+	}
+	if len(data) < 4 || !bytes.Equal(data[:4], method.ID) {
+		return nil, fmt.Errorf("calldata does not start with the selector %s of %s", hexutil.Encode(method.ID), method.Sig)
+	}
+	return (&ABI{gethabi.ABI{Methods: map[string]gethabi.Method{method.Name: *method}}}).DecodeCalldata(data)
+}
+
+// parseFunction parses a function signature, or a parameter list, which has
+// no selector
+func parseFunction(signature string) (*gethabi.Method, bool, error) {
+	signature = strings.TrimSpace(signature)
+	selector := !strings.HasPrefix(signature, "(")
+	if !selector {
+		signature = "function params" + signature
+	}
+	contract, err := ParseSignatures([]string{signature})
+	if err != nil {
+		return nil, false, err
+	}
+	if len(contract.Methods) != 1 {
+		return nil, false, fmt.Errorf("%q is not a function signature", signature)
+	}
+	for _, method := range contract.Methods {
+		return &method, selector, nil
+	}
+	return nil, false, nil
+}
+
+func parseArgs(inputs gethabi.Arguments, args []string) ([]interface{}, error) {
+	if len(args) != len(inputs) {
+		return nil, fmt.Errorf("expected %d arguments, got %d", len(inputs), len(args))
+	}
+	values := make([]interface{}, len(args))
+	for i, input := range inputs {
+		value, err := ParseValue(input.Type, args[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument %d (%s): %v", i+1, input.Type, err)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// ParseValue parses the text form of a value of type t into the Go value
+// packed by go-ethereum. Integers are decimal or 0x prefixed hex, addresses,
+// bytes and fixed bytes hex, arrays [v1, v2, ...] and tuples (v1, v2, ...).
+// Strings inside arrays and tuples are double quoted if they hold commas,
+// brackets or parentheses.
+func ParseValue(t gethabi.Type, s string) (interface{}, error) {
+	v, err := parseValue(t, strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+func parseValue(t gethabi.Type, s string) (reflect.Value, error) {
+	switch t.T {
+	case gethabi.IntTy, gethabi.UintTy:
+		// math.ParseBig256 takes negative decimals but not negative hex
+		n, ok := math.ParseBig256(strings.TrimPrefix(s, "-"))
+		if !ok || strings.TrimPrefix(s, "-") == "" {
+			return reflect.Value{}, fmt.Errorf("invalid integer %q", s)
+		}
+		if strings.HasPrefix(s, "-") {
+			n.Neg(n)
+		}
+		if err := checkIntRange(t, n); err != nil {
+			return reflect.Value{}, err
+		}
+		v := reflect.New(t.GetType()).Elem()
+		switch {
+		case v.Kind() == reflect.Ptr:
+			v.Set(reflect.ValueOf(n))
+		case t.T == gethabi.UintTy:
+			v.SetUint(n.Uint64())
+		default:
+			v.SetInt(n.Int64())
+		}
+		return v, nil
+	case gethabi.BoolTy:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid boolean %q", s)
+		}
+		return reflect.ValueOf(b), nil
+	case gethabi.StringTy:
+		if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+			unquoted, err := strconv.Unquote(s)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("invalid string %s: %v", s, err)
+			}
+			s = unquoted
+		}
+		return reflect.ValueOf(s), nil
+	case gethabi.AddressTy:
+		if !common.IsHexAddress(s) {
+			return reflect.Value{}, fmt.Errorf("invalid address %q", s)
+		}
+		return reflect.ValueOf(common.HexToAddress(s)), nil
+	case gethabi.BytesTy:
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid bytes %q: %v", s, err)
+		}
+		return reflect.ValueOf(b), nil
+	case gethabi.FixedBytesTy, gethabi.FunctionTy:
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid bytes %q: %v", s, err)
+		}
+		v := reflect.New(t.GetType()).Elem()
+		if len(b) > v.Len() {
+			return reflect.Value{}, fmt.Errorf("%d bytes do not fit in %s", len(b), t)
+		}
+		// shorter values are right padded, as Solidity converts them
+		reflect.Copy(v, reflect.ValueOf(b))
+		return v, nil
+	case gethabi.SliceTy, gethabi.ArrayTy:
+		elems, err := splitComposite(s, '[', ']')
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		var v reflect.Value
+		if t.T == gethabi.SliceTy {
+			v = reflect.MakeSlice(t.GetType(), len(elems), len(elems))
+		} else {
+			if len(elems) != t.Size {
+				return reflect.Value{}, fmt.Errorf("%s expects %d elements, got %d", t, t.Size, len(elems))
+			}
+			v = reflect.New(t.GetType()).Elem()
+		}
+		for i, elem := range elems {
+			ev, err := parseValue(*t.Elem, elem)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Index(i).Set(ev)
+		}
+		return v, nil
+	case gethabi.TupleTy:
+		elems, err := splitComposite(s, '(', ')')
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if len(elems) != len(t.TupleElems) {
+			return reflect.Value{}, fmt.Errorf("%s expects %d elements, got %d", t, len(t.TupleElems), len(elems))
+		}
+		v := reflect.New(t.GetType()).Elem()
+		for i, elem := range elems {
+			ev, err := parseValue(*t.TupleElems[i], elem)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Field(i).Set(ev)
+		}
+		return v, nil
+	}
+	return reflect.Value{}, fmt.Errorf("unsupported type %s", t)
+}
+
+// checkIntRange checks that n fits in the integer type t
+func checkIntRange(t gethabi.Type, n *big.Int) error {
+	lo, hi := new(big.Int), new(big.Int).Lsh(big.NewInt(1), uint(t.Size))
+	if t.T == gethabi.IntTy {
+		hi.Rsh(hi, 1)
+		lo.Neg(hi)
+	}
+	if n.Cmp(lo) < 0 || n.Cmp(hi) >= 0 {
+		return fmt.Errorf("%s out of range of %s", n, t)
+	}
+	return nil
+}
+
+// splitComposite splits the elements of an array or tuple on the commas
+// outside nested brackets, parentheses and quotes
+func splitComposite(s string, open, close byte) ([]string, error) {
+	if len(s) < 2 || s[0] != open || s[len(s)-1] != close {
+		return nil, fmt.Errorf("expected %c...%c, got %q", open, close, s)
+	}
+	inner := s[1 : len(s)-1]
+	if strings.TrimSpace(inner) == "" {
+		return nil, nil
+	}
+	var elems []string
+	depth, start, quoted := 0, 0, false
+	for i := 0; i < len(inner); i++ {
+		switch c := inner[i]; {
+		case quoted && c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			elems = append(elems, strings.TrimSpace(inner[start:i]))
+			start = i + 1
+		}
+	}
+	if depth != 0 || quoted {
+		return nil, fmt.Errorf("unbalanced %q", s)
+	}
+	return append(elems, strings.TrimSpace(inner[start:])), nil
+}
+
+// encodePacked encodes a value in the non-standard packed mode
+func encodePacked(t gethabi.Type, v reflect.Value) ([]byte, error) {
+	switch t.T {
+	case gethabi.IntTy, gethabi.UintTy:
+		var n *big.Int
+		switch v.Kind() {
+		case reflect.Ptr:
+			n = v.Interface().(*big.Int)
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = big.NewInt(v.Int())
+		default:
+			n = new(big.Int).SetUint64(v.Uint())
+		}
+		if n.Sign() < 0 {
+			// two's complement
+			n = new(big.Int).Add(n, new(big.Int).Lsh(big.NewInt(1), uint(t.Size)))
+		}
+		return math.PaddedBigBytes(n, t.Size/8), nil
+	case gethabi.BoolTy:
+		if v.Bool() {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case gethabi.StringTy:
+		return []byte(v.String()), nil
+	case gethabi.AddressTy, gethabi.FixedBytesTy, gethabi.FunctionTy, gethabi.BytesTy:
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return b, nil
+	case gethabi.SliceTy, gethabi.ArrayTy:
+		switch t.Elem.T {
+		case gethabi.StringTy, gethabi.BytesTy, gethabi.SliceTy, gethabi.ArrayTy, gethabi.TupleTy:
+			return nil, fmt.Errorf("packed arrays of %s are not supported", t.Elem)
+		}
+		var data []byte
+		for i := 0; i < v.Len(); i++ {
+			elem, err := gethabi.Arguments{{Type: *t.Elem}}.Pack(v.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, elem...)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("packed %s is not supported", t)
+}
@@ -0,0 +1,128 @@
+package abi
+
+import (
+	"math/big"
+	"testing"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	data, err := Encode("transfer(address,uint256)", []string{alice.Hex(), "1000"})
+	require.NoError(t, err)
+	require.Equal(t, "0xa9059cbb"+
+		"0000000000000000000000001111111111111111111111111111111111111111"+
+		"00000000000000000000000000000000000000000000000000000000000003e8", hexutil.Encode(data))
+
+	// parameters only, with a negative hex integer
+	data, err = Encode("(int8,bool,bytes2)", []string{"-0x1", "true", "0x42"})
+	require.NoError(t, err)
+	require.Equal(t, "0x"+
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"+
+		"0000000000000000000000000000000000000000000000000000000000000001"+
+		"4200000000000000000000000000000000000000000000000000000000000000", hexutil.Encode(data))
+
+	// nested structs, arrays, strings and bytes match go-ethereum packing
+	signature := "function submit((address target, (uint64 until, string memo)[] locks) order, bytes data, uint256[2] amounts)"
+	data, err = Encode(signature, []string{
+		`(` + bob.Hex() + `, [(1, "a, b"), (2, "(c)")])`, "0xdeadbeef", "[3, 0x04]",
+	})
+	require.NoError(t, err)
+	contract, err := ParseSignatures([]string{signature})
+	require.NoError(t, err)
+	type lock struct {
+		Until uint64
+		Memo  string
+	}
+	order := struct {
+		Target common.Address
+		Locks  []lock
+	}{bob, []lock{{1, "a, b"}, {2, "(c)"}}}
+	expected, err := contract.Pack("submit", order, hexutil.MustDecode("0xdeadbeef"), [2]*big.Int{big.NewInt(3), big.NewInt(4)})
+	require.NoError(t, err)
+	require.Equal(t, expected, data)
+
+	for _, tc := range []struct {
+		signature string
+		args      []string
+	}{
+		{"transfer(address,uint256)", []string{alice.Hex()}},
+		{"transfer(address,uint256)", []string{"0x1234", "1"}},
+		{"f(uint8)", []string{"256"}},
+		{"f(uint8)", []string{"-1"}},
+		{"f(int8)", []string{"-129"}},
+		{"f(uint256)", []string{""}},
+		{"f(bytes2)", []string{"0x010203"}},
+		{"f(uint256[2])", []string{"[1]"}},
+		{"f((uint256,bool))", []string{"(1)"}},
+		{"f(uint256[])", []string{"[1,(2]"}},
+		{"f(bool)", []string{"yes"}},
+		{"event Transfer(address)", []string{alice.Hex()}},
+	} {
+		_, err := Encode(tc.signature, tc.args)
+		require.Error(t, err, tc.signature, tc.args)
+	}
+}
+
+func TestEncodePacked(t *testing.T) {
+	// example of the Solidity documentation
+	data, err := EncodePacked("(int16,bytes1,uint16,string)", []string{"-1", "0x42", "0x03", "Hello, world!"})
+	require.NoError(t, err)
+	require.Equal(t, "0xffff42000348656c6c6f2c20776f726c6421", hexutil.Encode(data))
+
+	data, err = EncodePacked("(address,bool,bytes,uint8[])", []string{alice.Hex(), "false", "0x0102", "[1,2]"})
+	require.NoError(t, err)
+	require.Equal(t, "0x"+"1111111111111111111111111111111111111111"+"00"+"0102"+
+		"0000000000000000000000000000000000000000000000000000000000000001"+
+		"0000000000000000000000000000000000000000000000000000000000000002", hexutil.Encode(data))
+
+	_, err = EncodePacked("((uint256,bool))", []string{"(1,true)"})
+	require.Error(t, err)
+	_, err = EncodePacked("(string[])", []string{`["a"]`})
+	require.Error(t, err)
+}
+
+func TestDecode(t *testing.T) {
+	data, err := Encode("transfer(address to, uint256 amount)", []string{alice.Hex(), "1000"})
+	require.NoError(t, err)
+	call, err := Decode("transfer(address to, uint256 amount)", data)
+	require.NoError(t, err)
+	require.Equal(t, "transfer", call.Name)
+	require.Equal(t, "0xa9059cbb", call.Selector)
+	require.Equal(t, []Arg{{Name: "to", Type: "address", Value: alice.Hex()}, {Name: "amount", Type: "uint256", Value: "1000"}}, call.Args)
+
+	_, err = Decode("approve(address,uint256)", data)
+	require.Error(t, err)
+
+	data, err = Encode("((uint64,string),bytes32[])", []string{`(7, "x")`, "[0x01]"})
+	require.NoError(t, err)
+	call, err = Decode("((uint64,string),bytes32[])", data)
+	require.NoError(t, err)
+	require.Equal(t, "((uint64,string),bytes32[])", call.Signature)
+	require.Equal(t, []Arg{{Name: "field0", Type: "uint64", Value: "7"}, {Name: "field1", Type: "string", Value: "x"}}, call.Args[0].Value)
+	require.Equal(t, []interface{}{"0x0100000000000000000000000000000000000000000000000000000000000000"}, call.Args[1].Value)
+
+	_, err = Decode("(uint256,string)", data[:40])
+	require.Error(t, err)
+}
+
+func TestParseValue(t *testing.T) {
+	uint256, err := gethabi.NewType("uint256", "", nil)
+	require.NoError(t, err)
+	v, err := ParseValue(uint256, " 0xff ")
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(255), v)
+
+	str, err := gethabi.NewType("string[]", "", nil)
+	require.NoError(t, err)
+	v, err = ParseValue(str, `["a,b", "say \"hi\"", plain]`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a,b", `say "hi"`, "plain"}, v)
+
+	v, err = ParseValue(str, "[]")
+	require.NoError(t, err)
+	require.Equal(t, []string{}, v)
+}
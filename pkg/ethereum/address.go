@@ -0,0 +1,51 @@
+package ethereum
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// AddressInfo describes a hex Ethereum address and its EIP-55 checksum
+type AddressInfo struct {
+	Address string `json:"address"`
+	Chain   string `json:"chain"`
+	// Checksummed is the EIP-55 mixed-case form of the address
+	Checksummed string `json:"checksummed"`
+	// HasChecksum is set when the address is written in mixed case, all lower
+	// or all upper case addresses carry no checksum
+	HasChecksum bool     `json:"hasChecksum"`
+	Payload     string   `json:"payload"`
+	Valid       bool     `json:"valid"`
+	Error       string   `json:"error,omitempty"`
+	Corrections []string `json:"corrections,omitempty"`
+}
+
+// InspectAddress decodes a 0x prefixed hex address and checks its EIP-55
+// checksum. It fails only when the address is not 20 bytes of hex, a wrong
+// checksum is reported in the info.
+func InspectAddress(address string) (*AddressInfo, error) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(address, "0x"), "0X")
+	if len(digits) != 2*common.AddressLength || !common.IsHexAddress(digits) {
+		return nil, fmt.Errorf("not a hex address of %d bytes", common.AddressLength)
+	}
+
+	addr := common.HexToAddress(digits)
+	info := &AddressInfo{
+		Address:     address,
+		Chain:       "ethereum",
+		Checksummed: addr.Hex(),
+		HasChecksum: digits != strings.ToLower(digits) && digits != strings.ToUpper(digits),
+		Payload:     strings.ToLower(digits),
+		Valid:       true,
+	}
+	if info.HasChecksum && "0x"+digits != info.Checksummed {
+		// the case of the letters or one of the digits is wrong, only the
+		// first can be corrected
+		info.Valid = false
+		info.Error = "invalid EIP-55 checksum"
+		info.Corrections = []string{info.Checksummed}
+	}
+	return info, nil
+}
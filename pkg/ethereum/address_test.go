@@ -0,0 +1,37 @@
+package ethereum
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectAddress(t *testing.T) {
+	// EIP-55 test vector
+	const checksummed = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+	info, err := InspectAddress(checksummed)
+	require.NoError(t, err)
+	require.True(t, info.Valid)
+	require.True(t, info.HasChecksum)
+	require.Equal(t, "5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", info.Payload)
+
+	for _, address := range []string{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED"} {
+		info, err = InspectAddress(address)
+		require.NoError(t, err)
+		require.True(t, info.Valid)
+		require.False(t, info.HasChecksum)
+		require.Equal(t, checksummed, info.Checksummed)
+	}
+
+	info, err = InspectAddress("0x5aaeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	require.NoError(t, err)
+	require.False(t, info.Valid)
+	require.Equal(t, "invalid EIP-55 checksum", info.Error)
+	require.Equal(t, []string{checksummed}, info.Corrections)
+
+	_, err = InspectAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA")
+	require.Error(t, err)
+	_, err = InspectAddress("bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4")
+	require.Error(t, err)
+}
@@ -0,0 +1,402 @@
+package ethereum
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/holiman/uint256"
+)
+
+// Transaction types of a TxSpec
+const (
+	TxTypeLegacy     = "legacy"
+	TxTypeAccessList = "access-list"
+	TxTypeDynamicFee = "dynamic-fee"
+	TxTypeBlob       = "blob"
+	TxTypeSetCode    = "set-code"
+)
+
+// SetCodeTxType is the EIP-7702 transaction type
+const SetCodeTxType = 0x04
+
+// setCodeAuthMagic prefixes the EIP-7702 authorization signing payload
+const setCodeAuthMagic = 0x05
+
+// blobDataSize is the data packed in a blob, 31 bytes per field element so
+// that each stays below the BLS modulus
+const blobDataSize = 4096 * 31
+
+// TxSpec describes an unsigned transaction. Amounts are decimal or 0x
+// prefixed hex. The type is inferred from the fee fields when not set.
+type TxSpec struct {
+	Type                 string                `json:"type,omitempty"`
+	ChainID              *math.HexOrDecimal256 `json:"chainId,omitempty"`
+	Nonce                math.HexOrDecimal64   `json:"nonce"`
+	To                   *common.Address       `json:"to,omitempty"`
+	Value                *math.HexOrDecimal256 `json:"value,omitempty"`
+	Gas                  math.HexOrDecimal64   `json:"gas"`
+	GasPrice             *math.HexOrDecimal256 `json:"gasPrice,omitempty"`
+	MaxPriorityFeePerGas *math.HexOrDecimal256 `json:"maxPriorityFeePerGas,omitempty"`
+	MaxFeePerGas         *math.HexOrDecimal256 `json:"maxFeePerGas,omitempty"`
+	MaxFeePerBlobGas     *math.HexOrDecimal256 `json:"maxFeePerBlobGas,omitempty"`
+	Data                 hexutil.Bytes         `json:"data,omitempty"`
+	AccessList           types.AccessList      `json:"accessList,omitempty"`
+	// Blobs are the data carried by a blob transaction, one blob each
+	Blobs             []hexutil.Bytes     `json:"blobs,omitempty"`
+	AuthorizationList []AuthorizationSpec `json:"authorizationList,omitempty"`
+}
+
+// AuthorizationSpec is an EIP-7702 authorization. Without a signature it is
+// signed with the transaction key, its chain ID defaulting to the one of the
+// transaction and its nonce to the transaction nonce plus one, as the sender
+// account nonce is incremented before the authorizations are processed.
+type AuthorizationSpec struct {
+	ChainID *math.HexOrDecimal256 `json:"chainId,omitempty"`
+	Address common.Address        `json:"address"`
+	Nonce   *math.HexOrDecimal64  `json:"nonce,omitempty"`
+	YParity *math.HexOrDecimal64  `json:"yParity,omitempty"`
+	R       *math.HexOrDecimal256 `json:"r,omitempty"`
+	S       *math.HexOrDecimal256 `json:"s,omitempty"`
+}
+
+// Authorization is a signed EIP-7702 authorization, in its RLP field order
+type Authorization struct {
+	ChainID *big.Int
+	Address common.Address
+	Nonce   uint64
+	YParity uint8
+	R       *big.Int
+	S       *big.Int
+}
+
+// setCodeTx is an EIP-7702 transaction, in its RLP field order
+type setCodeTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         common.Address
+	Value      *big.Int
+	Data       []byte
+	AccessList types.AccessList
+	AuthList   []Authorization
+	V          *big.Int
+	R          *big.Int
+	S          *big.Int
+}
+
+// SignedTx is a signed transaction
+type SignedTx struct {
+	Type string
+	From common.Address
+	Hash common.Hash
+	// Raw is the network encoding of the transaction, which includes the
+	// blobs, commitments and proofs of a blob transaction
+	Raw []byte
+}
+
+// LoadTxSpec reads a JSON transaction spec
+func LoadTxSpec(path string) (*TxSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %v", err)
+	}
+	var spec TxSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %v", err)
+	}
+	return &spec, nil
+}
+
+// TxType returns the type of the transaction, inferred from its fields when
+// not set
+func (s *TxSpec) TxType() string {
+	switch {
+	case s.Type != "":
+		return s.Type
+	case len(s.AuthorizationList) > 0:
+		return TxTypeSetCode
+	case len(s.Blobs) > 0:
+		return TxTypeBlob
+	case s.MaxFeePerGas != nil:
+		return TxTypeDynamicFee
+	case len(s.AccessList) > 0:
+		return TxTypeAccessList
+	}
+	return TxTypeLegacy
+}
+
+// Sign signs the transaction with key
+func (s *TxSpec) Sign(key *ecdsa.PrivateKey) (*SignedTx, error) {
+	txType := s.TxType()
+	if err := s.validate(txType); err != nil {
+		return nil, err
+	}
+	chainID := bigInt(s.ChainID)
+
+	var inner types.TxData
+	switch txType {
+	case TxTypeLegacy:
+		inner = &types.LegacyTx{Nonce: uint64(s.Nonce), GasPrice: bigInt(s.GasPrice), Gas: uint64(s.Gas),
+			To: s.To, Value: bigInt(s.Value), Data: s.Data}
+	case TxTypeAccessList:
+		inner = &types.AccessListTx{ChainID: chainID, Nonce: uint64(s.Nonce), GasPrice: bigInt(s.GasPrice),
+			Gas: uint64(s.Gas), To: s.To, Value: bigInt(s.Value), Data: s.Data, AccessList: s.AccessList}
+	case TxTypeDynamicFee:
+		inner = &types.DynamicFeeTx{ChainID: chainID, Nonce: uint64(s.Nonce), GasTipCap: bigInt(s.MaxPriorityFeePerGas),
+			GasFeeCap: bigInt(s.MaxFeePerGas), Gas: uint64(s.Gas), To: s.To, Value: bigInt(s.Value), Data: s.Data,
+			AccessList: s.AccessList}
+	case TxTypeBlob:
+		sidecar, err := NewBlobSidecar(s.Blobs)
+		if err != nil {
+			return nil, err
+		}
+		fields, err := uint256Fields(chainID, bigInt(s.MaxPriorityFeePerGas), bigInt(s.MaxFeePerGas),
+			bigInt(s.Value), bigInt(s.MaxFeePerBlobGas))
+		if err != nil {
+			return nil, err
+		}
+		inner = &types.BlobTx{ChainID: fields[0], Nonce: uint64(s.Nonce), GasTipCap: fields[1], GasFeeCap: fields[2],
+			Gas: uint64(s.Gas), To: *s.To, Value: fields[3], Data: s.Data, AccessList: s.AccessList,
+			BlobFeeCap: fields[4], BlobHashes: sidecar.BlobHashes(), Sidecar: sidecar}
+	case TxTypeSetCode:
+		return s.signSetCode(key)
+	}
+
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(s.signerChainID()), inner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode transaction: %v", err)
+	}
+	return &SignedTx{Type: txType, From: crypto.PubkeyToAddress(key.PublicKey), Hash: tx.Hash(), Raw: raw}, nil
+}
+
+// signerChainID returns the chain ID to sign with, nil for a legacy
+// transaction without one, signed without EIP-155 replay protection
+func (s *TxSpec) signerChainID() *big.Int {
+	if s.ChainID == nil {
+		return nil
+	}
+	return bigInt(s.ChainID)
+}
+
+func (s *TxSpec) validate(txType string) error {
+	if s.Gas == 0 {
+		return fmt.Errorf("gas limit is required")
+	}
+	if s.ChainID == nil && txType != TxTypeLegacy {
+		return fmt.Errorf("chain id is required for %s transactions", txType)
+	}
+	for _, field := range []struct {
+		name  string
+		value *math.HexOrDecimal256
+	}{
+		{"chain id", s.ChainID}, {"value", s.Value}, {"gas price", s.GasPrice},
+		{"max priority fee per gas", s.MaxPriorityFeePerGas}, {"max fee per gas", s.MaxFeePerGas},
+		{"max fee per blob gas", s.MaxFeePerBlobGas},
+	} {
+		if isNegative(field.value) {
+			return fmt.Errorf("%s cannot be negative", field.name)
+		}
+	}
+	for i, auth := range s.AuthorizationList {
+		if isNegative(auth.ChainID) || isNegative(auth.R) || isNegative(auth.S) {
+			return fmt.Errorf("authorization %d has a negative chain id or signature value", i)
+		}
+	}
+	switch txType {
+	case TxTypeLegacy, TxTypeAccessList:
+		if s.GasPrice == nil {
+			return fmt.Errorf("gas price is required for %s transactions", txType)
+		}
+	case TxTypeDynamicFee, TxTypeBlob, TxTypeSetCode:
+		if s.MaxFeePerGas == nil || s.MaxPriorityFeePerGas == nil {
+			return fmt.Errorf("max fee and max priority fee per gas are required for %s transactions", txType)
+		}
+	default:
+		return fmt.Errorf("unsupported transaction type %s, expected %s, %s, %s, %s or %s", txType,
+			TxTypeLegacy, TxTypeAccessList, TxTypeDynamicFee, TxTypeBlob, TxTypeSetCode)
+	}
+	if (txType == TxTypeBlob || txType == TxTypeSetCode) && s.To == nil {
+		return fmt.Errorf("%s transactions cannot create contracts, a recipient is required", txType)
+	}
+	if txType == TxTypeBlob {
+		if len(s.Blobs) == 0 {
+			return fmt.Errorf("blob transactions require at least one blob")
+		}
+		if s.MaxFeePerBlobGas == nil {
+			return fmt.Errorf("max fee per blob gas is required for blob transactions")
+		}
+	}
+	if txType == TxTypeSetCode && len(s.AuthorizationList) == 0 {
+		return fmt.Errorf("set-code transactions require at least one authorization")
+	}
+	return nil
+}
+
+// signSetCode signs an EIP-7702 transaction, not yet supported by the
+// go-ethereum types
+func (s *TxSpec) signSetCode(key *ecdsa.PrivateKey) (*SignedTx, error) {
+	tx := setCodeTx{
+		ChainID:    bigInt(s.ChainID),
+		Nonce:      uint64(s.Nonce),
+		GasTipCap:  bigInt(s.MaxPriorityFeePerGas),
+		GasFeeCap:  bigInt(s.MaxFeePerGas),
+		Gas:        uint64(s.Gas),
+		To:         *s.To,
+		Value:      bigInt(s.Value),
+		Data:       s.Data,
+		AccessList: s.AccessList,
+	}
+	for _, spec := range s.AuthorizationList {
+		auth, err := spec.authorization(tx.ChainID, tx.Nonce+1, key)
+		if err != nil {
+			return nil, err
+		}
+		tx.AuthList = append(tx.AuthList, auth)
+	}
+
+	sig, err := crypto.Sign(tx.sigHash().Bytes(), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	tx.V, tx.R, tx.S = big.NewInt(int64(sig[64])), new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])
+	payload, err := rlp.EncodeToBytes(&tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode transaction: %v", err)
+	}
+	raw := append([]byte{SetCodeTxType}, payload...)
+	return &SignedTx{Type: TxTypeSetCode, From: crypto.PubkeyToAddress(key.PublicKey), Hash: crypto.Keccak256Hash(raw), Raw: raw}, nil
+}
+
+// sigHash returns the hash signed by the sender of an EIP-7702 transaction
+func (tx *setCodeTx) sigHash() common.Hash {
+	payload, _ := rlp.EncodeToBytes([]interface{}{tx.ChainID, tx.Nonce, tx.GasTipCap, tx.GasFeeCap, tx.Gas,
+		tx.To, tx.Value, tx.Data, tx.AccessList, tx.AuthList})
+	return crypto.Keccak256Hash(append([]byte{SetCodeTxType}, payload...))
+}
+
+// authorization returns the signed authorization, signing it with key when
+// the spec has no signature
+func (a AuthorizationSpec) authorization(chainID *big.Int, nonce uint64, key *ecdsa.PrivateKey) (Authorization, error) {
+	if a.ChainID != nil {
+		chainID = bigInt(a.ChainID)
+	}
+	if a.Nonce != nil {
+		nonce = uint64(*a.Nonce)
+	}
+	if a.R != nil || a.S != nil {
+		if a.R == nil || a.S == nil || a.YParity == nil || *a.YParity > 1 {
+			return Authorization{}, fmt.Errorf("authorization of %s has an incomplete signature", a.Address.Hex())
+		}
+		return Authorization{ChainID: chainID, Address: a.Address, Nonce: nonce, YParity: uint8(*a.YParity),
+			R: bigInt(a.R), S: bigInt(a.S)}, nil
+	}
+	return SignAuthorization(chainID, a.Address, nonce, key)
+}
+
+// SignAuthorization signs an EIP-7702 authorization delegating the account
+// of key to the code at address. A zero chain ID is valid on any chain.
+func SignAuthorization(chainID *big.Int, address common.Address, nonce uint64, key *ecdsa.PrivateKey) (Authorization, error) {
+	auth := Authorization{ChainID: chainID, Address: address, Nonce: nonce}
+	sig, err := crypto.Sign(auth.SigHash().Bytes(), key)
+	if err != nil {
+		return Authorization{}, fmt.Errorf("failed to sign authorization: %v", err)
+	}
+	auth.YParity, auth.R, auth.S = sig[64], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])
+	return auth, nil
+}
+
+// SigHash returns the hash signed by the authority:
+// keccak256(0x05 || rlp([chain_id, address, nonce]))
+func (a *Authorization) SigHash() common.Hash {
+	payload, _ := rlp.EncodeToBytes([]interface{}{a.ChainID, a.Address, a.Nonce})
+	return crypto.Keccak256Hash(append([]byte{setCodeAuthMagic}, payload...))
+}
+
+// Authority recovers the address of the account signing the authorization
+func (a *Authorization) Authority() (common.Address, error) {
+	if a.YParity > 1 || !crypto.ValidateSignatureValues(a.YParity, a.R, a.S, true) {
+		return common.Address{}, fmt.Errorf("invalid authorization signature")
+	}
+	sig := make([]byte, crypto.SignatureLength)
+	a.R.FillBytes(sig[:32])
+	a.S.FillBytes(sig[32:64])
+	sig[64] = a.YParity
+	pub, err := crypto.SigToPub(a.SigHash().Bytes(), sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to recover authority: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// NewBlobSidecar packs each data into a blob and computes its KZG commitment
+// and proof
+func NewBlobSidecar(data []hexutil.Bytes) (*types.BlobTxSidecar, error) {
+	sidecar := &types.BlobTxSidecar{}
+	for i, d := range data {
+		if len(d) > blobDataSize {
+			return nil, fmt.Errorf("blob %d has %d bytes, the maximum is %d", i, len(d), blobDataSize)
+		}
+		var blob kzg4844.Blob
+		for j := 0; j < len(d); j += 31 {
+			// the leading byte of each field element stays zero
+			copy(blob[j/31*32+1:], d[j:min(j+31, len(d))])
+		}
+		commitment, err := kzg4844.BlobToCommitment(&blob)
+		if err != nil {
+			return nil, fmt.Errorf("failed to commit to blob %d: %v", i, err)
+		}
+		proof, err := kzg4844.ComputeBlobProof(&blob, commitment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute proof of blob %d: %v", i, err)
+		}
+		sidecar.Blobs = append(sidecar.Blobs, blob)
+		sidecar.Commitments = append(sidecar.Commitments, commitment)
+		sidecar.Proofs = append(sidecar.Proofs, proof)
+	}
+	return sidecar, nil
+}
+
+// isNegative reports whether x is set to a value below zero
+func isNegative(x *math.HexOrDecimal256) bool {
+	return x != nil && (*big.Int)(x).Sign() < 0
+}
+
+// uint256Fields converts the fields of a blob transaction, which are encoded
+// as 256 bit unsigned integers
+func uint256Fields(xs ...*big.Int) ([]*uint256.Int, error) {
+	fields := make([]*uint256.Int, len(xs))
+	for i, x := range xs {
+		if x.Sign() < 0 {
+			return nil, fmt.Errorf("negative value %s", x)
+		}
+		var overflow bool
+		if fields[i], overflow = uint256.FromBig(x); overflow {
+			return nil, fmt.Errorf("value %s does not fit in 256 bits", x)
+		}
+	}
+	return fields, nil
+}
+
+// bigInt returns the value of x, zero when unset
+func bigInt(x *math.HexOrDecimal256) *big.Int {
+	if x == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set((*big.Int)(x))
+}
@@ -0,0 +1,132 @@
+package ethereum
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+func hexOrDecimal(x int64) *math.HexOrDecimal256 {
+	return (*math.HexOrDecimal256)(big.NewInt(x))
+}
+
+func TestSignTxSpec(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	accessList := types.AccessList{{Address: to, StorageKeys: []common.Hash{{0x01}}}}
+
+	for _, tc := range []struct {
+		spec    TxSpec
+		txType  string
+		txTypeN uint8
+	}{
+		{TxSpec{Nonce: 1, To: &to, Value: hexOrDecimal(1e18), Gas: 21000, GasPrice: hexOrDecimal(1e9)},
+			TxTypeLegacy, types.LegacyTxType},
+		{TxSpec{ChainID: hexOrDecimal(1), To: &to, Gas: 21000, GasPrice: hexOrDecimal(1e9)},
+			TxTypeLegacy, types.LegacyTxType},
+		{TxSpec{ChainID: hexOrDecimal(1), Gas: 100000, GasPrice: hexOrDecimal(1e9), Data: []byte{0x60, 0x00}, AccessList: accessList},
+			TxTypeAccessList, types.AccessListTxType},
+		{TxSpec{ChainID: hexOrDecimal(11155111), Nonce: 7, To: &to, Gas: 21000, MaxFeePerGas: hexOrDecimal(3e10), MaxPriorityFeePerGas: hexOrDecimal(1e9)},
+			TxTypeDynamicFee, types.DynamicFeeTxType},
+		{TxSpec{ChainID: hexOrDecimal(1), To: &to, Gas: 21000, MaxFeePerGas: hexOrDecimal(3e10), MaxPriorityFeePerGas: hexOrDecimal(1e9),
+			MaxFeePerBlobGas: hexOrDecimal(1e9), Blobs: []hexutil.Bytes{[]byte("hello blob")}},
+			TxTypeBlob, types.BlobTxType},
+	} {
+		require.Equal(t, tc.txType, tc.spec.TxType())
+		signed, err := tc.spec.Sign(key)
+		require.NoError(t, err, tc.txType)
+		require.Equal(t, from, signed.From)
+
+		var tx types.Transaction
+		require.NoError(t, tx.UnmarshalBinary(signed.Raw))
+		require.Equal(t, tc.txTypeN, tx.Type())
+		require.Equal(t, signed.Hash, tx.Hash())
+		require.Equal(t, uint64(tc.spec.Nonce), tx.Nonce())
+		sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), &tx)
+		require.NoError(t, err)
+		require.Equal(t, from, sender)
+		if tc.spec.ChainID == nil {
+			require.False(t, tx.Protected())
+		}
+		if tc.txType == TxTypeBlob {
+			sidecar := tx.BlobTxSidecar()
+			require.NotNil(t, sidecar)
+			require.Len(t, tx.BlobHashes(), 1)
+			require.NoError(t, kzg4844.VerifyBlobProof(&sidecar.Blobs[0], sidecar.Commitments[0], sidecar.Proofs[0]))
+			require.Equal(t, []byte("hello"), sidecar.Blobs[0][1:6])
+		}
+	}
+}
+
+func TestSignSetCodeTx(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	delegate := common.HexToAddress("0x63c0c19a282a1b52b07dd5a65b58948a07dae32b")
+
+	spec := TxSpec{ChainID: hexOrDecimal(1), Nonce: 3, To: &from, Gas: 100000,
+		MaxFeePerGas: hexOrDecimal(3e10), MaxPriorityFeePerGas: hexOrDecimal(1e9),
+		AuthorizationList: []AuthorizationSpec{{Address: delegate}}}
+	require.Equal(t, TxTypeSetCode, spec.TxType())
+	signed, err := spec.Sign(key)
+	require.NoError(t, err)
+	require.Equal(t, byte(SetCodeTxType), signed.Raw[0])
+	require.Equal(t, crypto.Keccak256Hash(signed.Raw), signed.Hash)
+
+	var tx setCodeTx
+	require.NoError(t, rlp.DecodeBytes(signed.Raw[1:], &tx))
+	require.Equal(t, uint64(3), tx.Nonce)
+	require.Len(t, tx.AuthList, 1)
+
+	// a self-sponsored authorization uses the nonce following the transaction
+	auth := tx.AuthList[0]
+	require.Equal(t, uint64(4), auth.Nonce)
+	require.Equal(t, delegate, auth.Address)
+	authority, err := auth.Authority()
+	require.NoError(t, err)
+	require.Equal(t, from, authority)
+
+	sig := make([]byte, crypto.SignatureLength)
+	tx.R.FillBytes(sig[:32])
+	tx.S.FillBytes(sig[32:64])
+	sig[64] = byte(tx.V.Uint64())
+	pub, err := crypto.SigToPub(tx.sigHash().Bytes(), sig)
+	require.NoError(t, err)
+	require.Equal(t, from, crypto.PubkeyToAddress(*pub))
+}
+
+func TestTxSpecErrors(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	to := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	for _, spec := range []TxSpec{
+		{To: &to, GasPrice: hexOrDecimal(1)},
+		{To: &to, Gas: 21000},
+		{Type: TxTypeDynamicFee, To: &to, Gas: 21000, MaxFeePerGas: hexOrDecimal(1), MaxPriorityFeePerGas: hexOrDecimal(1)},
+		{ChainID: hexOrDecimal(1), To: &to, Gas: 21000, MaxFeePerGas: hexOrDecimal(1)},
+		{ChainID: hexOrDecimal(1), Gas: 21000, MaxFeePerGas: hexOrDecimal(1), MaxPriorityFeePerGas: hexOrDecimal(1),
+			MaxFeePerBlobGas: hexOrDecimal(1), Blobs: []hexutil.Bytes{{0x01}}},
+		{Type: TxTypeSetCode, ChainID: hexOrDecimal(1), To: &to, Gas: 21000, MaxFeePerGas: hexOrDecimal(1), MaxPriorityFeePerGas: hexOrDecimal(1)},
+		{Type: "unknown", ChainID: hexOrDecimal(1), Gas: 21000},
+		{ChainID: hexOrDecimal(1), To: &to, Gas: 21000, MaxFeePerGas: hexOrDecimal(1), MaxPriorityFeePerGas: hexOrDecimal(1),
+			MaxFeePerBlobGas: hexOrDecimal(1), Blobs: []hexutil.Bytes{{0x01}}, Value: hexOrDecimal(-1)},
+		{ChainID: hexOrDecimal(-1), To: &to, Gas: 21000, GasPrice: hexOrDecimal(1)},
+		{To: &to, Gas: 21000, GasPrice: hexOrDecimal(-1)},
+		{ChainID: hexOrDecimal(1), To: &to, Gas: 21000, MaxFeePerGas: hexOrDecimal(-1), MaxPriorityFeePerGas: hexOrDecimal(1)},
+		{ChainID: hexOrDecimal(1), To: &to, Gas: 21000, MaxFeePerGas: hexOrDecimal(1), MaxPriorityFeePerGas: hexOrDecimal(1),
+			AuthorizationList: []AuthorizationSpec{{ChainID: hexOrDecimal(-1), Address: to}}},
+	} {
+		_, err := spec.Sign(key)
+		require.Error(t, err, "%+v", spec)
+	}
+}
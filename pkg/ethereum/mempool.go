@@ -6,6 +6,7 @@ import (
 	"log"
 	"math/big"
 
+	"github.com/alejoacosta74/cryptonaut/pkg/ethereum/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/params"
@@ -19,6 +20,7 @@ type MempoolSubscription struct {
 	subscription *rpc.ClientSubscription
 	txChan       chan *types.Transaction
 	txHashChan   chan common.Hash
+	contract     *abi.ABI // optional calldata decoder
 }
 
 // NewMempoolSubscription creates a new mempool subscription
@@ -37,6 +39,11 @@ func NewMempoolSubscription(client *EthereumClient, toAddress string) (*MempoolS
 	}, nil
 }
 
+// SetABI decodes the calldata of the transactions with the contract ABI
+func (s *MempoolSubscription) SetABI(contract *abi.ABI) {
+	s.contract = contract
+}
+
 // Start begins the subscription
 func (s *MempoolSubscription) Start(ctx context.Context) error {
 	gethClient := s.client.GetGethClient()
@@ -72,7 +79,7 @@ func (s *MempoolSubscription) handleTransactions(ctx context.Context) {
 			if s.toAddress != nil && tx.To() != nil && *s.toAddress != *tx.To() {
 				continue
 			}
-			processTransaction(tx, isPending)
+			s.processTransaction(tx, isPending)
 		case err := <-s.subscription.Err():
 			log.Printf("Subscription error: %v", err)
 			return
@@ -83,7 +90,7 @@ func (s *MempoolSubscription) handleTransactions(ctx context.Context) {
 	}
 }
 
-func processTransaction(tx *types.Transaction, isPending bool) {
+func (s *MempoolSubscription) processTransaction(tx *types.Transaction, isPending bool) {
 	fmt.Printf("Transaction found (pending: %t):\n", isPending)
 	fmt.Printf("  Hash: %s\n", tx.Hash().Hex())
 	fmt.Printf("  To: %s\n", tx.To())
@@ -95,6 +102,13 @@ func processTransaction(tx *types.Transaction, isPending bool) {
 		log.Printf("Failed to get sender address for transaction %s: %v", tx.Hash().Hex(), err)
 	}
 	fmt.Printf("  From: %s\n", msg.Hex())
+	if s.contract != nil && tx.To() != nil && len(tx.Data()) >= 4 {
+		if call, err := s.contract.DecodeCalldata(tx.Data()); err == nil {
+			fmt.Printf("  Call: %s\n", call)
+		} else {
+			fmt.Printf("  Call: %v\n", err)
+		}
+	}
 	fmt.Println()
 
 }
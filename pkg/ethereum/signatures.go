@@ -0,0 +1,228 @@
+package ethereum
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alejoacosta74/cryptonaut/pkg/ethereum/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+//go:embed signatures.txt
+var embeddedSignatures []byte
+
+// eventPrefix tags the event signatures of the database, the other
+// signatures being functions and errors
+const eventPrefix = "event "
+
+// SignatureDB maps 4-byte function and error selectors and 32-byte event
+// topics to the text signatures hashing to them
+type SignatureDB struct {
+	selectors map[[4]byte][]string
+	topics    map[common.Hash][]string
+	known     map[string]bool
+}
+
+// NewSignatureDB creates an empty database
+func NewSignatureDB() *SignatureDB {
+	return &SignatureDB{selectors: make(map[[4]byte][]string), topics: make(map[common.Hash][]string),
+		known: make(map[string]bool)}
+}
+
+// LoadSignatureDB loads the embedded signatures and those of the user file at
+// path, which may not exist yet
+func LoadSignatureDB(path string) (*SignatureDB, error) {
+	db := NewSignatureDB()
+	if _, err := db.Load(bytes.NewReader(embeddedSignatures)); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return db, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open signature database: %v", err)
+	}
+	defer f.Close()
+	if _, err := db.Load(f); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %v", path, err)
+	}
+	return db, nil
+}
+
+// Load adds the canonical signatures read from r, one per line with events
+// prefixed by "event ", skipping empty lines and # comments, and returns the
+// number of new signatures
+func (db *SignatureDB) Load(r io.Reader) (int, error) {
+	added := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if db.Add(line) {
+			added++
+		}
+	}
+	return added, scanner.Err()
+}
+
+// Add adds a canonical signature, returning false if it was already known.
+// Event signatures, prefixed by "event ", are only added to the topics and
+// the others only to the selectors.
+func (db *SignatureDB) Add(signature string) bool {
+	if db.known[signature] {
+		return false
+	}
+	db.known[signature] = true
+	if event, ok := CutEventPrefix(signature); ok {
+		topic := SignatureTopic(event)
+		db.topics[topic] = append(db.topics[topic], event)
+		sort.Strings(db.topics[topic])
+		return true
+	}
+	selector := [4]byte(SignatureSelector(signature))
+	db.selectors[selector] = append(db.selectors[selector], signature)
+	sort.Strings(db.selectors[selector])
+	return true
+}
+
+// Len returns the number of signatures
+func (db *SignatureDB) Len() int {
+	return len(db.known)
+}
+
+// LookupSelector returns the signatures of a function or error selector,
+// several on collisions
+func (db *SignatureDB) LookupSelector(selector []byte) []string {
+	if len(selector) != 4 {
+		return nil
+	}
+	return db.selectors[[4]byte(selector)]
+}
+
+// LookupTopic returns the signature of an event topic
+func (db *SignatureDB) LookupTopic(topic common.Hash) []string {
+	return db.topics[topic]
+}
+
+// GuessCalldata decodes calldata with the first signature of its selector
+// whose parameter types decode it and encode it back unchanged. The
+// arguments are unnamed.
+func (db *SignatureDB) GuessCalldata(data []byte) (*abi.Call, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("calldata too short, %d bytes", len(data))
+	}
+	candidates := db.LookupSelector(data[:4])
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("unknown selector 0x%x", data[:4])
+	}
+	for _, signature := range candidates {
+		contract, err := abi.ParseSignatures([]string{"function " + signature})
+		if err != nil {
+			continue
+		}
+		method, err := contract.MethodById(data[:4])
+		if err != nil {
+			continue
+		}
+		if len(method.Inputs) == 0 {
+			if len(data) != 4 {
+				continue
+			}
+		} else {
+			values, err := method.Inputs.Unpack(data[4:])
+			if err != nil {
+				continue
+			}
+			// the encoding is checked when the values can be packed back
+			if packed, err := method.Inputs.Pack(values...); err == nil && !bytes.Equal(packed, data[4:]) {
+				continue
+			}
+		}
+		return contract.DecodeCalldata(data)
+	}
+	return nil, fmt.Errorf("no signature of selector 0x%x matches the calldata", data[:4])
+}
+
+// CutEventPrefix returns signature without its "event " prefix and whether
+// it had one
+func CutEventPrefix(signature string) (string, bool) {
+	return strings.CutPrefix(signature, eventPrefix)
+}
+
+// SignatureSelector returns the 4-byte selector of a function or error
+// signature
+func SignatureSelector(signature string) []byte {
+	return crypto.Keccak256([]byte(signature))[:4]
+}
+
+// SignatureTopic returns the topic of an event signature
+func SignatureTopic(signature string) common.Hash {
+	return crypto.Keccak256Hash([]byte(signature))
+}
+
+// CanonicalSignatures returns the canonical signatures of the functions,
+// events and errors of a JSON ABI, compiler artifact or list of
+// human-readable signatures, see abi.Parse. Events are prefixed by "event ".
+func CanonicalSignatures(data []byte) ([]string, error) {
+	contract, err := abi.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	var signatures []string
+	for _, method := range contract.Methods {
+		signatures = append(signatures, method.Sig)
+	}
+	for _, event := range contract.Events {
+		signatures = append(signatures, eventPrefix+event.Sig)
+	}
+	for _, abiErr := range contract.Errors {
+		signatures = append(signatures, abiErr.Sig)
+	}
+	sort.Strings(signatures)
+	return signatures, nil
+}
+
+// ImportSignatures appends the signatures unknown to the database at path,
+// creating it if needed, and returns the number added
+func ImportSignatures(path string, signatures []string) (int, error) {
+	db, err := LoadSignatureDB(path)
+	if err != nil {
+		return 0, err
+	}
+	var added []string
+	for _, signature := range signatures {
+		if db.Add(signature) {
+			added = append(added, signature)
+		}
+	}
+	if len(added) == 0 {
+		return 0, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, fmt.Errorf("failed to create signature database directory: %v", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open signature database: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(strings.Join(added, "\n") + "\n"); err != nil {
+		return 0, fmt.Errorf("failed to write signature database: %v", err)
+	}
+	return len(added), nil
+}
@@ -0,0 +1,107 @@
+package ethereum
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alejoacosta74/cryptonaut/pkg/ethereum/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedSignatures(t *testing.T) {
+	db, err := LoadSignatureDB("")
+	require.NoError(t, err)
+	require.Greater(t, db.Len(), 100)
+	require.Equal(t, []string{"transfer(address,uint256)"}, db.LookupSelector(hexutil.MustDecode("0xa9059cbb")))
+	require.Equal(t, []string{"Transfer(address,address,uint256)"},
+		db.LookupTopic(common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")))
+	require.Empty(t, db.LookupSelector(hexutil.MustDecode("0xdeadbeef")))
+	// events are not offered as functions
+	require.Empty(t, db.LookupSelector(hexutil.MustDecode("0xddf252ad")))
+	_, err = db.GuessCalldata(hexutil.MustDecode("0xddf252ad"))
+	require.ErrorContains(t, err, "unknown selector")
+
+	// every embedded signature parses
+	for _, line := range strings.Split(string(embeddedSignatures), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			_, err := abi.ParseSignatures([]string{line})
+			require.NoError(t, err, line)
+		}
+	}
+}
+
+func TestGuessCalldata(t *testing.T) {
+	db, err := LoadSignatureDB("")
+	require.NoError(t, err)
+	to := common.HexToAddress("0x1234567890123456789012345678901234567890")
+
+	data := hexutil.MustDecode("0xa9059cbb" + common.Bytes2Hex(common.LeftPadBytes(to[:], 32)) +
+		common.Bytes2Hex(common.LeftPadBytes(big.NewInt(1000).Bytes(), 32)))
+	call, err := db.GuessCalldata(data)
+	require.NoError(t, err)
+	require.Equal(t, "transfer(address,uint256)", call.Signature)
+	require.Equal(t, []abi.Arg{{Type: "address", Value: to.Hex()}, {Type: "uint256", Value: "1000"}}, call.Args)
+
+	call, err = db.GuessCalldata(hexutil.MustDecode("0x18160ddd"))
+	require.NoError(t, err)
+	require.Equal(t, "totalSupply()", call.Signature)
+
+	// Uniswap V3 exactInputSingle, with a tuple parameter
+	swap, err := abi.ParseSignatures([]string{"exactInputSingle((address,address,uint24,address,uint256,uint256,uint256,uint160))"})
+	require.NoError(t, err)
+	params := struct {
+		Field0, Field1 common.Address
+		Field2         *big.Int
+		Field3         common.Address
+		Field4, Field5 *big.Int
+		Field6, Field7 *big.Int
+	}{to, to, big.NewInt(3000), to, big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(0)}
+	swapData, err := swap.Pack("exactInputSingle", params)
+	require.NoError(t, err)
+	call, err = db.GuessCalldata(swapData)
+	require.NoError(t, err)
+	require.Equal(t, "exactInputSingle((address,address,uint24,address,uint256,uint256,uint256,uint160))", call.Signature)
+	require.Equal(t, "3000", call.Args[0].Value.([]abi.Arg)[2].Value)
+
+	// an address argument with dirty high bytes is not a valid encoding
+	dirty := append([]byte{}, data...)
+	dirty[4] = 0xff
+	_, err = db.GuessCalldata(dirty)
+	require.Error(t, err)
+
+	_, err = db.GuessCalldata(hexutil.MustDecode("0xdeadbeef"))
+	require.Error(t, err)
+}
+
+func TestImportSignatures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db", "signatures.txt")
+	signatures, err := CanonicalSignatures([]byte(`
+function stake(uint256 amount, (address to, uint64 until) lock)
+event Staked(address indexed user, uint256 amount)
+function transfer(address to, uint amount)
+`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"event Staked(address,uint256)", "stake(uint256,(address,uint64))", "transfer(address,uint256)"}, signatures)
+
+	added, err := ImportSignatures(path, signatures)
+	require.NoError(t, err)
+	require.Equal(t, 2, added)
+	added, err = ImportSignatures(path, signatures)
+	require.NoError(t, err)
+	require.Zero(t, added)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "event Staked(address,uint256)\nstake(uint256,(address,uint64))\n", string(content))
+
+	db, err := LoadSignatureDB(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"stake(uint256,(address,uint64))"}, db.LookupSelector(SignatureSelector("stake(uint256,(address,uint64))")))
+	require.Equal(t, []string{"Staked(address,uint256)"}, db.LookupTopic(SignatureTopic("Staked(address,uint256)")))
+	require.Empty(t, db.LookupSelector(SignatureSelector("Staked(address,uint256)")))
+}
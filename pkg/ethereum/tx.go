@@ -3,18 +3,27 @@ package ethereum
 import (
 	"encoding/hex"
 	"fmt"
+	"math/big"
+	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
+// DecodeEthereumRawTx decodes a hex transaction, either in its EIP-2718
+// binary encoding or RLP encoded as in blocks
 func DecodeEthereumRawTx(rawTx string) (*types.Transaction, error) {
-	rawTxBytes, err := hex.DecodeString(rawTx)
+	rawTxBytes, err := hex.DecodeString(strings.TrimPrefix(rawTx, "0x"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode transaction: %v", err)
 	}
 
 	var tx types.Transaction
+	if err := tx.UnmarshalBinary(rawTxBytes); err == nil {
+		return &tx, nil
+	}
 
 	err = rlp.DecodeBytes(rawTxBytes, &tx)
 	if err != nil {
@@ -23,3 +32,158 @@ func DecodeEthereumRawTx(rawTx string) (*types.Transaction, error) {
 
 	return &tx, nil
 }
+
+// TxInfo is the content of a signed transaction of any type
+type TxInfo struct {
+	Type    uint8
+	Hash    common.Hash
+	ChainID *big.Int
+	Nonce   uint64
+	From    common.Address
+	// To is nil for contract creations
+	To    *common.Address
+	Value *big.Int
+	Gas   uint64
+	// GasPrice is set for legacy and access list transactions, the fee caps
+	// for the others
+	GasPrice      *big.Int
+	GasTipCap     *big.Int
+	GasFeeCap     *big.Int
+	BlobGasFeeCap *big.Int
+	Data          []byte
+	AccessList    types.AccessList
+	BlobHashes    []common.Hash
+	// Blobs is the number of blobs carried by a blob transaction in its
+	// network encoding
+	Blobs    int
+	AuthList []Authorization
+	V, R, S  *big.Int
+	YParity  uint8
+}
+
+// DecodeTx decodes a signed transaction of any type, including EIP-7702
+// set-code transactions, and recovers its sender
+func DecodeTx(rawTx string) (*TxInfo, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(rawTx, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode transaction: %v", err)
+	}
+	if len(raw) > 0 && raw[0] == SetCodeTxType {
+		return decodeSetCodeTx(raw)
+	}
+
+	tx, err := DecodeEthereumRawTx(rawTx)
+	if err != nil {
+		return nil, err
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover sender: %v", err)
+	}
+	v, r, s := tx.RawSignatureValues()
+	info := &TxInfo{
+		Type:       tx.Type(),
+		Hash:       tx.Hash(),
+		Nonce:      tx.Nonce(),
+		From:       from,
+		To:         tx.To(),
+		Value:      tx.Value(),
+		Gas:        tx.Gas(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+		BlobHashes: tx.BlobHashes(),
+		V:          v,
+		R:          r,
+		S:          s,
+	}
+	if tx.Type() != types.LegacyTxType || tx.Protected() {
+		info.ChainID = tx.ChainId()
+	}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		info.GasPrice = tx.GasPrice()
+		info.YParity = legacyYParity(v, info.ChainID)
+	case types.AccessListTxType:
+		info.GasPrice = tx.GasPrice()
+		info.YParity = uint8(v.Uint64())
+	default:
+		info.GasTipCap, info.GasFeeCap = tx.GasTipCap(), tx.GasFeeCap()
+		info.YParity = uint8(v.Uint64())
+	}
+	if tx.Type() == types.BlobTxType {
+		info.BlobGasFeeCap = tx.BlobGasFeeCap()
+		if sidecar := tx.BlobTxSidecar(); sidecar != nil {
+			info.Blobs = len(sidecar.Blobs)
+		}
+	}
+	return info, nil
+}
+
+// legacyYParity returns the parity of a legacy signature, whose v is 27 or 28,
+// or chainId*2+35 or 36 with EIP-155
+func legacyYParity(v, chainID *big.Int) uint8 {
+	if chainID == nil {
+		return uint8(v.Uint64() - 27)
+	}
+	parity := new(big.Int).Sub(v, new(big.Int).Mul(chainID, big.NewInt(2)))
+	return uint8(parity.Uint64() - 35)
+}
+
+// decodeSetCodeTx decodes an EIP-7702 transaction, not yet supported by the
+// go-ethereum types
+func decodeSetCodeTx(raw []byte) (*TxInfo, error) {
+	var tx setCodeTx
+	if err := rlp.DecodeBytes(raw[1:], &tx); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction: %v", err)
+	}
+	if !tx.V.IsUint64() || tx.V.Uint64() > 1 || !crypto.ValidateSignatureValues(byte(tx.V.Uint64()), tx.R, tx.S, true) {
+		return nil, fmt.Errorf("invalid transaction signature")
+	}
+	sig := make([]byte, crypto.SignatureLength)
+	tx.R.FillBytes(sig[:32])
+	tx.S.FillBytes(sig[32:64])
+	sig[64] = byte(tx.V.Uint64())
+	pub, err := crypto.SigToPub(tx.sigHash().Bytes(), sig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover sender: %v", err)
+	}
+	to := tx.To
+	return &TxInfo{
+		Type:       SetCodeTxType,
+		Hash:       crypto.Keccak256Hash(raw),
+		ChainID:    tx.ChainID,
+		Nonce:      tx.Nonce,
+		From:       crypto.PubkeyToAddress(*pub),
+		To:         &to,
+		Value:      tx.Value,
+		Gas:        tx.Gas,
+		GasTipCap:  tx.GasTipCap,
+		GasFeeCap:  tx.GasFeeCap,
+		Data:       tx.Data,
+		AccessList: tx.AccessList,
+		AuthList:   tx.AuthList,
+		V:          tx.V,
+		R:          tx.R,
+		S:          tx.S,
+		YParity:    sig[64],
+	}, nil
+}
+
+// Selector returns the 4-byte function selector of the call data, nil for
+// contract creations and plain transfers
+func (info *TxInfo) Selector() []byte {
+	if info.To == nil || len(info.Data) < 4 {
+		return nil
+	}
+	return info.Data[:4]
+}
+
+// ContractAddress returns the address of the contract created by the
+// transaction, nil if it is not a contract creation
+func (info *TxInfo) ContractAddress() *common.Address {
+	if info.To != nil {
+		return nil
+	}
+	address := crypto.CreateAddress(info.From, info.Nonce)
+	return &address
+}
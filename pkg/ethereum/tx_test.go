@@ -7,8 +7,11 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDecodeEthereumRawTx(t *testing.T) {
@@ -123,3 +126,78 @@ func TestDecodeEthereumRawTx(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeTx(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	delegate := common.HexToAddress("0x63c0c19a282a1b52b07dd5a65b58948a07dae32b")
+	calldata := hexutil.MustDecode("0xa9059cbb000000000000000000000000123456789012345678901234567890123456789000000000000000000000000000000000000000000000000000000000000003e8")
+
+	for _, spec := range []TxSpec{
+		{Nonce: 5, Gas: 100000, GasPrice: hexOrDecimal(1e9), Data: []byte{0x60, 0x00}},
+		{ChainID: hexOrDecimal(137), To: &to, Gas: 21000, GasPrice: hexOrDecimal(1e9), Value: hexOrDecimal(42)},
+		{ChainID: hexOrDecimal(1), To: &to, Gas: 60000, GasPrice: hexOrDecimal(1e9), Data: calldata,
+			AccessList: types.AccessList{{Address: to, StorageKeys: []common.Hash{{0x01}}}}},
+		{ChainID: hexOrDecimal(1), To: &to, Gas: 60000, MaxFeePerGas: hexOrDecimal(3e10), MaxPriorityFeePerGas: hexOrDecimal(2), Data: calldata},
+		{ChainID: hexOrDecimal(1), To: &to, Gas: 21000, MaxFeePerGas: hexOrDecimal(3e10), MaxPriorityFeePerGas: hexOrDecimal(2),
+			MaxFeePerBlobGas: hexOrDecimal(7), Blobs: []hexutil.Bytes{{0x01}, {0x02}}},
+		{ChainID: hexOrDecimal(1), Nonce: 9, To: &from, Gas: 100000, MaxFeePerGas: hexOrDecimal(3e10), MaxPriorityFeePerGas: hexOrDecimal(2),
+			AuthorizationList: []AuthorizationSpec{{Address: delegate}}},
+	} {
+		signed, err := spec.Sign(key)
+		require.NoError(t, err)
+		info, err := DecodeTx(hexutil.Encode(signed.Raw))
+		require.NoError(t, err, spec.TxType())
+		require.Equal(t, signed.Hash, info.Hash)
+		require.Equal(t, from, info.From)
+		require.Equal(t, uint64(spec.Nonce), info.Nonce)
+		require.Equal(t, uint64(spec.Gas), info.Gas)
+		require.Equal(t, hexutil.Encode(spec.Data), hexutil.Encode(info.Data))
+		require.Equal(t, spec.To, info.To)
+		require.LessOrEqual(t, info.YParity, uint8(1))
+
+		switch spec.TxType() {
+		case TxTypeLegacy:
+			require.Equal(t, bigInt(spec.GasPrice), info.GasPrice)
+			if spec.ChainID == nil {
+				require.Nil(t, info.ChainID)
+				require.Equal(t, crypto.CreateAddress(from, 5), *info.ContractAddress())
+				require.Nil(t, info.Selector())
+			} else {
+				require.Equal(t, big.NewInt(137), info.ChainID)
+			}
+		case TxTypeAccessList:
+			require.Equal(t, spec.AccessList, info.AccessList)
+			require.Equal(t, calldata[:4], info.Selector())
+		case TxTypeDynamicFee:
+			require.Nil(t, info.GasPrice)
+			require.Equal(t, big.NewInt(2), info.GasTipCap)
+			require.Equal(t, big.NewInt(3e10), info.GasFeeCap)
+		case TxTypeBlob:
+			require.Len(t, info.BlobHashes, 2)
+			require.Equal(t, 2, info.Blobs)
+			require.Equal(t, big.NewInt(7), info.BlobGasFeeCap)
+		case TxTypeSetCode:
+			require.Equal(t, uint8(SetCodeTxType), info.Type)
+			require.Len(t, info.AuthList, 1)
+			require.Equal(t, uint64(10), info.AuthList[0].Nonce)
+			authority, err := info.AuthList[0].Authority()
+			require.NoError(t, err)
+			require.Equal(t, from, authority)
+		}
+	}
+
+	// typed transactions RLP encoded as in blocks
+	signed, err := (&TxSpec{ChainID: hexOrDecimal(1), To: &to, Gas: 21000, MaxFeePerGas: hexOrDecimal(1), MaxPriorityFeePerGas: hexOrDecimal(1)}).Sign(key)
+	require.NoError(t, err)
+	wrapped, err := rlp.EncodeToBytes(signed.Raw)
+	require.NoError(t, err)
+	info, err := DecodeTx(hex.EncodeToString(wrapped))
+	require.NoError(t, err)
+	require.Equal(t, signed.Hash, info.Hash)
+
+	_, err = DecodeTx("0x04c0")
+	require.Error(t, err)
+}
@@ -11,7 +11,16 @@ import (
 	"github.com/tyler-smith/go-bip39"
 )
 
+// CreateBitcoinHDNode derives the external chain m/44'/0'/0'/0 of the
+// mnemonic, with the Bitcoin coin type whatever the network
 func CreateBitcoinHDNode(mnemonic string, network *chaincfg.Params) (*hdkeychain.ExtendedKey, error) {
+	return CreateBitcoinHDNodeWithCoinType(mnemonic, network, crypto.BitcoinCoinTypePath.ToUint32())
+}
+
+// CreateBitcoinHDNodeWithCoinType derives the external chain
+// m/44'/coinType'/0'/0 of the mnemonic, e.g. with the BIP44 coin type of the
+// network given by network.HDCoinType
+func CreateBitcoinHDNodeWithCoinType(mnemonic string, network *chaincfg.Params, coinType uint32) (*hdkeychain.ExtendedKey, error) {
 
 	// validate the mnemonic
 	if !bip39.IsMnemonicValid(mnemonic) {
@@ -27,7 +36,7 @@ func CreateBitcoinHDNode(mnemonic string, network *chaincfg.Params) (*hdkeychain
 		return nil, fmt.Errorf("failed to create master key: %v", err)
 	}
 
-	// Derive the path m/44'/0'/0'/0/
+	// Derive the path m/44'/coin'/0'/0/
 	// Hardened key derivations are offset by HardenedKeyStart (2^31)
 	const HardenedOffset uint32 = hdkeychain.HardenedKeyStart
 
@@ -37,8 +46,8 @@ func CreateBitcoinHDNode(mnemonic string, network *chaincfg.Params) (*hdkeychain
 		return nil, fmt.Errorf("failed to derive purpose key: %v", err)
 	}
 
-	// Derive 0' (Coin type for Bitcoin)
-	coinTypeKey, err := purposeKey.Derive(crypto.BitcoinCoinTypePath.ToUint32() + HardenedOffset)
+	// Derive the coin type (0' for Bitcoin, 1' for the BIP44 testnet one)
+	coinTypeKey, err := purposeKey.Derive(coinType + HardenedOffset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to derive coin type key: %v", err)
 	}
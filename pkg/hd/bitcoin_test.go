@@ -0,0 +1,36 @@
+package hd
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestCreateBitcoinHDNodeCoinType(t *testing.T) {
+	tests := []struct {
+		name    string
+		network *chaincfg.Params
+		// coinType is nil for the default Bitcoin coin type
+		coinType *uint32
+		address  string
+	}{
+		// m/44'/0'/0'/0/0 on every network by default
+		{"mainnet", &chaincfg.MainNetParams, nil, "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA"},
+		{"testnet", &chaincfg.TestNet3Params, nil, "n1M8ZVQtL7QoFvGMg24D6b2ojWvFXCGpoS"},
+		// m/44'/1'/0'/0/0 with the coin type of the network
+		{"testnet coin type", &chaincfg.TestNet3Params, &chaincfg.TestNet3Params.HDCoinType, "mkpZhYtJu2r87Js3pDiWJDmPte2NRZ8bJV"},
+	}
+	for _, tt := range tests {
+		node, err := CreateBitcoinHDNode(testMnemonic, tt.network)
+		if tt.coinType != nil {
+			node, err = CreateBitcoinHDNodeWithCoinType(testMnemonic, tt.network, *tt.coinType)
+		}
+		require.NoError(t, err)
+		address, err := DeriveBitcoinAddress(node, 0, tt.network)
+		require.NoError(t, err)
+		require.Equal(t, tt.address, address.EncodeAddress(), tt.name)
+	}
+}
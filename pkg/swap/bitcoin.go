@@ -0,0 +1,232 @@
+package swap
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// LockType is the timelock of the refund branch of an HTLC
+type LockType string
+
+const (
+	// LockCLTV locks the refund until an absolute block height or time
+	LockCLTV LockType = "cltv"
+	// LockCSV locks the refund for a number of blocks after confirmation
+	LockCSV LockType = "csv"
+)
+
+// ParseLockType parses a timelock type name
+func ParseLockType(s string) (LockType, error) {
+	switch t := LockType(strings.ToLower(s)); t {
+	case LockCLTV, LockCSV:
+		return t, nil
+	}
+	return "", fmt.Errorf("unsupported lock type %s, expected cltv or csv", s)
+}
+
+// BitcoinHTLC is a P2WSH hashed timelock contract. The recipient spends it
+// with the SHA256 preimage of the hash lock, the refund key after the
+// timelock:
+//
+//	OP_IF
+//	    OP_SIZE 32 OP_EQUALVERIFY OP_SHA256 <hash> OP_EQUALVERIFY <recipient>
+//	OP_ELSE
+//	    <locktime> OP_CHECKLOCKTIMEVERIFY|OP_CHECKSEQUENCEVERIFY OP_DROP <refund>
+//	OP_ENDIF
+//	OP_CHECKSIG
+type BitcoinHTLC struct {
+	HashLock      []byte
+	RecipientKey  []byte
+	RefundKey     []byte
+	LockType      LockType
+	LockTime      uint32
+	WitnessScript []byte
+	ScriptPubKey  []byte
+	Address       string
+}
+
+// NewBitcoinHTLC builds the HTLC paying the holder of the preimage of
+// hashLock with recipient, or refund after lockTime
+func NewBitcoinHTLC(hashLock []byte, recipient, refund *btcec.PublicKey, lockType LockType, lockTime uint32, net *chaincfg.Params) (*BitcoinHTLC, error) {
+	if len(hashLock) != sha256.Size {
+		return nil, fmt.Errorf("hash lock must be %d bytes, got %d", sha256.Size, len(hashLock))
+	}
+	if lockTime == 0 {
+		return nil, fmt.Errorf("locktime must be positive")
+	}
+	lockOp := byte(txscript.OP_CHECKLOCKTIMEVERIFY)
+	switch lockType {
+	case LockCLTV:
+	case LockCSV:
+		if lockTime&wire.SequenceLockTimeDisabled != 0 {
+			return nil, fmt.Errorf("invalid relative locktime %d", lockTime)
+		}
+		lockOp = txscript.OP_CHECKSEQUENCEVERIFY
+	default:
+		return nil, fmt.Errorf("unsupported lock type %s", lockType)
+	}
+
+	script, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_IF).
+		AddOp(txscript.OP_SIZE).AddInt64(sha256.Size).AddOp(txscript.OP_EQUALVERIFY).
+		AddOp(txscript.OP_SHA256).AddData(hashLock).AddOp(txscript.OP_EQUALVERIFY).
+		AddData(recipient.SerializeCompressed()).
+		AddOp(txscript.OP_ELSE).
+		AddInt64(int64(lockTime)).AddOp(lockOp).AddOp(txscript.OP_DROP).
+		AddData(refund.SerializeCompressed()).
+		AddOp(txscript.OP_ENDIF).
+		AddOp(txscript.OP_CHECKSIG).
+		Script()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build htlc script: %v", err)
+	}
+	return ParseBitcoinHTLC(script, net)
+}
+
+// ParseBitcoinHTLC parses an HTLC witness script built by NewBitcoinHTLC
+func ParseBitcoinHTLC(witnessScript []byte, net *chaincfg.Params) (*BitcoinHTLC, error) {
+	var ops []byte
+	var data [][]byte
+	tokenizer := txscript.MakeScriptTokenizer(0, witnessScript)
+	for tokenizer.Next() {
+		ops = append(ops, tokenizer.Opcode())
+		data = append(data, tokenizer.Data())
+	}
+	if err := tokenizer.Err(); err != nil {
+		return nil, fmt.Errorf("invalid script: %v", err)
+	}
+
+	notHTLC := fmt.Errorf("not an htlc script")
+	if len(ops) != 15 || ops[14] != txscript.OP_CHECKSIG ||
+		ops[0] != txscript.OP_IF || ops[1] != txscript.OP_SIZE ||
+		ops[3] != txscript.OP_EQUALVERIFY || ops[4] != txscript.OP_SHA256 ||
+		ops[6] != txscript.OP_EQUALVERIFY || ops[8] != txscript.OP_ELSE ||
+		ops[11] != txscript.OP_DROP || ops[13] != txscript.OP_ENDIF {
+		return nil, notHTLC
+	}
+	if size, ok := scriptNum(ops[2], data[2]); !ok || size != sha256.Size || len(data[5]) != sha256.Size {
+		return nil, notHTLC
+	}
+
+	htlc := &BitcoinHTLC{HashLock: data[5], RecipientKey: data[7], RefundKey: data[12], WitnessScript: witnessScript}
+	switch ops[10] {
+	case txscript.OP_CHECKLOCKTIMEVERIFY:
+		htlc.LockType = LockCLTV
+	case txscript.OP_CHECKSEQUENCEVERIFY:
+		htlc.LockType = LockCSV
+	default:
+		return nil, notHTLC
+	}
+	lockTime, ok := scriptNum(ops[9], data[9])
+	if !ok || lockTime <= 0 || lockTime > math.MaxUint32 {
+		return nil, fmt.Errorf("invalid htlc locktime")
+	}
+	htlc.LockTime = uint32(lockTime)
+	for _, key := range [][]byte{htlc.RecipientKey, htlc.RefundKey} {
+		if _, err := btcec.ParsePubKey(key); err != nil {
+			return nil, fmt.Errorf("invalid htlc public key: %v", err)
+		}
+	}
+
+	scriptHash := sha256.Sum256(witnessScript)
+	addr, err := btcutil.NewAddressWitnessScriptHash(scriptHash[:], net)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create address: %v", err)
+	}
+	htlc.Address = addr.EncodeAddress()
+	if htlc.ScriptPubKey, err = txscript.PayToAddrScript(addr); err != nil {
+		return nil, fmt.Errorf("failed to create script pubkey: %v", err)
+	}
+	return htlc, nil
+}
+
+// scriptNum decodes a minimally encoded script number of up to 5 bytes
+func scriptNum(opcode byte, data []byte) (int64, bool) {
+	switch {
+	case opcode >= txscript.OP_1 && opcode <= txscript.OP_16:
+		return int64(opcode - txscript.OP_1 + 1), true
+	case opcode == txscript.OP_0 || len(data) == 0 || len(data) > 5:
+		return 0, false
+	}
+	var n int64
+	for i, b := range data {
+		n |= int64(b) << (8 * i)
+	}
+	if data[len(data)-1]&0x80 != 0 {
+		n &^= int64(0x80) << (8 * (len(data) - 1))
+		n = -n
+	}
+	return n, true
+}
+
+// ClaimTx builds and signs the transaction spending the HTLC output at
+// outPoint to dest with the preimage of the hash lock, paying feeRate sat/vB
+func (h *BitcoinHTLC) ClaimTx(outPoint wire.OutPoint, value int64, preimage []byte, dest []byte, feeRate float64, key *btcec.PrivateKey) (*wire.MsgTx, error) {
+	if hash := sha256.Sum256(preimage); !bytes.Equal(hash[:], h.HashLock) {
+		return nil, fmt.Errorf("the preimage does not match the hash lock")
+	}
+	if !bytes.Equal(key.PubKey().SerializeCompressed(), h.RecipientKey) {
+		return nil, fmt.Errorf("the key is not the recipient key of the htlc")
+	}
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+	return h.spend(tx, value, [][]byte{preimage, {1}}, dest, feeRate, key)
+}
+
+// RefundTx builds and signs the transaction spending the HTLC output at
+// outPoint back to dest once the timelock expired, paying feeRate sat/vB
+func (h *BitcoinHTLC) RefundTx(outPoint wire.OutPoint, value int64, dest []byte, feeRate float64, key *btcec.PrivateKey) (*wire.MsgTx, error) {
+	if !bytes.Equal(key.PubKey().SerializeCompressed(), h.RefundKey) {
+		return nil, fmt.Errorf("the key is not the refund key of the htlc")
+	}
+	tx := wire.NewMsgTx(2)
+	txIn := wire.NewTxIn(&outPoint, nil, nil)
+	if h.LockType == LockCLTV {
+		// a final sequence would disable the locktime
+		txIn.Sequence = wire.MaxTxInSequenceNum - 1
+		tx.LockTime = h.LockTime
+	} else {
+		txIn.Sequence = h.LockTime
+	}
+	tx.AddTxIn(txIn)
+	return h.spend(tx, value, [][]byte{nil}, dest, feeRate, key)
+}
+
+// spend completes tx with the output to dest and the witness
+// <signature> <args...> <witness script>
+func (h *BitcoinHTLC) spend(tx *wire.MsgTx, value int64, args [][]byte, dest []byte, feeRate float64, key *btcec.PrivateKey) (*wire.MsgTx, error) {
+	if feeRate <= 0 {
+		return nil, fmt.Errorf("fee rate must be positive")
+	}
+	tx.AddTxOut(wire.NewTxOut(0, dest))
+
+	// size the transaction with the largest DER signature
+	witness := wire.TxWitness{make([]byte, 73)}
+	witness = append(witness, args...)
+	tx.TxIn[0].Witness = append(witness, h.WitnessScript)
+	fee := int64(math.Ceil(float64(bitcoin.TxVSize(tx)) * feeRate))
+	if dust := bitcoin.DustThreshold(dest); value-fee < dust {
+		return nil, fmt.Errorf("the htlc value of %d sats minus the %d sats fee is below the %d sats dust threshold",
+			value, fee, dust)
+	}
+	tx.TxOut[0].Value = value - fee
+
+	fetcher := txscript.NewCannedPrevOutputFetcher(h.ScriptPubKey, value)
+	sig, err := txscript.RawTxInWitnessSignature(tx, txscript.NewTxSigHashes(tx, fetcher), 0, value,
+		h.WitnessScript, txscript.SigHashAll, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign htlc input: %v", err)
+	}
+	tx.TxIn[0].Witness[0] = sig
+	return tx, nil
+}
@@ -0,0 +1,95 @@
+package swap
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHTLC(t *testing.T, lockType LockType, lockTime uint32) (*BitcoinHTLC, []byte, *btcec.PrivateKey, *btcec.PrivateKey) {
+	recipient, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	refund, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	secret, hashLock, err := NewSecret()
+	require.NoError(t, err)
+
+	htlc, err := NewBitcoinHTLC(hashLock, recipient.PubKey(), refund.PubKey(), lockType, lockTime, &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	return htlc, secret, recipient, refund
+}
+
+// executeHTLCSpend runs the script engine on the only input of tx
+func executeHTLCSpend(t *testing.T, htlc *BitcoinHTLC, tx *wire.MsgTx, value int64) error {
+	fetcher := txscript.NewCannedPrevOutputFetcher(htlc.ScriptPubKey, value)
+	vm, err := txscript.NewEngine(htlc.ScriptPubKey, tx, 0, txscript.StandardVerifyFlags, nil,
+		txscript.NewTxSigHashes(tx, fetcher), value, fetcher)
+	require.NoError(t, err)
+	return vm.Execute()
+}
+
+func TestBitcoinHTLCParse(t *testing.T) {
+	for _, lockType := range []LockType{LockCLTV, LockCSV} {
+		for _, lockTime := range []uint32{10, 144, 800000, 1700000000} {
+			htlc, _, _, _ := newTestHTLC(t, lockType, lockTime)
+			parsed, err := ParseBitcoinHTLC(htlc.WitnessScript, &chaincfg.RegressionNetParams)
+			require.NoError(t, err)
+			require.Equal(t, htlc, parsed)
+			require.Equal(t, lockTime, parsed.LockTime)
+			require.Equal(t, lockType, parsed.LockType)
+			require.Contains(t, parsed.Address, "bcrt1q")
+		}
+	}
+
+	_, err := ParseBitcoinHTLC([]byte{txscript.OP_TRUE}, &chaincfg.RegressionNetParams)
+	require.Error(t, err)
+}
+
+func TestBitcoinHTLCClaim(t *testing.T) {
+	htlc, secret, recipient, refund := newTestHTLC(t, LockCLTV, 800000)
+	outPoint := wire.OutPoint{Hash: chainhash.Hash{1}, Index: 0}
+	dest := htlc.ScriptPubKey
+
+	tx, err := htlc.ClaimTx(outPoint, 100000, secret, dest, 5, recipient)
+	require.NoError(t, err)
+	require.NoError(t, executeHTLCSpend(t, htlc, tx, 100000))
+	require.Less(t, tx.TxOut[0].Value, int64(100000))
+
+	// the fee leaves an output above zero but below the dust threshold
+	fee := 100000 - tx.TxOut[0].Value
+	_, err = htlc.ClaimTx(outPoint, fee+100, secret, dest, 5, recipient)
+	require.ErrorContains(t, err, "dust")
+
+	_, err = htlc.ClaimTx(outPoint, 100000, make([]byte, 32), dest, 5, recipient)
+	require.ErrorContains(t, err, "preimage")
+	_, err = htlc.ClaimTx(outPoint, 100000, secret, dest, 5, refund)
+	require.ErrorContains(t, err, "recipient key")
+}
+
+func TestBitcoinHTLCRefund(t *testing.T) {
+	outPoint := wire.OutPoint{Hash: chainhash.Hash{1}, Index: 0}
+
+	htlc, _, recipient, refund := newTestHTLC(t, LockCLTV, 800000)
+	tx, err := htlc.RefundTx(outPoint, 100000, htlc.ScriptPubKey, 5, refund)
+	require.NoError(t, err)
+	require.Equal(t, uint32(800000), tx.LockTime)
+	require.NoError(t, executeHTLCSpend(t, htlc, tx, 100000))
+
+	// an earlier locktime fails the CHECKLOCKTIMEVERIFY
+	tx.LockTime = 799999
+	require.Error(t, executeHTLCSpend(t, htlc, tx, 100000))
+
+	htlc, _, _, refund = newTestHTLC(t, LockCSV, 144)
+	tx, err = htlc.RefundTx(outPoint, 100000, htlc.ScriptPubKey, 5, refund)
+	require.NoError(t, err)
+	require.Equal(t, uint32(144), tx.TxIn[0].Sequence)
+	require.NoError(t, executeHTLCSpend(t, htlc, tx, 100000))
+
+	_, err = htlc.RefundTx(outPoint, 100000, htlc.ScriptPubKey, 5, recipient)
+	require.ErrorContains(t, err, "refund key")
+}
@@ -0,0 +1,92 @@
+package swap
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// htlcABI is the interface of the HashedTimelock contract, the reference
+// Ethereum HTLC: ether locked with newContract is released to the receiver
+// by withdraw with the SHA256 preimage of the hash lock, or back to the
+// sender by refund once the timelock (a unix time) passed.
+const htlcABI = `[
+	{"type": "function", "name": "newContract", "stateMutability": "payable",
+	 "inputs": [{"name": "_receiver", "type": "address"}, {"name": "_hashlock", "type": "bytes32"}, {"name": "_timelock", "type": "uint256"}],
+	 "outputs": [{"name": "contractId", "type": "bytes32"}]},
+	{"type": "function", "name": "withdraw", "stateMutability": "nonpayable",
+	 "inputs": [{"name": "_contractId", "type": "bytes32"}, {"name": "_preimage", "type": "bytes32"}],
+	 "outputs": [{"name": "", "type": "bool"}]},
+	{"type": "function", "name": "refund", "stateMutability": "nonpayable",
+	 "inputs": [{"name": "_contractId", "type": "bytes32"}],
+	 "outputs": [{"name": "", "type": "bool"}]}
+]`
+
+var htlcContract = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(htlcABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// EthereumHTLC is a swap locked in the HashedTimelock contract
+type EthereumHTLC struct {
+	Sender   common.Address
+	Receiver common.Address
+	// Amount is the locked value in wei
+	Amount   *big.Int
+	HashLock [32]byte
+	// TimeLock is the unix time after which the sender can refund
+	TimeLock *big.Int
+}
+
+// NewContractCalldata returns the calldata of the newContract call locking
+// the swap, sent by Sender with Amount as value
+func (h *EthereumHTLC) NewContractCalldata() ([]byte, error) {
+	data, err := htlcContract.Pack("newContract", h.Receiver, h.HashLock, h.TimeLock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode newContract call: %v", err)
+	}
+	return data, nil
+}
+
+// ContractID returns the identifier the contract assigns to the swap:
+// sha256(abi.encodePacked(sender, receiver, amount, hashlock, timelock))
+func (h *EthereumHTLC) ContractID() [32]byte {
+	var packed []byte
+	packed = append(packed, h.Sender.Bytes()...)
+	packed = append(packed, h.Receiver.Bytes()...)
+	packed = append(packed, math.U256Bytes(new(big.Int).Set(h.Amount))...)
+	packed = append(packed, h.HashLock[:]...)
+	packed = append(packed, math.U256Bytes(new(big.Int).Set(h.TimeLock))...)
+	return sha256.Sum256(packed)
+}
+
+// WithdrawCalldata returns the calldata of the withdraw call claiming the
+// swap contractID with the preimage of its hash lock
+func WithdrawCalldata(contractID [32]byte, preimage []byte) ([]byte, error) {
+	if len(preimage) != SecretSize {
+		return nil, fmt.Errorf("preimage must be %d bytes, got %d", SecretSize, len(preimage))
+	}
+	data, err := htlcContract.Pack("withdraw", contractID, [32]byte(preimage))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode withdraw call: %v", err)
+	}
+	return data, nil
+}
+
+// RefundCalldata returns the calldata of the refund call of the swap
+// contractID
+func RefundCalldata(contractID [32]byte) ([]byte, error) {
+	data, err := htlcContract.Pack("refund", contractID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode refund call: %v", err)
+	}
+	return data, nil
+}
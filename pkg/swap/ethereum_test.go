@@ -0,0 +1,54 @@
+package swap
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEthereumHTLC(t *testing.T) {
+	secret, hashLock, err := NewSecret()
+	require.NoError(t, err)
+	require.Equal(t, hashLock, HashSecret(secret))
+
+	htlc := &EthereumHTLC{
+		Sender:   common.HexToAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"),
+		Receiver: common.HexToAddress("0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"),
+		Amount:   big.NewInt(1e18),
+		HashLock: [32]byte(hashLock),
+		TimeLock: big.NewInt(1700000000),
+	}
+
+	data, err := htlc.NewContractCalldata()
+	require.NoError(t, err)
+	require.Equal(t, crypto.Keccak256([]byte("newContract(address,bytes32,uint256)"))[:4], data[:4])
+	require.Len(t, data, 4+3*32)
+	require.Equal(t, htlc.Receiver.Bytes(), data[4+12:4+32])
+	require.Equal(t, hashLock, data[4+32:4+64])
+
+	var packed []byte
+	packed = append(packed, htlc.Sender.Bytes()...)
+	packed = append(packed, htlc.Receiver.Bytes()...)
+	packed = append(packed, common.LeftPadBytes(htlc.Amount.Bytes(), 32)...)
+	packed = append(packed, hashLock...)
+	packed = append(packed, common.LeftPadBytes(htlc.TimeLock.Bytes(), 32)...)
+	require.Equal(t, sha256.Sum256(packed), htlc.ContractID())
+
+	contractID := htlc.ContractID()
+	data, err = WithdrawCalldata(contractID, secret)
+	require.NoError(t, err)
+	require.Equal(t, crypto.Keccak256([]byte("withdraw(bytes32,bytes32)"))[:4], data[:4])
+	require.Equal(t, secret, data[4+32:])
+
+	data, err = RefundCalldata(contractID)
+	require.NoError(t, err)
+	require.Equal(t, crypto.Keccak256([]byte("refund(bytes32)"))[:4], data[:4])
+	require.Equal(t, contractID[:], data[4:])
+
+	_, err = WithdrawCalldata(contractID, secret[:31])
+	require.Error(t, err)
+}
@@ -0,0 +1,27 @@
+package swap
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+)
+
+// SecretSize is the size of swap preimages. Both the Bitcoin script and the
+// Ethereum contract hash the preimage with SHA256, so a single secret
+// unlocks both legs of a swap.
+const SecretSize = 32
+
+// NewSecret returns a random preimage and its SHA256 hash lock
+func NewSecret() (secret, hashLock []byte, err error) {
+	secret = make([]byte, SecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate secret: %v", err)
+	}
+	return secret, HashSecret(secret), nil
+}
+
+// HashSecret returns the hash lock of a preimage
+func HashSecret(secret []byte) []byte {
+	hash := sha256.Sum256(secret)
+	return hash[:]
+}
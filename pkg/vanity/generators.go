@@ -0,0 +1,285 @@
+package vanity
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/alejoacosta74/cryptonaut/pkg/alphabet"
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// BitcoinGenerator generates bitcoin addresses of one type and network
+type BitcoinGenerator struct {
+	Type bitcoin.AddressType
+	Net  *chaincfg.Params
+}
+
+// Compile implements Generator
+func (g BitcoinGenerator) Compile(p Pattern) (Pattern, float64, error) {
+	switch g.Type {
+	case bitcoin.AddressTypeP2PKH:
+		return base58Compile(p, g.Net.PubKeyHashAddrID)
+	case bitcoin.AddressTypeP2SHP2WPKH:
+		return base58Compile(p, g.Net.ScriptHashAddrID)
+	case bitcoin.AddressTypeP2WPKH, bitcoin.AddressTypeP2TR:
+		if g.Net.Bech32HRPSegwit == "" {
+			return p, 0, fmt.Errorf("%s addresses are not supported on %s, which has no segwit", g.Type, g.Net.Name)
+		}
+		version := "q"
+		if g.Type == bitcoin.AddressTypeP2TR {
+			version = "p"
+		}
+		return bech32Compile(p, g.Net.Bech32HRPSegwit+"1"+version)
+	}
+	return p, 0, fmt.Errorf("unsupported address type %s", g.Type)
+}
+
+// NewWorker implements Generator
+func (g BitcoinGenerator) NewWorker() (Worker, error) {
+	walker, err := newKeyWalker()
+	if err != nil {
+		return nil, err
+	}
+	w := &bitcoinWorker{keyWalker: walker, gen: g}
+	if _, err := bitcoin.AddressFromPublicKey(walker.pubKey(), g.Type, g.Net); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+type bitcoinWorker struct {
+	*keyWalker
+	gen BitcoinGenerator
+}
+
+func (w *bitcoinWorker) Next() string {
+	// the address type was checked by NewWorker
+	addr, _ := bitcoin.AddressFromPublicKey(w.next(), w.gen.Type, w.gen.Net)
+	return addr.EncodeAddress()
+}
+
+// EthereumGenerator generates Ethereum account addresses
+type EthereumGenerator struct{}
+
+// Compile implements Generator
+func (EthereumGenerator) Compile(p Pattern) (Pattern, float64, error) {
+	return ethereumCompile(p)
+}
+
+// NewWorker implements Generator
+func (EthereumGenerator) NewWorker() (Worker, error) {
+	walker, err := newKeyWalker()
+	if err != nil {
+		return nil, err
+	}
+	return &ethereumWorker{walker}, nil
+}
+
+type ethereumWorker struct {
+	*keyWalker
+}
+
+func (w *ethereumWorker) Next() string {
+	pub := w.next().SerializeUncompressed()
+	return common.BytesToAddress(crypto.Keccak256(pub[1:])[12:]).Hex()
+}
+
+// Create2Generator generates the addresses of a contract deployed with
+// CREATE2 by Deployer, searching the salt
+type Create2Generator struct {
+	Deployer     common.Address
+	InitCodeHash common.Hash
+}
+
+// Compile implements Generator
+func (Create2Generator) Compile(p Pattern) (Pattern, float64, error) {
+	return ethereumCompile(p)
+}
+
+// NewWorker implements Generator
+func (g Create2Generator) NewWorker() (Worker, error) {
+	w := &create2Worker{gen: g}
+	if _, err := rand.Read(w.salt[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %v", err)
+	}
+	return w, nil
+}
+
+type create2Worker struct {
+	gen  Create2Generator
+	salt [32]byte
+}
+
+func (w *create2Worker) Next() string {
+	increment(&w.salt)
+	return crypto.CreateAddress2(w.gen.Deployer, w.salt, w.gen.InitCodeHash[:]).Hex()
+}
+
+func (w *create2Worker) Key() []byte {
+	return bytes.Clone(w.salt[:])
+}
+
+// CosmosGenerator generates the bech32 account addresses of ed25519 keys,
+// as the cosmos commands do
+type CosmosGenerator struct {
+	Prefix string
+}
+
+// Compile implements Generator
+func (g CosmosGenerator) Compile(p Pattern) (Pattern, float64, error) {
+	return bech32Compile(p, g.Prefix+"1")
+}
+
+// NewWorker implements Generator
+func (g CosmosGenerator) NewWorker() (Worker, error) {
+	if g.Prefix == "" || strings.ToLower(g.Prefix) != g.Prefix {
+		return nil, fmt.Errorf("invalid address prefix %q", g.Prefix)
+	}
+	w := &cosmosWorker{prefix: g.Prefix}
+	if _, err := rand.Read(w.seed[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate seed: %v", err)
+	}
+	return w, nil
+}
+
+type cosmosWorker struct {
+	prefix string
+	seed   [32]byte
+	key    ed25519.PrivateKey
+}
+
+func (w *cosmosWorker) Next() string {
+	// the seed is hashed into the key, consecutive seeds give unrelated keys
+	increment(&w.seed)
+	w.key = ed25519.NewKeyFromSeed(w.seed[:])
+	hash := sha256.Sum256(w.key[ed25519.SeedSize:])
+	data, _ := bech32.ConvertBits(hash[:20], 8, 5, true)
+	address, _ := bech32.Encode(w.prefix, data)
+	return address
+}
+
+// Key returns the 64-byte ed25519 private key in the format of the cosmos
+// commands
+func (w *cosmosWorker) Key() []byte {
+	return bytes.Clone(w.key)
+}
+
+// keyWalker walks secp256k1 keys from a random start, adding the generator
+// to the public key instead of multiplying it for every candidate
+type keyWalker struct {
+	key   btcec.ModNScalar
+	point btcec.JacobianPoint
+	g     btcec.JacobianPoint
+}
+
+func newKeyWalker() (*keyWalker, error) {
+	privKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate private key: %v", err)
+	}
+	w := &keyWalker{key: privKey.Key}
+	privKey.PubKey().AsJacobian(&w.point)
+	btcec.GeneratorJacobian(&w.g)
+	return w, nil
+}
+
+// pubKey returns the public key of the current key
+func (w *keyWalker) pubKey() *btcec.PublicKey {
+	return btcec.NewPublicKey(&w.point.X, &w.point.Y)
+}
+
+// next moves to the following key and returns its public key
+func (w *keyWalker) next() *btcec.PublicKey {
+	var one btcec.ModNScalar
+	one.SetInt(1)
+	w.key.Add(&one)
+	btcec.AddNonConst(&w.point, &w.g, &w.point)
+	w.point.ToAffine()
+	return w.pubKey()
+}
+
+func (w *keyWalker) Key() []byte {
+	key := w.key.Bytes()
+	return key[:]
+}
+
+// increment adds one to the last 8 bytes of b
+func increment(b *[32]byte) {
+	binary.BigEndian.PutUint64(b[24:], binary.BigEndian.Uint64(b[24:])+1)
+}
+
+// base58Compile checks a pattern for Base58Check addresses of a version
+// byte, whose first character is limited to a range of the alphabet
+func base58Compile(p Pattern, version byte) (Pattern, float64, error) {
+	lo := strings.IndexByte(alphabet.Base58, base58.CheckEncode(make([]byte, 20), version)[0])
+	hi := strings.IndexByte(alphabet.Base58, base58.CheckEncode(bytes.Repeat([]byte{0xff}, 20), version)[0])
+	first := alphabet.Base58[lo : hi+1]
+
+	difficulty := 1.0
+	if p.Prefix != "" {
+		d, err := charsDifficulty(p.Prefix[:1], first, p.IgnoreCase)
+		if err != nil {
+			return p, 0, fmt.Errorf("these addresses start with one of %q", first)
+		}
+		if difficulty, err = charsDifficulty(p.Prefix[1:], alphabet.Base58, p.IgnoreCase); err != nil {
+			return p, 0, fmt.Errorf("invalid prefix: %v", err)
+		}
+		difficulty *= d
+	}
+	d, err := charsDifficulty(p.Suffix, alphabet.Base58, p.IgnoreCase)
+	if err != nil {
+		return p, 0, fmt.Errorf("invalid suffix: %v", err)
+	}
+	return p, difficulty * d, nil
+}
+
+// bech32Compile checks a pattern for bech32 addresses starting with head.
+// Bech32 strings are case insensitive, the pattern is matched in lower case.
+func bech32Compile(p Pattern, head string) (Pattern, float64, error) {
+	p.Prefix, p.Suffix = strings.ToLower(p.Prefix), strings.ToLower(p.Suffix)
+	n := min(len(p.Prefix), len(head))
+	if p.Prefix[:n] != head[:n] {
+		return p, 0, fmt.Errorf("these addresses start with %s", head)
+	}
+	difficulty, err := charsDifficulty(p.Prefix[n:], alphabet.Bech32, false)
+	if err != nil {
+		return p, 0, fmt.Errorf("invalid prefix: %v", err)
+	}
+	d, err := charsDifficulty(p.Suffix, alphabet.Bech32, false)
+	if err != nil {
+		return p, 0, fmt.Errorf("invalid suffix: %v", err)
+	}
+	return p, difficulty * d, nil
+}
+
+// ethereumCompile checks a pattern for hex addresses, prefixed with 0x if
+// needed. Unless the case is ignored, each letter also has to match the case
+// of the EIP-55 checksum, which halves its chance.
+func ethereumCompile(p Pattern) (Pattern, float64, error) {
+	if p.Prefix != "" {
+		p.Prefix = "0x" + strings.TrimPrefix(strings.TrimPrefix(p.Prefix, "0x"), "0X")
+	}
+	difficulty := 1.0
+	for _, c := range p.Prefix[min(len(p.Prefix), 2):] + p.Suffix {
+		switch {
+		case c >= '0' && c <= '9', p.IgnoreCase && strings.ContainsRune("abcdefABCDEF", c):
+			difficulty *= 16
+		case strings.ContainsRune("abcdefABCDEF", c):
+			difficulty *= 32
+		default:
+			return p, 0, fmt.Errorf("character %q is not hexadecimal", c)
+		}
+	}
+	return p, difficulty, nil
+}
@@ -0,0 +1,241 @@
+package vanity
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// attemptBatch is the number of candidates a worker tries between reports
+const attemptBatch = 1 << 8
+
+// Pattern describes the wanted addresses. Prefix and suffix are matched
+// against the whole address, including the fixed part of its format (the
+// "1" of P2PKH, "bc1q" of P2WPKH, "0x" of Ethereum or the HRP of Cosmos).
+type Pattern struct {
+	Prefix string
+	Suffix string
+	Regex  string
+	// IgnoreCase matches regardless of case. Otherwise Ethereum addresses are
+	// matched in their EIP-55 checksummed form.
+	IgnoreCase bool
+}
+
+// Generator derives the candidate addresses of an address format
+type Generator interface {
+	// Compile validates the prefix and suffix of p against the address
+	// format and returns the pattern to match addresses with and the
+	// expected number of attempts to match its prefix and suffix
+	Compile(p Pattern) (Pattern, float64, error)
+	// NewWorker returns the candidate source of one search goroutine
+	NewWorker() (Worker, error)
+}
+
+// Worker walks through candidate addresses
+type Worker interface {
+	// Next moves to the next candidate and returns its address
+	Next() string
+	// Key returns the private key, or the salt, of the current candidate
+	Key() []byte
+}
+
+// Matcher matches addresses against a compiled pattern
+type Matcher struct {
+	prefix string
+	suffix string
+	re     *regexp.Regexp
+	fold   bool
+	// Difficulty is the expected number of attempts to match the prefix and
+	// suffix, a lower bound when a regular expression is also given
+	Difficulty float64
+}
+
+// Compile checks p against the address format of gen
+func Compile(gen Generator, p Pattern) (*Matcher, error) {
+	if p.Prefix == "" && p.Suffix == "" && p.Regex == "" {
+		return nil, fmt.Errorf("a prefix, suffix or regular expression is required")
+	}
+	p, difficulty, err := gen.Compile(p)
+	if err != nil {
+		return nil, err
+	}
+	m := &Matcher{prefix: p.Prefix, suffix: p.Suffix, fold: p.IgnoreCase, Difficulty: difficulty}
+	if m.fold {
+		m.prefix, m.suffix = strings.ToLower(m.prefix), strings.ToLower(m.suffix)
+	}
+	if p.Regex != "" {
+		expr := p.Regex
+		if p.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+		if m.re, err = regexp.Compile(expr); err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %v", err)
+		}
+	}
+	return m, nil
+}
+
+// Match reports whether address matches the pattern
+func (m *Matcher) Match(address string) bool {
+	if m.re != nil && !m.re.MatchString(address) {
+		return false
+	}
+	if m.fold {
+		address = strings.ToLower(address)
+	}
+	return strings.HasPrefix(address, m.prefix) && strings.HasSuffix(address, m.suffix)
+}
+
+// Result is a matching address and its key
+type Result struct {
+	Address string
+	// Key is the private key, or the CREATE2 salt, of the address
+	Key      []byte
+	Attempts uint64
+	Elapsed  time.Duration
+}
+
+// Rate returns the attempts per second it took to find the result
+func (r *Result) Rate() float64 {
+	return rate(r.Attempts, r.Elapsed)
+}
+
+// Searcher looks for matching addresses across worker goroutines
+type Searcher struct {
+	workers  int
+	attempts atomic.Uint64
+	// start is the unix time in nanoseconds the search began
+	start atomic.Int64
+}
+
+// NewSearcher creates a searcher running workers goroutines
+func NewSearcher(workers int) *Searcher {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Searcher{workers: workers}
+}
+
+// Search generates candidates of gen until one matches m or ctx is done
+func (s *Searcher) Search(ctx context.Context, gen Generator, m *Matcher) (*Result, error) {
+	workers := make([]Worker, s.workers)
+	for i := range workers {
+		var err error
+		if workers[i], err = gen.NewWorker(); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s.attempts.Store(0)
+	start := time.Now()
+	s.start.Store(start.UnixNano())
+	results := make(chan Result, 1)
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func(w Worker) {
+			defer wg.Done()
+			s.work(ctx, w, m, results)
+		}(w)
+	}
+
+	select {
+	case result := <-results:
+		cancel()
+		wg.Wait()
+		result.Attempts = s.attempts.Load()
+		result.Elapsed = time.Since(start)
+		return &result, nil
+	case <-ctx.Done():
+		wg.Wait()
+		return nil, ctx.Err()
+	}
+}
+
+// Attempts returns the number of candidates tried by the current or last
+// search
+func (s *Searcher) Attempts() uint64 {
+	return s.attempts.Load()
+}
+
+// Rate returns the attempts per second of the current or last search
+func (s *Searcher) Rate() float64 {
+	start := s.start.Load()
+	if start == 0 {
+		return 0
+	}
+	return rate(s.attempts.Load(), time.Since(time.Unix(0, start)))
+}
+
+func (s *Searcher) work(ctx context.Context, w Worker, m *Matcher, results chan<- Result) {
+	var count uint64
+	defer func() { s.attempts.Add(count % attemptBatch) }()
+	for {
+		address := w.Next()
+		count++
+		if m.Match(address) {
+			select {
+			case results <- Result{Address: address, Key: w.Key()}:
+			default:
+			}
+			return
+		}
+		if count%attemptBatch == 0 {
+			s.attempts.Add(attemptBatch)
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}
+}
+
+// Probability returns the probability of a match within attempts tries of
+// a pattern of the given difficulty
+func Probability(difficulty float64, attempts uint64) float64 {
+	if difficulty <= 1 {
+		return 1
+	}
+	return -math.Expm1(float64(attempts) * math.Log1p(-1/difficulty))
+}
+
+// AttemptsFor returns the number of attempts matching a pattern of the
+// given difficulty with probability p
+func AttemptsFor(difficulty, p float64) float64 {
+	if difficulty <= 1 {
+		return 1
+	}
+	return math.Log1p(-p) / math.Log1p(-1/difficulty)
+}
+
+func rate(attempts uint64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(attempts) / elapsed.Seconds()
+}
+
+// charsDifficulty returns the attempts to match the characters of s drawn
+// uniformly from alphabet, one case of a letter sufficing when fold is set
+func charsDifficulty(s, alphabet string, fold bool) (float64, error) {
+	difficulty := 1.0
+	for _, c := range s {
+		matches := 0
+		for _, a := range alphabet {
+			if a == c || fold && strings.EqualFold(string(a), string(c)) {
+				matches++
+			}
+		}
+		if matches == 0 {
+			return 0, fmt.Errorf("character %q never appears in these addresses", c)
+		}
+		difficulty *= float64(len(alphabet)) / float64(matches)
+	}
+	return difficulty, nil
+}
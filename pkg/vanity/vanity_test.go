@@ -0,0 +1,146 @@
+package vanity
+
+import (
+	"context"
+	"encoding/hex"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alejoacosta74/cryptonaut/pkg/bitcoin"
+	"github.com/alejoacosta74/cryptonaut/pkg/cosmos"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func search(t *testing.T, gen Generator, p Pattern) *Result {
+	m, err := Compile(gen, p)
+	require.NoError(t, err)
+	result, err := NewSearcher(4).Search(context.Background(), gen, m)
+	require.NoError(t, err)
+	require.True(t, m.Match(result.Address))
+	require.NotZero(t, result.Attempts)
+	return result
+}
+
+func TestKeyWalker(t *testing.T) {
+	w, err := newKeyWalker()
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		pub := w.next()
+		privKey, _ := btcec.PrivKeyFromBytes(w.Key())
+		require.True(t, privKey.PubKey().IsEqual(pub))
+	}
+}
+
+func TestSearchBitcoin(t *testing.T) {
+	net := &chaincfg.MainNetParams
+	for _, tc := range []struct {
+		addrType bitcoin.AddressType
+		pattern  Pattern
+	}{
+		{bitcoin.AddressTypeP2WPKH, Pattern{Prefix: "bc1qa"}},
+		{bitcoin.AddressTypeP2TR, Pattern{Suffix: "Q", IgnoreCase: true}},
+		{bitcoin.AddressTypeP2PKH, Pattern{Prefix: "1a", IgnoreCase: true}},
+		{bitcoin.AddressTypeP2SHP2WPKH, Pattern{Regex: "^3.*z$"}},
+	} {
+		gen := BitcoinGenerator{Type: tc.addrType, Net: net}
+		result := search(t, gen, tc.pattern)
+		privKey, _ := btcec.PrivKeyFromBytes(result.Key)
+		addr, err := bitcoin.AddressFromPublicKey(privKey.PubKey(), tc.addrType, net)
+		require.NoError(t, err)
+		require.Equal(t, addr.EncodeAddress(), result.Address)
+	}
+}
+
+func TestSearchEthereum(t *testing.T) {
+	// EIP-55: a case sensitive letter must also match the checksum case
+	result := search(t, EthereumGenerator{}, Pattern{Prefix: "B"})
+	require.True(t, strings.HasPrefix(result.Address, "0xB"))
+	privKey, err := crypto.ToECDSA(result.Key)
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(privKey.PublicKey).Hex(), result.Address)
+
+	result = search(t, EthereumGenerator{}, Pattern{Prefix: "0xab", IgnoreCase: true})
+	require.True(t, strings.HasPrefix(strings.ToLower(result.Address), "0xab"))
+}
+
+func TestSearchCreate2(t *testing.T) {
+	gen := Create2Generator{
+		Deployer:     common.HexToAddress("0x4e59b44847b379578588920ca78fbf26c0b4956c"),
+		InitCodeHash: crypto.Keccak256Hash([]byte{0x00}),
+	}
+	result := search(t, gen, Pattern{Suffix: "00"})
+	require.Len(t, result.Key, 32)
+	require.Equal(t, crypto.CreateAddress2(gen.Deployer, [32]byte(result.Key), gen.InitCodeHash[:]).Hex(), result.Address)
+}
+
+func TestSearchCosmos(t *testing.T) {
+	result := search(t, CosmosGenerator{Prefix: "cosmos"}, Pattern{Prefix: "cosmos1q"})
+	address := cosmos.GenerateBech32AddressFromPrivateKeyHex(hex.EncodeToString(result.Key),
+		cosmos.AddressConfig{AccountAddressPrefix: "cosmos", AccountPubKeyPrefix: "cosmospub"})
+	require.Equal(t, address, result.Address)
+}
+
+func TestCompile(t *testing.T) {
+	mainnet := &chaincfg.MainNetParams
+	for _, tc := range []struct {
+		gen        Generator
+		pattern    Pattern
+		difficulty float64
+	}{
+		{BitcoinGenerator{Type: bitcoin.AddressTypeP2WPKH, Net: mainnet}, Pattern{Prefix: "BC1QAA"}, 1024},
+		{BitcoinGenerator{Type: bitcoin.AddressTypeP2WPKH, Net: mainnet}, Pattern{Prefix: "bc", Suffix: "x"}, 32},
+		{BitcoinGenerator{Type: bitcoin.AddressTypeP2PKH, Net: mainnet}, Pattern{Prefix: "1A"}, 58},
+		{BitcoinGenerator{Type: bitcoin.AddressTypeP2PKH, Net: &chaincfg.TestNet3Params}, Pattern{Prefix: "m"}, 2},
+		{EthereumGenerator{}, Pattern{Prefix: "dead"}, math.Pow(32, 4)},
+		{EthereumGenerator{}, Pattern{Prefix: "0xdead", IgnoreCase: true}, math.Pow(16, 4)},
+		{EthereumGenerator{}, Pattern{Suffix: "00"}, 256},
+		{CosmosGenerator{Prefix: "osmo"}, Pattern{Prefix: "osmo1qq"}, 1024},
+	} {
+		m, err := Compile(tc.gen, tc.pattern)
+		require.NoError(t, err, "%+v", tc.pattern)
+		require.Equal(t, tc.difficulty, m.Difficulty, "%+v", tc.pattern)
+	}
+
+	for _, tc := range []struct {
+		gen     Generator
+		pattern Pattern
+	}{
+		{BitcoinGenerator{Type: bitcoin.AddressTypeP2PKH, Net: mainnet}, Pattern{Prefix: "2"}},
+		{BitcoinGenerator{Type: bitcoin.AddressTypeP2PKH, Net: mainnet}, Pattern{Prefix: "10"}},
+		{BitcoinGenerator{Type: bitcoin.AddressTypeP2WPKH, Net: mainnet}, Pattern{Prefix: "bc1p"}},
+		{BitcoinGenerator{Type: bitcoin.AddressTypeP2WPKH, Net: mainnet}, Pattern{Suffix: "b"}},
+		{BitcoinGenerator{Type: bitcoin.AddressTypeP2WPKH, Net: &bitcoin.DogecoinParams}, Pattern{Prefix: "d"}},
+		{EthereumGenerator{}, Pattern{Prefix: "0xg"}},
+		{EthereumGenerator{}, Pattern{}},
+		{EthereumGenerator{}, Pattern{Regex: "("}},
+	} {
+		_, err := Compile(tc.gen, tc.pattern)
+		require.Error(t, err, "%+v", tc.pattern)
+	}
+}
+
+func TestProbability(t *testing.T) {
+	require.InDelta(t, 0.5, Probability(1000, uint64(math.Round(AttemptsFor(1000, 0.5)))), 0.001)
+	require.InDelta(t, 1000*math.Ln2, AttemptsFor(1000, 0.5), 1)
+	require.Equal(t, 0.0, Probability(1000, 0))
+	require.Equal(t, 1.0, Probability(1, 0))
+}
+
+func TestSearchCancel(t *testing.T) {
+	gen := EthereumGenerator{}
+	m, err := Compile(gen, Pattern{Prefix: "0x0000000000000000"})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	searcher := NewSearcher(2)
+	_, err = searcher.Search(ctx, gen, m)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NotZero(t, searcher.Attempts())
+	require.NotZero(t, searcher.Rate())
+}